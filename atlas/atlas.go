@@ -5,8 +5,6 @@ import (
 	"context"
 	"log"
 	"os"
-	"strconv"
-	"strings"
 	"sync"
 
 	"github.com/go-spatial/geom/slippy"
@@ -14,42 +12,11 @@ import (
 	"github.com/go-spatial/tegola/cache"
 )
 
-var (
-	simplifyGeometries    bool = true
-	simplificationMaxZoom uint = 10
-)
-
 func init() {
-	// TODO(arolek): the following env variable processing was pulled form the mvt package when
-	// geometry processing was pulled out of the encoding package. This functionality could be
-	// deprecated/removed as it's not well documented and is really a band aid to work around
-	// some simplification issues. These concepts could just as easily live in the config file.
-	options := strings.ToLower(os.Getenv("TEGOLA_OPTIONS"))
-	if strings.Contains(options, "dontsimplifygeo") {
-		simplifyGeometries = false
-		log.Println("simplification is disable")
-	}
-
-	if strings.Contains(options, "simplifymaxzoom=") {
-		idx := strings.Index(options, "simplifymaxzoom=")
-		idx += 16
-
-		eidx := strings.IndexAny(options[idx:], ",.\t \n")
-		if eidx == -1 {
-			eidx = len(options)
-		} else {
-			eidx += idx
-		}
-
-		i, err := strconv.Atoi(options[idx:eidx])
-		if err != nil {
-			log.Printf("invalid value for SimplifyMaxZoom (%v). using default (%v).", options[idx:eidx], simplificationMaxZoom)
-			return
-		}
-
-		simplificationMaxZoom = uint(i + 1)
-
-		log.Printf("SimplifyMaxZoom set to (%v)", simplificationMaxZoom)
+	// simplification used to be configured with the TEGOLA_OPTIONS environment
+	// variable. it's now configured per map layer
+	if os.Getenv("TEGOLA_OPTIONS") != "" {
+		log.Println("TEGOLA_OPTIONS is no longer supported. use the map layer 'dont_simplify', 'simplify_max_zoom' and 'simplify_tolerance' config instead")
 	}
 }
 
@@ -70,6 +37,8 @@ type Atlas struct {
 	sync.RWMutex
 	// hold maps
 	maps map[string]Map
+	// tracks the work using the current maps, created with the first maps. see Track and ReplaceMaps
+	inflight *sync.WaitGroup
 	// holds a reference to the cache backend
 	cacher cache.Interface
 }
@@ -195,10 +164,68 @@ func (a *Atlas) AddMap(m Map) {
 	if a.maps == nil {
 		a.maps = map[string]Map{}
 	}
+	if a.inflight == nil {
+		a.inflight = &sync.WaitGroup{}
+	}
 
 	a.maps[m.Name] = m
 }
 
+// ReplaceMaps atomically replaces all the maps of the atlas. The returned wait function
+// blocks until the work tracked against the previous maps (see Track) is done. Once it
+// returns, the providers which are only used by the previous maps can be cleaned up.
+func (a *Atlas) ReplaceMaps(maps []Map) (wait func()) {
+	if a == nil {
+		// Use the default Atlas if a, is nil. This way the empty value is
+		// still useful.
+		return defaultAtlas.ReplaceMaps(maps)
+	}
+
+	newMaps := make(map[string]Map, len(maps))
+	for _, m := range maps {
+		newMaps[m.Name] = m
+	}
+
+	a.Lock()
+	prevInflight := a.inflight
+	a.maps = newMaps
+	a.inflight = &sync.WaitGroup{}
+	a.Unlock()
+
+	return func() {
+		if prevInflight != nil {
+			prevInflight.Wait()
+		}
+	}
+}
+
+// Track registers work (i.e. a tile request) which uses the current maps of the atlas.
+// The returned done function must be called once the work is complete. See ReplaceMaps.
+func (a *Atlas) Track() (done func()) {
+	if a == nil {
+		// Use the default Atlas if a, is nil. This way the empty value is
+		// still useful.
+		return defaultAtlas.Track()
+	}
+
+	// the wait group is only replaced while holding the write lock, so adding
+	// to it while holding the read lock can't race with ReplaceMaps waiting on it
+	a.RLock()
+	inflight := a.inflight
+	if inflight != nil {
+		inflight.Add(1)
+	}
+	a.RUnlock()
+
+	// the wait group is created with the first maps, an atlas without maps
+	// has no work to track
+	if inflight == nil {
+		return func() {}
+	}
+
+	return inflight.Done
+}
+
 // GetCache returns the registered cache if one is registered, otherwise nil
 func (a *Atlas) GetCache() cache.Interface {
 	if a == nil {
@@ -235,6 +262,16 @@ func AddMap(m Map) {
 	defaultAtlas.AddMap(m)
 }
 
+// ReplaceMaps atomically replaces all the maps of defaultAtlas. See Atlas.ReplaceMaps
+func ReplaceMaps(maps []Map) (wait func()) {
+	return defaultAtlas.ReplaceMaps(maps)
+}
+
+// Track registers work which uses the current maps of defaultAtlas. See Atlas.Track
+func Track() (done func()) {
+	return defaultAtlas.Track()
+}
+
 // GetCache returns the registered cache for defaultAtlas, if one is registered, otherwise nil
 func GetCache() cache.Interface {
 	return defaultAtlas.GetCache()
@@ -1,6 +1,9 @@
 package atlas_test
 
 import (
+	"testing"
+	"time"
+
 	"github.com/go-spatial/geom"
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/provider/test"
@@ -50,3 +53,44 @@ var testMap = atlas.Map{
 		testLayer3,
 	},
 }
+
+func TestReplaceMaps(t *testing.T) {
+	var a atlas.Atlas
+	a.AddMap(testMap)
+
+	// a request using the original maps
+	done := a.Track()
+
+	wait := a.ReplaceMaps([]atlas.Map{{Name: "new-map"}})
+
+	if _, err := a.Map(testMap.Name); err == nil {
+		t.Errorf("expected map (%v) to be replaced", testMap.Name)
+	}
+	if _, err := a.Map("new-map"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// work tracked after the replacement doesn't hold up the wait
+	newDone := a.Track()
+	defer newDone()
+
+	waited := make(chan struct{})
+	go func() {
+		wait()
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+		t.Fatalf("expected wait to block until the tracked work is done")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	done()
+
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatalf("expected wait to return once the tracked work is done")
+	}
+}
@@ -17,3 +17,12 @@ type ErrMapNotFound struct {
 func (e ErrMapNotFound) Error() string {
 	return fmt.Sprintf("atlas: map (%v) not found", e.Name)
 }
+
+// ErrInvalidFeatureQuery is returned when the point or zoom of a feature query are invalid
+type ErrInvalidFeatureQuery struct {
+	Reason string
+}
+
+func (e ErrInvalidFeatureQuery) Error() string {
+	return fmt.Sprintf("atlas: invalid feature query: %v", e.Reason)
+}
@@ -0,0 +1,237 @@
+package atlas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/geom/encoding/geojson"
+	"github.com/go-spatial/geom/planar/clip"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/basic"
+	"github.com/go-spatial/tegola/maths/proj"
+	"github.com/go-spatial/tegola/provider"
+)
+
+// featureQueryTile is the provider.Tile of a feature query. Its extent is the tile which
+// contains the queried point, so tokens derived from the tile (i.e. !SCALE_DENOMINATOR!)
+// have the values of the tiles at the zoom. Its buffered extent, which providers select
+// features with, is the area around the point.
+type featureQueryTile struct {
+	z, x, y uint
+	srid    uint64
+	extent  *geom.Extent
+	query   *geom.Extent
+}
+
+func (t featureQueryTile) ZXY() (uint, uint, uint) { return t.z, t.x, t.y }
+
+func (t featureQueryTile) Extent() (*geom.Extent, uint64) { return t.extent, t.srid }
+
+func (t featureQueryTile) BufferedExtent() (*geom.Extent, uint64) { return t.query, t.srid }
+
+// QueryFeatures returns the features of the map's layers which intersect the area of
+// tolerance pixels around the WGS84 point at zoom z, as a GeoJSON FeatureCollection in
+// WGS84. The geometries are neither simplified nor clipped. The layer of each feature
+// is set in the GeoJSONLayerProperty property. The layers are not filtered by zoom,
+// use FilterLayersByZoom first.
+func (m Map) QueryFeatures(ctx context.Context, z uint, lng, lat, tolerance float64) ([]byte, error) {
+	ctx, err := m.queryParameterContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tms := m.Matrix()
+	if z > tms.MaxZoom() {
+		return nil, ErrInvalidFeatureQuery{Reason: fmt.Sprintf("zoom (%v) is above the max zoom (%v)", z, tms.MaxZoom())}
+	}
+
+	// the point in the tile matrix set SRID
+	transform, err := proj.Transformer(tegola.WGS84, tms.SRID)
+	if err != nil {
+		return nil, err
+	}
+	pt, err := transform(lng, lat)
+	if err != nil {
+		return nil, ErrInvalidFeatureQuery{Reason: fmt.Sprintf("point (%v, %v) can't be transformed: %v", lng, lat, err)}
+	}
+
+	// the tile containing the point
+	span := tms.TileSpan(z)
+	col := math.Floor((pt[0] - tms.Origin[0]) / span)
+	row := math.Floor((tms.Origin[1] - pt[1]) / span)
+	if col < 0 || row < 0 || !tms.Contains(z, uint(col), uint(row)) {
+		return nil, ErrInvalidFeatureQuery{Reason: fmt.Sprintf("point (%v, %v) is outside of the tile matrix set (%v)", lng, lat, tms.Name)}
+	}
+
+	// tolerance is in display pixels
+	units := tms.Resolutions[z] * tolerance
+	tile := featureQueryTile{
+		z:      z,
+		x:      uint(col),
+		y:      uint(row),
+		srid:   tms.SRID,
+		extent: tms.Extent(z, uint(col), uint(row)),
+		query:  geom.NewExtent([2]float64{pt[0] - units, pt[1] - units}, [2]float64{pt[0] + units, pt[1] + units}),
+	}
+
+	// features are collected per layer so the output order follows the layer order
+	layerFeatures := make([][]geojson.Feature, len(m.Layers))
+
+	desc := fmt.Sprintf("features (z: %v, lng: %v, lat: %v)", z, lng, lat)
+	_, err = m.queryLayers(ctx, desc, func(i int, l Layer) error {
+		return l.Provider.TileFeatures(ctx, l.ProviderLayerName, tile, func(f *provider.Feature) error {
+			// skip row if geometry collection empty.
+			if g, ok := f.Geometry.(geom.Collection); ok && len(g.Geometries()) == 0 {
+				return nil
+			}
+
+			// providers select features by their bounding box
+			geo, err := basic.Transform(f.SRID, tile.srid, f.Geometry)
+			if err != nil {
+				return fmt.Errorf("unable to transform geometry from SRID (%v) to SRID (%v) for feature %v due to error: %v", f.SRID, tile.srid, f.ID, err)
+			}
+			if !intersectsExtent(ctx, geo, tile.query) {
+				return nil
+			}
+
+			geo, err = basic.Transform(f.SRID, tegola.WGS84, f.Geometry)
+			if err != nil {
+				return fmt.Errorf("unable to transform geometry from SRID (%v) to SRID (%v) for feature %v due to error: %v", f.SRID, tegola.WGS84, f.ID, err)
+			}
+
+			props := make(map[string]interface{}, len(f.Tags)+len(l.DefaultTags)+1)
+			for k, v := range l.DefaultTags {
+				props[k] = v
+			}
+			for k, v := range f.Tags {
+				props[k] = v
+			}
+			props[GeoJSONLayerProperty] = l.MVTName()
+
+			id := f.ID
+			layerFeatures[i] = append(layerFeatures[i], geojson.Feature{
+				ID:         &id,
+				Geometry:   geojson.Geometry{Geometry: geo},
+				Properties: props,
+			})
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fc := geojson.FeatureCollection{
+		Features: []geojson.Feature{},
+	}
+	for i := range layerFeatures {
+		fc.Features = append(fc.Features, layerFeatures[i]...)
+	}
+
+	return json.Marshal(fc)
+}
+
+// intersectsExtent reports if the geometry intersects the extent
+func intersectsExtent(ctx context.Context, geo geom.Geometry, ext *geom.Extent) bool {
+	switch g := geo.(type) {
+	case geom.Pointer:
+		return ext.ContainsPoint(g.XY())
+	case geom.MultiPointer:
+		for _, pt := range g.Points() {
+			if ext.ContainsPoint(pt) {
+				return true
+			}
+		}
+		return false
+	case geom.LineStringer:
+		return lineIntersectsExtent(ctx, g.Vertices(), ext)
+	case geom.MultiLineStringer:
+		for _, l := range g.LineStrings() {
+			if lineIntersectsExtent(ctx, l, ext) {
+				return true
+			}
+		}
+		return false
+	case geom.Polygoner:
+		return polygonIntersectsExtent(ctx, g.LinearRings(), ext)
+	case geom.MultiPolygoner:
+		for _, p := range g.Polygons() {
+			if polygonIntersectsExtent(ctx, p, ext) {
+				return true
+			}
+		}
+		return false
+	case geom.Collectioner:
+		for _, cg := range g.Geometries() {
+			if intersectsExtent(ctx, cg, ext) {
+				return true
+			}
+		}
+		return false
+	default:
+		// keep the features which can't be checked
+		return true
+	}
+}
+
+// lineIntersectsExtent reports if part of the line is inside the extent
+func lineIntersectsExtent(ctx context.Context, line [][2]float64, ext *geom.Extent) bool {
+	clipped, err := clip.LineStringer(ctx, geom.LineString(line), ext)
+	if err != nil {
+		// keep the features which can't be checked
+		return true
+	}
+
+	return len(clipped) > 0
+}
+
+// polygonIntersectsExtent reports if the polygon intersects the extent. Either one of
+// the rings crosses or is inside the extent or the extent is inside the polygon.
+func polygonIntersectsExtent(ctx context.Context, rings [][][2]float64, ext *geom.Extent) bool {
+	if len(rings) == 0 {
+		return false
+	}
+
+	for _, ring := range rings {
+		if len(ring) == 0 {
+			continue
+		}
+
+		// close the ring so the closing edge is checked
+		line := append(append(make([][2]float64, 0, len(ring)+1), ring...), ring[0])
+		if lineIntersectsExtent(ctx, line, ext) {
+			return true
+		}
+	}
+
+	// the extent is either inside the polygon or outside of it
+	center := [2]float64{(ext.MinX() + ext.MaxX()) / 2, (ext.MinY() + ext.MaxY()) / 2}
+	if !ringContains(rings[0], center) {
+		return false
+	}
+	for _, hole := range rings[1:] {
+		if ringContains(hole, center) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ringContains reports if the point is inside the ring using ray casting
+func ringContains(ring [][2]float64, pt [2]float64) bool {
+	var inside bool
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > pt[1]) != (b[1] > pt[1]) && pt[0] < (b[0]-a[0])*(pt[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
@@ -0,0 +1,165 @@
+package atlas_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/test"
+)
+
+// featuresProvider passes all its features to fn, like a provider which selects
+// features by their bounding box would for a large enough extent
+type featuresProvider struct {
+	test.TileProvider
+}
+
+func (p *featuresProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	for i := range p.Features {
+		if err := fn(&p.Features[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestQueryFeatures(t *testing.T) {
+	// lng 1, lat 1 is at 111319.49, 111325.14 in web mercator. a pixel is 152.87m at zoom 10
+	features := []provider.Feature{
+		{
+			ID:       1,
+			Geometry: geom.Point{111500, 111400},
+			SRID:     tegola.WebMercator,
+			Tags:     map[string]interface{}{"name": "near point"},
+		},
+		{
+			ID:       2,
+			Geometry: geom.Point{115000, 115000},
+			SRID:     tegola.WebMercator,
+		},
+		{
+			ID:       3,
+			Geometry: geom.Polygon{{{100000, 100000}, {120000, 100000}, {120000, 120000}, {100000, 120000}}},
+			SRID:     tegola.WebMercator,
+		},
+		{
+			ID: 4,
+			Geometry: geom.Polygon{
+				{{100000, 100000}, {120000, 100000}, {120000, 120000}, {100000, 120000}},
+				{{110000, 110000}, {113000, 110000}, {113000, 113000}, {110000, 113000}},
+			},
+			SRID: tegola.WebMercator,
+		},
+		{
+			ID:       5,
+			Geometry: geom.LineString{{111000, 100000}, {111000, 120000}},
+			SRID:     tegola.WebMercator,
+		},
+		{
+			ID:       6,
+			Geometry: geom.LineString{{105000, 100000}, {105000, 120000}},
+			SRID:     tegola.WebMercator,
+		},
+	}
+
+	type tcase struct {
+		z         uint
+		lng, lat  float64
+		tolerance float64
+		expected  []uint64
+		expErr    error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			m := atlas.NewWebMercatorMap("test")
+			m.Layers = []atlas.Layer{
+				{
+					Name:        "layer",
+					Provider:    &featuresProvider{test.TileProvider{Features: features}},
+					DefaultTags: map[string]interface{}{"name": "default", "kind": "test"},
+				},
+			}
+
+			b, err := m.QueryFeatures(context.Background(), tc.z, tc.lng, tc.lat, tc.tolerance)
+			if tc.expErr != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tc.expErr) {
+					t.Errorf("error, expected %T got %v", tc.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error, expected nil got %v", err)
+			}
+
+			var fc struct {
+				Features []struct {
+					ID         uint64                 `json:"id"`
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"features"`
+			}
+			if err := json.Unmarshal(b, &fc); err != nil {
+				t.Fatalf("error decoding GeoJSON, expected nil got %v", err)
+			}
+
+			ids := []uint64{}
+			for _, f := range fc.Features {
+				ids = append(ids, f.ID)
+
+				if f.Properties[atlas.GeoJSONLayerProperty] != "layer" {
+					t.Errorf("feature %v layer, expected layer got %v", f.ID, f.Properties[atlas.GeoJSONLayerProperty])
+				}
+				if f.Properties["kind"] != "test" {
+					t.Errorf("feature %v default tag, expected test got %v", f.ID, f.Properties["kind"])
+				}
+				if f.ID == 1 && f.Properties["name"] != "near point" {
+					t.Errorf("feature 1 tag, expected near point got %v", f.Properties["name"])
+				}
+			}
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+			if !reflect.DeepEqual(ids, tc.expected) {
+				t.Errorf("features, expected %v got %v", tc.expected, ids)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"point": {
+			z:         10,
+			lng:       1,
+			lat:       1,
+			tolerance: 5,
+			expected:  []uint64{1, 3, 5},
+		},
+		"larger tolerance": {
+			z:         10,
+			lng:       1,
+			lat:       1,
+			tolerance: 50,
+			expected:  []uint64{1, 2, 3, 4, 5, 6},
+		},
+		"zoom above max": {
+			z:      30,
+			lng:    1,
+			lat:    1,
+			expErr: atlas.ErrInvalidFeatureQuery{},
+		},
+		"outside of the tile matrix set": {
+			z:      10,
+			lng:    1,
+			lat:    89,
+			expErr: atlas.ErrInvalidFeatureQuery{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
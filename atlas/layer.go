@@ -2,6 +2,7 @@ package atlas
 
 import (
 	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/provider"
 )
 
@@ -22,6 +23,18 @@ type Layer struct {
 	// DontClip indicates wheather feature clipping should be applied.
 	// We use a negative in the name so the default is to clip
 	DontClip bool
+	// DontMakeValid indicates wheather the makevalid step should be applied.
+	// Without it polygons are not clipped. Points and lines are still clipped
+	DontMakeValid bool
+	// SimplifyTolerance is the simplification tolerance in tile pixels (see tilematrix.MvtTileDim).
+	// If zero tegola.DefaultEpsilon is used
+	SimplifyTolerance float64
+	// SimplifyMaxZoom is the last zoom features are simplified at.
+	// If nil tegola.DefaultSimplifyMaxZoom is used
+	SimplifyMaxZoom *uint
+	// SimplifyAlgorithm is one of the algorithms of the maths/simplify package.
+	// If empty simplify.DouglasPeucker is used
+	SimplifyAlgorithm string
 }
 
 // MVTName will return the value that will be encoded in the Name field when the layer is encoded as MVT
@@ -32,3 +45,25 @@ func (l *Layer) MVTName() string {
 
 	return l.ProviderLayerName
 }
+
+// simplifies reports if the layer's features are simplified at zoom z
+func (l *Layer) simplifies(z uint) bool {
+	if l.DontSimplify {
+		return false
+	}
+
+	maxZoom := uint(tegola.DefaultSimplifyMaxZoom)
+	if l.SimplifyMaxZoom != nil {
+		maxZoom = *l.SimplifyMaxZoom
+	}
+
+	return z <= maxZoom
+}
+
+// simplifyTolerance returns the simplification tolerance in tile pixels
+func (l *Layer) simplifyTolerance() float64 {
+	if l.SimplifyTolerance == 0 {
+		return tegola.DefaultEpsilon
+	}
+	return l.SimplifyTolerance
+}
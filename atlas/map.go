@@ -5,27 +5,33 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 
 	"github.com/go-spatial/geom"
+	"github.com/go-spatial/geom/encoding/geojson"
 	"github.com/go-spatial/geom/encoding/mvt"
+	vectorTile "github.com/go-spatial/geom/encoding/mvt/vector_tile"
 	"github.com/go-spatial/geom/encoding/wkb"
 	"github.com/go-spatial/geom/encoding/wkt"
 	"github.com/go-spatial/geom/planar"
 	"github.com/go-spatial/geom/planar/clip"
 	"github.com/go-spatial/geom/planar/makevalid"
 	"github.com/go-spatial/geom/planar/makevalid/hitmap"
-	"github.com/go-spatial/geom/planar/simplify"
 	"github.com/go-spatial/geom/slippy"
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/basic"
 	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/maths/simplify"
+	"github.com/go-spatial/tegola/maths/tilematrix"
 	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/provider/debug"
 )
@@ -35,11 +41,12 @@ func NewWebMercatorMap(name string) Map {
 	return Map{
 		Name: name,
 		// default bounds
-		Bounds:     tegola.WGS84Bounds,
-		Layers:     []Layer{},
-		SRID:       tegola.WebMercator,
-		TileExtent: uint64(mvt.DefaultExtent),
-		TileBuffer: uint64(tegola.DefaultTileBuffer),
+		Bounds:        tegola.WGS84Bounds,
+		Layers:        []Layer{},
+		SRID:          tegola.WebMercator,
+		TileMatrixSet: tilematrix.WebMercatorQuad,
+		TileExtent:    uint64(mvt.DefaultExtent),
+		TileBuffer:    uint64(tegola.DefaultTileBuffer),
 	}
 }
 
@@ -58,9 +65,35 @@ type Map struct {
 	Layers []Layer
 
 	SRID uint64
+	// The tile grid the map is served in. nil defaults to WebMercatorQuad
+	TileMatrixSet *tilematrix.TileMatrixSet
 	// MVT output values
 	TileExtent uint64
 	TileBuffer uint64
+	// The Cache-Control header of the map's tiles. Empty uses the webserver headers
+	CacheControl string
+	// Params are read from the query string of tile requests and passed to the
+	// providers in the context of the request
+	Params []provider.QueryParameter
+	// Public maps are served without credentials when the server requires them
+	Public bool
+}
+
+// Matrix returns the tile matrix set of the map, defaulting to WebMercatorQuad
+func (m Map) Matrix() *tilematrix.TileMatrixSet {
+	if m.TileMatrixSet == nil {
+		return tilematrix.WebMercatorQuad
+	}
+	return m.TileMatrixSet
+}
+
+// srid returns the SRID the map is encoded in. Maps which don't declare
+// an SRID are encoded in the SRID of their tile matrix set.
+func (m Map) srid() uint64 {
+	if m.SRID == 0 {
+		return m.Matrix().SRID
+	}
+	return m.SRID
 }
 
 // AddDebugLayers returns a copy of a Map with the debug layers appended to the layer list
@@ -134,16 +167,38 @@ func (m Map) FilterLayersByName(names ...string) Map {
 	return m
 }
 
-// EncodeTile will return the map as an encode mvt tile
-// TODO (arolek): support for max zoom
-func (m Map) EncodeMVTTile(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
-	// tile container
-	var mvtTile mvt.Tile
+// featureFunc is called with a feature of the layer at index i of the map and its
+// geometry once it has been reprojected, simplified, clipped and made valid
+type featureFunc func(i int, f *provider.Feature, geo geom.Geometry) error
+
+// firstError records the first error set by concurrently fetched layers
+type firstError struct {
+	sync.Mutex
+	err error
+}
+
+func (e *firstError) set(err error) {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+// queryLayers calls query with each of the map's layers concurrently. The duration and
+// errors of the queries are recorded in the provider metrics. A query which timed out
+// fails all the layers rather than leaving the layer out, other errors are logged with
+// desc (i.e. the tile) and leave the layer out. The returned slice reports which layers
+// were queried successfully.
+func (m Map) queryLayers(ctx context.Context, desc string, query func(i int, l Layer) error) ([]bool, error) {
 	// wait group for concurrent layer fetching
 	var wg sync.WaitGroup
 
-	// layer stack
-	mvtLayers := make([]*mvt.Layer, len(m.Layers))
+	queried := make([]bool, len(m.Layers))
+
+	// a layer query which timed out fails the query rather than leaving the layer out
+	var timeout firstError
 
 	// set our waitgroup count
 	wg.Add(len(m.Layers))
@@ -153,148 +208,344 @@ func (m Map) EncodeMVTTile(ctx context.Context, tile *slippy.Tile) ([]byte, erro
 
 		// go routine for fetching the layer concurrently
 		go func(i int, l Layer) {
-			mvtLayer := mvt.Layer{
-				Name: l.MVTName(),
-			}
-
 			// on completion let the wait group know
 			defer wg.Done()
 
-			ptile := provider.NewTile(tile.Z, tile.X, tile.Y,
-				uint(m.TileBuffer), uint(m.SRID))
+			start := time.Now()
+			err := query(i, l)
+			providerQueryDuration.ObserveDuration(start, m.Name, l.MVTName())
+			if err != nil {
+				if _, ok := err.(provider.ErrQueryTimeout); ok {
+					providerErrors.Inc(m.Name, l.MVTName())
+					timeout.set(err)
+					return
+				}
 
-			// fetch layer from data provider
-			err := l.Provider.TileFeatures(ctx, l.ProviderLayerName, ptile, func(f *provider.Feature) error {
-				// skip row if geometry collection empty.
-				g, ok := f.Geometry.(geom.Collection)
-				if ok && len(g.Geometries()) == 0 {
-					return nil
+				switch err {
+				case context.Canceled:
+					// the request was canceled, nothing to report
+				default:
+					providerErrors.Inc(m.Name, l.MVTName())
+					// TODO (arolek): should we return an error to the response or just log the error?
+					// we can't just write to the response as the waitgroup is going to write to the response as well
+					log.Printf("err fetching %v layer (%v): %v", desc, l.MVTName(), err)
 				}
+				return
+			}
 
-				geo := f.Geometry
+			queried[i] = true
+		}(i, layer)
+	}
 
-				// check if the feature SRID and map SRID are different. If they are then reporject
-				if f.SRID != m.SRID {
+	// wait for the waitgroup to finish
+	wg.Wait()
 
-					// TODO(arolek): support for additional projections
-					g, err := basic.ToWebMercator(f.SRID, geo)
-					if err != nil {
-						return fmt.Errorf("unable to transform geometry to webmercator from SRID (%v) for feature %v due to error: %v", f.SRID, f.ID, err)
-					}
-					geo = g
+	// stop processing if the context has an error. this check is necessary
+	// otherwise the server continues processing even if the request was canceled
+	// as the waitgroup was not notified of the cancel
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 
-				}
+	if timeout.err != nil {
+		return nil, timeout.err
+	}
 
-				// add default tags, but don't overwrite a tag that already exists
-				for k, v := range l.DefaultTags {
-					if _, ok := f.Tags[k]; !ok {
-						f.Tags[k] = v
-					}
-				}
+	return queried, nil
+}
 
-				// geo-processing is hard and error prone and
-				// tracking the actual geometries that cause errors is imensely
-				// helpful, especially at this point in the pipeline
-				defer func() {
-					if r := recover(); r != nil {
-						// writePanicGeometry will write a wkb and wkt file a
-						// geometry that causes a panic
-						writePanicGeometry(geo, l.MVTName(), tile)
-					}
-				}()
+// tileDesc describes the tile in logs
+func tileDesc(tile *slippy.Tile) string {
+	z, x, y := tile.ZXY()
+	return fmt.Sprintf("tile (z: %v, x: %v, y: %v)", z, x, y)
+}
 
-				// multiple ways to turn off simplification. check the atlas init() function
-				// for how the second two conditions are set
-				if !l.DontSimplify && simplifyGeometries && tile.Z < simplificationMaxZoom {
-					simp := simplify.DouglasPeucker{
-						Tolerance: slippy.Pixels2Webs(tile.Z, tegola.DefaultEpsilon),
-					}
+// fetchLayers fetches the features of the map's layers concurrently and calls fn with each
+// prepared feature. Calls to fn are concurrent across layers but not within a layer.
+// The returned slice reports which layers were fetched successfully.
+func (m Map) fetchLayers(ctx context.Context, tile *slippy.Tile, fn featureFunc) ([]bool, error) {
+	tms := m.Matrix()
+	mapSRID := m.srid()
 
-					var err error
-					geo, err = planar.Simplify(ctx, simp, geo)
-					if err != nil {
-						return err
-					}
+	// the tile extent in map coordinates
+	tileExtent := tms.Extent(tile.Z, tile.X, tile.Y)
+
+	return m.queryLayers(ctx, tileDesc(tile), func(i int, l Layer) error {
+		ptile := provider.NewTileInMatrixSet(tms, tile.Z, tile.X, tile.Y, uint(m.TileBuffer))
+
+		// fetch layer from data provider
+		return l.Provider.TileFeatures(ctx, l.ProviderLayerName, ptile, func(f *provider.Feature) error {
+			// skip row if geometry collection empty.
+			g, ok := f.Geometry.(geom.Collection)
+			if ok && len(g.Geometries()) == 0 {
+				return nil
+			}
+
+			geo := f.Geometry
+
+			// check if the feature SRID and map SRID are different. If they are then reporject
+			if f.SRID != mapSRID {
+				g, err := basic.Transform(f.SRID, mapSRID, geo)
+				if err != nil {
+					return fmt.Errorf("unable to transform geometry from SRID (%v) to SRID (%v) for feature %v due to error: %v", f.SRID, mapSRID, f.ID, err)
 				}
+				geo = g
+			}
 
-				// check if we need to clip and if we do build the clip region (tile extent)
-				var clipRegion *geom.Extent
-				if !l.DontClip {
-					webs := slippy.Pixels2Webs(tile.Z, uint(m.TileBuffer))
-					clipRegion = tile.Extent3857().ExpandBy(webs)
+			// add default tags, but don't overwrite a tag that already exists
+			for k, v := range l.DefaultTags {
+				if _, ok := f.Tags[k]; !ok {
+					f.Tags[k] = v
 				}
+			}
 
-				// create a hitmap for the makevalid function
-				hm, err := hitmap.New(clipRegion, geo)
-				if err != nil {
-					return err
+			// geo-processing is hard and error prone and
+			// tracking the actual geometries that cause errors is imensely
+			// helpful, especially at this point in the pipeline
+			defer func() {
+				if r := recover(); r != nil {
+					// writePanicGeometry will write a wkb and wkt file a
+					// geometry that causes a panic
+					writePanicGeometry(geo, l.MVTName(), tile)
 				}
+			}()
 
-				// instantiate a new makevalid struct holding the hitmap
-				mv := makevalid.Makevalid{
-					Hitmap:  hm,
-					Clipper: clip.Default,
+			if l.simplifies(tile.Z) {
+				simp, err := simplify.For(l.SimplifyAlgorithm, tms.Pixels2Units(tile.Z, l.simplifyTolerance()))
+				if err != nil {
+					return err
 				}
 
-				// apply make valid routine
-				geo, _, err = mv.Makevalid(ctx, geo, clipRegion)
+				geo, err = planar.Simplify(ctx, simp, geo)
 				if err != nil {
 					return err
 				}
+			}
 
-				// tranlate the geometry to tile coordinates
-				geo = mvt.PrepareGeo(geo, tile.Extent3857(), float64(m.TileExtent))
-				if geo == nil {
-					return nil
+			// check if we need to clip and if we do build the clip region (tile extent)
+			var clipRegion *geom.Extent
+			if !l.DontClip {
+				units := tms.Pixels2Units(tile.Z, float64(m.TileBuffer))
+				clipRegion = tileExtent.ExpandBy(units)
+			}
+
+			if l.DontMakeValid {
+				// without makevalid only points and lines can be clipped
+				if clipRegion != nil {
+					clipped, err := clip.Geometry(ctx, geo, clipRegion)
+					switch err {
+					case nil:
+						if clipped == nil {
+							// the geometry is outside of the clip region
+							return nil
+						}
+						geo = clipped
+					case clip.ErrUnsupportedGeometry:
+					default:
+						return err
+					}
 				}
 
-				mvtLayer.AddFeatures(mvt.Feature{
-					ID:       &f.ID,
-					Tags:     f.Tags,
-					Geometry: geo,
-				})
+				return fn(i, f, geo)
+			}
+
+			// create a hitmap for the makevalid function
+			hm, err := hitmap.New(clipRegion, geo)
+			if err != nil {
+				return err
+			}
+
+			// instantiate a new makevalid struct holding the hitmap
+			mv := makevalid.Makevalid{
+				Hitmap:  hm,
+				Clipper: clip.Default,
+			}
 
-				return nil
-			})
+			// apply make valid routine
+			geo, _, err = mv.Makevalid(ctx, geo, clipRegion)
 			if err != nil {
-				switch err {
-				case context.Canceled:
-					// TODO (arolek): add debug logs
-				default:
-					z, x, y := tile.ZXY()
-					// TODO (arolek): should we return an error to the response or just log the error?
-					// we can't just write to the response as the waitgroup is going to write to the response as well
-					log.Printf("err fetching tile (z: %v, x: %v, y: %v) features: %v", z, x, y, err)
-				}
-				return
+				return err
 			}
 
-			// add the layer to the slice position
-			mvtLayers[i] = &mvtLayer
-		}(i, layer)
+			return fn(i, f, geo)
+		})
+	})
+}
+
+// EncodeTile will return the map as an encode mvt tile
+// TODO (arolek): support for max zoom
+func (m Map) EncodeMVTTile(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
+	ctx, err := m.queryParameterContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// wait for the waitgroup to finish
+	// the encoded layers in map order
+	encoded := make([][]byte, len(m.Layers))
+
+	// layers whose provider encodes vector tiles natively are fetched as encoded
+	// bytes and skip the geometry pipeline. the rest are encoded by encodeMVTLayers
+	native, pipeline := m, m
+	native.Layers, pipeline.Layers = nil, nil
+	var nativeIdx, pipelineIdx []int
+
+	for i, layer := range m.Layers {
+		if _, ok := layer.Provider.(provider.MVTTiler); ok {
+			native.Layers = append(native.Layers, layer)
+			nativeIdx = append(nativeIdx, i)
+			continue
+		}
+		pipeline.Layers = append(pipeline.Layers, layer)
+		pipelineIdx = append(pipelineIdx, i)
+	}
+
+	// the native layers are fetched while the pipeline layers are encoded
+	var nativeErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, nativeErr = native.queryLayers(ctx, tileDesc(tile), func(i int, l Layer) error {
+			ptile := provider.NewTileInMatrixSet(m.Matrix(), tile.Z, tile.X, tile.Y, uint(m.TileBuffer))
+
+			b, err := l.Provider.(provider.MVTTiler).MVTForLayer(ctx, l.ProviderLayerName, l.MVTName(), ptile, uint(m.TileExtent))
+			if err != nil {
+				return err
+			}
+
+			encoded[nativeIdx[i]] = b
+			return nil
+		})
+	}()
+
+	pipelineLayers, err := pipeline.encodeMVTLayers(ctx, tile)
+
 	wg.Wait()
 
-	// stop processing if the context has an error. this check is necessary
-	// otherwise the server continues processing even if the request was canceled
-	// as the waitgroup was not notified of the cancel
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
+	if err != nil {
+		return nil, err
 	}
 
-	// add layers to our tile
-	mvtTile.AddLayers(mvtLayers...)
+	if nativeErr != nil {
+		return nil, nativeErr
+	}
+
+	for i := range pipelineLayers {
+		encoded[pipelineIdx[i]] = pipelineLayers[i]
+	}
 
-	// generate the MVT tile
-	vtile, err := mvtTile.VTile(ctx)
+	// a vector tile's layers field is repeated so encoded tiles can be
+	// concatenated into a single tile
+	return bytes.Join(encoded, nil), nil
+}
+
+// encodeMVTLayers runs the map's layers through the geometry pipeline and returns
+// each layer encoded as a single layer vector tile. Layers which fail are nil.
+func (m Map) encodeMVTLayers(ctx context.Context, tile *slippy.Tile) ([][]byte, error) {
+	encoded := make([][]byte, len(m.Layers))
+	if len(m.Layers) == 0 {
+		return encoded, nil
+	}
+
+	// layer stack
+	mvtLayers := make([]*mvt.Layer, len(m.Layers))
+	for i := range m.Layers {
+		mvtLayers[i] = &mvt.Layer{
+			Name: m.Layers[i].MVTName(),
+		}
+	}
+
+	// the tile extent in map coordinates
+	tileExtent := m.Matrix().Extent(tile.Z, tile.X, tile.Y)
+
+	fetched, err := m.fetchLayers(ctx, tile, func(i int, f *provider.Feature, geo geom.Geometry) error {
+		// tranlate the geometry to tile coordinates
+		geo = mvt.PrepareGeo(geo, tileExtent, float64(m.TileExtent))
+		if geo == nil {
+			return nil
+		}
+
+		mvtLayers[i].AddFeatures(mvt.Feature{
+			ID:       &f.ID,
+			Tags:     f.Tags,
+			Geometry: geo,
+		})
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// encode our mvt tile
-	return proto.Marshal(vtile)
+	for i := range mvtLayers {
+		// layers which failed are left out of the tile
+		if !fetched[i] {
+			continue
+		}
+
+		vtLayer, err := mvtLayers[i].VTileLayer(ctx)
+		if err != nil {
+			switch err {
+			case context.Canceled:
+				return nil, err
+			default:
+				return nil, fmt.Errorf("error Getting VTileLayer: %v", err)
+			}
+		}
+
+		sortTags(vtLayer)
+
+		// encode our mvt layer
+		encoded[i], err = proto.Marshal(&vectorTile.Tile{
+			Layers: []*vectorTile.Tile_Layer{vtLayer},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return encoded, nil
+}
+
+// sortTags orders the keys of the layer and the tags of its features by key, and the
+// values by their first use. The tags are encoded from maps so without ordering them
+// the same features are encoded differently by each render, which changes the tile's ETag.
+func sortTags(l *vectorTile.Tile_Layer) {
+	keys := make([]string, len(l.Keys))
+	copy(keys, l.Keys)
+	sort.Strings(keys)
+
+	// the new index of each key
+	keyIdx := make([]uint32, len(l.Keys))
+	for i, k := range l.Keys {
+		keyIdx[i] = uint32(sort.SearchStrings(keys, k))
+	}
+
+	// the new index of each value
+	valIdx := make(map[uint32]uint32, len(l.Values))
+	values := make([]*vectorTile.Tile_Value, 0, len(l.Values))
+
+	for _, f := range l.Features {
+		// tags are pairs of key and value indexes
+		pairs := make([][2]uint32, 0, len(f.Tags)/2)
+		for i := 0; i+1 < len(f.Tags); i += 2 {
+			pairs = append(pairs, [2]uint32{keyIdx[f.Tags[i]], f.Tags[i+1]})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+
+		for i, pair := range pairs {
+			v, ok := valIdx[pair[1]]
+			if !ok {
+				v = uint32(len(values))
+				valIdx[pair[1]] = v
+				values = append(values, l.Values[pair[1]])
+			}
+			f.Tags[2*i], f.Tags[2*i+1] = pair[0], v
+		}
+	}
+
+	l.Keys, l.Values = keys, values
 }
 
 // Encode will call EncodeTile to encode the tile and then gzip the contents
@@ -305,12 +556,86 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 		return nil, err
 	}
 
+	return gzipBytes(tileBytes)
+}
+
+// GeoJSONLayerProperty is the feature property which holds the name of the
+// layer a feature belongs to in GeoJSON tiles
+const GeoJSONLayerProperty = "layer"
+
+// EncodeGeoJSONTile will return the map as a GeoJSON FeatureCollection. Coordinates are
+// WGS84 longitude / latitude as required by RFC 7946. The layer of each feature is set
+// in the GeoJSONLayerProperty property, overwriting a tag with the same name.
+func (m Map) EncodeGeoJSONTile(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
+	ctx, err := m.queryParameterContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mapSRID := m.srid()
+
+	// features are collected per layer so the output order follows the layer order
+	layerFeatures := make([][]geojson.Feature, len(m.Layers))
+
+	_, err = m.fetchLayers(ctx, tile, func(i int, f *provider.Feature, geo geom.Geometry) error {
+		// makevalid may have removed the geometry entirely
+		if geo == nil {
+			return nil
+		}
+
+		geo, err := basic.Transform(mapSRID, tegola.WGS84, geo)
+		if err != nil {
+			return fmt.Errorf("unable to transform geometry from SRID (%v) to SRID (%v) for feature %v due to error: %v", mapSRID, tegola.WGS84, f.ID, err)
+		}
+
+		props := make(map[string]interface{}, len(f.Tags)+1)
+		for k, v := range f.Tags {
+			props[k] = v
+		}
+		props[GeoJSONLayerProperty] = m.Layers[i].MVTName()
+
+		id := f.ID
+		layerFeatures[i] = append(layerFeatures[i], geojson.Feature{
+			ID:         &id,
+			Geometry:   geojson.Geometry{Geometry: geo},
+			Properties: props,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fc := geojson.FeatureCollection{
+		Features: []geojson.Feature{},
+	}
+	for i := range layerFeatures {
+		fc.Features = append(fc.Features, layerFeatures[i]...)
+	}
+
+	return json.Marshal(fc)
+}
+
+// EncodeGeoJSON will call EncodeGeoJSONTile to encode the tile and then gzip the contents
+func (m Map) EncodeGeoJSON(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
+
+	tileBytes, err := m.EncodeGeoJSONTile(ctx, tile)
+	if err != nil {
+		return nil, err
+	}
+
+	return gzipBytes(tileBytes)
+}
+
+// gzipBytes compresses the provided bytes
+func gzipBytes(b []byte) ([]byte, error) {
 	// buffer to store our compressed bytes
 	var gzipBuf bytes.Buffer
 
 	// compress the encoded bytes
 	w := gzip.NewWriter(&gzipBuf)
-	_, err = w.Write(tileBytes)
+	_, err := w.Write(b)
 	if err != nil {
 		return nil, err
 	}
@@ -320,7 +645,7 @@ func (m Map) Encode(ctx context.Context, tile *slippy.Tile) ([]byte, error) {
 		return nil, err
 	}
 
-	// return encoded, gzipped tile
+	// return encoded, gzipped bytes
 	return gzipBuf.Bytes(), nil
 }
 
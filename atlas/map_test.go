@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 
+	"github.com/go-spatial/geom"
 	vectorTile "github.com/go-spatial/geom/encoding/mvt/vector_tile"
 	"github.com/go-spatial/geom/slippy"
 	"github.com/go-spatial/geom/encoding/mvt"
@@ -437,6 +441,85 @@ func TestEncode(t *testing.T) {
 				},
 			},
 		},
+		"dont make valid": {
+			grid: atlas.Map{
+				Layers: []atlas.Layer{
+					{
+						Name:          "layer1",
+						MinZoom:       0,
+						MaxZoom:       2,
+						DontSimplify:  true,
+						DontMakeValid: true,
+						Provider: &test.TileProvider{
+							Features: []provider.Feature{
+								{
+									SRID:     3857,
+									Geometry: slippy.NewTile(0, 0, 0).Extent3857().AsPolygon(),
+								},
+							},
+						},
+					},
+				},
+				TileBuffer: uint64(tegola.DefaultTileBuffer),
+				TileExtent: uint64(mvt.DefaultExtent),
+			},
+			tile: slippy.NewTile(2, 3, 3),
+			expected: vectorTile.Tile{
+				Layers: []*vectorTile.Tile_Layer{
+					{
+						Version: p.Uint32(2),
+						Name:    p.String("layer1"),
+						Features: []*vectorTile.Tile_Feature{
+							{
+								Id:   p.Uint64(0),
+								Type: &polygon,
+								// polygons are not clipped without makevalid
+								Geometry: []uint32{9, 24573, 24573, 26, 32766, 0, 0, 32766, 32765, 0, 15},
+							},
+						},
+						Extent: p.Uint32(vectorTile.Default_Tile_Layer_Extent),
+					},
+				},
+			},
+		},
+		"visvalingam": {
+			grid: atlas.Map{
+				Layers: []atlas.Layer{
+					{
+						Name:              "layer1",
+						MinZoom:           0,
+						MaxZoom:           2,
+						SimplifyAlgorithm: "visvalingam",
+						Provider:          &test.TileProvider{},
+					},
+				},
+				TileBuffer: uint64(tegola.DefaultTileBuffer),
+				TileExtent: uint64(mvt.DefaultExtent),
+			},
+			tile: slippy.NewTile(2, 3, 3),
+			expected: vectorTile.Tile{
+				Layers: []*vectorTile.Tile_Layer{
+					{
+						Version: p.Uint32(2),
+						Name:    p.String("layer1"),
+						Features: []*vectorTile.Tile_Feature{
+							{
+								Id:       p.Uint64(0),
+								Type:     &polygon,
+								Geometry: []uint32{9, 0, 8192, 26, 0, 8191, 8192, 0, 0, 8192, 15},
+							},
+						},
+						Keys: []string{"type"},
+						Values: []*vectorTile.Tile_Value{
+							{
+								StringValue: p.String("debug_buffer_outline"),
+							},
+						},
+						Extent: p.Uint32(vectorTile.Default_Tile_Layer_Extent),
+					},
+				},
+			},
+		},
 		"empty_collection": {
 			grid: atlas.Map{
 				Layers: []atlas.Layer{
@@ -470,3 +553,273 @@ func TestEncode(t *testing.T) {
 		t.Run(name, fn(tc))
 	}
 }
+
+func TestEncodeDeterministic(t *testing.T) {
+	var features []provider.Feature
+	for i := 0; i < 10; i++ {
+		tags := make(map[string]interface{})
+		for j := 0; j < 10; j++ {
+			tags[fmt.Sprintf("key%v", j)] = fmt.Sprintf("value%v", (i+j)%4)
+		}
+
+		features = append(features, provider.Feature{
+			ID:       uint64(i),
+			Geometry: geom.Point{float64(i) * 100000, float64(i) * 100000},
+			SRID:     tegola.WebMercator,
+			Tags:     tags,
+		})
+	}
+
+	m := atlas.Map{
+		Layers: []atlas.Layer{
+			{
+				Name:     "points",
+				Provider: &featuresProvider{test.TileProvider{Features: features}},
+			},
+		},
+		TileBuffer: uint64(tegola.DefaultTileBuffer),
+		TileExtent: uint64(mvt.DefaultExtent),
+	}
+
+	var expected []byte
+	for i := 0; i < 20; i++ {
+		out, err := m.EncodeMVTTile(context.Background(), slippy.NewTile(0, 0, 0))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if i == 0 {
+			expected = out
+			continue
+		}
+		if !bytes.Equal(out, expected) {
+			t.Fatalf("render %v, expected the same bytes as the first render", i)
+		}
+	}
+}
+
+func TestEncodeGeoJSON(t *testing.T) {
+	type tcase struct {
+		grid atlas.Map
+		tile *slippy.Tile
+		// expected properties of each feature, in order
+		expected []map[string]interface{}
+		// expected bounding box of the features in WGS84
+		bounds [4]float64
+	}
+
+	fn := func(tc tcase) func(t *testing.T) {
+		return func(t *testing.T) {
+			out, err := tc.grid.EncodeGeoJSONTile(context.Background(), tc.tile)
+			if err != nil {
+				t.Errorf("err: %v", err)
+				return
+			}
+
+			var fc struct {
+				Type     string `json:"type"`
+				Features []struct {
+					Type     string `json:"type"`
+					Geometry struct {
+						Type        string      `json:"type"`
+						Coordinates interface{} `json:"coordinates"`
+					} `json:"geometry"`
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"features"`
+			}
+
+			if err = json.Unmarshal(out, &fc); err != nil {
+				t.Errorf("error unmarshalling output: %v", err)
+				return
+			}
+
+			if fc.Type != "FeatureCollection" {
+				t.Errorf("expected type FeatureCollection got %v", fc.Type)
+			}
+
+			if len(fc.Features) != len(tc.expected) {
+				t.Errorf("expected (%d) features, got (%d)", len(tc.expected), len(fc.Features))
+				return
+			}
+
+			for i, f := range fc.Features {
+				if !reflect.DeepEqual(f.Properties, tc.expected[i]) {
+					t.Errorf("feature (%d) expected properties %v got %v", i, tc.expected[i], f.Properties)
+				}
+
+				for _, pt := range geoJSONPoints(f.Geometry.Coordinates) {
+					if pt[0] < tc.bounds[0]-1e-6 || pt[1] < tc.bounds[1]-1e-6 || pt[0] > tc.bounds[2]+1e-6 || pt[1] > tc.bounds[3]+1e-6 {
+						t.Errorf("feature (%d) point %v outside of %v", i, pt, tc.bounds)
+					}
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"test_provider": {
+			grid: atlas.Map{
+				Layers: []atlas.Layer{
+					{
+						Name:     "layer1",
+						Provider: &test.TileProvider{},
+						DefaultTags: map[string]interface{}{
+							"foo": "bar",
+						},
+					},
+					{
+						Name:     "layer2",
+						Provider: &test.TileProvider{},
+					},
+				},
+				TileBuffer: uint64(tegola.DefaultTileBuffer),
+				TileExtent: uint64(mvt.DefaultExtent),
+			},
+			tile: slippy.NewTile(2, 3, 3),
+			expected: []map[string]interface{}{
+				{"type": "debug_buffer_outline", "foo": "bar", "layer": "layer1"},
+				{"type": "debug_buffer_outline", "layer": "layer2"},
+			},
+			bounds: [4]float64{90, -85.06, 180, -66.5},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+// geoJSONPoints returns the positions of decoded GeoJSON coordinates of any depth
+func geoJSONPoints(coords interface{}) (pts [][2]float64) {
+	vals, ok := coords.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	if len(vals) >= 2 {
+		x, xok := vals[0].(float64)
+		y, yok := vals[1].(float64)
+		if xok && yok {
+			return [][2]float64{{x, y}}
+		}
+	}
+
+	for _, v := range vals {
+		pts = append(pts, geoJSONPoints(v)...)
+	}
+
+	return pts
+}
+
+func TestEncodeMVTProvider(t *testing.T) {
+	grid := atlas.Map{
+		Layers: []atlas.Layer{
+			{
+				Name:     "native",
+				Provider: &test.MVTTileProvider{},
+			},
+			{
+				Name:     "pipeline",
+				Provider: &test.TileProvider{},
+			},
+			{
+				Name:     "native2",
+				Provider: &test.MVTTileProvider{},
+			},
+		},
+		TileBuffer: uint64(tegola.DefaultTileBuffer),
+		TileExtent: uint64(mvt.DefaultExtent),
+	}
+
+	out, err := grid.EncodeMVTTile(context.Background(), slippy.NewTile(2, 3, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var tile vectorTile.Tile
+	if err = proto.Unmarshal(out, &tile); err != nil {
+		t.Fatalf("error unmarshalling output: %v", err)
+	}
+
+	expected := []string{"native", "pipeline", "native2"}
+	if len(tile.Layers) != len(expected) {
+		t.Fatalf("expected (%d) layers, got (%d)", len(expected), len(tile.Layers))
+	}
+
+	for i, l := range tile.Layers {
+		if l.GetName() != expected[i] {
+			t.Errorf("layer (%d) expected name %v got %v", i, expected[i], l.GetName())
+		}
+		if len(l.Features) != 1 {
+			t.Errorf("layer (%d) expected 1 feature got %v", i, len(l.Features))
+		}
+	}
+
+	if tile.Layers[0].GetFeatures()[0].GetType() != vectorTile.Tile_POINT {
+		t.Errorf("expected the native layer to hold a point")
+	}
+}
+
+// timeoutProvider is a provider whose layer queries time out
+type timeoutProvider struct {
+	test.TileProvider
+}
+
+func (timeoutProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	return provider.ErrQueryTimeout{Provider: "test", Layer: layer, Timeout: time.Second}
+}
+
+// timeoutMVTProvider is a native vector tile provider whose layer queries time out
+type timeoutMVTProvider struct {
+	test.MVTTileProvider
+}
+
+func (timeoutMVTProvider) MVTForLayer(ctx context.Context, layer string, mvtName string, t provider.Tile, extent uint) ([]byte, error) {
+	return nil, provider.ErrQueryTimeout{Provider: "test", Layer: layer, Timeout: time.Second}
+}
+
+func TestEncodeMVTTileQueryTimeout(t *testing.T) {
+	type tcase struct {
+		provider provider.Tiler
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			m := atlas.Map{
+				Layers: []atlas.Layer{
+					{
+						Name:     "ok",
+						Provider: &test.TileProvider{},
+					},
+					{
+						Name:              "slow",
+						ProviderLayerName: "slow-layer",
+						Provider:          tc.provider,
+					},
+				},
+				TileBuffer: uint64(tegola.DefaultTileBuffer),
+				TileExtent: uint64(mvt.DefaultExtent),
+			}
+
+			expected := provider.ErrQueryTimeout{Provider: "test", Layer: "slow-layer", Timeout: time.Second}
+
+			_, err := m.EncodeMVTTile(context.Background(), slippy.NewTile(2, 3, 3))
+			if err != expected {
+				t.Errorf("error, expected %v got %v", expected, err)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"features provider": {
+			provider: &timeoutProvider{},
+		},
+		"mvt provider": {
+			provider: &timeoutMVTProvider{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
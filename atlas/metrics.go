@@ -0,0 +1,23 @@
+package atlas
+
+import (
+	"github.com/go-spatial/tegola/internal/metrics"
+)
+
+var (
+	// providerQueryDuration tracks the time spent fetching a layer from its provider. For
+	// providers which return features it includes the time spent processing the features.
+	providerQueryDuration = metrics.NewHistogramVec(
+		"tegola_provider_query_duration_seconds",
+		"Time spent fetching the features of a map layer from its provider.",
+		metrics.DefaultDurationBuckets,
+		"map", "layer",
+	)
+
+	// providerErrors counts the failed provider fetches. canceled requests are not counted.
+	providerErrors = metrics.NewCounterVec(
+		"tegola_provider_errors_total",
+		"Number of errors returned by providers when fetching the features of a map layer.",
+		"map", "layer",
+	)
+)
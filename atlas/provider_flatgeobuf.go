@@ -0,0 +1,12 @@
+// +build !noFlatGeobufProvider
+
+package atlas
+
+// The point of this file is to load and register the FlatGeobuf provider.
+// the FlatGeobuf provider can be excluded during the build with the `noFlatGeobufProvider` build flag
+// for example from the cmd/tegola directory:
+//
+// go build -tags 'noFlatGeobufProvider'
+import (
+	_ "github.com/go-spatial/tegola/provider/flatgeobuf"
+)
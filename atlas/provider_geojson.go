@@ -0,0 +1,12 @@
+// +build !noGeoJSONProvider
+
+package atlas
+
+// The point of this file is to load and register the GeoJSON provider.
+// the GeoJSON provider can be excluded during the build with the `noGeoJSONProvider` build flag
+// for example from the cmd/tegola directory:
+//
+// go build -tags 'noGeoJSONProvider'
+import (
+	_ "github.com/go-spatial/tegola/provider/geojson"
+)
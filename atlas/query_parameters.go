@@ -0,0 +1,79 @@
+package atlas
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/go-spatial/tegola/provider"
+)
+
+// QueryParameterValues returns the values of the map's query parameters read from
+// the query string. Parameters the query string does not set use their default.
+func (m Map) QueryParameterValues(query url.Values) (provider.QueryParameterValues, error) {
+	vals := provider.QueryParameterValues{}
+
+	for _, p := range m.Params {
+		raw, ok := query[p.Name]
+		switch {
+		case ok && len(raw) > 0:
+			// the first value is used, like url.Values.Get
+		case p.Default != nil:
+			raw = []string{*p.Default}
+		default:
+			return nil, provider.ErrMissingQueryParameter{Name: p.Name}
+		}
+
+		val, err := p.Parse(raw[0])
+		if err != nil {
+			return nil, err
+		}
+		vals[p.Token()] = val
+	}
+
+	return vals, nil
+}
+
+// QueryParameterKey returns the query string values of the map's query parameters
+// which don't equal their default, encoded in a stable order (i.e. "year=2019").
+// An empty string is returned when the tile is rendered with the default values so
+// the key identifies the tile rendered for the query string.
+func (m Map) QueryParameterKey(query url.Values) (string, error) {
+	vals, err := m.QueryParameterValues(query)
+	if err != nil {
+		return "", err
+	}
+
+	key := url.Values{}
+	for _, p := range m.Params {
+		val := vals[p.Token()]
+		if p.Default != nil {
+			// the default has been checked when the parameter was created
+			if def, _ := p.Parse(*p.Default); def == val {
+				continue
+			}
+		}
+		key.Set(p.Name, fmt.Sprintf("%v", val))
+	}
+
+	return key.Encode(), nil
+}
+
+// queryParameterContext returns a context carrying the values of the map's query
+// parameters. When ctx does not carry them, i.e. when seeding the cache, the
+// default values are used.
+func (m Map) queryParameterContext(ctx context.Context) (context.Context, error) {
+	if len(m.Params) == 0 {
+		return ctx, nil
+	}
+	if _, ok := provider.QueryParametersFromContext(ctx); ok {
+		return ctx, nil
+	}
+
+	vals, err := m.QueryParameterValues(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.ContextWithQueryParameters(ctx, vals), nil
+}
@@ -0,0 +1,150 @@
+package atlas_test
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/test"
+)
+
+func newQueryParameter(t *testing.T, name, typ string, def *string, values ...string) provider.QueryParameter {
+	p, err := provider.NewQueryParameter(name, typ, def, values, "")
+	if err != nil {
+		t.Fatalf("error creating query parameter, expected nil got %v", err)
+	}
+	return p
+}
+
+func TestMapQueryParameters(t *testing.T) {
+	year := "2019"
+
+	type tcase struct {
+		query     string
+		expected  provider.QueryParameterValues
+		expKey    string
+		expectErr error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			m := atlas.Map{
+				Params: []provider.QueryParameter{
+					newQueryParameter(t, "year", provider.QueryParameterTypeInt, &year),
+					newQueryParameter(t, "category", provider.QueryParameterTypeString, nil, "roads", "rail"),
+				},
+			}
+
+			query, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("error parsing query, expected nil got %v", err)
+			}
+
+			vals, err := m.QueryParameterValues(query)
+			if err != tc.expectErr {
+				t.Errorf("error, expected %v got %v", tc.expectErr, err)
+				return
+			}
+			if !reflect.DeepEqual(vals, tc.expected) {
+				t.Errorf("values, expected %v got %v", tc.expected, vals)
+			}
+
+			key, err := m.QueryParameterKey(query)
+			if err != tc.expectErr {
+				t.Errorf("key error, expected %v got %v", tc.expectErr, err)
+				return
+			}
+			if key != tc.expKey {
+				t.Errorf("key, expected %q got %q", tc.expKey, key)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"default": {
+			query:    "category=roads",
+			expected: provider.QueryParameterValues{"!PARAM_YEAR!": int64(2019), "!PARAM_CATEGORY!": "roads"},
+			expKey:   "category=roads",
+		},
+		"set": {
+			query:    "year=2020&category=rail&debug=true",
+			expected: provider.QueryParameterValues{"!PARAM_YEAR!": int64(2020), "!PARAM_CATEGORY!": "rail"},
+			expKey:   "category=rail&year=2020",
+		},
+		"set to the default": {
+			query:    "year=02019&category=rail",
+			expected: provider.QueryParameterValues{"!PARAM_YEAR!": int64(2019), "!PARAM_CATEGORY!": "rail"},
+			expKey:   "category=rail",
+		},
+		"missing": {
+			query:     "year=2020",
+			expectErr: provider.ErrMissingQueryParameter{Name: "category"},
+		},
+		"invalid": {
+			query:     "year=2020&category=water",
+			expectErr: provider.ErrInvalidQueryParameter{Name: "category", Value: "water", Reason: "not one of roads, rail"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+// paramsProvider records the query parameters its layers are queried with
+type paramsProvider struct {
+	test.TileProvider
+	params provider.QueryParameterValues
+}
+
+func (p *paramsProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	p.params, _ = provider.QueryParametersFromContext(ctx)
+	return nil
+}
+
+func TestEncodeQueryParameters(t *testing.T) {
+	year := "2019"
+
+	type tcase struct {
+		ctx      context.Context
+		expected provider.QueryParameterValues
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			prvd := &paramsProvider{}
+			m := atlas.NewWebMercatorMap("test")
+			m.Layers = []atlas.Layer{{Name: "layer", Provider: prvd}}
+			m.Params = []provider.QueryParameter{
+				newQueryParameter(t, "year", provider.QueryParameterTypeInt, &year),
+			}
+
+			if _, err := m.EncodeMVTTile(tc.ctx, slippy.NewTile(2, 3, 3)); err != nil {
+				t.Fatalf("error, expected nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(prvd.params, tc.expected) {
+				t.Errorf("params, expected %v got %v", tc.expected, prvd.params)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"request values": {
+			ctx:      provider.ContextWithQueryParameters(context.Background(), provider.QueryParameterValues{"!PARAM_YEAR!": int64(2020)}),
+			expected: provider.QueryParameterValues{"!PARAM_YEAR!": int64(2020)},
+		},
+		"defaults": {
+			ctx:      context.Background(),
+			expected: provider.QueryParameterValues{"!PARAM_YEAR!": int64(2019)},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-spatial/geom"
 	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/maths/proj"
 	"github.com/go-spatial/tegola/maths/webmercator"
 )
 
@@ -17,6 +18,22 @@ func ApplyToPoints(geometry geom.Geometry, f func(coords ...float64) ([]float64,
 	default:
 		return nil, fmt.Errorf("unknown Geometry: %T", geometry)
 
+	// the geom package's processing (i.e. makevalid) returns pointers to geometries
+	case *geom.Point:
+		return ApplyToPoints(*geo, f)
+	case *geom.MultiPoint:
+		return ApplyToPoints(*geo, f)
+	case *geom.LineString:
+		return ApplyToPoints(*geo, f)
+	case *geom.MultiLineString:
+		return ApplyToPoints(*geo, f)
+	case *geom.Polygon:
+		return ApplyToPoints(*geo, f)
+	case *geom.MultiPolygon:
+		return ApplyToPoints(*geo, f)
+	case *geom.Collection:
+		return ApplyToPoints(*geo, f)
+
 	case geom.Point:
 		c, err := f(geo.X(), geo.Y())
 		if err != nil {
@@ -115,6 +132,19 @@ func ApplyToPoints(geometry geom.Geometry, f func(coords ...float64) ([]float64,
 			mpoly[i] = polyv
 		}
 		return mpoly, nil
+
+	case geom.Collection:
+		coll := make(geom.Collection, len(geo))
+
+		for i, g := range geo {
+			gi, err := ApplyToPoints(g, f)
+			if err != nil {
+				return nil, fmt.Errorf("got error converting geometry(%v) of collection: %v", i, err)
+			}
+
+			coll[i] = gi
+		}
+		return coll, nil
 	}
 }
 
@@ -197,14 +227,44 @@ func CloneGeometry(geometry geom.Geometry) (geom.Geometry, error) {
 			mpoly[i] = polyv
 		}
 		return mpoly, nil
+
+	case geom.Collection:
+		coll := make(geom.Collection, len(geo))
+		for i, g := range geo {
+			gi, err := CloneGeometry(g)
+			if err != nil {
+				return nil, fmt.Errorf("got error converting geometry(%v) of collection: %v", i, err)
+			}
+
+			coll[i] = gi
+		}
+		return coll, nil
 	}
 }
 
+// Transform takes a geometry encoded using the fromSRID and returns a copy of the geometry encoded
+// using the toSRID. The SRIDs must be known to the proj package registry.
+func Transform(fromSRID, toSRID uint64, geometry geom.Geometry) (geom.Geometry, error) {
+	if fromSRID == toSRID {
+		// Instead of just returning the geometry, we are cloning it so that the user of the API can rely
+		// on the result to alway be a copy. Instead of being a reference in the on instance that it's already
+		// in the same SRID.
+		return CloneGeometry(geometry)
+	}
+
+	fn, err := proj.Transformer(fromSRID, toSRID)
+	if err != nil {
+		return nil, fmt.Errorf("don't know how to convert from %v to %v: %v", fromSRID, toSRID, err)
+	}
+
+	return ApplyToPoints(geometry, fn)
+}
+
 // ToWebMercator takes a SRID and a geometry encode using that srid, and returns a geometry encoded as a WebMercator.
 func ToWebMercator(SRID uint64, geometry geom.Geometry) (geom.Geometry, error) {
 	switch SRID {
 	default:
-		return nil, fmt.Errorf("don't know how to convert from %v to %v.", tegola.WebMercator, SRID)
+		return Transform(SRID, tegola.WebMercator, geometry)
 	case tegola.WebMercator:
 		// Instead of just returning the geometry, we are cloning it so that the user of the API can rely
 		// on the result to alway be a copy. Instead of being a reference in the on instance that it's already
@@ -221,7 +281,7 @@ func ToWebMercator(SRID uint64, geometry geom.Geometry) (geom.Geometry, error) {
 func FromWebMercator(SRID uint64, geometry geom.Geometry) (geom.Geometry, error) {
 	switch SRID {
 	default:
-		return nil, fmt.Errorf("don't know how to convert from %v to %v.", SRID, tegola.WebMercator)
+		return Transform(tegola.WebMercator, SRID, geometry)
 	case tegola.WebMercator:
 		// Instead of just returning the geometry, we are cloning it so that the user of the API can rely
 		// on the result to alway be a copy. Instead of being a reference in the on instance that it's already
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/dict"
@@ -20,7 +21,19 @@ type Interface interface {
 	Purge(key *Key) error
 }
 
-// ParseKey will parse a string in the format /:map/:layer/:z/:x/:y into a Key struct. The :layer value is optional
+// Locker is implemented by cache backends which can lock a key across tegola instances.
+// It's used to stop several instances from rendering the same tile at the same time.
+type Locker interface {
+	// Lock tries to lock the key. When the lock is acquired unlock must be called to
+	// release it. A lock which is not released expires after LockTTL.
+	Lock(key *Key) (unlock func(), acquired bool, err error)
+	// LockTTL is the max duration a lock is held for
+	LockTTL() time.Duration
+}
+
+// ParseKey will parse a string in the format /:map/:layer/:z/:x/:y into a Key struct. The :layer value is optional.
+// An extension on the :y value (i.e. 123.geojson) is used as the Format of the key. The "pbf" and "mvt"
+// extensions are the default format.
 // ParseKey also supports other OS delimeters (i.e. Windows - "\")
 func ParseKey(str string) (*Key, error) {
 	var err error
@@ -103,6 +116,14 @@ func ParseKey(str string) (*Key, error) {
 	}
 	key.Y = uint(placeholder)
 
+	if len(yParts) > 1 {
+		switch ext := yParts[len(yParts)-1]; ext {
+		case "pbf", "mvt":
+		default:
+			key.Format = ext
+		}
+	}
+
 	return &key, nil
 }
 
@@ -112,15 +133,26 @@ type Key struct {
 	Z         uint
 	X         uint
 	Y         uint
+	// Format of the tile (i.e. "geojson"). Empty for the default mvt format
+	Format string
+	// Params are the encoded query parameters the tile was rendered with (i.e. "year=2019").
+	// Empty for tiles rendered with the default query parameters of the map
+	Params string
 }
 
 func (k Key) String() string {
+	y := strconv.FormatUint(uint64(k.Y), 10)
+	if k.Format != "" {
+		y += "." + k.Format
+	}
+
 	return filepath.Join(
 		k.MapName,
 		k.LayerName,
+		k.Params,
 		strconv.FormatUint(uint64(k.Z), 10),
 		strconv.FormatUint(uint64(k.X), 10),
-		strconv.FormatUint(uint64(k.Y), 10))
+		y)
 }
 
 // InitFunc initilize a cache given a config map.
@@ -39,6 +39,26 @@ func TestParseKey(t *testing.T) {
 				LayerName: "buildings",
 			},
 		},
+		{
+			input: "/osm/12/11/123.pbf",
+			expected: &cache.Key{
+				Z:       12,
+				X:       11,
+				Y:       123,
+				MapName: "osm",
+			},
+		},
+		{
+			input: "/osm/buildings/12/11/123.geojson",
+			expected: &cache.Key{
+				Z:         12,
+				X:         11,
+				Y:         123,
+				MapName:   "osm",
+				LayerName: "buildings",
+				Format:    "geojson",
+			},
+		},
 	}
 
 	for i, tc := range testcases {
@@ -54,3 +74,25 @@ func TestParseKey(t *testing.T) {
 		}
 	}
 }
+
+func TestKeyString(t *testing.T) {
+	testcases := []struct {
+		key      cache.Key
+		expected string
+	}{
+		{
+			key:      cache.Key{MapName: "osm", Z: 12, X: 11, Y: 123},
+			expected: "osm/12/11/123",
+		},
+		{
+			key:      cache.Key{MapName: "osm", LayerName: "buildings", Z: 12, X: 11, Y: 123, Format: "geojson"},
+			expected: "osm/buildings/12/11/123.geojson",
+		},
+	}
+
+	for i, tc := range testcases {
+		if output := tc.key.String(); output != tc.expected {
+			t.Errorf("testcase (%v) failed. expected (%v) does not match output (%v)", i, tc.expected, output)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package mbtiles
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingFilepath = errors.New("mbtiles: missing required param 'filepath'")
+	ErrMissingMap      = errors.New("mbtiles: missing required param 'map'")
+)
+
+type ErrInvalidFile struct {
+	Filepath string
+	Err      error
+}
+
+func (e ErrInvalidFile) Error() string {
+	return fmt.Sprintf("mbtiles: unable to set up the tables of file (%v): %v", e.Filepath, e.Err)
+}
@@ -0,0 +1,207 @@
+// +build cgo
+
+// Package mbtiles implements a cache backed by an MBTiles SQLite file. The file
+// holds the vector tiles of a single map and can be shipped as an offline tile
+// package or served by tegola.
+package mbtiles
+
+import (
+	"database/sql"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/dict"
+)
+
+const CacheType = "mbtiles"
+
+const (
+	ConfigKeyFilepath = "filepath"
+	ConfigKeyMap      = "map"
+	ConfigKeyMaxZoom  = "max_zoom"
+)
+
+func init() {
+	cache.Register(CacheType, New)
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS metadata (name TEXT, value TEXT);
+CREATE UNIQUE INDEX IF NOT EXISTS metadata_index ON metadata (name);
+CREATE TABLE IF NOT EXISTS tiles (zoom_level INTEGER, tile_column INTEGER, tile_row INTEGER, tile_data BLOB);
+CREATE UNIQUE INDEX IF NOT EXISTS tile_index ON tiles (zoom_level, tile_column, tile_row);
+`
+
+// New instantiates a Cache. The file is created if it does not exist. The config expects the following params:
+//
+//	filepath (string): [Required] the path of the MBTiles file
+//	map (string): [Required] the name of the map the file holds the tiles of
+//	max_zoom (int): [Optional] max zoom to use the cache. beyond this zoom cache Set() calls will be ignored
+func New(config dict.Dicter) (cache.Interface, error) {
+	var err error
+
+	c := Cache{}
+
+	if c.Filepath, err = config.String(ConfigKeyFilepath, nil); err != nil || c.Filepath == "" {
+		return nil, ErrMissingFilepath
+	}
+
+	if c.MapName, err = config.String(ConfigKeyMap, nil); err != nil || c.MapName == "" {
+		return nil, ErrMissingMap
+	}
+
+	defaultMaxZoom := uint(tegola.MaxZ)
+	if c.MaxZoom, err = config.Uint(ConfigKeyMaxZoom, &defaultMaxZoom); err != nil {
+		return nil, err
+	}
+
+	// wait for locks held by the writer instead of failing reads with SQLITE_BUSY
+	if c.db, err = sql.Open("sqlite3", c.Filepath+"?_busy_timeout=5000"); err != nil {
+		return nil, err
+	}
+
+	if _, err = c.db.Exec(schema); err != nil {
+		c.db.Close()
+		return nil, ErrInvalidFile{Filepath: c.Filepath, Err: err}
+	}
+
+	return &c, nil
+}
+
+// Cache stores the vector tiles of a map in an MBTiles file. Only the tiles of the
+// whole map in the default (mvt) format and without query parameters are stored,
+// requests for other maps, single layers, other formats or with query parameters
+// are cache misses.
+type Cache struct {
+	Filepath string
+	// MapName is the name of the map the file holds the tiles of
+	MapName string
+	// MaxZoom determines the max zoom the cache to persist. Beyond this
+	// zoom, cache Set() calls will be ignored.
+	MaxZoom uint
+
+	// sqlite only supports a single writer
+	sync.Mutex
+	db *sql.DB
+}
+
+// holds reports if the file holds the tile of the key
+func (c *Cache) holds(key *cache.Key) bool {
+	// the tiles table is keyed by zoom, column and row only
+	return key.MapName == c.MapName && key.LayerName == "" && key.Format == "" && key.Params == ""
+}
+
+func (c *Cache) Get(key *cache.Key) ([]byte, bool, error) {
+	if !c.holds(key) {
+		return nil, false, nil
+	}
+
+	var data []byte
+	err := c.db.QueryRow(
+		"SELECT tile_data FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?",
+		key.Z, key.X, flipY(key.Z, key.Y),
+	).Scan(&data)
+	switch err {
+	case nil:
+		return data, true, nil
+	case sql.ErrNoRows:
+		return nil, false, nil
+	default:
+		return nil, false, err
+	}
+}
+
+func (c *Cache) Set(key *cache.Key, val []byte) error {
+	if !c.holds(key) || key.Z > c.MaxZoom {
+		return nil
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	_, err := c.db.Exec(
+		"INSERT OR REPLACE INTO tiles (zoom_level, tile_column, tile_row, tile_data) VALUES (?, ?, ?, ?)",
+		key.Z, key.X, flipY(key.Z, key.Y), val,
+	)
+	return err
+}
+
+func (c *Cache) Purge(key *cache.Key) error {
+	if !c.holds(key) {
+		return nil
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	_, err := c.db.Exec(
+		"DELETE FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?",
+		key.Z, key.X, flipY(key.Z, key.Y),
+	)
+	return err
+}
+
+// SetMetadata replaces the metadata of the file
+func (c *Cache) SetMetadata(md Metadata) error {
+	rows, err := md.rows()
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM metadata"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, row := range rows {
+		if _, err = tx.Exec("INSERT INTO metadata (name, value) VALUES (?, ?)", row[0], row[1]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Metadata returns the name / value rows of the metadata table
+func (c *Cache) Metadata() (map[string]string, error) {
+	rows, err := c.db.Query("SELECT name, value FROM metadata")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	md := map[string]string{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		md[name] = value
+	}
+
+	return md, rows.Err()
+}
+
+// ZoomRange returns the min and max zoom of the tiles in the file. Both are 0 when the file has no tiles.
+func (c *Cache) ZoomRange() (min, max uint, err error) {
+	var minZoom, maxZoom sql.NullInt64
+	err = c.db.QueryRow("SELECT MIN(zoom_level), MAX(zoom_level) FROM tiles").Scan(&minZoom, &maxZoom)
+	return uint(minZoom.Int64), uint(maxZoom.Int64), err
+}
+
+// Close closes the file
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
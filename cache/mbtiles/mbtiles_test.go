@@ -0,0 +1,215 @@
+// +build cgo
+
+package mbtiles_test
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/mbtiles"
+	"github.com/go-spatial/tegola/dict"
+)
+
+func newTestCache(t *testing.T) (*mbtiles.Cache, func()) {
+	dir, err := ioutil.TempDir("", "tegola-mbtiles")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+
+	c, err := mbtiles.New(dict.Dict{
+		"filepath": filepath.Join(dir, "test.mbtiles"),
+		"map":      "test-map",
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	mbt := c.(*mbtiles.Cache)
+	return mbt, func() {
+		mbt.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestNew(t *testing.T) {
+	type tcase struct {
+		config dict.Dict
+		err    error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			_, err := mbtiles.New(tc.config)
+			if err != tc.err {
+				t.Errorf("error, expected %v got %v", tc.err, err)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"missing filepath": {
+			config: dict.Dict{"map": "test-map"},
+			err:    mbtiles.ErrMissingFilepath,
+		},
+		"missing map": {
+			config: dict.Dict{"filepath": "test.mbtiles"},
+			err:    mbtiles.ErrMissingMap,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestSetGetPurge(t *testing.T) {
+	c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	type tcase struct {
+		key    cache.Key
+		expHit bool
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			val := []byte("tile")
+
+			if err := c.Set(&tc.key, val); err != nil {
+				t.Fatalf("set, unexpected error %v", err)
+			}
+
+			got, hit, err := c.Get(&tc.key)
+			if err != nil {
+				t.Fatalf("get, unexpected error %v", err)
+			}
+			if hit != tc.expHit {
+				t.Fatalf("hit, expected %v got %v", tc.expHit, hit)
+			}
+			if !hit {
+				return
+			}
+			if !reflect.DeepEqual(got, val) {
+				t.Errorf("val, expected %s got %s", val, got)
+			}
+
+			if err := c.Purge(&tc.key); err != nil {
+				t.Fatalf("purge, unexpected error %v", err)
+			}
+			if _, hit, _ := c.Get(&tc.key); hit {
+				t.Errorf("expected miss after purge")
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"map tile": {
+			key:    cache.Key{MapName: "test-map", Z: 3, X: 1, Y: 2},
+			expHit: true,
+		},
+		"other map": {
+			key: cache.Key{MapName: "other-map", Z: 3, X: 1, Y: 2},
+		},
+		"layer tile": {
+			key: cache.Key{MapName: "test-map", LayerName: "water", Z: 3, X: 1, Y: 2},
+		},
+		"geojson tile": {
+			key: cache.Key{MapName: "test-map", Z: 3, X: 1, Y: 2, Format: "geojson"},
+		},
+		"query parameters tile": {
+			key: cache.Key{MapName: "test-map", Z: 3, X: 1, Y: 2, Params: "year=2019"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestTMSRows(t *testing.T) {
+	c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	if err := c.Set(&cache.Key{MapName: "test-map", Z: 2, X: 1, Y: 0}, []byte("tile")); err != nil {
+		t.Fatalf("set, unexpected error %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", c.Filepath)
+	if err != nil {
+		t.Fatalf("unable to open file: %v", err)
+	}
+	defer db.Close()
+
+	// xyz row 0 at zoom 2 is tms row 3
+	var row int
+	if err := db.QueryRow("SELECT tile_row FROM tiles WHERE zoom_level = 2 AND tile_column = 1").Scan(&row); err != nil {
+		t.Fatalf("unable to read tile row: %v", err)
+	}
+	if row != 3 {
+		t.Errorf("tile_row, expected 3 got %v", row)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	for _, z := range []uint{2, 5} {
+		if err := c.Set(&cache.Key{MapName: "test-map", Z: z}, []byte("tile")); err != nil {
+			t.Fatalf("set, unexpected error %v", err)
+		}
+	}
+
+	min, max, err := c.ZoomRange()
+	if err != nil {
+		t.Fatalf("zoom range, unexpected error %v", err)
+	}
+	if min != 2 || max != 5 {
+		t.Errorf("zoom range, expected 2-5 got %v-%v", min, max)
+	}
+
+	md := mbtiles.Metadata{
+		Name:    "test-map",
+		Bounds:  [4]float64{-180, -85.0511, 180, 85.0511},
+		Center:  [3]float64{1.5, 2, 3},
+		MinZoom: min,
+		MaxZoom: max,
+		VectorLayers: []mbtiles.VectorLayer{
+			{ID: "water", MinZoom: 2, MaxZoom: 5, Fields: map[string]string{"class": "String"}},
+			{ID: "roads", MinZoom: 4, MaxZoom: 5},
+		},
+	}
+
+	// writing the metadata twice replaces it
+	for i := 0; i < 2; i++ {
+		if err := c.SetMetadata(md); err != nil {
+			t.Fatalf("set metadata, unexpected error %v", err)
+		}
+	}
+
+	got, err := c.Metadata()
+	if err != nil {
+		t.Fatalf("metadata, unexpected error %v", err)
+	}
+
+	expected := map[string]string{
+		"name":    "test-map",
+		"format":  "pbf",
+		"type":    "overlay",
+		"version": "1",
+		"bounds":  "-180,-85.0511,180,85.0511",
+		"center":  "1.5,2,3",
+		"minzoom": "2",
+		"maxzoom": "5",
+		"json":    `{"vector_layers":[{"id":"water","description":"","minzoom":2,"maxzoom":5,"fields":{"class":"String"}},{"id":"roads","description":"","minzoom":4,"maxzoom":5,"fields":{}}]}`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("metadata, expected\n%v\ngot\n%v", expected, got)
+	}
+}
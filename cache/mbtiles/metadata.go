@@ -0,0 +1,107 @@
+package mbtiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Metadata describes the tileset of an MBTiles file. It's written to the
+// metadata table as described by the MBTiles 1.3 spec:
+// https://github.com/mapbox/mbtiles-spec/blob/master/1.3/spec.md
+type Metadata struct {
+	Name        string
+	Description string
+	Attribution string
+	// Bounds in WGS84 in the order left, bottom, right, top
+	Bounds [4]float64
+	// Center in WGS84 as longitude, latitude and zoom
+	Center  [3]float64
+	MinZoom uint
+	MaxZoom uint
+	// VectorLayers describes the layers of the vector tiles
+	VectorLayers []VectorLayer
+}
+
+// VectorLayer describes a layer of the vector tiles
+type VectorLayer struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	MinZoom     uint   `json:"minzoom"`
+	MaxZoom     uint   `json:"maxzoom"`
+	// Fields maps the attribute names of the layer to their types: "Number", "Boolean" or "String"
+	Fields map[string]string `json:"fields"`
+}
+
+// rows returns the name / value rows of the metadata table
+func (md Metadata) rows() ([][2]string, error) {
+	layers := md.VectorLayers
+	if layers == nil {
+		layers = []VectorLayer{}
+	}
+	for i := range layers {
+		// the spec requires the fields object
+		if layers[i].Fields == nil {
+			layers[i].Fields = map[string]string{}
+		}
+	}
+
+	vectorLayers, err := json.Marshal(struct {
+		VectorLayers []VectorLayer `json:"vector_layers"`
+	}{layers})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][2]string{
+		{"name", md.Name},
+		{"format", "pbf"},
+		{"type", "overlay"},
+		{"version", "1"},
+		{"bounds", joinFloats(md.Bounds[:])},
+		{"center", joinFloats(md.Center[:])},
+		{"minzoom", strconv.FormatUint(uint64(md.MinZoom), 10)},
+		{"maxzoom", strconv.FormatUint(uint64(md.MaxZoom), 10)},
+		{"json", string(vectorLayers)},
+	}
+
+	if md.Description != "" {
+		rows = append(rows, [2]string{"description", md.Description})
+	}
+	if md.Attribution != "" {
+		rows = append(rows, [2]string{"attribution", md.Attribution})
+	}
+
+	return rows, nil
+}
+
+func joinFloats(fs []float64) string {
+	strs := make([]string, len(fs))
+	for i := range fs {
+		strs[i] = strconv.FormatFloat(fs[i], 'f', -1, 64)
+	}
+	return strings.Join(strs, ",")
+}
+
+// FieldType returns the MBTiles field type of a tag value
+func FieldType(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "Boolean"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return "Number"
+	default:
+		return "String"
+	}
+}
+
+// flipY converts between the XYZ row of a tile and the TMS row used by MBTiles
+func flipY(z, y uint) uint {
+	return (1 << z) - 1 - y
+}
+
+// String returns a description of the metadata for logging
+func (md Metadata) String() string {
+	return fmt.Sprintf("%v (zoom %v-%v, %v layers)", md.Name, md.MinZoom, md.MaxZoom, len(md.VectorLayers))
+}
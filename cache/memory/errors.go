@@ -0,0 +1,15 @@
+package memory
+
+import "fmt"
+
+type ErrInvalidEviction string
+
+func (e ErrInvalidEviction) Error() string {
+	return fmt.Sprintf("memory: invalid eviction policy (%v). supported policies: %v, %v", string(e), EvictionLRU, EvictionLFU)
+}
+
+type ErrInvalidTTL int
+
+func (e ErrInvalidTTL) Error() string {
+	return fmt.Sprintf("memory: invalid ttl (%v). ttl must not be negative", int(e))
+}
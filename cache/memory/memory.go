@@ -2,46 +2,202 @@ package memory
 
 import (
 	"sync"
+	"time"
 
 	"github.com/go-spatial/tegola/cache"
 	"github.com/go-spatial/tegola/dict"
 )
 
-const CacheType  = "memory"
+const CacheType = "memory"
+
+const (
+	ConfigKeyMaxBytes   = "max_bytes"
+	ConfigKeyMaxEntries = "max_entries"
+	ConfigKeyEviction   = "eviction"
+	ConfigKeyTTL        = "ttl"
+)
+
+// eviction policies
+const (
+	// EvictionLRU evicts the least recently used tile first. It's the default.
+	EvictionLRU = "lru"
+	// EvictionLFU evicts the least frequently used tile first. Ties are broken
+	// by evicting the least recently used tile.
+	EvictionLFU = "lfu"
+)
 
 func init() {
 	cache.Register(CacheType, New)
 }
 
-func New(_ dict.Dicter) (cache.Interface, error) {
-	return &MemoryCache{
-		keyVals: map[string][]byte{},
-	}, nil
+// New instantiates a MemoryCache. The config expects the following optional params:
+//
+//	max_bytes (int): the max size in bytes of the cached tiles. 0 (default) is unbounded.
+//	max_entries (int): the max number of cached tiles. 0 (default) is unbounded.
+//	eviction (string): the eviction policy when a limit is reached, "lru" (default) or "lfu".
+//	ttl (int): the number of seconds a tile is cached for. 0 (default) does not expire tiles.
+func New(config dict.Dicter) (cache.Interface, error) {
+	mc := MemoryCache{}
+
+	// the memory cache is also used without a config in tests
+	if config == nil {
+		config = dict.Dict{}
+	}
+
+	var err error
+
+	defaultLimit := uint(0)
+	if mc.MaxBytes, err = config.Uint(ConfigKeyMaxBytes, &defaultLimit); err != nil {
+		return nil, err
+	}
+	if mc.MaxEntries, err = config.Uint(ConfigKeyMaxEntries, &defaultLimit); err != nil {
+		return nil, err
+	}
+
+	defaultEviction := EvictionLRU
+	if mc.Eviction, err = config.String(ConfigKeyEviction, &defaultEviction); err != nil {
+		return nil, err
+	}
+
+	defaultTTL := 0
+	ttl, err := config.Int(ConfigKeyTTL, &defaultTTL)
+	if err != nil {
+		return nil, err
+	}
+	if ttl < 0 {
+		return nil, ErrInvalidTTL(ttl)
+	}
+	mc.TTL = time.Duration(ttl) * time.Second
+
+	if err = mc.init(); err != nil {
+		return nil, err
+	}
+
+	return &mc, nil
 }
 
-// test cacher, implements the cache.Interface
+// MemoryCache caches tiles in memory and implements the cache.Interface.
+// When MaxBytes or MaxEntries is reached tiles are evicted using the Eviction policy.
+// The limits and policy must not be changed once the cache is in use.
 type MemoryCache struct {
-	keyVals map[string][]byte
-	sync.RWMutex
+	// MaxBytes is the max size in bytes of the cached tiles. 0 is unbounded.
+	MaxBytes uint
+	// MaxEntries is the max number of cached tiles. 0 is unbounded.
+	MaxEntries uint
+	// Eviction is the eviction policy, EvictionLRU (default) or EvictionLFU.
+	Eviction string
+	// TTL is how long a tile is cached for. 0 does not expire tiles. Expired
+	// tiles are removed when they're read or evicted to make room.
+	TTL time.Duration
+
+	sync.Mutex
+	entries map[string]*entry
+	policy  policy
+	stats   Stats
+}
+
+// Stats reports the occupancy of the cache and the number of tiles removed
+type Stats struct {
+	// Entries is the number of cached tiles
+	Entries int
+	// Bytes is the size in bytes of the cached tiles
+	Bytes int
+	// Evictions is the number of tiles evicted to stay within the limits
+	Evictions uint64
+	// Expirations is the number of expired tiles removed
+	Expirations uint64
+}
+
+// entry is a cached tile
+type entry struct {
+	key     string
+	val     []byte
+	expires time.Time
+
+	// bookkeeping for the eviction policy
+	policyData
+}
+
+// init sets up the cache. it's safe to call more than once and allows the zero
+// value MemoryCache to be used.
+func (mc *MemoryCache) init() error {
+	if mc.entries != nil {
+		return nil
+	}
+
+	switch mc.Eviction {
+	case "", EvictionLRU:
+		mc.policy = newLRU()
+	case EvictionLFU:
+		mc.policy = newLFU()
+	default:
+		return ErrInvalidEviction(mc.Eviction)
+	}
+
+	mc.entries = map[string]*entry{}
+	return nil
 }
 
 func (mc *MemoryCache) Get(key *cache.Key) ([]byte, bool, error) {
-	mc.RLock()
-	defer mc.RUnlock()
+	mc.Lock()
+	defer mc.Unlock()
+
+	if err := mc.init(); err != nil {
+		return nil, false, err
+	}
 
-	val, ok := mc.keyVals[key.String()]
+	e, ok := mc.entries[key.String()]
 	if !ok {
 		return nil, false, nil
 	}
 
-	return val, true, nil
+	if mc.expired(e, time.Now()) {
+		mc.remove(e)
+		mc.stats.Expirations++
+		expirations.Inc()
+		return nil, false, nil
+	}
+
+	mc.policy.touch(e)
+
+	return e.val, true, nil
 }
 
 func (mc *MemoryCache) Set(key *cache.Key, val []byte) error {
 	mc.Lock()
 	defer mc.Unlock()
 
-	mc.keyVals[key.String()] = val
+	if err := mc.init(); err != nil {
+		return err
+	}
+
+	// a tile larger than the cache would evict everything and then not fit
+	if mc.MaxBytes > 0 && uint(len(val)) > mc.MaxBytes {
+		return nil
+	}
+
+	k := key.String()
+	if e, ok := mc.entries[k]; ok {
+		mc.remove(e)
+	}
+
+	e := &entry{
+		key: k,
+		val: val,
+	}
+	if mc.TTL > 0 {
+		e.expires = time.Now().Add(mc.TTL)
+	}
+
+	// make room before adding the tile, otherwise a new tile could be the first evicted
+	mc.evict(len(val))
+
+	mc.entries[k] = e
+	mc.policy.add(e)
+	mc.stats.Entries++
+	mc.stats.Bytes += len(val)
+	entriesGauge.Add(1)
+	bytesGauge.Add(float64(len(val)))
 
 	return nil
 }
@@ -50,7 +206,65 @@ func (mc *MemoryCache) Purge(key *cache.Key) error {
 	mc.Lock()
 	defer mc.Unlock()
 
-	delete(mc.keyVals, key.String())
+	if err := mc.init(); err != nil {
+		return err
+	}
+
+	if e, ok := mc.entries[key.String()]; ok {
+		mc.remove(e)
+	}
 
 	return nil
 }
+
+// Stats returns the current stats of the cache
+func (mc *MemoryCache) Stats() Stats {
+	mc.Lock()
+	defer mc.Unlock()
+
+	return mc.stats
+}
+
+// evict removes tiles until a tile of size bytes can be added within the limits.
+// expired tiles chosen by the policy are counted as expirations rather than evictions.
+func (mc *MemoryCache) evict(size int) {
+	now := time.Now()
+
+	for mc.full(size) {
+		e := mc.policy.victim()
+		if e == nil {
+			return
+		}
+
+		mc.remove(e)
+
+		if mc.expired(e, now) {
+			mc.stats.Expirations++
+			expirations.Inc()
+			continue
+		}
+
+		mc.stats.Evictions++
+		evictions.Inc()
+	}
+}
+
+// full reports if adding a tile of size bytes would exceed the limits
+func (mc *MemoryCache) full(size int) bool {
+	return (mc.MaxEntries > 0 && uint(mc.stats.Entries+1) > mc.MaxEntries) ||
+		(mc.MaxBytes > 0 && uint(mc.stats.Bytes+size) > mc.MaxBytes)
+}
+
+func (mc *MemoryCache) expired(e *entry, now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// remove deletes the entry from the cache and its eviction policy
+func (mc *MemoryCache) remove(e *entry) {
+	delete(mc.entries, e.key)
+	mc.policy.remove(e)
+	mc.stats.Entries--
+	mc.stats.Bytes -= len(e.val)
+	entriesGauge.Add(-1)
+	bytesGauge.Add(-float64(len(e.val)))
+}
@@ -0,0 +1,195 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/dict"
+)
+
+func TestNew(t *testing.T) {
+	type tcase struct {
+		config dict.Dict
+		err    error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			_, err := memory.New(tc.config)
+			if tc.err == nil && err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+				t.Fatalf("error, expected %v got %v", tc.err, err)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"no config": {},
+		"limits": {
+			config: dict.Dict{
+				"max_bytes":   uint(1024),
+				"max_entries": uint(10),
+				"eviction":    "lfu",
+				"ttl":         60,
+			},
+		},
+		"invalid eviction": {
+			config: dict.Dict{
+				"eviction": "fifo",
+			},
+			err: memory.ErrInvalidEviction("fifo"),
+		},
+		"invalid ttl": {
+			config: dict.Dict{
+				"ttl": -1,
+			},
+			err: memory.ErrInvalidTTL(-1),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestEviction(t *testing.T) {
+	type op struct {
+		// get the key when val is nil, otherwise set it
+		key string
+		val []byte
+	}
+
+	type tcase struct {
+		cache *memory.MemoryCache
+		ops   []op
+		// keys expected to be cached and evicted after the ops
+		expHits   []string
+		expMisses []string
+		expStats  memory.Stats
+	}
+
+	key := func(y uint) *cache.Key {
+		return &cache.Key{MapName: "test", Z: 2, X: 1, Y: y}
+	}
+	keys := map[string]*cache.Key{
+		"a": key(0),
+		"b": key(1),
+		"c": key(2),
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			for _, o := range tc.ops {
+				if o.val == nil {
+					if _, _, err := tc.cache.Get(keys[o.key]); err != nil {
+						t.Fatalf("get, unexpected error %v", err)
+					}
+					continue
+				}
+				if err := tc.cache.Set(keys[o.key], o.val); err != nil {
+					t.Fatalf("set, unexpected error %v", err)
+				}
+			}
+
+			// read the stats before the checks as reads change the usage
+			stats := tc.cache.Stats()
+			if stats != tc.expStats {
+				t.Errorf("stats, expected %+v got %+v", tc.expStats, stats)
+			}
+
+			for _, k := range tc.expHits {
+				if _, hit, _ := tc.cache.Get(keys[k]); !hit {
+					t.Errorf("key %v, expected hit got miss", k)
+				}
+			}
+			for _, k := range tc.expMisses {
+				if _, hit, _ := tc.cache.Get(keys[k]); hit {
+					t.Errorf("key %v, expected miss got hit", k)
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"unbounded": {
+			cache:    &memory.MemoryCache{},
+			ops:      []op{{"a", []byte("aa")}, {"b", []byte("bb")}, {"c", []byte("cc")}},
+			expHits:  []string{"a", "b", "c"},
+			expStats: memory.Stats{Entries: 3, Bytes: 6},
+		},
+		"lru max entries": {
+			cache: &memory.MemoryCache{MaxEntries: 2},
+			// reading a makes b the least recently used
+			ops:       []op{{"a", []byte("aa")}, {"b", []byte("bb")}, {"a", nil}, {"c", []byte("cc")}},
+			expHits:   []string{"a", "c"},
+			expMisses: []string{"b"},
+			expStats:  memory.Stats{Entries: 2, Bytes: 4, Evictions: 1},
+		},
+		"lru max bytes": {
+			cache:     &memory.MemoryCache{MaxBytes: 5},
+			ops:       []op{{"a", []byte("aa")}, {"b", []byte("bb")}, {"c", []byte("ccc")}},
+			expHits:   []string{"b", "c"},
+			expMisses: []string{"a"},
+			expStats:  memory.Stats{Entries: 2, Bytes: 5, Evictions: 1},
+		},
+		"replace value": {
+			cache:    &memory.MemoryCache{MaxBytes: 5},
+			ops:      []op{{"a", []byte("aa")}, {"a", []byte("aaaa")}},
+			expHits:  []string{"a"},
+			expStats: memory.Stats{Entries: 1, Bytes: 4},
+		},
+		"value larger than max bytes": {
+			cache:     &memory.MemoryCache{MaxBytes: 2},
+			ops:       []op{{"a", []byte("aa")}, {"b", []byte("bbb")}},
+			expHits:   []string{"a"},
+			expMisses: []string{"b"},
+			expStats:  memory.Stats{Entries: 1, Bytes: 2},
+		},
+		"lfu max entries": {
+			cache: &memory.MemoryCache{MaxEntries: 2, Eviction: memory.EvictionLFU},
+			// a is read twice and b once so b is evicted even though a was read less recently
+			ops:       []op{{"a", []byte("aa")}, {"b", []byte("bb")}, {"a", nil}, {"a", nil}, {"b", nil}, {"c", []byte("cc")}},
+			expHits:   []string{"a", "c"},
+			expMisses: []string{"b"},
+			expStats:  memory.Stats{Entries: 2, Bytes: 4, Evictions: 1},
+		},
+		"lfu ties evict least recently used": {
+			cache:     &memory.MemoryCache{MaxEntries: 2, Eviction: memory.EvictionLFU},
+			ops:       []op{{"a", []byte("aa")}, {"b", []byte("bb")}, {"b", nil}, {"a", nil}, {"c", []byte("cc")}},
+			expHits:   []string{"a", "c"},
+			expMisses: []string{"b"},
+			expStats:  memory.Stats{Entries: 2, Bytes: 4, Evictions: 1},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestTTL(t *testing.T) {
+	mc := &memory.MemoryCache{TTL: 10 * time.Millisecond}
+	key := &cache.Key{MapName: "test", Z: 0, X: 0, Y: 0}
+
+	if err := mc.Set(key, []byte("tile")); err != nil {
+		t.Fatalf("set, unexpected error %v", err)
+	}
+	if _, hit, _ := mc.Get(key); !hit {
+		t.Fatalf("expected hit before the ttl")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, hit, _ := mc.Get(key); hit {
+		t.Fatalf("expected miss after the ttl")
+	}
+
+	expected := memory.Stats{Expirations: 1}
+	if stats := mc.Stats(); stats != expected {
+		t.Errorf("stats, expected %+v got %+v", expected, stats)
+	}
+}
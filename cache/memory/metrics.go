@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"github.com/go-spatial/tegola/internal/metrics"
+)
+
+// the metrics are shared by all the memory caches of the process
+var (
+	entriesGauge = metrics.NewGaugeVec(
+		"tegola_memory_cache_entries",
+		"Number of tiles in the memory cache.",
+	)
+
+	bytesGauge = metrics.NewGaugeVec(
+		"tegola_memory_cache_bytes",
+		"Size in bytes of the tiles in the memory cache.",
+	)
+
+	evictions = metrics.NewCounterVec(
+		"tegola_memory_cache_evictions_total",
+		"Number of tiles evicted from the memory cache to stay within its limits.",
+	)
+
+	expirations = metrics.NewCounterVec(
+		"tegola_memory_cache_expirations_total",
+		"Number of expired tiles removed from the memory cache.",
+	)
+)
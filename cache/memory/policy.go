@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// policy orders the cached entries for eviction
+type policy interface {
+	// add starts tracking a new entry
+	add(e *entry)
+	// touch records a read of the entry
+	touch(e *entry)
+	// remove stops tracking the entry
+	remove(e *entry)
+	// victim returns the entry to evict next, nil if there are no entries
+	victim() *entry
+}
+
+// policyData is the per entry bookkeeping of the policies
+type policyData struct {
+	// lru
+	elem *list.Element
+
+	// lfu
+	hits    uint64
+	lastUse uint64
+	heapIdx int
+}
+
+// lru evicts the least recently used entry. the front of the list is the most recently used.
+type lru struct {
+	order *list.List
+}
+
+func newLRU() *lru {
+	return &lru{order: list.New()}
+}
+
+func (p *lru) add(e *entry) {
+	e.elem = p.order.PushFront(e)
+}
+
+func (p *lru) touch(e *entry) {
+	p.order.MoveToFront(e.elem)
+}
+
+func (p *lru) remove(e *entry) {
+	p.order.Remove(e.elem)
+	e.elem = nil
+}
+
+func (p *lru) victim() *entry {
+	back := p.order.Back()
+	if back == nil {
+		return nil
+	}
+	return back.Value.(*entry)
+}
+
+// lfu evicts the least frequently used entry, breaking ties by evicting the least recently used
+type lfu struct {
+	entries lfuHeap
+	// clock orders the uses of entries
+	clock uint64
+}
+
+func newLFU() *lfu {
+	return &lfu{}
+}
+
+func (p *lfu) add(e *entry) {
+	p.clock++
+	e.hits, e.lastUse = 0, p.clock
+	heap.Push(&p.entries, e)
+}
+
+func (p *lfu) touch(e *entry) {
+	p.clock++
+	e.hits++
+	e.lastUse = p.clock
+	heap.Fix(&p.entries, e.heapIdx)
+}
+
+func (p *lfu) remove(e *entry) {
+	heap.Remove(&p.entries, e.heapIdx)
+}
+
+func (p *lfu) victim() *entry {
+	if len(p.entries) == 0 {
+		return nil
+	}
+	return p.entries[0]
+}
+
+// lfuHeap is a min heap of entries ordered by hits then last use
+type lfuHeap []*entry
+
+func (h lfuHeap) Len() int { return len(h) }
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].hits != h[j].hits {
+		return h[i].hits < h[j].hits
+	}
+	return h[i].lastUse < h[j].lastUse
+}
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIdx = i
+	h[j].heapIdx = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.heapIdx = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	e.heapIdx = -1
+	return e
+}
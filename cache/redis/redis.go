@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -19,8 +21,18 @@ const (
 	ConfigKeyDB       = "db"
 	ConfigKeyMaxZoom  = "max_zoom"
 	ConfigKeyTTL      = "ttl"
+	// render lock
+	ConfigKeyRenderLock    = "render_lock"
+	ConfigKeyRenderLockTTL = "render_lock_ttl"
 )
 
+// DefaultRenderLockTTL is how long a render lock is held for, in seconds, when not configured
+const DefaultRenderLockTTL = 30
+
+// unlockScript deletes a lock only if it's still held with the token of the caller,
+// so a lock which expired and was acquired by another instance is not released
+const unlockScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
 func init() {
 	cache.Register(CacheType, New)
 }
@@ -34,6 +46,8 @@ func New(config dict.Dicter) (rcache cache.Interface, err error) {
 	defaultDB := 0
 	defaultMaxZoom := uint(tegola.MaxZ)
 	defaultTTL := 0
+	defaultRenderLock := false
+	defaultRenderLockTTL := DefaultRenderLockTTL
 
 	c := config
 
@@ -85,10 +99,22 @@ func New(config dict.Dicter) (rcache cache.Interface, err error) {
 		return nil, err
 	}
 
+	renderLock, err := c.Bool(ConfigKeyRenderLock, &defaultRenderLock)
+	if err != nil {
+		return nil, err
+	}
+
+	renderLockTTL, err := c.Int(ConfigKeyRenderLockTTL, &defaultRenderLockTTL)
+	if err != nil {
+		return nil, err
+	}
+
 	return &RedisCache{
-		Redis:      client,
-		MaxZoom:    maxZoom,
-		Expiration: time.Duration(ttl) * time.Second,
+		Redis:         client,
+		MaxZoom:       maxZoom,
+		Expiration:    time.Duration(ttl) * time.Second,
+		RenderLock:    renderLock,
+		RenderLockTTL: time.Duration(renderLockTTL) * time.Second,
 	}, nil
 }
 
@@ -96,6 +122,41 @@ type RedisCache struct {
 	Redis      *redis.Client
 	Expiration time.Duration
 	MaxZoom    uint
+	// RenderLock enables locking tiles while they're rendered so tegola
+	// instances sharing the cache don't render the same tile at once
+	RenderLock bool
+	// RenderLockTTL is the max duration a render lock is held for
+	RenderLockTTL time.Duration
+}
+
+// Lock implements the cache.Locker interface. When RenderLock is disabled
+// the lock is always acquired without contacting redis.
+func (rdc *RedisCache) Lock(key *cache.Key) (unlock func(), acquired bool, err error) {
+	if !rdc.RenderLock {
+		return func() {}, true, nil
+	}
+
+	token := make([]byte, 16)
+	if _, err = rand.Read(token); err != nil {
+		return nil, false, err
+	}
+
+	lockKey := key.String() + ":lock"
+	lockToken := hex.EncodeToString(token)
+
+	acquired, err = rdc.Redis.SetNX(lockKey, lockToken, rdc.RenderLockTTL).Result()
+	if err != nil || !acquired {
+		return nil, false, err
+	}
+
+	return func() {
+		rdc.Redis.Eval(unlockScript, []string{lockKey}, lockToken)
+	}, true, nil
+}
+
+// LockTTL implements the cache.Locker interface
+func (rdc *RedisCache) LockTTL() time.Duration {
+	return rdc.RenderLockTTL
 }
 
 func (rdc *RedisCache) Set(key *cache.Key, val []byte) error {
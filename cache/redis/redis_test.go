@@ -357,3 +357,36 @@ func TestMaxZoom(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderLock(t *testing.T) {
+	ttools.ShouldSkip(t, TESTENV)
+
+	rc, err := redis.New(dict.Dict{
+		"render_lock":     true,
+		"render_lock_ttl": 5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err, expected %v got %v", nil, err)
+	}
+
+	locker := rc.(cache.Locker)
+	key := cache.Key{MapName: "lock-test", Z: 1, X: 1, Y: 1}
+
+	unlock, acquired, err := locker.Lock(&key)
+	if err != nil || !acquired {
+		t.Fatalf("first lock, expected acquired got %v (err %v)", acquired, err)
+	}
+
+	// the lock is held so a second lock fails
+	if _, acquired, err := locker.Lock(&key); err != nil || acquired {
+		t.Fatalf("second lock, expected not acquired got %v (err %v)", acquired, err)
+	}
+
+	unlock()
+
+	unlock, acquired, err = locker.Lock(&key)
+	if err != nil || !acquired {
+		t.Fatalf("lock after unlock, expected acquired got %v (err %v)", acquired, err)
+	}
+	unlock()
+}
@@ -0,0 +1,24 @@
+package tiered
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMissingTiers = errors.New("tiered: missing required param 'tiers'")
+
+type ErrTierMissingType int
+
+func (e ErrTierMissingType) Error() string {
+	return fmt.Sprintf("tiered: tier (%v) missing required param 'type'", int(e))
+}
+
+type ErrTier struct {
+	Index int
+	Type  string
+	Err   error
+}
+
+func (e ErrTier) Error() string {
+	return fmt.Sprintf("tiered: unable to create tier (%v) of type (%v): %v", e.Index, e.Type, e.Err)
+}
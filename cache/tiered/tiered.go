@@ -0,0 +1,193 @@
+// Package tiered implements a cache made of an ordered list of caches (tiers).
+// Tiles are read from the fastest tier that has them and written to every
+// writable tier, so a local memory cache can sit in front of a shared remote
+// cache such as S3 or Redis.
+package tiered
+
+import (
+	"time"
+
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/internal/log"
+)
+
+const CacheType = "tiered"
+
+const (
+	ConfigKeyTiers    = "tiers"
+	ConfigKeyType     = "type"
+	ConfigKeyReadOnly = "read_only"
+)
+
+func init() {
+	cache.Register(CacheType, New)
+}
+
+// New instantiates a tiered Cache. The config expects the following params:
+//
+//	tiers ([]table): [Required] the cache configs in the order they're read, fastest first.
+//	Each tier is configured as the cache of its type with the following additional params:
+//		type (string): [Required] the cache type of the tier.
+//		read_only (bool): [Optional] tiles are not written to or purged from the tier. defaults to false.
+func New(config dict.Dicter) (cache.Interface, error) {
+	tierConfigs, err := config.MapSlice(ConfigKeyTiers)
+	if err != nil {
+		return nil, err
+	}
+	if len(tierConfigs) == 0 {
+		return nil, ErrMissingTiers
+	}
+
+	var c Cache
+	for i, tierConfig := range tierConfigs {
+		cacheType, err := tierConfig.String(ConfigKeyType, nil)
+		if err != nil {
+			return nil, ErrTierMissingType(i)
+		}
+
+		defaultReadOnly := false
+		readOnly, err := tierConfig.Bool(ConfigKeyReadOnly, &defaultReadOnly)
+		if err != nil {
+			return nil, err
+		}
+
+		tierCache, err := cache.For(cacheType, tierConfig)
+		if err != nil {
+			return nil, ErrTier{Index: i, Type: cacheType, Err: err}
+		}
+
+		c.Tiers = append(c.Tiers, Tier{
+			Type:     cacheType,
+			ReadOnly: readOnly,
+			Cache:    tierCache,
+		})
+	}
+
+	return &c, nil
+}
+
+// Tier is one of the caches of a tiered Cache
+type Tier struct {
+	// Type is the cache type of the tier, used for error messages
+	Type string
+	// ReadOnly tiers are only read from
+	ReadOnly bool
+	Cache    cache.Interface
+}
+
+// Cache implements the cache.Interface on top of an ordered list of tiers
+type Cache struct {
+	// Tiers in the order they're read, fastest first
+	Tiers []Tier
+}
+
+// Get reads the tile from each tier in order until there's a hit. The tile is then
+// written to the writable tiers before the one it was found in. Errors reading from
+// a tier are logged and the tier is treated as a miss so a failing tier does not
+// prevent the other tiers from being used. If every tier fails the first error is returned.
+func (c *Cache) Get(key *cache.Key) ([]byte, bool, error) {
+	var firstErr error
+	failed := 0
+
+	for i, tier := range c.Tiers {
+		val, hit, err := tier.Cache.Get(key)
+		if err != nil {
+			err = cache.ErrGettingFromCache{Err: err, CacheType: tier.Type}
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+
+			log.Warn(err)
+			continue
+		}
+		if !hit {
+			continue
+		}
+
+		// backfill the faster tiers
+		for _, faster := range c.Tiers[:i] {
+			if faster.ReadOnly {
+				continue
+			}
+
+			if err := faster.Cache.Set(key, val); err != nil {
+				log.Warn(cache.ErrSettingToCache{Err: err, CacheType: faster.Type})
+			}
+		}
+
+		return val, true, nil
+	}
+
+	if failed == len(c.Tiers) {
+		return nil, false, firstErr
+	}
+
+	return nil, false, nil
+}
+
+// Set writes the tile to every writable tier. All the tiers are written to even
+// if one fails, the first error is returned.
+func (c *Cache) Set(key *cache.Key, val []byte) error {
+	var firstErr error
+
+	for _, tier := range c.Tiers {
+		if tier.ReadOnly {
+			continue
+		}
+
+		if err := tier.Cache.Set(key, val); err != nil && firstErr == nil {
+			firstErr = cache.ErrSettingToCache{Err: err, CacheType: tier.Type}
+		}
+	}
+
+	return firstErr
+}
+
+// Purge removes the tile from every writable tier. All the tiers are purged even
+// if one fails, the first error is returned.
+func (c *Cache) Purge(key *cache.Key) error {
+	var firstErr error
+
+	for _, tier := range c.Tiers {
+		if tier.ReadOnly {
+			continue
+		}
+
+		if err := tier.Cache.Purge(key); err != nil && firstErr == nil {
+			firstErr = cache.ErrPurgingCache{Err: err, CacheType: tier.Type}
+		}
+	}
+
+	return firstErr
+}
+
+// locker returns the first tier which can lock keys across instances
+func (c *Cache) locker() cache.Locker {
+	for _, tier := range c.Tiers {
+		if locker, ok := tier.Cache.(cache.Locker); ok {
+			return locker
+		}
+	}
+	return nil
+}
+
+// Lock implements the cache.Locker interface using the first tier which supports
+// locking. Without such a tier the lock is always acquired.
+func (c *Cache) Lock(key *cache.Key) (unlock func(), acquired bool, err error) {
+	locker := c.locker()
+	if locker == nil {
+		return func() {}, true, nil
+	}
+	return locker.Lock(key)
+}
+
+// LockTTL implements the cache.Locker interface
+func (c *Cache) LockTTL() time.Duration {
+	locker := c.locker()
+	if locker == nil {
+		return 0
+	}
+	return locker.LockTTL()
+}
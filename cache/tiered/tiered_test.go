@@ -0,0 +1,239 @@
+package tiered_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/cache/tiered"
+	"github.com/go-spatial/tegola/dict"
+)
+
+// errCache fails every call
+type errCache struct{}
+
+var errTest = errors.New("test error")
+
+func (errCache) Get(*cache.Key) ([]byte, bool, error) { return nil, false, errTest }
+func (errCache) Set(*cache.Key, []byte) error         { return errTest }
+func (errCache) Purge(*cache.Key) error               { return errTest }
+
+func TestNew(t *testing.T) {
+	type tcase struct {
+		config   dict.Dict
+		expTiers int
+		err      error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			c, err := tiered.New(tc.config)
+			if tc.err != nil {
+				if err == nil || err.Error() != tc.err.Error() {
+					t.Fatalf("error, expected %v got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if tiers := len(c.(*tiered.Cache).Tiers); tiers != tc.expTiers {
+				t.Errorf("tiers, expected %v got %v", tc.expTiers, tiers)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"two tiers": {
+			config: dict.Dict{
+				"tiers": []map[string]interface{}{
+					{"type": "memory", "max_entries": uint(10)},
+					{"type": "memory", "read_only": true},
+				},
+			},
+			expTiers: 2,
+		},
+		"missing tiers": {
+			config: dict.Dict{},
+			err:    tiered.ErrMissingTiers,
+		},
+		"missing tier type": {
+			config: dict.Dict{
+				"tiers": []map[string]interface{}{
+					{"type": "memory"},
+					{"max_entries": uint(10)},
+				},
+			},
+			err: tiered.ErrTierMissingType(1),
+		},
+		"invalid tier config": {
+			config: dict.Dict{
+				"tiers": []map[string]interface{}{
+					{"type": "memory", "eviction": "fifo"},
+				},
+			},
+			err: tiered.ErrTier{Index: 0, Type: "memory", Err: memory.ErrInvalidEviction("fifo")},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestCache(t *testing.T) {
+	key := &cache.Key{MapName: "test", Z: 1, X: 0, Y: 1}
+	val := []byte("tile")
+
+	type tcase struct {
+		// the read only and failing flags of each tier
+		readOnly []bool
+		failing  []bool
+		// the tiers the tile is cached in before the test
+		cachedIn []int
+		// the expected results of Get
+		expHit bool
+		expErr error
+		// the tiers the tile is expected to be cached in after the Get
+		expCachedIn []int
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			var c tiered.Cache
+			mems := make([]*memory.MemoryCache, len(tc.readOnly))
+			for i := range tc.readOnly {
+				mems[i] = &memory.MemoryCache{}
+
+				var ci cache.Interface = mems[i]
+				if tc.failing[i] {
+					ci = errCache{}
+				}
+
+				c.Tiers = append(c.Tiers, tiered.Tier{
+					Type:     "memory",
+					ReadOnly: tc.readOnly[i],
+					Cache:    ci,
+				})
+			}
+			for _, i := range tc.cachedIn {
+				mems[i].Set(key, val)
+			}
+
+			got, hit, err := c.Get(key)
+			if err != tc.expErr {
+				t.Fatalf("error, expected %v got %v", tc.expErr, err)
+			}
+			if hit != tc.expHit {
+				t.Fatalf("hit, expected %v got %v", tc.expHit, hit)
+			}
+			if hit && !reflect.DeepEqual(got, val) {
+				t.Errorf("val, expected %s got %s", val, got)
+			}
+
+			var cachedIn []int
+			for i := range mems {
+				if _, hit, _ := mems[i].Get(key); hit {
+					cachedIn = append(cachedIn, i)
+				}
+			}
+			if !reflect.DeepEqual(cachedIn, tc.expCachedIn) {
+				t.Errorf("cached in, expected %v got %v", tc.expCachedIn, cachedIn)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"miss": {
+			readOnly: []bool{false, false},
+			failing:  []bool{false, false},
+			expHit:   false,
+		},
+		"hit first tier": {
+			readOnly:    []bool{false, false},
+			failing:     []bool{false, false},
+			cachedIn:    []int{0},
+			expHit:      true,
+			expCachedIn: []int{0},
+		},
+		"hit last tier backfills": {
+			readOnly:    []bool{false, false, false},
+			failing:     []bool{false, false, false},
+			cachedIn:    []int{2},
+			expHit:      true,
+			expCachedIn: []int{0, 1, 2},
+		},
+		"backfill skips read only tiers": {
+			readOnly:    []bool{false, true, false},
+			failing:     []bool{false, false, false},
+			cachedIn:    []int{2},
+			expHit:      true,
+			expCachedIn: []int{0, 2},
+		},
+		"failing tier is skipped": {
+			readOnly:    []bool{false, false, false},
+			failing:     []bool{false, true, false},
+			cachedIn:    []int{2},
+			expHit:      true,
+			expCachedIn: []int{0, 2},
+		},
+		"every tier failing": {
+			readOnly: []bool{false, false},
+			failing:  []bool{true, true},
+			expHit:   false,
+			expErr:   cache.ErrGettingFromCache{Err: errTest, CacheType: "memory"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestSetPurge(t *testing.T) {
+	key := &cache.Key{MapName: "test", Z: 1, X: 0, Y: 1}
+
+	writable, readOnly, after := &memory.MemoryCache{}, &memory.MemoryCache{}, &memory.MemoryCache{}
+	c := tiered.Cache{
+		Tiers: []tiered.Tier{
+			{Type: "memory", Cache: writable},
+			{Type: "memory", Cache: readOnly, ReadOnly: true},
+			{Type: "failing", Cache: errCache{}},
+			{Type: "memory", Cache: after},
+		},
+	}
+
+	expErr := cache.ErrSettingToCache{Err: errTest, CacheType: "failing"}
+	if err := c.Set(key, []byte("tile")); err != expErr {
+		t.Errorf("set error, expected %v got %v", expErr, err)
+	}
+
+	type check struct {
+		name   string
+		mc     *memory.MemoryCache
+		expHit bool
+	}
+
+	// the failing tier does not stop the tiers after it from being written to
+	for _, chk := range []check{{"writable", writable, true}, {"read only", readOnly, false}, {"after failing", after, true}} {
+		if _, hit, _ := chk.mc.Get(key); hit != chk.expHit {
+			t.Errorf("set %v, expected hit %v got %v", chk.name, chk.expHit, hit)
+		}
+	}
+
+	readOnly.Set(key, []byte("tile"))
+
+	expPurgeErr := cache.ErrPurgingCache{Err: errTest, CacheType: "failing"}
+	if err := c.Purge(key); err != expPurgeErr {
+		t.Errorf("purge error, expected %v got %v", expPurgeErr, err)
+	}
+
+	for _, chk := range []check{{"writable", writable, false}, {"read only", readOnly, true}, {"after failing", after, false}} {
+		if _, hit, _ := chk.mc.Get(key); hit != chk.expHit {
+			t.Errorf("purge %v, expected hit %v got %v", chk.name, chk.expHit, hit)
+		}
+	}
+}
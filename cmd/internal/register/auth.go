@@ -0,0 +1,70 @@
+package register
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-spatial/tegola/config"
+	"github.com/go-spatial/tegola/server"
+)
+
+type ErrAuthFile struct {
+	Name string
+	Path string
+	Err  error
+}
+
+func (e ErrAuthFile) Error() string {
+	return fmt.Sprintf("error reading auth %v (%v): %v", e.Name, e.Path, e.Err)
+}
+
+// Auth returns the authenticator of the webserver auth config. The API keys
+// of the config are added to the keys read from the api_keys_file.
+func Auth(conf config.Auth) (*server.Authenticator, error) {
+	auth := server.Authenticator{
+		APIKeys:  map[string]server.Grant{},
+		Issuer:   string(conf.JWTIssuer),
+		Audience: string(conf.JWTAudience),
+	}
+
+	if conf.APIKeysFile != "" {
+		f, err := os.Open(string(conf.APIKeysFile))
+		if err != nil {
+			return nil, ErrAuthFile{Name: "api_keys_file", Path: string(conf.APIKeysFile), Err: err}
+		}
+		defer f.Close()
+
+		if auth.APIKeys, err = server.ReadAPIKeys(f); err != nil {
+			return nil, ErrAuthFile{Name: "api_keys_file", Path: string(conf.APIKeysFile), Err: err}
+		}
+	}
+
+	for _, k := range conf.APIKeys {
+		var grant server.Grant
+		for _, m := range k.Maps {
+			grant.Maps = append(grant.Maps, string(m))
+		}
+		for _, l := range k.Layers {
+			grant.Layers = append(grant.Layers, string(l))
+		}
+		auth.APIKeys[string(k.Key)] = grant
+	}
+
+	if conf.JWTSecret != "" {
+		auth.JWTSecret = []byte(conf.JWTSecret)
+	}
+
+	if conf.JWKSFile != "" {
+		f, err := os.Open(string(conf.JWKSFile))
+		if err != nil {
+			return nil, ErrAuthFile{Name: "jwks_file", Path: string(conf.JWKSFile), Err: err}
+		}
+		defer f.Close()
+
+		if auth.JWKS, err = server.ReadJWKS(f); err != nil {
+			return nil, ErrAuthFile{Name: "jwks_file", Path: string(conf.JWKSFile), Err: err}
+		}
+	}
+
+	return &auth, nil
+}
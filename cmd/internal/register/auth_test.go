@@ -0,0 +1,116 @@
+package register_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/cmd/internal/register"
+	"github.com/go-spatial/tegola/config"
+	"github.com/go-spatial/tegola/internal/env"
+	"github.com/go-spatial/tegola/server"
+)
+
+func TestAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tegola-auth")
+	if err != nil {
+		t.Fatalf("creating temp dir, expected nil got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keysFile := filepath.Join(dir, "keys")
+	keys := "# keys\nkey1\nkey2 osm,natural_earth\n\nkey3 osm roads,water\nkey4 * roads\n"
+	if err := ioutil.WriteFile(keysFile, []byte(keys), 0600); err != nil {
+		t.Fatalf("writing keys file, expected nil got %v", err)
+	}
+
+	invalidKeysFile := filepath.Join(dir, "invalid-keys")
+	if err := ioutil.WriteFile(invalidKeysFile, []byte("key1 osm roads extra\n"), 0600); err != nil {
+		t.Fatalf("writing keys file, expected nil got %v", err)
+	}
+
+	jwksFile := filepath.Join(dir, "jwks.json")
+	jwks := `{"keys": [{"kty": "oct", "kid": "shared", "k": "c2VjcmV0"}]}`
+	if err := ioutil.WriteFile(jwksFile, []byte(jwks), 0600); err != nil {
+		t.Fatalf("writing JWKS file, expected nil got %v", err)
+	}
+
+	type tcase struct {
+		config      config.Auth
+		expectedErr bool
+		expected    map[string]server.Grant
+		expJWKS     bool
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			auth, err := register.Auth(tc.config)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("error, expected an error got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error, expected nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(auth.APIKeys, tc.expected) {
+				t.Errorf("api keys, expected %v got %v", tc.expected, auth.APIKeys)
+			}
+			if (auth.JWKS != nil) != tc.expJWKS {
+				t.Errorf("jwks, expected %v got %v", tc.expJWKS, auth.JWKS != nil)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"api keys": {
+			config: config.Auth{
+				APIKeys: []config.APIKey{
+					{Key: "key1"},
+					{Key: "key5", Maps: []env.String{"osm"}, Layers: []env.String{"roads"}},
+				},
+			},
+			expected: map[string]server.Grant{
+				"key1": {},
+				"key5": {Maps: []string{"osm"}, Layers: []string{"roads"}},
+			},
+		},
+		"api keys file": {
+			config: config.Auth{
+				APIKeys:     []config.APIKey{{Key: "key1", Maps: []env.String{"osm"}}},
+				APIKeysFile: env.String(keysFile),
+			},
+			expected: map[string]server.Grant{
+				"key1": {Maps: []string{"osm"}},
+				"key2": {Maps: []string{"osm", "natural_earth"}},
+				"key3": {Maps: []string{"osm"}, Layers: []string{"roads", "water"}},
+				"key4": {Layers: []string{"roads"}},
+			},
+		},
+		"invalid api keys file": {
+			config:      config.Auth{APIKeysFile: env.String(invalidKeysFile)},
+			expectedErr: true,
+		},
+		"missing api keys file": {
+			config:      config.Auth{APIKeysFile: env.String(filepath.Join(dir, "missing"))},
+			expectedErr: true,
+		},
+		"jwks file": {
+			config:   config.Auth{JWKSFile: env.String(jwksFile)},
+			expected: map[string]server.Grant{},
+			expJWKS:  true,
+		},
+		"invalid jwks file": {
+			config:      config.Auth{JWKSFile: env.String(keysFile)},
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/config"
+	"github.com/go-spatial/tegola/maths/tilematrix"
 	"github.com/go-spatial/tegola/provider"
 )
 
@@ -106,6 +107,20 @@ func initLayer(l *config.MapLayer, mapName string, layerProvider provider.Layere
 		maxZoom = uint(*l.MaxZoom)
 	}
 
+	var simplifyMaxZoom *uint
+	if l.SimplifyMaxZoom != nil {
+		z := uint(*l.SimplifyMaxZoom)
+		simplifyMaxZoom = &z
+	}
+
+	// a tolerance of zero does not eliminate any points
+	dontSimplify := bool(l.DontSimplify)
+	var simplifyTolerance float64
+	if l.SimplifyTolerance != nil {
+		simplifyTolerance = float64(*l.SimplifyTolerance)
+		dontSimplify = dontSimplify || simplifyTolerance == 0
+	}
+
 	prvd, _ := layerProvider.(provider.Tiler)
 
 	// add our layer to our layers slice
@@ -117,19 +132,42 @@ func initLayer(l *config.MapLayer, mapName string, layerProvider provider.Layere
 		Provider:          prvd,
 		DefaultTags:       defaultTags,
 		GeomType:          layerGeomType,
-		DontSimplify:      bool(l.DontSimplify),
+		DontSimplify:      dontSimplify,
 		DontClip:          bool(l.DontClip),
+		DontMakeValid:     bool(l.DontMakeValid),
+		SimplifyTolerance: simplifyTolerance,
+		SimplifyMaxZoom:   simplifyMaxZoom,
+		SimplifyAlgorithm: string(l.SimplifyAlgorithm),
 	}, nil
 }
 
-// Maps registers maps with with atlas
-func Maps(a *atlas.Atlas, maps []config.Map, providers map[string]provider.Tiler) error {
+// Maps registers maps with with atlas. tileMatrixSets holds the tile matrix sets the maps
+// can reference (see TileMatrixSets). If nil, only the builtin tile matrix sets are available.
+func Maps(a *atlas.Atlas, maps []config.Map, providers map[string]provider.Tiler, tileMatrixSets map[string]*tilematrix.TileMatrixSet) error {
+	if tileMatrixSets == nil {
+		var err error
+		if tileMatrixSets, err = TileMatrixSets(nil); err != nil {
+			return err
+		}
+	}
 
 	// iterate our maps
 	for _, m := range maps {
 		newMap := atlas.NewWebMercatorMap(string(m.Name))
 		newMap.Attribution = html.EscapeString(string(m.Attribution))
 
+		if m.TileMatrixSet != "" {
+			tms, ok := tileMatrixSets[string(m.TileMatrixSet)]
+			if !ok {
+				return ErrTileMatrixSetNotFound{
+					TileMatrixSet: string(m.TileMatrixSet),
+					Map:           string(m.Name),
+				}
+			}
+			newMap.TileMatrixSet = tms
+			newMap.SRID = tms.SRID
+		}
+
 		// convert from env package
 		centerArr := [3]float64{}
 		for i, v := range m.Center {
@@ -151,6 +189,17 @@ func Maps(a *atlas.Atlas, maps []config.Map, providers map[string]provider.Tiler
 			newMap.TileBuffer = uint64(*m.TileBuffer)
 		}
 
+		newMap.CacheControl = m.CacheControlHeader()
+		newMap.Public = bool(m.Public)
+
+		for _, p := range m.Params {
+			param, err := p.QueryParameter()
+			if err != nil {
+				return err
+			}
+			newMap.Params = append(newMap.Params, param)
+		}
+
 		// iterate our layers
 		for _, l := range m.Layers {
 			providerName, _, err := l.ProviderLayerName()
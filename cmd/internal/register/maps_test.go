@@ -32,7 +32,7 @@ func TestMaps(t *testing.T) {
 			return
 		}
 
-		err = register.Maps(&tc.atlas, tc.maps, providers)
+		err = register.Maps(&tc.atlas, tc.maps, providers, nil)
 		if tc.expectedErr != nil {
 			if err.Error() != tc.expectedErr.Error() {
 				t.Errorf("invalid error. expected: %v, got: %v", tc.expectedErr, err.Error())
@@ -128,6 +128,24 @@ func TestMaps(t *testing.T) {
 				ProviderLayer: "test.debug-tile-outline",
 			},
 		},
+		"tile matrix set not found": {
+			maps: []config.Map{
+				{
+					Name:          "foo",
+					TileMatrixSet: "bar",
+				},
+			},
+			providers: []dict.Dict{
+				{
+					"name": "test",
+					"type": "debug",
+				},
+			},
+			expectedErr: register.ErrTileMatrixSetNotFound{
+				TileMatrixSet: "bar",
+				Map:           "foo",
+			},
+		},
 		"success": {
 			maps: []config.Map{},
 			providers: []dict.Dict{
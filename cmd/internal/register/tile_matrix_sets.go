@@ -0,0 +1,71 @@
+package register
+
+import (
+	"fmt"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/config"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+)
+
+type ErrTileMatrixSetNotFound struct {
+	TileMatrixSet string
+	Map           string
+}
+
+func (e ErrTileMatrixSetNotFound) Error() string {
+	return fmt.Sprintf("tile matrix set (%v) for map (%v) not defined", e.TileMatrixSet, e.Map)
+}
+
+type ErrTileMatrixSetBoundsInvalid struct {
+	TileMatrixSet string
+}
+
+func (e ErrTileMatrixSetBoundsInvalid) Error() string {
+	return fmt.Sprintf("'bounds' for tile matrix set (%v) should have 4 values", e.TileMatrixSet)
+}
+
+// TileMatrixSets returns the builtin tile matrix sets along with the
+// tile matrix sets defined in the config, keyed by name
+func TileMatrixSets(sets []config.TileMatrixSet) (map[string]*tilematrix.TileMatrixSet, error) {
+	tileMatrixSets := map[string]*tilematrix.TileMatrixSet{}
+	for _, tms := range tilematrix.Builtin() {
+		tileMatrixSets[tms.Name] = tms
+	}
+
+	for _, s := range sets {
+		tms := tilematrix.TileMatrixSet{
+			Name:     string(s.Name),
+			SRID:     uint64(s.SRID),
+			Origin:   [2]float64{float64(s.Origin[0]), float64(s.Origin[1])},
+			TileSize: tilematrix.DefaultTileSize,
+		}
+
+		if s.TileSize != nil {
+			tms.TileSize = uint(*s.TileSize)
+		}
+
+		for _, res := range s.Resolutions {
+			tms.Resolutions = append(tms.Resolutions, float64(res))
+		}
+
+		switch len(s.Bounds) {
+		case 0:
+		case 4:
+			tms.Bounds = geom.NewExtent(
+				[2]float64{float64(s.Bounds[0]), float64(s.Bounds[1])},
+				[2]float64{float64(s.Bounds[2]), float64(s.Bounds[3])},
+			)
+		default:
+			return nil, ErrTileMatrixSetBoundsInvalid{TileMatrixSet: tms.Name}
+		}
+
+		if err := tms.Validate(); err != nil {
+			return nil, err
+		}
+
+		tileMatrixSets[tms.Name] = &tms
+	}
+
+	return tileMatrixSets, nil
+}
@@ -0,0 +1,96 @@
+package register_test
+
+import (
+	"testing"
+
+	"github.com/go-spatial/tegola/cmd/internal/register"
+	"github.com/go-spatial/tegola/config"
+	"github.com/go-spatial/tegola/internal/env"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+)
+
+func TestTileMatrixSets(t *testing.T) {
+	type tcase struct {
+		sets        []config.TileMatrixSet
+		expectedErr error
+		// expected tile size of the LV95 tile matrix set
+		tileSize uint
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			tileMatrixSets, err := register.TileMatrixSets(tc.sets)
+			if tc.expectedErr != nil {
+				if err == nil || err.Error() != tc.expectedErr.Error() {
+					t.Errorf("invalid error. expected: %v, got: %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected err: %v", err)
+				return
+			}
+
+			for _, tms := range tilematrix.Builtin() {
+				if tileMatrixSets[tms.Name] != tms {
+					t.Errorf("expected builtin tile matrix set (%v)", tms.Name)
+				}
+			}
+
+			if len(tc.sets) == 0 {
+				return
+			}
+
+			tms, ok := tileMatrixSets["LV95"]
+			if !ok {
+				t.Errorf("expected tile matrix set (LV95)")
+				return
+			}
+
+			if tms.TileSize != tc.tileSize {
+				t.Errorf("invalid tile size. expected: %v, got: %v", tc.tileSize, tms.TileSize)
+			}
+		}
+	}
+
+	lv95 := config.TileMatrixSet{
+		Name:        "LV95",
+		SRID:        2056,
+		Origin:      [2]env.Float{2420000, 1350000},
+		Resolutions: []env.Float{4000, 3750, 3500},
+		Bounds:      []env.Float{2420000, 1030000, 2900000, 1350000},
+	}
+
+	withTileSize := lv95
+	withTileSize.TileSize = env.UintPtr(512)
+
+	invalidBounds := lv95
+	invalidBounds.Bounds = []env.Float{2420000, 1030000}
+
+	invalidResolutions := lv95
+	invalidResolutions.Resolutions = nil
+
+	tests := map[string]tcase{
+		"builtin only": {},
+		"default tile size": {
+			sets:     []config.TileMatrixSet{lv95},
+			tileSize: 256,
+		},
+		"tile size": {
+			sets:     []config.TileMatrixSet{withTileSize},
+			tileSize: 512,
+		},
+		"invalid bounds": {
+			sets:        []config.TileMatrixSet{invalidBounds},
+			expectedErr: register.ErrTileMatrixSetBoundsInvalid{TileMatrixSet: "LV95"},
+		},
+		"invalid resolutions": {
+			sets:        []config.TileMatrixSet{invalidResolutions},
+			expectedErr: tilematrix.ErrInvalid{Name: "LV95", Reason: "at least one resolution is required"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -25,7 +25,8 @@ var Cmd = &cobra.Command{
 	Short: "command to manage the cache",
 	Long:  "command to manage the cache",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		RequireCache = true
+		// an MBTiles file can be used instead of the configured cache
+		RequireCache = cacheMBTiles == ""
 		if cmd.HasParent() {
 			// run the parents Persistent Run commands.
 			pcmd := cmd.Parent()
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/go-spatial/cobra"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/mbtiles"
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+)
+
+// mbtilesCache is the part of the mbtiles cache used by the seed and purge commands.
+// it's accessed through the cache registry as the backend requires cgo.
+type mbtilesCache interface {
+	cache.Interface
+	SetMetadata(mbtiles.Metadata) error
+	ZoomRange() (min, max uint, err error)
+	Close() error
+}
+
+// the MBTiles file being seeded or purged, nil when the configured cache is used
+var seedPurgeMBTiles mbtilesCache
+
+// setupMBTiles opens the MBTiles file the tiles of the map are seeded into or purged
+// from and sets it as the cache of the default atlas
+func setupMBTiles(filepath string, maps []atlas.Map) error {
+	if len(maps) != 1 {
+		return fmt.Errorf("--mbtiles requires a single map, set one with --map")
+	}
+	m := maps[0]
+
+	// the MBTiles spec only supports the web mercator tile grid
+	if m.Matrix().Name != tilematrix.WebMercatorQuad.Name {
+		return fmt.Errorf("--mbtiles requires a map in the %v tile matrix set, map (%v) uses %v", tilematrix.WebMercatorQuad.Name, m.Name, m.Matrix().Name)
+	}
+
+	c, err := cache.For(mbtiles.CacheType, dict.Dict{
+		mbtiles.ConfigKeyFilepath: filepath,
+		mbtiles.ConfigKeyMap:      m.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("could not open mbtiles file (%v): %v", filepath, err)
+	}
+
+	mbt, ok := c.(mbtilesCache)
+	if !ok {
+		return fmt.Errorf("cache type (%v) does not support metadata", mbtiles.CacheType)
+	}
+
+	seedPurgeMBTiles = mbt
+	atlas.SetCache(mbt)
+
+	return nil
+}
+
+// finishMBTiles writes the metadata of the seeded map and closes the MBTiles file
+func finishMBTiles(cmd *cobra.Command, args []string) error {
+	if seedPurgeMBTiles == nil {
+		return nil
+	}
+	defer seedPurgeMBTiles.Close()
+
+	md := mbtilesMetadata(seedPurgeMaps[0])
+
+	// the zoom range of the tiles in the file, which may have been seeded over several runs
+	var err error
+	if md.MinZoom, md.MaxZoom, err = seedPurgeMBTiles.ZoomRange(); err != nil {
+		return fmt.Errorf("could not read the zoom range of the mbtiles file: %v", err)
+	}
+
+	if err = seedPurgeMBTiles.SetMetadata(md); err != nil {
+		return fmt.Errorf("could not write the mbtiles metadata: %v", err)
+	}
+
+	log.Infof("wrote mbtiles metadata for %v", md)
+	return nil
+}
+
+// mbtilesMetadata builds the MBTiles metadata of a map. Layers sharing a name are
+// combined into a single vector layer covering their zoom ranges.
+func mbtilesMetadata(m atlas.Map) mbtiles.Metadata {
+	md := mbtiles.Metadata{
+		Name:        m.Name,
+		Attribution: m.Attribution,
+		Center:      m.Center,
+	}
+
+	if m.Bounds != nil {
+		md.Bounds = [4]float64{m.Bounds.MinX(), m.Bounds.MinY(), m.Bounds.MaxX(), m.Bounds.MaxY()}
+	}
+
+	layerIdx := map[string]int{}
+	for _, l := range m.Layers {
+		name := l.MVTName()
+
+		i, ok := layerIdx[name]
+		if !ok {
+			i = len(md.VectorLayers)
+			layerIdx[name] = i
+			md.VectorLayers = append(md.VectorLayers, mbtiles.VectorLayer{
+				ID:      name,
+				MinZoom: l.MinZoom,
+				MaxZoom: l.MaxZoom,
+				Fields:  map[string]string{},
+			})
+		}
+
+		vl := &md.VectorLayers[i]
+		if l.MinZoom < vl.MinZoom {
+			vl.MinZoom = l.MinZoom
+		}
+		if l.MaxZoom > vl.MaxZoom {
+			vl.MaxZoom = l.MaxZoom
+		}
+
+		// the fields returned by providers are not known until the features are
+		// fetched, only the default tags are described
+		for k, v := range l.DefaultTags {
+			vl.Fields[k] = mbtiles.FieldType(v)
+		}
+	}
+
+	return md
+}
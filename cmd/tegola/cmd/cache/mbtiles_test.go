@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache/mbtiles"
+)
+
+func TestMBTilesMetadata(t *testing.T) {
+	m := atlas.NewWebMercatorMap("test-map")
+	m.Attribution = "test attribution"
+	m.Bounds = geom.NewExtent([2]float64{-10, -20}, [2]float64{30, 40})
+	m.Center = [3]float64{1, 2, 3}
+	m.Layers = []atlas.Layer{
+		{Name: "water", MinZoom: 0, MaxZoom: 6, DefaultTags: map[string]interface{}{"class": "sea", "depth": 10}},
+		{Name: "roads", MinZoom: 8, MaxZoom: 14},
+		// a second layer with the same name extends the zoom range
+		{Name: "water", MinZoom: 7, MaxZoom: 14, DefaultTags: map[string]interface{}{"navigable": true}},
+	}
+
+	expected := mbtiles.Metadata{
+		Name:        "test-map",
+		Attribution: "test attribution",
+		Bounds:      [4]float64{-10, -20, 30, 40},
+		Center:      [3]float64{1, 2, 3},
+		VectorLayers: []mbtiles.VectorLayer{
+			{ID: "water", MinZoom: 0, MaxZoom: 14, Fields: map[string]string{"class": "String", "depth": "Number", "navigable": "Boolean"}},
+			{ID: "roads", MinZoom: 8, MaxZoom: 14, Fields: map[string]string{}},
+		},
+	}
+
+	got := mbtilesMetadata(m)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("metadata, expected %+v got %+v", expected, got)
+	}
+}
@@ -7,10 +7,13 @@ import (
 	"strings"
 
 	"github.com/go-spatial/cobra"
+	"github.com/go-spatial/geom"
 	"github.com/go-spatial/geom/slippy"
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/internal/log"
 	"github.com/go-spatial/tegola/maths"
+	"github.com/go-spatial/tegola/maths/proj"
+	"github.com/go-spatial/tegola/maths/tilematrix"
 	"github.com/go-spatial/tegola/provider"
 
 	gdcmd "github.com/go-spatial/tegola/internal/cmd"
@@ -48,6 +51,8 @@ var (
 	cacheBounds string
 	// name of the map
 	cacheMap string
+	// path of an MBTiles file to use instead of the configured cache
+	cacheMBTiles string
 )
 
 // variables that are not flags but set by the command.
@@ -70,12 +75,14 @@ func init() {
 	SeedPurgeCmd.PersistentFlags().StringVarP(&cacheMap, "map", "", "", "map name as defined in the config")
 	SeedPurgeCmd.PersistentFlags().IntVarP(&cacheConcurrency, "concurrency", "", runtime.NumCPU(), "the amount of concurrency to use. defaults to the number of CPUs on the machine")
 	SeedPurgeCmd.PersistentFlags().BoolVarP(&cacheOverwrite, "overwrite", "", false, "overwrite the cache if a tile already exists (default false)")
+	SeedPurgeCmd.PersistentFlags().StringVarP(&cacheMBTiles, "mbtiles", "", "", "path of an MBTiles file to seed the map into or purge it from instead of the configured cache")
 
 	SeedPurgeCmd.Flags().StringVarP(&cacheBounds, "bounds", "", "-180,-85.0511,180,85.0511", "lng/lat bounds to seed the cache with in the format: minx, miny, maxx, maxy")
 
 	SeedPurgeCmd.PersistentPreRunE = seedPurgeCmdValidatePersistent
 	SeedPurgeCmd.PreRunE = seedPurgeCmdValidate
 	SeedPurgeCmd.RunE = seedPurgeCommand
+	SeedPurgeCmd.PersistentPostRunE = finishMBTiles
 
 	SeedPurgeCmd.SetUsageTemplate(defaultUsage)
 
@@ -111,6 +118,12 @@ func seedPurgeCmdValidatePersistent(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if cacheMBTiles != "" {
+		if err := setupMBTiles(cacheMBTiles, seedPurgeMaps); err != nil {
+			return err
+		}
+	}
+
 	// Find the seed command and find out what it was called as.
 	seedcmd := cmd
 	cmdName := ""
@@ -185,12 +198,42 @@ func seedPurgeCommand(cmd *cobra.Command, args []string) (err error) {
 	}()
 
 	log.Info("zoom list: ", zooms)
-	tilechannel := generateTilesForBounds(ctx, seedPurgeBounds, zooms)
 
-	return doWork(ctx, tilechannel, seedPurgeMaps, cacheConcurrency, seedPurgeWorker)
+	// the tiles of each map are generated in the map's tile matrix set
+	for _, maps := range mapsByMatrix(seedPurgeMaps) {
+		tilechannel := generateTilesForBounds(ctx, maps[0].Matrix(), seedPurgeBounds, zooms)
+
+		if err = doWork(ctx, tilechannel, maps, cacheConcurrency, seedPurgeWorker); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// mapsByMatrix groups the maps by the name of their tile matrix set, in the order
+// the tile matrix sets are first used
+func mapsByMatrix(maps []atlas.Map) (groups [][]atlas.Map) {
+	idx := make(map[string]int)
+	for _, m := range maps {
+		name := m.Matrix().Name
+		i, ok := idx[name]
+		if !ok {
+			i = len(groups)
+			idx[name] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], m)
+	}
+	return groups
 }
 
-func generateTilesForBounds(ctx context.Context, bounds [4]float64, zooms []uint) *TileChannel {
+// generateTilesForBounds generates the tiles of the tile matrix set which cover the
+// lng/lat bounds at each zoom. Zooms beyond the last zoom of the tile matrix set are skipped.
+func generateTilesForBounds(ctx context.Context, tms *tilematrix.TileMatrixSet, bounds [4]float64, zooms []uint) *TileChannel {
 
 	tce := &TileChannel{
 		channel: make(chan *slippy.Tile),
@@ -198,29 +241,54 @@ func generateTilesForBounds(ctx context.Context, bounds [4]float64, zooms []uint
 
 	go func() {
 		defer tce.Close()
+
+		// the bounds in the units of the tile matrix set. web mercator tiles are
+		// calculated from the lng/lat of the bounds
+		var extent *geom.Extent
+		if tms != tilematrix.WebMercatorQuad {
+			var err error
+			extent, err = proj.TransformExtent(proj.WGS84, tms.SRID, geom.NewExtent(
+				[2]float64{bounds[0], bounds[1]},
+				[2]float64{bounds[2], bounds[3]},
+			))
+			if err != nil {
+				tce.setError(fmt.Errorf("could not transform bounds (%v) to tile matrix set (%v): %v", bounds, tms.Name, err))
+				return
+			}
+		}
+
 		for _, z := range zooms {
-			// get the tiles at the corners given the bounds and zoom
-			corner1 := slippy.NewTileLatLon(z, bounds[1], bounds[0])
-			corner2 := slippy.NewTileLatLon(z, bounds[3], bounds[2])
+			if z > tms.MaxZoom() {
+				continue
+			}
 
-			// x,y initials and finals
-			_, xi, yi := corner1.ZXY()
-			_, xf, yf := corner2.ZXY()
+			var xi, yi, xf, yf uint
+			if extent != nil {
+				xi, yi, xf, yf = tms.TileRange(z, extent)
+			} else {
+				// get the tiles at the corners given the bounds and zoom
+				corner1 := slippy.NewTileLatLon(z, bounds[1], bounds[0])
+				corner2 := slippy.NewTileLatLon(z, bounds[3], bounds[2])
 
-			maxXYatZ := uint(maths.Exp2(uint64(z))) - 1
+				// x,y initials and finals
+				_, xi, yi = corner1.ZXY()
+				_, xf, yf = corner2.ZXY()
 
-			// ensure the initials are smaller than finals
-			// this breaks at the anti meridian: https://github.com/go-spatial/tegola/issues/500
-			if xi > xf {
-				xi, xf = xf, xi
-			}
-			if yi > yf {
-				yi, yf = yf, yi
-			}
+				maxXYatZ := uint(maths.Exp2(uint64(z))) - 1
+
+				// ensure the initials are smaller than finals
+				// this breaks at the anti meridian: https://github.com/go-spatial/tegola/issues/500
+				if xi > xf {
+					xi, xf = xf, xi
+				}
+				if yi > yf {
+					yi, yf = yf, yi
+				}
 
-			// prevent seeding out of bounds
-			xf = maths.Min(xf, maxXYatZ)
-			yf = maths.Min(yf, maxXYatZ)
+				// prevent seeding out of bounds
+				xf = maths.Min(xf, maxXYatZ)
+				yf = maths.Min(yf, maxXYatZ)
+			}
 
 		MainLoop:
 			for x := xi; x <= xf; x++ {
@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/maths/tilematrix"
 )
 
 type sTiles []*slippy.Tile
@@ -78,6 +80,8 @@ func TestGenerateTilesForBounds(t *testing.T) {
 	worldBounds := [4]float64{-180.0, -85.0511, 180, 85.0511}
 
 	type tcase struct {
+		// tms defaults to WebMercatorQuad
+		tms    *tilematrix.TileMatrixSet
 		zooms  []uint
 		bounds [4]float64
 		tiles  sTiles
@@ -87,8 +91,13 @@ func TestGenerateTilesForBounds(t *testing.T) {
 	fn := func(tc tcase) func(t *testing.T) {
 		return func(t *testing.T) {
 
+			tms := tc.tms
+			if tms == nil {
+				tms = tilematrix.WebMercatorQuad
+			}
+
 			// Setup up the generator.
-			tilechannel := generateTilesForBounds(context.Background(), tc.bounds, tc.zooms)
+			tilechannel := generateTilesForBounds(context.Background(), tms, tc.bounds, tc.zooms)
 			tiles := make(sTiles, 0, len(tc.tiles))
 			for tile := range tilechannel.Channel() {
 				tiles = append(tiles, tile)
@@ -142,6 +151,37 @@ func TestGenerateTilesForBounds(t *testing.T) {
 				slippy.NewTile(1, 1, 1),
 			},
 		},
+		"WorldCRS84Quad max_zoom=0": {
+			tms:    tilematrix.WorldCRS84Quad,
+			zooms:  []uint{0},
+			bounds: worldBounds,
+			tiles: sTiles{
+				slippy.NewTile(0, 0, 0),
+				slippy.NewTile(0, 1, 0),
+			},
+		},
+		"WorldCRS84Quad min_zoom=1 max_zoom=1 bounds=0,0,180,90": {
+			tms:    tilematrix.WorldCRS84Quad,
+			zooms:  []uint{1},
+			bounds: [4]float64{0.0, 0.0, 180.0, 90.0},
+			tiles: sTiles{
+				slippy.NewTile(1, 2, 0),
+				slippy.NewTile(1, 3, 0),
+			},
+		},
+		"zooms beyond the tile matrix set": {
+			tms: &tilematrix.TileMatrixSet{
+				Name:        "two-zooms",
+				SRID:        3857,
+				Origin:      tilematrix.WebMercatorQuad.Origin,
+				TileSize:    tilematrix.DefaultTileSize,
+				Resolutions: tilematrix.WebMercatorQuad.Resolutions[:2],
+				Bounds:      tilematrix.WebMercatorQuad.Bounds,
+			},
+			zooms:  []uint{0, 2, 3},
+			bounds: worldBounds,
+			tiles:  sTiles{slippy.NewTile(0, 0, 0)},
+		},
 	}
 
 	for name, tc := range tests {
@@ -149,3 +189,27 @@ func TestGenerateTilesForBounds(t *testing.T) {
 	}
 
 }
+
+func TestMapsByMatrix(t *testing.T) {
+	osm := atlas.NewWebMercatorMap("osm")
+	boston := atlas.NewWebMercatorMap("boston")
+	world := atlas.NewWebMercatorMap("world")
+	world.TileMatrixSet = tilematrix.WorldCRS84Quad
+
+	groups := mapsByMatrix([]atlas.Map{osm, world, boston})
+
+	expected := [][]string{{"osm", "boston"}, {"world"}}
+	if len(groups) != len(expected) {
+		t.Fatalf("groups, expected %v got %v", len(expected), len(groups))
+	}
+	for i := range expected {
+		if len(groups[i]) != len(expected[i]) {
+			t.Fatalf("group %v, expected %v maps got %v", i, len(expected[i]), len(groups[i]))
+		}
+		for j := range expected[i] {
+			if groups[i][j].Name != expected[i][j] {
+				t.Errorf("group %v map %v, expected %v got %v", i, j, expected[i][j], groups[i][j].Name)
+			}
+		}
+	}
+}
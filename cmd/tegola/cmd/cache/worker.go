@@ -42,6 +42,12 @@ func seedWorker(overwrite bool) func(ctx context.Context, mt MapTile) error {
 
 		z, x, y := mt.Tile.ZXY()
 
+		//	tiles from a tile list may not be part of the map's tile matrix set
+		if !m.Matrix().Contains(z, x, y) {
+			log.Warnf("map (%v) tile (%v/%v/%v) is not in the tile matrix set (%v). skipping", mt.MapName, z, x, y, m.Matrix().Name)
+			return nil
+		}
+
 		//	filter down the layers we need for this zoom
 		m = m.FilterLayersByZoom(z)
 
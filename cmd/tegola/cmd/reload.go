@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-spatial/tegola/config"
+	gdcmd "github.com/go-spatial/tegola/internal/cmd"
+	"github.com/go-spatial/tegola/internal/log"
+)
+
+// configPollInterval is how often the config file is checked for changes when watching it
+const configPollInterval = 5 * time.Second
+
+// reloadConfig loads and validates the config file and replaces the providers and maps
+// being served. If an error is returned the running providers and maps are left untouched.
+// The webserver and cache settings are not reloaded.
+func reloadConfig(configFile string) error {
+	c, err := config.LoadAndValidate(configFile)
+	if err != nil {
+		return err
+	}
+
+	if err = registerConfig(c); err != nil {
+		return err
+	}
+
+	// the running server still accepts the previous credentials, i.e. revoked API keys
+	if !reflect.DeepEqual(conf.Webserver.Auth, c.Webserver.Auth) {
+		log.Warnf("config file (%v) webserver.auth changed, restart tegola for the new credentials to be used", configFile)
+	}
+
+	conf = c
+	return nil
+}
+
+// watchConfig reloads the config when the process receives a SIGHUP and, if watch is
+// true, when the modification time of the config file changes. It returns once the
+// command is cancelled.
+func watchConfig(configFile string, watch bool) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// a nil channel blocks forever so polling is disabled unless we're watching a local file
+	var poll <-chan time.Time
+	var modTime time.Time
+	if watch {
+		if strings.HasPrefix(configFile, "http") {
+			log.Warnf("remote config (%v) can not be watched for changes, use SIGHUP to reload it", configFile)
+		} else {
+			if fi, err := os.Stat(configFile); err == nil {
+				modTime = fi.ModTime()
+			}
+
+			ticker := time.NewTicker(configPollInterval)
+			defer ticker.Stop()
+			poll = ticker.C
+		}
+	}
+
+	reload := func(reason string) {
+		log.Infof("reloading config file (%v): %v", configFile, reason)
+		if err := reloadConfig(configFile); err != nil {
+			log.Errorf("config not reloaded, still serving the previous config: %v", err)
+			return
+		}
+		log.Infof("config file (%v) reloaded", configFile)
+	}
+
+	for {
+		select {
+		case <-gdcmd.Cancelled():
+			return
+
+		case <-hup:
+			reload("received SIGHUP")
+
+		case <-poll:
+			fi, err := os.Stat(configFile)
+			if err != nil {
+				log.Warnf("could not stat config file (%v): %v", configFile, err)
+				continue
+			}
+			if fi.ModTime().Equal(modTime) {
+				continue
+			}
+
+			modTime = fi.ModTime()
+			reload("file changed")
+		}
+	}
+}
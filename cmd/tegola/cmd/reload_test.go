@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-spatial/tegola/atlas"
+	_ "github.com/go-spatial/tegola/provider/test"
+)
+
+const reloadTestConfig = `
+[[providers]]
+name = "test"
+type = "test"
+
+[[maps]]
+name = "%v"
+
+	[[maps.layers]]
+	provider_layer = "test.test-layer"
+`
+
+func TestReloadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tegola-reload")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "config.toml")
+	writeConfig := func(t *testing.T, config string) {
+		if err := ioutil.WriteFile(configFile, []byte(config), 0644); err != nil {
+			t.Fatalf("unable to write config: %v", err)
+		}
+	}
+
+	type tcase struct {
+		config  string
+		expMaps []string
+		expErr  bool
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			writeConfig(t, tc.config)
+
+			err := reloadConfig(configFile)
+			if tc.expErr != (err != nil) {
+				t.Fatalf("error, expected error %v got %v", tc.expErr, err)
+			}
+
+			maps := atlas.AllMaps()
+			if len(maps) != len(tc.expMaps) {
+				t.Fatalf("maps, expected %v got %v", len(tc.expMaps), len(maps))
+			}
+			for i := range tc.expMaps {
+				if maps[i].Name != tc.expMaps[i] {
+					t.Errorf("map name, expected %v got %v", tc.expMaps[i], maps[i].Name)
+				}
+			}
+		}
+	}
+
+	// the cases are run in order as each reload builds on the previous one
+	tests := []struct {
+		name string
+		tc   tcase
+	}{
+		{
+			name: "initial",
+			tc: tcase{
+				config:  fmt.Sprintf(reloadTestConfig, "first"),
+				expMaps: []string{"first"},
+			},
+		},
+		{
+			name: "replace map",
+			tc: tcase{
+				config:  fmt.Sprintf(reloadTestConfig, "second"),
+				expMaps: []string{"second"},
+			},
+		},
+		{
+			name: "invalid config keeps maps",
+			tc: tcase{
+				config:  fmt.Sprintf(reloadTestConfig, "third") + "\n[[maps]\n",
+				expMaps: []string{"second"},
+				expErr:  true,
+			},
+		},
+		{
+			name: "unknown provider keeps maps",
+			tc: tcase{
+				config:  fmt.Sprintf(reloadTestConfig, "fourth") + "\n\t[[maps.layers]]\n\tprovider_layer = \"missing.layer\"\n",
+				expMaps: []string{"second"},
+				expErr:  true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, fn(test.tc))
+	}
+}
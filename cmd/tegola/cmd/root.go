@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/go-spatial/cobra"
 	"github.com/go-spatial/tegola/atlas"
@@ -10,6 +11,7 @@ import (
 	"github.com/go-spatial/tegola/config"
 	"github.com/go-spatial/tegola/dict"
 	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/provider"
 )
 
 var (
@@ -21,6 +23,8 @@ var (
 
 	// require cache
 	RequireCache bool
+	// providers used by the maps of the default atlas
+	registeredProviders map[string]provider.Tiler
 )
 
 func init() {
@@ -30,6 +34,7 @@ func init() {
 	// server
 	serverCmd.Flags().StringVarP(&serverPort, "port", "p", ":8080", "port to bind tile server to")
 	serverCmd.Flags().BoolVarP(&serverNoCache, "no-cache", "n", false, "turn off the cache")
+	serverCmd.Flags().BoolVarP(&serverWatchConfig, "watch", "w", false, "reload the providers and maps when the config file changes")
 	RootCmd.AddCommand(serverCmd)
 	// cache seed / purge
 	cachecmd.Config = &conf
@@ -66,22 +71,11 @@ func initConfig(configFile string, cacheRequired bool) (err error) {
 		return err
 	}
 
-	// init our providers
-	// but first convert []env.Map -> []dict.Dicter
-	provArr := make([]dict.Dicter, len(conf.Providers))
-	for i := range provArr {
-		provArr[i] = conf.Providers[i]
-	}
-
-	providers, err := register.Providers(provArr)
-	if err != nil {
-		return fmt.Errorf("could not register providers: %v", err)
+	// init our providers and maps
+	if err = registerConfig(conf); err != nil {
+		return err
 	}
 
-	// init our maps
-	if err = register.Maps(nil, conf.Maps, providers); err != nil {
-		return fmt.Errorf("could not register maps: %v", err)
-	}
 	if len(conf.Cache) == 0 && cacheRequired {
 		return fmt.Errorf("No cache defined in config, please check your config (%v).", configFile)
 	}
@@ -99,3 +93,63 @@ func initConfig(configFile string, cacheRequired bool) (err error) {
 	}
 	return nil
 }
+
+// registerConfig registers the providers, tile matrix sets and maps of the config and
+// swaps the maps into the default atlas. The providers of the previous maps are closed
+// once the requests using them are done. If an error is returned the default atlas is
+// left untouched.
+func registerConfig(c config.Config) error {
+	// init our providers
+	// but first convert []env.Map -> []dict.Dicter
+	provArr := make([]dict.Dicter, len(c.Providers))
+	for i := range provArr {
+		provArr[i] = c.Providers[i]
+	}
+
+	providers, err := register.Providers(provArr)
+	if err != nil {
+		closeProviders(providers)
+		return fmt.Errorf("could not register providers: %v", err)
+	}
+
+	// init our tile matrix sets
+	tileMatrixSets, err := register.TileMatrixSets(c.TileMatrixSets)
+	if err != nil {
+		closeProviders(providers)
+		return fmt.Errorf("could not register tile matrix sets: %v", err)
+	}
+
+	// init our maps in a separate atlas so the default atlas is only
+	// modified if all the maps register
+	var a atlas.Atlas
+	if err = register.Maps(&a, c.Maps, providers, tileMatrixSets); err != nil {
+		closeProviders(providers)
+		return fmt.Errorf("could not register maps: %v", err)
+	}
+
+	wait := atlas.ReplaceMaps(a.AllMaps())
+
+	// close the providers of the replaced maps once they're no longer in use
+	prevProviders := registeredProviders
+	registeredProviders = providers
+	go func() {
+		wait()
+		closeProviders(prevProviders)
+	}()
+
+	return nil
+}
+
+// closeProviders closes the providers which hold resources (i.e. database connections)
+func closeProviders(providers map[string]provider.Tiler) {
+	for name, p := range providers {
+		closer, ok := p.(io.Closer)
+		if !ok {
+			continue
+		}
+
+		if err := closer.Close(); err != nil {
+			log.Errorf("error closing provider (%v): %v", name, err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/go-spatial/cobra"
+	"github.com/go-spatial/tegola/cmd/internal/register"
 	gdcmd "github.com/go-spatial/tegola/internal/cmd"
 	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/server"
@@ -17,6 +18,8 @@ var (
 	serverPort      string
 	serverNoCache   bool
 	defaultHTTPPort = ":8080"
+	// reload the config when the config file changes
+	serverWatchConfig bool
 )
 
 var serverCmd = &cobra.Command{
@@ -50,6 +53,24 @@ var serverCmd = &cobra.Command{
 			server.Headers[name] = val
 		}
 
+		server.Metrics = bool(conf.Webserver.Metrics)
+
+		if conf.Webserver.Auth != nil {
+			auth, err := register.Auth(*conf.Webserver.Auth)
+			if err != nil {
+				log.Fatal(err)
+			}
+			server.Auth = auth
+		}
+
+		if rl := conf.Webserver.RateLimit; rl != nil {
+			if rl.RequestsPerSecond > 0 {
+				server.RateLimit = server.NewRateLimiter(float64(rl.RequestsPerSecond), int(rl.Burst))
+			}
+			server.TrustForwardedFor = bool(rl.TrustForwardedFor)
+			server.MaxConcurrentRenders = int(rl.MaxConcurrentRenders)
+		}
+
 		if conf.Webserver.URIPrefix != "" {
 			server.URIPrefix = string(conf.Webserver.URIPrefix)
 		}
@@ -72,6 +93,10 @@ var serverCmd = &cobra.Command{
 		// start our webserver
 		srv := server.Start(nil, serverPort)
 		shutdown(srv)
+
+		// reload the providers and maps on SIGHUP or config file changes
+		go watchConfig(configFile, serverWatchConfig)
+
 		<-gdcmd.Cancelled()
 		gdcmd.Complete()
 
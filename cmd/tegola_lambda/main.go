@@ -72,8 +72,14 @@ func init() {
 		log.Fatal(err)
 	}
 
+	// register the tile matrix sets
+	tileMatrixSets, err := register.TileMatrixSets(conf.TileMatrixSets)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// register the maps
-	if err = register.Maps(nil, conf.Maps, providers); err != nil {
+	if err = register.Maps(nil, conf.Maps, providers, tileMatrixSets); err != nil {
 		log.Fatal(err)
 	}
 
@@ -107,6 +113,24 @@ func init() {
 		server.Headers[name] = val
 	}
 
+	server.Metrics = bool(conf.Webserver.Metrics)
+
+	if conf.Webserver.Auth != nil {
+		auth, err := register.Auth(*conf.Webserver.Auth)
+		if err != nil {
+			log.Fatal(err)
+		}
+		server.Auth = auth
+	}
+
+	if rl := conf.Webserver.RateLimit; rl != nil {
+		if rl.RequestsPerSecond > 0 {
+			server.RateLimit = server.NewRateLimiter(float64(rl.RequestsPerSecond), int(rl.Burst))
+		}
+		server.TrustForwardedFor = bool(rl.TrustForwardedFor)
+		server.MaxConcurrentRenders = int(rl.MaxConcurrentRenders)
+	}
+
 	if conf.Webserver.URIPrefix != "" {
 		server.URIPrefix = string(conf.Webserver.URIPrefix)
 	}
@@ -116,12 +140,12 @@ func init() {
 }
 
 func main() {
-	// the second argument here tells algnhasa to watch for the MVT MimeType Content-Type headers
-	// if it detects this in the response the payload will be base64 encoded. Lambda needs to be configured
+	// the second argument here tells algnhasa to watch for the MVT and GeoJSON MimeType Content-Type headers
+	// if it detects these in the response the payload will be base64 encoded. Lambda needs to be configured
 	// to handle binary responses so it can convert the base64 encoded payload back into binary prior
-	// to sending to the client
+	// to sending to the client. GeoJSON tiles are binary as they can be gzipped.
 	algnhsa.ListenAndServe(mux, &algnhsa.Options{
-		BinaryContentTypes: []string{mvt.MimeType},
+		BinaryContentTypes: []string{mvt.MimeType, server.GeoJSONMimeType},
 		UseProxyPath:       true,
 	})
 }
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -13,6 +14,9 @@ import (
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/internal/env"
 	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/maths/simplify"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+	"github.com/go-spatial/tegola/provider"
 )
 
 var blacklistHeaders = []string{"content-encoding", "content-length", "content-type"}
@@ -30,6 +34,8 @@ type Config struct {
 	// Map of providers.
 	Providers []env.Dict `toml:"providers"`
 	Maps      []Map      `toml:"maps"`
+	// TileMatrixSets are custom tile grids which maps can be served in
+	TileMatrixSets []TileMatrixSet `toml:"tile_matrix_sets"`
 }
 
 type Webserver struct {
@@ -39,6 +45,51 @@ type Webserver struct {
 	Headers   env.Dict   `toml:"headers"`
 	SSLCert   env.String `toml:"ssl_cert"`
 	SSLKey    env.String `toml:"ssl_key"`
+	// Metrics enables the Prometheus /metrics endpoint
+	Metrics env.Bool `toml:"metrics"`
+	// Auth requires credentials for the maps which are not public
+	Auth *Auth `toml:"auth"`
+	// RateLimit limits the requests of each client and the renders of each map
+	RateLimit *RateLimit `toml:"rate_limit"`
+}
+
+// RateLimit represents the admission control of the webserver in the Tegola Config file.
+type RateLimit struct {
+	// RequestsPerSecond is the rate the requests of each client, identified by its
+	// credentials or address, are refilled at. 0 does not limit clients.
+	RequestsPerSecond env.Float `toml:"requests_per_second"`
+	// Burst is the number of requests a client can make at once.
+	// Defaults to requests_per_second rounded up.
+	Burst env.Int `toml:"burst"`
+	// TrustForwardedFor identifies clients by the first address of the
+	// X-Forwarded-For header. Only set it behind a proxy which sets the header.
+	TrustForwardedFor env.Bool `toml:"trust_forwarded_for"`
+	// MaxConcurrentRenders caps the tile renders and feature queries in progress
+	// for each map. Cache hits are not capped. 0 does not cap renders.
+	MaxConcurrentRenders env.Int `toml:"max_concurrent_renders"`
+}
+
+// Auth represents the credentials accepted by the webserver in the Tegola Config file.
+type Auth struct {
+	APIKeys []APIKey `toml:"api_keys"`
+	// APIKeysFile is read at startup. Each line is an API key followed by
+	// the optional comma separated maps and layers it can access.
+	APIKeysFile env.String `toml:"api_keys_file"`
+	// JWTSecret verifies the HMAC (HS256, HS384 and HS512) signed tokens
+	JWTSecret env.String `toml:"jwt_secret"`
+	// JWKSFile is a JSON Web Key Set which verifies the tokens signed by its keys
+	JWKSFile env.String `toml:"jwks_file"`
+	// JWTIssuer and JWTAudience, when set, must match the iss and aud claims of the tokens
+	JWTIssuer   env.String `toml:"jwt_issuer"`
+	JWTAudience env.String `toml:"jwt_audience"`
+}
+
+// An APIKey represents an API key and the maps and layers it can access.
+// A key without maps or layers can access all of them.
+type APIKey struct {
+	Key    env.String   `toml:"key"`
+	Maps   []env.String `toml:"maps"`
+	Layers []env.String `toml:"layers"`
 }
 
 // A Map represents a map in the Tegola Config file.
@@ -49,6 +100,84 @@ type Map struct {
 	Center      [3]env.Float `toml:"center"`
 	Layers      []MapLayer   `toml:"layers"`
 	TileBuffer  *env.Int     `toml:"tile_buffer"`
+	// TileMatrixSet is the name of the tile grid the map is served in.
+	// Defaults to WebMercatorQuad.
+	TileMatrixSet env.String `toml:"tile_matrix_set"`
+	// CacheControl is the Cache-Control header of the map's tiles (i.e. "public").
+	// It overrides a Cache-Control header set in the webserver headers.
+	CacheControl env.String `toml:"cache_control"`
+	// MaxAge and StaleWhileRevalidate, in seconds, are added to the Cache-Control header
+	MaxAge               *env.Int `toml:"max_age"`
+	StaleWhileRevalidate *env.Int `toml:"stale_while_revalidate"`
+	// Params are read from the query string of tile requests and bound to the
+	// !PARAM_<name>! tokens of the provider layers' SQL
+	Params []QueryParameter `toml:"params"`
+	// Public maps are served without credentials when the webserver has auth configured
+	Public env.Bool `toml:"public"`
+}
+
+// A QueryParameter represents a map query parameter in the Tegola Config file.
+type QueryParameter struct {
+	Name env.String `toml:"name"`
+	// Type is one of int, float, string or bool
+	Type env.String `toml:"type"`
+	// Default is used when the request does not set the parameter.
+	// A parameter without a default is required.
+	Default *env.String  `toml:"default"`
+	Values  []env.String `toml:"values"`
+	Regex   env.String   `toml:"regex"`
+}
+
+// queryParameterNameRe are the valid query parameter names
+var queryParameterNameRe = regexp.MustCompile("^[a-zA-Z0-9_]+$")
+
+// reservedQueryParameters are query string values used by tegola
+var reservedQueryParameters = []string{"debug", "api_key", "access_token", "f"}
+
+// QueryParameter returns the provider query parameter of the config
+func (p QueryParameter) QueryParameter() (provider.QueryParameter, error) {
+	var def *string
+	if p.Default != nil {
+		d := string(*p.Default)
+		def = &d
+	}
+
+	var values []string
+	for _, v := range p.Values {
+		values = append(values, string(v))
+	}
+
+	return provider.NewQueryParameter(string(p.Name), string(p.Type), def, values, string(p.Regex))
+}
+
+// CacheControlHeader returns the Cache-Control header of the map's tiles. An empty
+// string is returned if the map does not configure one.
+func (m Map) CacheControlHeader() string {
+	var directives []string
+	if m.CacheControl != "" {
+		directives = append(directives, string(m.CacheControl))
+	}
+	if m.MaxAge != nil {
+		directives = append(directives, fmt.Sprintf("max-age=%d", int(*m.MaxAge)))
+	}
+	if m.StaleWhileRevalidate != nil {
+		directives = append(directives, fmt.Sprintf("stale-while-revalidate=%d", int(*m.StaleWhileRevalidate)))
+	}
+	return strings.Join(directives, ", ")
+}
+
+// A TileMatrixSet represents a custom tile grid in the Tegola Config file.
+type TileMatrixSet struct {
+	Name env.String `toml:"name"`
+	SRID env.Uint   `toml:"srid"`
+	// the top left corner of the tile grid
+	Origin [2]env.Float `toml:"origin"`
+	// width and height of the tiles in pixels. Defaults to 256
+	TileSize *env.Uint `toml:"tile_size"`
+	// size of a pixel in SRID units for each zoom, starting at zoom 0
+	Resolutions []env.Float `toml:"resolutions"`
+	// extent covered by the tile grid in SRID units, in the order left, bottom, right, top
+	Bounds []env.Float `toml:"bounds"`
 }
 
 type MapLayer struct {
@@ -65,6 +194,18 @@ type MapLayer struct {
 	// DontClip indicates wheather feature clipping should be applied.
 	// We use a negative in the name so the default is to clipping
 	DontClip env.Bool `toml:"dont_clip"`
+	// DontMakeValid indicates wheather the makevalid step should be applied.
+	// Without it polygons are not clipped. Points and lines are still clipped
+	DontMakeValid env.Bool `toml:"dont_make_valid"`
+	// SimplifyTolerance is the simplification tolerance in tile pixels (4096 per tile).
+	// Defaults to tegola.DefaultEpsilon
+	SimplifyTolerance *env.Float `toml:"simplify_tolerance"`
+	// SimplifyMaxZoom is the last zoom features are simplified at.
+	// Defaults to tegola.DefaultSimplifyMaxZoom
+	SimplifyMaxZoom *env.Uint `toml:"simplify_max_zoom"`
+	// SimplifyAlgorithm is the simplification algorithm, see the maths/simplify package.
+	// Defaults to "douglas_peucker"
+	SimplifyAlgorithm env.String `toml:"simplify_algorithm"`
 }
 
 // ProviderLayerName returns the provider and layer names
@@ -122,6 +263,20 @@ func (c *Config) Validate() error {
 				c.Maps[mapKey].Layers[layerKey].MinZoom = &ph
 			}
 
+			// check the simplification settings
+			if _, err := simplify.For(string(l.SimplifyAlgorithm), 0); err != nil {
+				return ErrInvalidSimplifyAlgorithm{
+					ProviderLayer: string(l.ProviderLayer),
+					Algorithm:     string(l.SimplifyAlgorithm),
+				}
+			}
+			if l.SimplifyTolerance != nil && *l.SimplifyTolerance < 0 {
+				return ErrInvalidSimplifyTolerance{
+					ProviderLayer: string(l.ProviderLayer),
+					Tolerance:     float64(*l.SimplifyTolerance),
+				}
+			}
+
 			// check if we already have this layer
 			if val, ok := mapLayers[string(m.Name)][name]; ok {
 				// we have a hit. check for zoom range overlap
@@ -139,6 +294,62 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// check the tile matrix sets have unique names and the maps reference known tile matrix sets
+	tileMatrixSets := map[string]bool{}
+	for _, tms := range tilematrix.Builtin() {
+		tileMatrixSets[tms.Name] = true
+	}
+	for _, tms := range c.TileMatrixSets {
+		if tileMatrixSets[string(tms.Name)] {
+			return ErrTileMatrixSetAlreadyDefined{Name: string(tms.Name)}
+		}
+		tileMatrixSets[string(tms.Name)] = true
+	}
+	for _, m := range c.Maps {
+		if m.TileMatrixSet != "" && !tileMatrixSets[string(m.TileMatrixSet)] {
+			return ErrUnknownTileMatrixSet{
+				MapName:       string(m.Name),
+				TileMatrixSet: string(m.TileMatrixSet),
+			}
+		}
+	}
+
+	// check the cache control settings of the maps
+	for _, m := range c.Maps {
+		if m.MaxAge != nil && *m.MaxAge < 0 {
+			return ErrInvalidCacheControl{MapName: string(m.Name), Directive: "max_age", Value: int(*m.MaxAge)}
+		}
+		if m.StaleWhileRevalidate != nil && *m.StaleWhileRevalidate < 0 {
+			return ErrInvalidCacheControl{MapName: string(m.Name), Directive: "stale_while_revalidate", Value: int(*m.StaleWhileRevalidate)}
+		}
+	}
+
+	// check the query parameters of the maps
+	for _, m := range c.Maps {
+		tokens := map[string]bool{}
+		for _, p := range m.Params {
+			name := string(p.Name)
+			if !queryParameterNameRe.MatchString(name) {
+				return ErrInvalidQueryParameter{MapName: string(m.Name), Name: name, Reason: "names can only contain letters, numbers and underscores"}
+			}
+			for _, r := range reservedQueryParameters {
+				if strings.EqualFold(name, r) {
+					return ErrInvalidQueryParameter{MapName: string(m.Name), Name: name, Reason: "the name is reserved"}
+				}
+			}
+			// tokens are case insensitive
+			token := provider.QueryParameterToken(name)
+			if tokens[token] {
+				return ErrInvalidQueryParameter{MapName: string(m.Name), Name: name, Reason: "the name is already used"}
+			}
+			tokens[token] = true
+
+			if _, err := p.QueryParameter(); err != nil {
+				return ErrInvalidQueryParameter{MapName: string(m.Name), Name: name, Reason: err.Error()}
+			}
+		}
+	}
+
 	// check for blacklisted headers
 	for k := range c.Webserver.Headers {
 		for _, v := range blacklistHeaders {
@@ -148,6 +359,31 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// check the webserver auth has credentials
+	if auth := c.Webserver.Auth; auth != nil {
+		if len(auth.APIKeys) == 0 && auth.APIKeysFile == "" && auth.JWTSecret == "" && auth.JWKSFile == "" {
+			return ErrInvalidAuth{Reason: "one of api_keys, api_keys_file, jwt_secret or jwks_file is required"}
+		}
+		for i, k := range auth.APIKeys {
+			if k.Key == "" {
+				return ErrInvalidAuth{Reason: fmt.Sprintf("api_keys[%v] has no key", i)}
+			}
+		}
+	}
+
+	// check the webserver rate limits
+	if rl := c.Webserver.RateLimit; rl != nil {
+		if rl.RequestsPerSecond < 0 {
+			return ErrInvalidRateLimit{Name: "requests_per_second", Value: float64(rl.RequestsPerSecond)}
+		}
+		if rl.Burst < 0 {
+			return ErrInvalidRateLimit{Name: "burst", Value: float64(rl.Burst)}
+		}
+		if rl.MaxConcurrentRenders < 0 {
+			return ErrInvalidRateLimit{Name: "max_concurrent_renders", Value: float64(rl.MaxConcurrentRenders)}
+		}
+	}
+
 	// check if webserver.uri_prefix is set and if so
 	// confirm it starts with a forward slash "/"
 	if string(c.Webserver.URIPrefix) != "" {
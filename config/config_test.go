@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-spatial/tegola/config"
 	"github.com/go-spatial/tegola/internal/env"
+	"github.com/go-spatial/tegola/provider"
 )
 
 const (
@@ -133,7 +134,11 @@ func TestParse(t *testing.T) {
 					min_zoom = 10
 					max_zoom = 20
 					dont_simplify = true
-					dont_clip = true`,
+					dont_clip = true
+					dont_make_valid = true
+					simplify_tolerance = 2.5
+					simplify_max_zoom = 14
+					simplify_algorithm = "visvalingam"`,
 			expected: config.Config{
 				TileBuffer:   env.IntPtr(env.Int(12)),
 				LocationName: "",
@@ -182,6 +187,10 @@ func TestParse(t *testing.T) {
 								MaxZoom:       env.UintPtr(20),
 								DontSimplify:  true,
 								DontClip:  true,
+								DontMakeValid:     true,
+								SimplifyTolerance: env.FloatPtr(2.5),
+								SimplifyMaxZoom:   env.UintPtr(14),
+								SimplifyAlgorithm: "visvalingam",
 							},
 						},
 					},
@@ -716,6 +725,272 @@ func TestValidate(t *testing.T) {
 				Header: "Content-Encoding",
 			},
 		},
+		"7 unknown tile matrix set": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name:          "swiss",
+						TileMatrixSet: "LV95",
+					},
+				},
+			},
+			expectedErr: config.ErrUnknownTileMatrixSet{
+				MapName:       "swiss",
+				TileMatrixSet: "LV95",
+			},
+		},
+		"8 tile matrix set shadowing a builtin": {
+			config: config.Config{
+				TileMatrixSets: []config.TileMatrixSet{
+					{
+						Name: "WebMercatorQuad",
+						SRID: 3857,
+					},
+				},
+			},
+			expectedErr: config.ErrTileMatrixSetAlreadyDefined{
+				Name: "WebMercatorQuad",
+			},
+		},
+		"9 tile matrix set": {
+			config: config.Config{
+				TileMatrixSets: []config.TileMatrixSet{
+					{
+						Name:        "LV95",
+						SRID:        2056,
+						Origin:      [2]env.Float{2420000, 1350000},
+						Resolutions: []env.Float{4000, 3750, 3500},
+					},
+				},
+				Maps: []config.Map{
+					{
+						Name:          "swiss",
+						TileMatrixSet: "LV95",
+					},
+					{
+						Name:          "world",
+						TileMatrixSet: "WorldCRS84Quad",
+					},
+				},
+			},
+		},
+		"10 unknown simplify algorithm": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name: "osm",
+						Layers: []config.MapLayer{
+							{
+								ProviderLayer:     "provider1.water",
+								SimplifyAlgorithm: "bogus",
+							},
+						},
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidSimplifyAlgorithm{
+				ProviderLayer: "provider1.water",
+				Algorithm:     "bogus",
+			},
+		},
+		"11 negative simplify tolerance": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name: "osm",
+						Layers: []config.MapLayer{
+							{
+								ProviderLayer:     "provider1.water",
+								SimplifyTolerance: env.FloatPtr(-1),
+							},
+						},
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidSimplifyTolerance{
+				ProviderLayer: "provider1.water",
+				Tolerance:     -1,
+			},
+		},
+		"12 simplify settings": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name: "osm",
+						Layers: []config.MapLayer{
+							{
+								ProviderLayer:     "provider1.water",
+								SimplifyAlgorithm: "visvalingam",
+								SimplifyTolerance: env.FloatPtr(2.5),
+								SimplifyMaxZoom:   env.UintPtr(14),
+								DontMakeValid:     true,
+							},
+						},
+					},
+				},
+			},
+		},
+		"13 negative max age": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name:   "osm",
+						MaxAge: env.IntPtr(-1),
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidCacheControl{
+				MapName:   "osm",
+				Directive: "max_age",
+				Value:     -1,
+			},
+		},
+		"14 negative stale while revalidate": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name:                 "osm",
+						MaxAge:               env.IntPtr(60),
+						StaleWhileRevalidate: env.IntPtr(-5),
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidCacheControl{
+				MapName:   "osm",
+				Directive: "stale_while_revalidate",
+				Value:     -5,
+			},
+		},
+		"15 query parameters": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name: "osm",
+						Params: []config.QueryParameter{
+							{Name: "year", Type: "int", Default: env.StringPtr("2019")},
+							{Name: "category", Type: "string", Values: []env.String{"roads", "rail"}},
+							{Name: "tenant_id", Type: "string", Regex: "[a-z0-9]+"},
+						},
+					},
+				},
+			},
+		},
+		"16 invalid query parameter name": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name:   "osm",
+						Params: []config.QueryParameter{{Name: "year!", Type: "int"}},
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidQueryParameter{
+				MapName: "osm",
+				Name:    "year!",
+				Reason:  "names can only contain letters, numbers and underscores",
+			},
+		},
+		"17 reserved query parameter name": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name:   "osm",
+						Params: []config.QueryParameter{{Name: "debug", Type: "bool"}},
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidQueryParameter{
+				MapName: "osm",
+				Name:    "debug",
+				Reason:  "the name is reserved",
+			},
+		},
+		"18 duplicate query parameter token": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name: "osm",
+						Params: []config.QueryParameter{
+							{Name: "year", Type: "int"},
+							{Name: "YEAR", Type: "int"},
+						},
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidQueryParameter{
+				MapName: "osm",
+				Name:    "YEAR",
+				Reason:  "the name is already used",
+			},
+		},
+		"19 invalid query parameter default": {
+			config: config.Config{
+				Maps: []config.Map{
+					{
+						Name:   "osm",
+						Params: []config.QueryParameter{{Name: "year", Type: "int", Default: env.StringPtr("last")}},
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidQueryParameter{
+				MapName: "osm",
+				Name:    "year",
+				Reason:  provider.ErrInvalidQueryParameter{Name: "year", Value: "last", Reason: "not a valid int"}.Error(),
+			},
+		},
+		"20 auth": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					Auth: &config.Auth{
+						APIKeys:   []config.APIKey{{Key: "abc", Maps: []env.String{"osm"}}},
+						JWTSecret: "secret",
+					},
+				},
+			},
+		},
+		"21 auth without credentials": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					Auth: &config.Auth{JWTIssuer: "tegola"},
+				},
+			},
+			expectedErr: config.ErrInvalidAuth{
+				Reason: "one of api_keys, api_keys_file, jwt_secret or jwks_file is required",
+			},
+		},
+		"22 auth api key without key": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					Auth: &config.Auth{
+						APIKeys: []config.APIKey{{Key: "abc"}, {Maps: []env.String{"osm"}}},
+					},
+				},
+			},
+			expectedErr: config.ErrInvalidAuth{Reason: "api_keys[1] has no key"},
+		},
+		"23 rate limit": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					RateLimit: &config.RateLimit{RequestsPerSecond: 2.5, Burst: 10, MaxConcurrentRenders: 4},
+				},
+			},
+		},
+		"24 negative rate limit": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					RateLimit: &config.RateLimit{RequestsPerSecond: -1},
+				},
+			},
+			expectedErr: config.ErrInvalidRateLimit{Name: "requests_per_second", Value: -1},
+		},
+		"25 negative max concurrent renders": {
+			config: config.Config{
+				Webserver: config.Webserver{
+					RateLimit: &config.RateLimit{MaxConcurrentRenders: -2},
+				},
+			},
+			expectedErr: config.ErrInvalidRateLimit{Name: "max_concurrent_renders", Value: -2},
+		},
 	}
 
 	for name, tc := range tests {
@@ -841,3 +1116,42 @@ func TestConfigureTileBuffers(t *testing.T) {
 		})
 	}
 }
+
+func TestMapCacheControlHeader(t *testing.T) {
+	type tcase struct {
+		m        config.Map
+		expected string
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			if got := tc.m.CacheControlHeader(); got != tc.expected {
+				t.Errorf("expected %q got %q", tc.expected, got)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"not set": {},
+		"cache control": {
+			m:        config.Map{CacheControl: "no-cache"},
+			expected: "no-cache",
+		},
+		"max age": {
+			m:        config.Map{MaxAge: env.IntPtr(3600)},
+			expected: "max-age=3600",
+		},
+		"all": {
+			m: config.Map{
+				CacheControl:         "public",
+				MaxAge:               env.IntPtr(3600),
+				StaleWhileRevalidate: env.IntPtr(60),
+			},
+			expected: "public, max-age=3600, stale-while-revalidate=60",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
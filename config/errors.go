@@ -1,6 +1,11 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-spatial/tegola/maths/simplify"
+)
 
 type ErrMapNotFound struct {
 	MapName string
@@ -66,3 +71,75 @@ type ErrInvalidURIPrefix string
 func (e ErrInvalidURIPrefix) Error() string {
 	return fmt.Sprintf("config: invalid uri_prefix (%v). uri_prefix must start with a forward slash '/' ", string(e))
 }
+
+type ErrTileMatrixSetAlreadyDefined struct {
+	Name string
+}
+
+func (e ErrTileMatrixSetAlreadyDefined) Error() string {
+	return fmt.Sprintf("config: tile matrix set (%v) already defined", e.Name)
+}
+
+type ErrUnknownTileMatrixSet struct {
+	MapName       string
+	TileMatrixSet string
+}
+
+func (e ErrUnknownTileMatrixSet) Error() string {
+	return fmt.Sprintf("config: map (%v) references unknown tile matrix set (%v)", e.MapName, e.TileMatrixSet)
+}
+
+type ErrInvalidSimplifyAlgorithm struct {
+	ProviderLayer string
+	Algorithm     string
+}
+
+func (e ErrInvalidSimplifyAlgorithm) Error() string {
+	return fmt.Sprintf("config: invalid simplify_algorithm (%v) for provider layer (%v). supported algorithms: %v", e.Algorithm, e.ProviderLayer, strings.Join(simplify.Algorithms(), ", "))
+}
+
+type ErrInvalidSimplifyTolerance struct {
+	ProviderLayer string
+	Tolerance     float64
+}
+
+func (e ErrInvalidSimplifyTolerance) Error() string {
+	return fmt.Sprintf("config: simplify_tolerance (%v) for provider layer (%v) can not be negative", e.Tolerance, e.ProviderLayer)
+}
+
+type ErrInvalidCacheControl struct {
+	MapName   string
+	Directive string
+	Value     int
+}
+
+func (e ErrInvalidCacheControl) Error() string {
+	return fmt.Sprintf("config: %v (%v) for map (%v) can not be negative", e.Directive, e.Value, e.MapName)
+}
+
+type ErrInvalidQueryParameter struct {
+	MapName string
+	Name    string
+	Reason  string
+}
+
+func (e ErrInvalidQueryParameter) Error() string {
+	return fmt.Sprintf("config: invalid query parameter (%v) for map (%v): %v", e.Name, e.MapName, e.Reason)
+}
+
+type ErrInvalidAuth struct {
+	Reason string
+}
+
+func (e ErrInvalidAuth) Error() string {
+	return fmt.Sprintf("config: invalid webserver auth: %v", e.Reason)
+}
+
+type ErrInvalidRateLimit struct {
+	Name  string
+	Value float64
+}
+
+func (e ErrInvalidRateLimit) Error() string {
+	return fmt.Sprintf("config: webserver rate_limit %v (%v) can not be negative", e.Name, e.Value)
+}
@@ -1,9 +1,10 @@
 package tegola
 
 const (
-	DefaultEpsilon    = 10.0
-	DefaultExtent     = 4096
-	DefaultTileBuffer = 64.0
-	MaxZ              = 22
+	DefaultEpsilon         = 10.0
+	DefaultExtent          = 4096
+	DefaultTileBuffer      = 64.0
+	DefaultSimplifyMaxZoom = 9
+	MaxZ                   = 22
 )
 
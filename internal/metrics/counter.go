@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// CounterVec is a counter partitioned by label values
+type CounterVec struct {
+	family
+
+	sync.Mutex
+	values map[string]*counter
+}
+
+type counter struct {
+	value float64
+}
+
+// Inc increments the counter for the label values by 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to the counter for the label values. v must not be negative.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter (%v) can not decrease", c.metricName))
+	}
+
+	key := c.key(labelValues)
+
+	c.Lock()
+	defer c.Unlock()
+
+	val, ok := c.values[key]
+	if !ok {
+		val = &counter{}
+		c.values[key] = val
+	}
+	val.value += v
+}
+
+// Value returns the counter for the label values
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+
+	c.Lock()
+	defer c.Unlock()
+
+	if val, ok := c.values[key]; ok {
+		return val.value
+	}
+	return 0
+}
+
+func (c *CounterVec) writeTo(w io.Writer) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.writeHeader(w, "counter"); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+
+	for _, k := range sortedKeys(keys) {
+		if _, err := fmt.Fprintf(w, "%v%v %v\n", c.metricName, c.labels(k), formatFloat(c.values[k].value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// GaugeVec is a value which can go up and down partitioned by label values
+type GaugeVec struct {
+	family
+
+	sync.Mutex
+	values map[string]float64
+}
+
+// NewGaugeVec creates a gauge partitioned by the label names and registers it on the registry
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{
+		family: newFamily(name, help, labelNames),
+		values: map[string]float64{},
+	}
+	r.register(g)
+	return g
+}
+
+// NewGaugeVec creates a gauge registered on the DefaultRegistry
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return DefaultRegistry.NewGaugeVec(name, help, labelNames...)
+}
+
+// Set sets the gauge for the label values to v
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.Lock()
+	defer g.Unlock()
+
+	g.values[key] = v
+}
+
+// Add adds v, which may be negative, to the gauge for the label values
+func (g *GaugeVec) Add(v float64, labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.Lock()
+	defer g.Unlock()
+
+	g.values[key] += v
+}
+
+// Value returns the gauge for the label values
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	key := g.key(labelValues)
+
+	g.Lock()
+	defer g.Unlock()
+
+	return g.values[key]
+}
+
+func (g *GaugeVec) writeTo(w io.Writer) error {
+	g.Lock()
+	defer g.Unlock()
+
+	if err := g.writeHeader(w, "gauge"); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(g.values))
+	for k := range g.values {
+		keys = append(keys, k)
+	}
+
+	for _, k := range sortedKeys(keys) {
+		if _, err := fmt.Fprintf(w, "%v%v %v\n", g.metricName, g.labels(k), formatFloat(g.values[k])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
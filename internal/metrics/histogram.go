@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HistogramVec is a histogram partitioned by label values
+type HistogramVec struct {
+	family
+	buckets []float64
+
+	sync.Mutex
+	values map[string]*histogram
+}
+
+type histogram struct {
+	// counts of observations per bucket, not cumulative
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe adds the observation v to the histogram for the label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.Lock()
+	defer h.Unlock()
+
+	val, ok := h.values[key]
+	if !ok {
+		val = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = val
+	}
+
+	// the first bucket with an upper bound >= v. values larger than the last bucket
+	// are only counted in the implicit +Inf bucket
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		val.counts[i]++
+	}
+	val.count++
+	val.sum += v
+}
+
+// ObserveDuration adds the seconds elapsed since start to the histogram for the label values
+func (h *HistogramVec) ObserveDuration(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// Count returns the number of observations for the label values
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+
+	h.Lock()
+	defer h.Unlock()
+
+	if val, ok := h.values[key]; ok {
+		return val.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) writeTo(w io.Writer) error {
+	h.Lock()
+	defer h.Unlock()
+
+	if err := h.writeHeader(w, "histogram"); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(h.values))
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+
+	for _, k := range sortedKeys(keys) {
+		val := h.values[k]
+
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += val.counts[i]
+			if _, err := fmt.Fprintf(w, "%v_bucket%v %v\n", h.metricName, h.labels(k, "le", formatFloat(le)), cumulative); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "%v_bucket%v %v\n", h.metricName, h.labels(k, "le", formatFloat(math.Inf(1))), val.count); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%v_sum%v %v\n", h.metricName, h.labels(k), formatFloat(val.sum)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%v_count%v %v\n", h.metricName, h.labels(k), val.count); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
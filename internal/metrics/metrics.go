@@ -0,0 +1,202 @@
+/*
+Package metrics provides counters and histograms which are exposed in the
+Prometheus text exposition format (version 0.0.4). It's deliberately small so
+tegola can be scraped by Prometheus without depending on an external service or
+client library.
+
+Collectors are registered on a Registry when created. Collectors created with
+the package level constructors are registered on the DefaultRegistry, which is
+what Handler serves.
+*/
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	// DefaultRegistry is the registry the package level constructors register collectors on
+	DefaultRegistry = &Registry{}
+
+	// DefaultDurationBuckets are buckets, in seconds, suited to request and query latencies
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// DefaultSizeBuckets are buckets, in bytes, suited to encoded tile sizes
+	DefaultSizeBuckets = []float64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 128 << 10, 256 << 10, 512 << 10, 1 << 20, 2 << 20}
+)
+
+// collector is a metric family which can write itself in the text exposition format
+type collector interface {
+	name() string
+	writeTo(w io.Writer) error
+}
+
+// Registry holds the collectors which are exposed together
+type Registry struct {
+	sync.RWMutex
+	collectors []collector
+}
+
+func (r *Registry) register(c collector) {
+	r.Lock()
+	defer r.Unlock()
+
+	for i := range r.collectors {
+		if r.collectors[i].name() == c.name() {
+			panic(fmt.Sprintf("metrics: collector (%v) already registered", c.name()))
+		}
+	}
+
+	r.collectors = append(r.collectors, c)
+}
+
+// NewCounterVec creates a counter partitioned by the label names and registers it on the registry
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		family: newFamily(name, help, labelNames),
+		values: map[string]*counter{},
+	}
+	r.register(c)
+	return c
+}
+
+// NewHistogramVec creates a histogram partitioned by the label names and registers it on the registry.
+// The buckets are the upper bounds of the buckets and must be sorted in increasing order.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: histogram (%v) buckets are not sorted", name))
+	}
+
+	h := &HistogramVec{
+		family:  newFamily(name, help, labelNames),
+		buckets: buckets,
+		values:  map[string]*histogram{},
+	}
+	r.register(h)
+	return h
+}
+
+// Write writes all the collectors of the registry in the text exposition format
+func (r *Registry) Write(w io.Writer) error {
+	r.RLock()
+	defer r.RUnlock()
+
+	for i := range r.collectors {
+		if err := r.collectors[i].writeTo(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ServeHTTP adheres to the http.Handler interface
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.Write(w)
+}
+
+// NewCounterVec creates a counter registered on the DefaultRegistry
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labelNames...)
+}
+
+// NewHistogramVec creates a histogram registered on the DefaultRegistry
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return DefaultRegistry.NewHistogramVec(name, help, buckets, labelNames...)
+}
+
+// Handler returns an http.Handler which serves the collectors of the DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry
+}
+
+// family holds the name, help and label names of a metric
+type family struct {
+	metricName string
+	help       string
+	labelNames []string
+}
+
+func newFamily(name, help string, labelNames []string) family {
+	return family{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+	}
+}
+
+func (f family) name() string { return f.metricName }
+
+// key formats the label pairs of the label values, which is used as the map key
+// of the values. The values are escaped so distinct label values always have
+// distinct keys. it panics if the number of label values does not match the number
+// of label names as that's a programming error.
+func (f family) key(labelValues []string) string {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: %v expects %v label values, got %v", f.metricName, len(f.labelNames), len(labelValues)))
+	}
+
+	pairs := make([]string, len(labelValues))
+	for i, v := range labelValues {
+		pairs[i] = labelPair(f.labelNames[i], v)
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// labels formats the label pairs for a key, with the additional pair appended when not empty
+func (f family) labels(key string, extra ...string) string {
+	var pairs []string
+	if key != "" {
+		pairs = append(pairs, key)
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, labelPair(extra[0], extra[1]))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func labelPair(name, value string) string {
+	return fmt.Sprintf(`%v="%v"`, name, labelEscaper.Replace(value))
+}
+
+func (f family) writeHeader(w io.Writer, typ string) error {
+	_, err := fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", f.metricName, f.help, f.metricName, typ)
+	return err
+}
+
+// labelEscaper escapes label values as required by the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// sortedKeys returns the keys of the values map in order so the output is stable
+func sortedKeys(keys []string) []string {
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
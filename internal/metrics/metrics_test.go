@@ -0,0 +1,127 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-spatial/tegola/internal/metrics"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	type tcase struct {
+		record   func(r *metrics.Registry)
+		expected string
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			var r metrics.Registry
+			tc.record(&r)
+
+			var buf bytes.Buffer
+			if err := r.Write(&buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if buf.String() != tc.expected {
+				t.Errorf("output, expected\n%v\ngot\n%v", tc.expected, buf.String())
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"counter": {
+			record: func(r *metrics.Registry) {
+				c := r.NewCounterVec("requests_total", "Requests.", "map", "code")
+				c.Inc("osm", "200")
+				c.Inc("osm", "200")
+				c.Add(3, "boston", "404")
+			},
+			expected: `# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{map="boston",code="404"} 3
+requests_total{map="osm",code="200"} 2
+`,
+		},
+		"counter no labels": {
+			record: func(r *metrics.Registry) {
+				r.NewCounterVec("errors_total", "Errors.").Inc()
+			},
+			expected: `# HELP errors_total Errors.
+# TYPE errors_total counter
+errors_total 1
+`,
+		},
+		"escaped label": {
+			record: func(r *metrics.Registry) {
+				r.NewCounterVec("errors_total", "Errors.", "name").Inc(`a "b"\c`)
+			},
+			expected: `# HELP errors_total Errors.
+# TYPE errors_total counter
+errors_total{name="a \"b\"\\c"} 1
+`,
+		},
+		"label values with separators": {
+			record: func(r *metrics.Registry) {
+				c := r.NewCounterVec("requests_total", "Requests.", "map", "layer")
+				c.Inc("a\xffb", "c")
+				c.Inc(`a",layer="b`, "c")
+				c.Inc("a", `b",layer="c`)
+			},
+			expected: "# HELP requests_total Requests.\n" +
+				"# TYPE requests_total counter\n" +
+				`requests_total{map="a",layer="b\",layer=\"c"} 1` + "\n" +
+				`requests_total{map="a\",layer=\"b",layer="c"} 1` + "\n" +
+				"requests_total{map=\"a\xffb\",layer=\"c\"} 1\n",
+		},
+		"gauge": {
+			record: func(r *metrics.Registry) {
+				g := r.NewGaugeVec("entries", "Entries.")
+				g.Add(3)
+				g.Add(-1)
+			},
+			expected: `# HELP entries Entries.
+# TYPE entries gauge
+entries 2
+`,
+		},
+		"histogram": {
+			record: func(r *metrics.Registry) {
+				h := r.NewHistogramVec("size_bytes", "Sizes.", []float64{10, 100}, "map")
+				h.Observe(5, "osm")
+				h.Observe(10, "osm")
+				h.Observe(50, "osm")
+				h.Observe(500, "osm")
+			},
+			expected: `# HELP size_bytes Sizes.
+# TYPE size_bytes histogram
+size_bytes_bucket{map="osm",le="10"} 2
+size_bytes_bucket{map="osm",le="100"} 3
+size_bytes_bucket{map="osm",le="+Inf"} 4
+size_bytes_sum{map="osm"} 565
+size_bytes_count{map="osm"} 4
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestRegistryServeHTTP(t *testing.T) {
+	var r metrics.Registry
+	r.NewCounterVec("requests_total", "Requests.").Inc()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != metrics.ContentType {
+		t.Errorf("content type, expected %v got %v", metrics.ContentType, ct)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte("requests_total 1\n")) {
+		t.Errorf("body, expected requests_total 1 got %v", w.Body.String())
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Package rtree provides a static R-tree of bounding boxes. It's used by the file based
+providers to find the features of a tile.
+*/
+package rtree
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-spatial/geom"
+)
+
+// the number of children of the nodes of the tree
+const nodeSize = 16
+
+// node is a node of the tree. For the leaves first and last are the range of
+// the entries, otherwise they are the range of the nodes of the level below.
+type node struct {
+	ext         geom.Extent
+	first, last int
+}
+
+// entry is the bounding box of an item
+type entry struct {
+	ext  geom.Extent
+	item int
+}
+
+// RTree is a static R-tree of the bounding boxes of items packed with the
+// Sort-Tile-Recursive algorithm. It can't be modified once it's built and is safe
+// for concurrent use.
+type RTree struct {
+	entries []entry
+	// levels[0] holds the leaves and the last level holds the root
+	levels [][]node
+}
+
+// New builds the tree of the bounding boxes. The items are the indices of the
+// bounding boxes. Items with a nil bounding box (i.e. null geometries) are not
+// added to the tree.
+func New(bounds []*geom.Extent) *RTree {
+	t := RTree{}
+	for i, ext := range bounds {
+		if ext != nil {
+			t.entries = append(t.entries, entry{ext: *ext, item: i})
+		}
+	}
+	if len(t.entries) == 0 {
+		return &t
+	}
+
+	// sort the entries into vertical slices by their center x, then each slice by
+	// center y so consecutive entries are close to each other
+	center := func(e geom.Extent, axis int) float64 { return e[axis] + e[axis+2] }
+
+	sort.Slice(t.entries, func(i, j int) bool {
+		return center(t.entries[i].ext, 0) < center(t.entries[j].ext, 0)
+	})
+
+	numLeaves := (len(t.entries) + nodeSize - 1) / nodeSize
+	sliceSize := int(math.Ceil(math.Sqrt(float64(numLeaves)))) * nodeSize
+	for start := 0; start < len(t.entries); start += sliceSize {
+		slice := t.entries[start:minInt(start+sliceSize, len(t.entries))]
+		sort.Slice(slice, func(i, j int) bool {
+			return center(slice[i].ext, 1) < center(slice[j].ext, 1)
+		})
+	}
+
+	// pack the leaves, then each level into the level above until there is a single root
+	level := make([]node, 0, numLeaves)
+	for first := 0; first < len(t.entries); first += nodeSize {
+		node := node{first: first, last: minInt(first+nodeSize, len(t.entries)) - 1}
+		node.ext = t.entries[first].ext
+		for _, e := range t.entries[first+1 : node.last+1] {
+			node.ext.Add(&e.ext)
+		}
+		level = append(level, node)
+	}
+	t.levels = append(t.levels, level)
+
+	for len(level) > 1 {
+		parents := make([]node, 0, (len(level)+nodeSize-1)/nodeSize)
+		for first := 0; first < len(level); first += nodeSize {
+			node := node{first: first, last: minInt(first+nodeSize, len(level)) - 1}
+			node.ext = level[first].ext
+			for _, child := range level[first+1 : node.last+1] {
+				node.ext.Add(&child.ext)
+			}
+			parents = append(parents, node)
+		}
+		t.levels = append(t.levels, parents)
+		level = parents
+	}
+
+	return &t
+}
+
+// Len returns the number of items in the tree
+func (t *RTree) Len() int { return len(t.entries) }
+
+// Search returns the items with a bounding box which intersects the extent, in
+// ascending order
+func (t *RTree) Search(ext *geom.Extent) (items []int) {
+	if len(t.levels) == 0 {
+		return nil
+	}
+
+	type item struct{ level, node int }
+	stack := []item{{level: len(t.levels) - 1, node: 0}}
+
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := t.levels[it.level][it.node]
+		if !intersects(&node.ext, ext) {
+			continue
+		}
+
+		if it.level > 0 {
+			for child := node.first; child <= node.last; child++ {
+				stack = append(stack, item{level: it.level - 1, node: child})
+			}
+			continue
+		}
+
+		for _, e := range t.entries[node.first : node.last+1] {
+			if intersects(&e.ext, ext) {
+				items = append(items, e.item)
+			}
+		}
+	}
+
+	sort.Ints(items)
+	return items
+}
+
+// intersects reports if the extents intersect, including touching edges
+func intersects(a, b *geom.Extent) bool {
+	return a.MinX() <= b.MaxX() && b.MinX() <= a.MaxX() &&
+		a.MinY() <= b.MaxY() && b.MinY() <= a.MaxY()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
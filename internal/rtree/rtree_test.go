@@ -0,0 +1,73 @@
+package rtree_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/internal/rtree"
+)
+
+func TestSearch(t *testing.T) {
+	// a 100 x 100 grid of unit squares, with every 7th item without a bounding box
+	bounds := make([]*geom.Extent, 100*100)
+	for i := range bounds {
+		if i%7 == 0 {
+			continue
+		}
+		x, y := float64(i%100), float64(i/100)
+		bounds[i] = &geom.Extent{x, y, x + 1, y + 1}
+	}
+	tree := rtree.New(bounds)
+
+	type tcase struct {
+		extent   *geom.Extent
+		expected []int
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			got := tree.Search(tc.extent)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("items, expected %v got %v", tc.expected, got)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"inside a square": {
+			extent:   &geom.Extent{10.2, 20.2, 10.8, 20.8},
+			expected: []int{2010},
+		},
+		"touching squares": {
+			// item 4949 has no bounding box
+			extent:   &geom.Extent{50, 50, 51, 50.5},
+			expected: []int{4950, 4951, 5049, 5050, 5051},
+		},
+		"items without bounding box": {
+			// item 7 has no bounding box
+			extent:   &geom.Extent{6.5, 0.2, 7.5, 0.8},
+			expected: []int{6},
+		},
+		"outside": {
+			extent: &geom.Extent{200, 200, 300, 300},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+
+	t.Run("everything", func(t *testing.T) {
+		got := tree.Search(&geom.Extent{-1, -1, 101, 101})
+		if len(got) != tree.Len() {
+			t.Errorf("items, expected %v got %v", tree.Len(), len(got))
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if got := rtree.New([]*geom.Extent{nil}).Search(&geom.Extent{-1, -1, 1, 1}); got != nil {
+			t.Errorf("items, expected nil got %v", got)
+		}
+	})
+}
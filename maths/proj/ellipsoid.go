@@ -0,0 +1,161 @@
+package proj
+
+import "math"
+
+// ellipsoid describes the shape of the earth used by a datum or a projection.
+type ellipsoid struct {
+	// semi-major axis in meters
+	a float64
+	// flattening
+	f float64
+}
+
+// newEllipsoidB creates an ellipsoid from the semi-major and semi-minor axis
+func newEllipsoidB(a, b float64) ellipsoid {
+	return ellipsoid{a: a, f: (a - b) / a}
+}
+
+// newEllipsoidRF creates an ellipsoid from the semi-major axis and the reverse flattening
+func newEllipsoidRF(a, rf float64) ellipsoid {
+	return ellipsoid{a: a, f: 1 / rf}
+}
+
+// es returns the first eccentricity squared
+func (e ellipsoid) es() float64 { return e.f * (2 - e.f) }
+
+// e returns the first eccentricity
+func (e ellipsoid) e() float64 { return math.Sqrt(e.es()) }
+
+// b returns the semi-minor axis
+func (e ellipsoid) b() float64 { return e.a * (1 - e.f) }
+
+// ellipsoids supported by the +ellps parameter
+var ellipsoids = map[string]ellipsoid{
+	"WGS84":     newEllipsoidRF(6378137.0, 298.257223563),
+	"GRS80":     newEllipsoidRF(6378137.0, 298.257222101),
+	"WGS72":     newEllipsoidRF(6378135.0, 298.26),
+	"airy":      newEllipsoidB(6377563.396, 6356256.910),
+	"mod_airy":  newEllipsoidB(6377340.189, 6356034.446),
+	"bessel":    newEllipsoidRF(6377397.155, 299.1528128),
+	"clrk66":    newEllipsoidB(6378206.4, 6356583.8),
+	"clrk80ign": newEllipsoidB(6378249.2, 6356515.0),
+	"intl":      newEllipsoidRF(6378388.0, 297.0),
+	"krass":     newEllipsoidRF(6378245.0, 298.3),
+	"sphere":    newEllipsoidB(6370997.0, 6370997.0),
+}
+
+// datum is an ellipsoid plus the 7 parameter helmert transformation
+// (position vector convention) which shifts it onto WGS84.
+type datum struct {
+	ellipsoid ellipsoid
+	// dx, dy, dz (meters), rx, ry, rz (arc seconds), s (parts per million)
+	toWGS84 [7]float64
+	// hasShift is false if the datum is equivalent to WGS84 or the shift is unknown
+	hasShift bool
+}
+
+// datums supported by the +datum parameter
+var datums = map[string]datum{
+	"WGS84":   {ellipsoid: ellipsoids["WGS84"]},
+	"NAD83":   {ellipsoid: ellipsoids["GRS80"]},
+	"ETRS89":  {ellipsoid: ellipsoids["GRS80"]},
+	"GGRS87":  {ellipsoid: ellipsoids["GRS80"], toWGS84: [7]float64{-199.87, 74.79, 246.62}, hasShift: true},
+	"OSGB36":  {ellipsoid: ellipsoids["airy"], toWGS84: [7]float64{446.448, -125.157, 542.060, 0.1502, 0.2470, 0.8421, -20.4894}, hasShift: true},
+	"potsdam": {ellipsoid: ellipsoids["bessel"], toWGS84: [7]float64{598.1, 73.7, 418.2, 0.202, 0.045, -2.455, 6.7}, hasShift: true},
+	"nzgd49":  {ellipsoid: ellipsoids["intl"], toWGS84: [7]float64{59.47, -5.04, 187.44, 0.47, -0.1, 1.024, -4.5993}, hasShift: true},
+	// NAD27 is properly defined by grid shift files. the three parameter
+	// shift is the CONUS mean and is accurate to a few meters.
+	"NAD27": {ellipsoid: ellipsoids["clrk66"], toWGS84: [7]float64{-8, 160, 176}, hasShift: true},
+}
+
+// equal reports if two datums can be used interchangeably without a shift
+func (d datum) equal(o datum) bool {
+	if !d.hasShift && !o.hasShift {
+		return true
+	}
+
+	return d.hasShift == o.hasShift && d.toWGS84 == o.toWGS84 && d.ellipsoid == o.ellipsoid
+}
+
+// toGeocentric converts geodetic coordinates (radians) to geocentric cartesian coordinates
+func (d datum) toGeocentric(lam, phi, h float64) (x, y, z float64) {
+	a, es := d.ellipsoid.a, d.ellipsoid.es()
+
+	sinphi, cosphi := math.Sin(phi), math.Cos(phi)
+	n := a / math.Sqrt(1-es*sinphi*sinphi)
+
+	x = (n + h) * cosphi * math.Cos(lam)
+	y = (n + h) * cosphi * math.Sin(lam)
+	z = (n*(1-es) + h) * sinphi
+
+	return x, y, z
+}
+
+// fromGeocentric converts geocentric cartesian coordinates to geodetic coordinates (radians)
+func (d datum) fromGeocentric(x, y, z float64) (lam, phi, h float64) {
+	a, es := d.ellipsoid.a, d.ellipsoid.es()
+
+	p := math.Hypot(x, y)
+	lam = math.Atan2(y, x)
+	phi = math.Atan2(z, p*(1-es))
+
+	// converges to well below a millimeter in a handful of iterations
+	for i := 0; i < 10; i++ {
+		sinphi := math.Sin(phi)
+		n := a / math.Sqrt(1-es*sinphi*sinphi)
+		h = p/math.Cos(phi) - n
+
+		next := math.Atan2(z, p*(1-es*n/(n+h)))
+		if math.Abs(next-phi) < 1e-12 {
+			phi = next
+			break
+		}
+		phi = next
+	}
+
+	return lam, phi, h
+}
+
+const secToRad = math.Pi / (180 * 3600)
+
+// geocentricToWGS84 applies the datum's helmert transformation
+func (d datum) geocentricToWGS84(x, y, z float64) (float64, float64, float64) {
+	if !d.hasShift {
+		return x, y, z
+	}
+
+	p := d.toWGS84
+	rx, ry, rz := p[3]*secToRad, p[4]*secToRad, p[5]*secToRad
+	m := 1 + p[6]/1e6
+
+	return m*(x-rz*y+ry*z) + p[0],
+		m*(rz*x+y-rx*z) + p[1],
+		m*(-ry*x+rx*y+z) + p[2]
+}
+
+// geocentricFromWGS84 applies the inverse of the datum's helmert transformation
+func (d datum) geocentricFromWGS84(x, y, z float64) (float64, float64, float64) {
+	if !d.hasShift {
+		return x, y, z
+	}
+
+	p := d.toWGS84
+	rx, ry, rz := p[3]*secToRad, p[4]*secToRad, p[5]*secToRad
+	m := 1 + p[6]/1e6
+
+	x, y, z = (x-p[0])/m, (y-p[1])/m, (z-p[2])/m
+
+	return x + rz*y - ry*z,
+		-rz*x + y + rx*z,
+		ry*x - rx*y + z
+}
+
+// shift moves geodetic coordinates (radians) from one datum to another
+func shift(from, to datum, lam, phi float64) (float64, float64) {
+	x, y, z := from.toGeocentric(lam, phi, 0)
+	x, y, z = from.geocentricToWGS84(x, y, z)
+	x, y, z = to.geocentricFromWGS84(x, y, z)
+	lam, phi, _ = to.fromGeocentric(x, y, z)
+
+	return lam, phi
+}
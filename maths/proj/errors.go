@@ -0,0 +1,38 @@
+package proj
+
+import "fmt"
+
+type ErrUnknownSRID struct {
+	SRID uint64
+}
+
+func (e ErrUnknownSRID) Error() string {
+	return fmt.Sprintf("proj: unknown SRID (%v)", e.SRID)
+}
+
+type ErrSRIDAlreadyRegistered struct {
+	SRID uint64
+}
+
+func (e ErrSRIDAlreadyRegistered) Error() string {
+	return fmt.Sprintf("proj: SRID (%v) already registered", e.SRID)
+}
+
+type ErrInvalidDefinition struct {
+	Definition string
+	Reason     string
+}
+
+func (e ErrInvalidDefinition) Error() string {
+	return fmt.Sprintf("proj: invalid definition (%v): %v", e.Definition, e.Reason)
+}
+
+type ErrTransform struct {
+	From   uint64
+	To     uint64
+	Coords []float64
+}
+
+func (e ErrTransform) Error() string {
+	return fmt.Sprintf("proj: unable to transform coordinates %v from SRID (%v) to SRID (%v)", e.Coords, e.From, e.To)
+}
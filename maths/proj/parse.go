@@ -0,0 +1,259 @@
+package proj
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// prime meridians supported by the +pm parameter, in degrees from greenwich
+var primeMeridians = map[string]float64{
+	"greenwich": 0,
+	"paris":     2.337229166666667,
+	"bern":      7.439583333333333,
+	"madrid":    -3.687938888888889,
+	"rome":      12.45233333333333,
+}
+
+// units supported by the +units parameter, in meters
+var units = map[string]float64{
+	"m":     1,
+	"km":    1000,
+	"ft":    0.3048,
+	"us-ft": 1200.0 / 3937.0,
+}
+
+// Parse parses a PROJ.4 style definition into a CRS
+func Parse(definition string) (*CRS, error) {
+	params := map[string]string{}
+	for _, tok := range strings.Fields(definition) {
+		tok = strings.TrimPrefix(tok, "+")
+		if tok == "" {
+			continue
+		}
+		kv := strings.SplitN(tok, "=", 2)
+		if len(kv) == 1 {
+			params[kv[0]] = ""
+			continue
+		}
+		params[kv[0]] = kv[1]
+	}
+
+	invalid := func(reason string) error {
+		return ErrInvalidDefinition{Definition: definition, Reason: reason}
+	}
+
+	float := func(key string, def float64) (float64, error) {
+		v, ok := params[key]
+		if !ok {
+			return def, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, invalid("+" + key + " is not a number")
+		}
+		return f, nil
+	}
+
+	// angles are provided in degrees and used in radians
+	angle := func(key string) (float64, error) {
+		f, err := float(key, 0)
+		return f * math.Pi / 180, err
+	}
+
+	name, ok := params["proj"]
+	if !ok {
+		return nil, invalid("missing +proj")
+	}
+
+	// datum and ellipsoid
+	d := datums["WGS84"]
+	if v, ok := params["datum"]; ok {
+		if d, ok = datums[v]; !ok {
+			return nil, invalid("unknown +datum " + v)
+		}
+	}
+
+	if v, ok := params["ellps"]; ok {
+		ell, ok := ellipsoids[v]
+		if !ok {
+			return nil, invalid("unknown +ellps " + v)
+		}
+		// an ellipsoid without a shift is an unknown datum
+		d = datum{ellipsoid: ell}
+	}
+
+	ell := d.ellipsoid
+	if _, ok := params["a"]; ok {
+		a, err := float("a", 0)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case params["b"] != "":
+			b, err := float("b", 0)
+			if err != nil {
+				return nil, err
+			}
+			ell = newEllipsoidB(a, b)
+		case params["rf"] != "":
+			rf, err := float("rf", 0)
+			if err != nil {
+				return nil, err
+			}
+			ell = newEllipsoidRF(a, rf)
+		default:
+			ell = newEllipsoidB(a, a)
+		}
+	}
+	if _, ok := params["R"]; ok {
+		r, err := float("R", 0)
+		if err != nil {
+			return nil, err
+		}
+		ell = newEllipsoidB(r, r)
+	}
+	if ell.a <= 0 {
+		return nil, invalid("semi-major axis must be positive")
+	}
+	d.ellipsoid = ell
+
+	if v, ok := params["towgs84"]; ok {
+		parts := strings.Split(v, ",")
+		if len(parts) != 3 && len(parts) != 7 {
+			return nil, invalid("+towgs84 requires 3 or 7 values")
+		}
+
+		d.toWGS84 = [7]float64{}
+		for i := range parts {
+			f, err := strconv.ParseFloat(parts[i], 64)
+			if err != nil {
+				return nil, invalid("+towgs84 values must be numbers")
+			}
+			d.toWGS84[i] = f
+		}
+		d.hasShift = d.toWGS84 != [7]float64{}
+	}
+
+	// the null grid declares the coordinates are WGS84 regardless of the
+	// ellipsoid used by the projection (i.e. web mercator)
+	if params["nadgrids"] == "@null" {
+		d = datums["WGS84"]
+	}
+
+	c := CRS{
+		Definition: definition,
+		datum:      d,
+		toMeter:    1,
+	}
+
+	if v, ok := params["pm"]; ok {
+		pm, known := primeMeridians[v]
+		if !known {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, invalid("unknown +pm " + v)
+			}
+			pm = f
+		}
+		c.pm = pm * math.Pi / 180
+	}
+
+	if v, ok := params["units"]; ok {
+		if c.toMeter, ok = units[v]; !ok {
+			return nil, invalid("unknown +units " + v)
+		}
+	}
+	if _, ok := params["to_meter"]; ok {
+		f, err := float("to_meter", 1)
+		if err != nil {
+			return nil, err
+		}
+		if f <= 0 {
+			return nil, invalid("+to_meter must be positive")
+		}
+		c.toMeter = f
+	}
+
+	lat0, err := angle("lat_0")
+	if err != nil {
+		return nil, err
+	}
+	lon0, err := angle("lon_0")
+	if err != nil {
+		return nil, err
+	}
+	x0, err := float("x_0", 0)
+	if err != nil {
+		return nil, err
+	}
+	y0, err := float("y_0", 0)
+	if err != nil {
+		return nil, err
+	}
+	k0, err := float("k_0", 1)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := params["k"]; ok {
+		if k0, err = float("k", 1); err != nil {
+			return nil, err
+		}
+	}
+
+	switch name {
+	case "longlat", "latlong", "lonlat", "latlon":
+		// geographic, no projection
+
+	case "merc":
+		latTS, err := angle("lat_ts")
+		if err != nil {
+			return nil, err
+		}
+		c.proj = newMercator(ell, latTS, lon0, k0, x0, y0)
+
+	case "tmerc":
+		c.proj = newTransverseMercator(ell, lat0, lon0, k0, x0, y0)
+
+	case "utm":
+		zone, err := strconv.Atoi(params["zone"])
+		if err != nil || zone < 1 || zone > 60 {
+			return nil, invalid("+zone must be between 1 and 60")
+		}
+
+		lon0 = (float64(zone)*6 - 183) * math.Pi / 180
+		y0 = 0
+		if _, ok := params["south"]; ok {
+			y0 = 10000000
+		}
+		c.proj = newTransverseMercator(ell, 0, lon0, 0.9996, 500000, y0)
+
+	case "lcc":
+		lat1, err := angle("lat_1")
+		if err != nil {
+			return nil, err
+		}
+		lat2 := lat1
+		if _, ok := params["lat_2"]; ok {
+			if lat2, err = angle("lat_2"); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := params["lat_0"]; !ok {
+			lat0 = lat1
+		}
+		if math.Abs(lat1+lat2) < 1e-10 {
+			return nil, invalid("standard parallels can not be opposite each other")
+		}
+		c.proj = newLambertConformalConic(ell, lat0, lat1, lat2, lon0, k0, x0, y0)
+
+	case "somerc":
+		c.proj = newSwissObliqueMercator(ell, lat0, lon0, k0, x0, y0)
+
+	default:
+		return nil, invalid("unsupported +proj " + name)
+	}
+
+	return &c, nil
+}
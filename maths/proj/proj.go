@@ -0,0 +1,303 @@
+/*
+Package proj provides a registry of coordinate reference systems, addressed by
+their EPSG code (SRID), and the transformations between them.
+
+Coordinate reference systems are described with a subset of the PROJ.4
+definition syntax (i.e. "+proj=tmerc +lat_0=49 +lon_0=-2 ..."). The following
+projections are supported:
+
+	longlat - geographic coordinates (degrees)
+	merc    - mercator, including the spherical pseudo mercator (EPSG:3857)
+	tmerc   - transverse mercator
+	utm     - universal transverse mercator (requires +zone, optionally +south)
+	lcc     - lambert conformal conic with one or two standard parallels
+	somerc  - swiss oblique mercator
+
+Datum shifts are applied with the 7 parameter helmert transformation provided
+by the +towgs84 parameter or a known +datum.
+*/
+package proj
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/maths/webmercator"
+)
+
+const (
+	WebMercator = 3857
+	WGS84       = 4326
+)
+
+// TransformFunc transforms a set of coordinates (x, y) from one SRID to another.
+// If more then x, y is given (i.e. z and m) they will be returned untransformed.
+// The signature matches the one expected by basic.ApplyToPoints.
+type TransformFunc func(coords ...float64) ([]float64, error)
+
+// CRS is a parsed coordinate reference system
+type CRS struct {
+	// Definition is the PROJ.4 string the CRS was parsed from
+	Definition string
+
+	datum datum
+	// nil for geographic coordinate systems
+	proj projection
+	// prime meridian offset from greenwich in radians
+	pm float64
+	// multiplier to convert the projected units to meters
+	toMeter float64
+}
+
+// IsGeographic reports if the CRS coordinates are longitude / latitude degrees
+func (c *CRS) IsGeographic() bool { return c.proj == nil }
+
+// MetersPerUnit returns the size of a coordinate unit in meters. The size of a
+// degree is measured along the equator.
+func (c *CRS) MetersPerUnit() float64 {
+	if c.proj == nil {
+		return 2 * math.Pi * c.datum.ellipsoid.a / 360
+	}
+	return c.toMeter
+}
+
+// Bounds returns the area of use of the CRS in WGS84 longitude / latitude degrees
+func (c *CRS) Bounds() *geom.Extent {
+	if c.proj == nil {
+		return &geom.Extent{-180, -90, 180, 90}
+	}
+
+	b := c.proj.bounds()
+	pm := c.pm * 180 / math.Pi
+
+	return &geom.Extent{b[0] + pm, b[1], b[2] + pm, b[3]}
+}
+
+// toGeodetic converts coordinates in the CRS to geodetic coordinates (radians, relative to greenwich)
+func (c *CRS) toGeodetic(x, y float64) (lam, phi float64) {
+	if c.proj == nil {
+		return x*webmercator.Deg2Rad + c.pm, y * webmercator.Deg2Rad
+	}
+
+	lam, phi = c.proj.inverse(x*c.toMeter, y*c.toMeter)
+
+	return lam + c.pm, phi
+}
+
+// fromGeodetic converts geodetic coordinates (radians, relative to greenwich) to coordinates in the CRS
+func (c *CRS) fromGeodetic(lam, phi float64) (x, y float64) {
+	lam -= c.pm
+	if c.proj == nil {
+		return lam * webmercator.Rad2Deg, phi * webmercator.Rad2Deg
+	}
+
+	x, y = c.proj.forward(lam, phi)
+
+	return x / c.toMeter, y / c.toMeter
+}
+
+// registry of known coordinate reference systems
+var registry = struct {
+	sync.RWMutex
+	// PROJ.4 definitions keyed by SRID
+	defs map[uint64]string
+	// lazily parsed definitions
+	crs map[uint64]*CRS
+}{
+	defs: map[uint64]string{},
+	crs:  map[uint64]*CRS{},
+}
+
+// Register adds a coordinate reference system to the registry. The definition
+// is validated before it's added. An SRID can only be registered once.
+func Register(srid uint64, definition string) error {
+	c, err := Parse(definition)
+	if err != nil {
+		return err
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.defs[srid]; ok {
+		return ErrSRIDAlreadyRegistered{SRID: srid}
+	}
+
+	registry.defs[srid] = definition
+	registry.crs[srid] = c
+
+	return nil
+}
+
+// Registered returns the SRIDs that have been registered, in ascending order
+func Registered() (srids []uint64) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	for k := range registry.defs {
+		srids = append(srids, k)
+	}
+	sort.Slice(srids, func(i, j int) bool { return srids[i] < srids[j] })
+
+	return srids
+}
+
+// Lookup returns the coordinate reference system registered for an SRID
+func Lookup(srid uint64) (*CRS, error) {
+	registry.RLock()
+	c, ok := registry.crs[srid]
+	def, known := registry.defs[srid]
+	registry.RUnlock()
+
+	if ok {
+		return c, nil
+	}
+	if !known {
+		return nil, ErrUnknownSRID{SRID: srid}
+	}
+
+	c, err := Parse(def)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.Lock()
+	registry.crs[srid] = c
+	registry.Unlock()
+
+	return c, nil
+}
+
+// Transformer returns a function which transforms coordinates between two SRIDs
+func Transformer(from, to uint64) (TransformFunc, error) {
+	switch {
+	case from == to:
+		return func(c ...float64) ([]float64, error) {
+			return append([]float64(nil), c...), nil
+		}, nil
+	// keep the long standing web mercator math for the most common case
+	case from == WGS84 && to == WebMercator:
+		return webmercator.PToXY, nil
+	case from == WebMercator && to == WGS84:
+		return webmercator.PToLonLat, nil
+	}
+
+	src, err := Lookup(from)
+	if err != nil {
+		return nil, err
+	}
+
+	dst, err := Lookup(to)
+	if err != nil {
+		return nil, err
+	}
+
+	shiftDatum := !src.datum.equal(dst.datum)
+
+	return func(c ...float64) ([]float64, error) {
+		if len(c) < 2 {
+			return c, webmercator.ErrCoordsRequire2Values
+		}
+
+		lam, phi := src.toGeodetic(c[0], c[1])
+		if shiftDatum {
+			lam, phi = shift(src.datum, dst.datum, lam, phi)
+		}
+		x, y := dst.fromGeodetic(lam, phi)
+
+		if math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+			return nil, ErrTransform{From: from, To: to, Coords: c}
+		}
+
+		crds := []float64{x, y}
+		crds = append(crds, c[2:]...)
+		return crds, nil
+	}, nil
+}
+
+// densify is the number of segments each edge of an extent is split into
+// when it's transformed. Edges of an extent are generally curved once they
+// are reprojected so the corners alone are not enough.
+const densify = 16
+
+// TransformExtent returns the extent, in the to SRID, which covers the provided
+// extent in the from SRID. The extent is clipped to the area of use of the
+// destination coordinate reference system so that extents covering the world
+// (i.e. low zoom tiles) can be transformed into regional grids.
+func TransformExtent(from, to uint64, extent *geom.Extent) (*geom.Extent, error) {
+	if extent == nil {
+		return nil, nil
+	}
+
+	if from == to {
+		ext := *extent
+		return &ext, nil
+	}
+
+	dst, err := Lookup(to)
+	if err != nil {
+		return nil, err
+	}
+
+	// move the extent into WGS84 so it can be clipped to the area of use
+	geo := extent
+	if from != WGS84 {
+		if geo, err = envelope(from, WGS84, extent); err != nil {
+			return nil, err
+		}
+	}
+
+	b := dst.Bounds()
+	geo = &geom.Extent{
+		clamp(geo.MinX(), b.MinX(), b.MaxX()),
+		clamp(geo.MinY(), b.MinY(), b.MaxY()),
+		clamp(geo.MaxX(), b.MinX(), b.MaxX()),
+		clamp(geo.MaxY(), b.MinY(), b.MaxY()),
+	}
+
+	return envelope(WGS84, to, geo)
+}
+
+// envelope transforms the densified edges of an extent and returns their bounding box
+func envelope(from, to uint64, extent *geom.Extent) (*geom.Extent, error) {
+	fn, err := Transformer(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var ext *geom.Extent
+	minx, miny, maxx, maxy := extent.MinX(), extent.MinY(), extent.MaxX(), extent.MaxY()
+	dx, dy := (maxx-minx)/densify, (maxy-miny)/densify
+
+	add := func(x, y float64) {
+		c, err := fn(x, y)
+		if err != nil {
+			// points outside of the projection's domain are skipped
+			return
+		}
+		if ext == nil {
+			ext = geom.NewExtent([2]float64{c[0], c[1]})
+			return
+		}
+		ext.AddPoints([2]float64{c[0], c[1]})
+	}
+
+	for i := 0; i <= densify; i++ {
+		add(minx+float64(i)*dx, miny)
+		add(minx+float64(i)*dx, maxy)
+		add(minx, miny+float64(i)*dy)
+		add(maxx, miny+float64(i)*dy)
+	}
+
+	if ext == nil {
+		return nil, ErrTransform{From: from, To: to, Coords: []float64{minx, miny, maxx, maxy}}
+	}
+
+	return ext, nil
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
@@ -0,0 +1,233 @@
+package proj_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/maths/proj"
+)
+
+// dms converts degrees, minutes and seconds to decimal degrees
+func dms(d, m, s float64) float64 { return d + m/60 + s/3600 }
+
+func TestTransformer(t *testing.T) {
+	type tcase struct {
+		from, to  uint64
+		in        [2]float64
+		expected  [2]float64
+		tolerance float64
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			transform, err := proj.Transformer(tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			out, err := transform(tc.in[0], tc.in[1])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if math.Abs(out[0]-tc.expected[0]) > tc.tolerance || math.Abs(out[1]-tc.expected[1]) > tc.tolerance {
+				t.Errorf("incorrect coordinates, expected %v got %v", tc.expected, out)
+			}
+
+			// round trip back to the source
+			inverse, err := proj.Transformer(tc.to, tc.from)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			back, err := inverse(out...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if math.Abs(back[0]-tc.in[0]) > 1e-6 || math.Abs(back[1]-tc.in[1]) > 1e-6 {
+				t.Errorf("incorrect round trip, expected %v got %v", tc.in, back)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		// Ordnance Survey, A guide to coordinate systems in Great Britain (annex C)
+		"osgb36 to british national grid": {
+			from:      4277,
+			to:        27700,
+			in:        [2]float64{dms(1, 43, 4.5177), dms(52, 39, 27.2531)},
+			expected:  [2]float64{651409.903, 313177.270},
+			tolerance: 0.001,
+		},
+		"wgs84 to utm 33n central meridian": {
+			from:      4326,
+			to:        32633,
+			in:        [2]float64{15, 0},
+			expected:  [2]float64{500000, 0},
+			tolerance: 0.001,
+		},
+		"wgs84 to utm 19s": {
+			from:      4326,
+			to:        32719,
+			in:        [2]float64{-69, -33},
+			expected:  [2]float64{500000, 6348713.056},
+			tolerance: 0.01,
+		},
+		"etrs89 to lambert 93 origin": {
+			from:      4171,
+			to:        2154,
+			in:        [2]float64{3, 46.5},
+			expected:  [2]float64{700000, 6600000},
+			tolerance: 0.001,
+		},
+		// swisstopo, approximate formulas for the transformation between swiss projection coordinates and WGS84
+		"wgs84 to lv95": {
+			from:      4326,
+			to:        2056,
+			in:        [2]float64{dms(8, 43, 49.79), dms(46, 2, 38.87)},
+			expected:  [2]float64{2700000, 1100000},
+			tolerance: 2,
+		},
+		"lv95 origin": {
+			from:      4150,
+			to:        2056,
+			in:        [2]float64{7.439583333333333, 46.95240555555556},
+			expected:  [2]float64{2600000, 1200000},
+			tolerance: 0.001,
+		},
+		"wgs84 to web mercator": {
+			from:      4326,
+			to:        3857,
+			in:        [2]float64{-180, 0},
+			expected:  [2]float64{-20037508.342789244, 0},
+			tolerance: 1e-9,
+		},
+		"utm 32n to web mercator": {
+			from:      32632,
+			to:        3857,
+			in:        [2]float64{500000, 0},
+			expected:  [2]float64{1001875.4171394621, 0},
+			tolerance: 0.001,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestParse(t *testing.T) {
+	// Snyder, Map Projections - A Working Manual, numerical example for the lambert conformal conic
+	c, err := proj.Parse("+proj=lcc +lat_1=33 +lat_2=45 +lat_0=23 +lon_0=-96 +ellps=clrk66")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.IsGeographic() {
+		t.Errorf("expected a projected CRS")
+	}
+
+	for _, def := range []string{
+		"",
+		"+proj=foo",
+		"+proj=utm +zone=61",
+		"+proj=tmerc +ellps=foo",
+		"+proj=tmerc +towgs84=1,2",
+		"+proj=tmerc +lat_0=north",
+	} {
+		if _, err := proj.Parse(def); err == nil {
+			t.Errorf("expected an error for definition (%v)", def)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	const srid = 990001
+	def := "+proj=lcc +lat_1=33 +lat_2=45 +lat_0=23 +lon_0=-96 +ellps=clrk66"
+
+	if err := proj.Register(srid, def); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := proj.Register(srid, def); err != (proj.ErrSRIDAlreadyRegistered{SRID: srid}) {
+		t.Errorf("expected ErrSRIDAlreadyRegistered, got %v", err)
+	}
+
+	// the datum is unknown so no shift is applied
+	transform, err := proj.Transformer(4326, srid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := transform(-75, 35)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [2]float64{1894410.9, 1564649.5}
+	if math.Abs(out[0]-expected[0]) > 0.1 || math.Abs(out[1]-expected[1]) > 0.1 {
+		t.Errorf("incorrect coordinates, expected %v got %v", expected, out)
+	}
+
+	if _, err := proj.Transformer(4326, 1); err != (proj.ErrUnknownSRID{SRID: 1}) {
+		t.Errorf("expected ErrUnknownSRID, got %v", err)
+	}
+}
+
+func TestTransformExtent(t *testing.T) {
+	type tcase struct {
+		from, to uint64
+		extent   *geom.Extent
+		// the expected extent must be contained by the output
+		contains *geom.Extent
+		// the output must be contained by the within extent
+		within *geom.Extent
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			ext, err := proj.TransformExtent(tc.from, tc.to, tc.extent)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !ext.Contains(tc.contains) {
+				t.Errorf("expected %v to contain %v", ext, tc.contains)
+			}
+
+			if tc.within != nil && !tc.within.Contains(ext) {
+				t.Errorf("expected %v to be within %v", ext, tc.within)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"same srid": {
+			from:     3857,
+			to:       3857,
+			extent:   &geom.Extent{1, 2, 3, 4},
+			contains: &geom.Extent{1, 2, 3, 4},
+			within:   &geom.Extent{1, 2, 3, 4},
+		},
+		"world to british national grid": {
+			from:   3857,
+			to:     27700,
+			extent: &geom.Extent{-20037508.34, -20037508.34, 20037508.34, 20037508.34},
+			// great britain
+			contains: &geom.Extent{0, 0, 700000, 1300000},
+		},
+		"curved edges": {
+			from: 4326,
+			to:   27700,
+			// the southern edge bows below its corners in the grid so the corners are not enough
+			extent:   &geom.Extent{-8, 50, 2, 60},
+			contains: &geom.Extent{-29000, 12000, 686000, 1139000},
+			within:   &geom.Extent{-100000, -100000, 1000000, 1300000},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
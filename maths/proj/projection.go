@@ -0,0 +1,344 @@
+package proj
+
+import (
+	"math"
+)
+
+// projection maps geodetic coordinates, in radians relative to the prime
+// meridian, to projected coordinates in meters and back again.
+type projection interface {
+	forward(lam, phi float64) (x, y float64)
+	inverse(x, y float64) (lam, phi float64)
+	// bounds returns the area of use in degrees (minLon, minLat, maxLon, maxLat)
+	// outside of which the projection is not usable.
+	bounds() [4]float64
+}
+
+// adjlon wraps a longitude (radians) into the range -π to π
+func adjlon(lam float64) float64 {
+	if math.Abs(lam) <= math.Pi {
+		return lam
+	}
+
+	return lam - 2*math.Pi*math.Floor((lam+math.Pi)/(2*math.Pi))
+}
+
+// tsfn computes the function t(φ) from Snyder, Map Projections - A Working Manual (eq 15-9)
+func tsfn(phi, sinphi, e float64) float64 {
+	esinphi := e * sinphi
+	return math.Tan(0.5*(math.Pi/2-phi)) / math.Pow((1-esinphi)/(1+esinphi), 0.5*e)
+}
+
+// phi2 iteratively inverts tsfn (Snyder eq 7-9)
+func phi2(ts, e float64) float64 {
+	phi := math.Pi/2 - 2*math.Atan(ts)
+	for i := 0; i < 15; i++ {
+		esinphi := e * math.Sin(phi)
+		next := math.Pi/2 - 2*math.Atan(ts*math.Pow((1-esinphi)/(1+esinphi), 0.5*e))
+		if math.Abs(next-phi) < 1e-12 {
+			return next
+		}
+		phi = next
+	}
+
+	return phi
+}
+
+// msfn computes the function m(φ) from Snyder (eq 14-15)
+func msfn(sinphi, cosphi, es float64) float64 {
+	return cosphi / math.Sqrt(1-es*sinphi*sinphi)
+}
+
+// degree bounds helper which keeps a window around a central longitude
+func lonWindow(lon0, width, minLat, maxLat float64) [4]float64 {
+	lon0 = lon0 * 180 / math.Pi
+	return [4]float64{lon0 - width, minLat, lon0 + width, maxLat}
+}
+
+// mercator implements the (ellipsoidal) Mercator projection.
+// With a spherical ellipsoid it is the pseudo mercator used by EPSG:3857.
+type mercator struct {
+	a, e, k0 float64
+	lon0     float64
+	x0, y0   float64
+}
+
+func newMercator(ell ellipsoid, latTS, lon0, k0, x0, y0 float64) *mercator {
+	e := ell.e()
+	if latTS != 0 {
+		k0 = msfn(math.Sin(latTS), math.Cos(latTS), ell.es())
+	}
+
+	return &mercator{a: ell.a, e: e, k0: k0, lon0: lon0, x0: x0, y0: y0}
+}
+
+func (p *mercator) forward(lam, phi float64) (float64, float64) {
+	x := p.a * p.k0 * adjlon(lam-p.lon0)
+	if p.e == 0 {
+		return x + p.x0, p.a*p.k0*math.Log(math.Tan(math.Pi/4+phi/2)) + p.y0
+	}
+
+	return x + p.x0, -p.a*p.k0*math.Log(tsfn(phi, math.Sin(phi), p.e)) + p.y0
+}
+
+func (p *mercator) inverse(x, y float64) (float64, float64) {
+	lam := (x-p.x0)/(p.a*p.k0) + p.lon0
+	ts := math.Exp(-(y - p.y0) / (p.a * p.k0))
+	if p.e == 0 {
+		return lam, math.Pi/2 - 2*math.Atan(ts)
+	}
+
+	return lam, phi2(ts, p.e)
+}
+
+func (p *mercator) bounds() [4]float64 { return [4]float64{-180, -85.0511, 180, 85.0511} }
+
+// transverseMercator implements the ellipsoidal transverse mercator using the
+// Krüger series expanded to the third order of the third flattening, which is
+// accurate to well under a millimeter within the usual zone widths.
+type transverseMercator struct {
+	e                  float64
+	k0, lon0, x0, y0   float64
+	ka                 float64 // k0 * A (rectifying radius)
+	m0                 float64 // northing of the latitude of origin
+	alpha, beta, delta [3]float64
+}
+
+func newTransverseMercator(ell ellipsoid, lat0, lon0, k0, x0, y0 float64) *transverseMercator {
+	n := ell.f / (2 - ell.f)
+	n2, n3 := n*n, n*n*n
+
+	p := transverseMercator{
+		e:    ell.e(),
+		k0:   k0,
+		lon0: lon0,
+		x0:   x0,
+		y0:   y0,
+		ka:   k0 * ell.a / (1 + n) * (1 + n2/4 + n2*n2/64),
+		alpha: [3]float64{
+			n/2 - 2*n2/3 + 5*n3/16,
+			13*n2/48 - 3*n3/5,
+			61 * n3 / 240,
+		},
+		beta: [3]float64{
+			n/2 - 2*n2/3 + 37*n3/96,
+			n2/48 + n3/15,
+			17 * n3 / 480,
+		},
+		delta: [3]float64{
+			2*n - 2*n2/3 - 2*n3,
+			7*n2/3 - 8*n3/5,
+			56 * n3 / 15,
+		},
+	}
+
+	p.m0, _ = p.xiEta(0, lat0)
+	p.m0 *= p.ka
+
+	return &p
+}
+
+// xiEta returns the normalized northing (ξ) and easting (η) for a longitude
+// relative to the central meridian
+func (p *transverseMercator) xiEta(dlam, phi float64) (xi, eta float64) {
+	sinphi := math.Sin(phi)
+	t := math.Sinh(math.Atanh(sinphi) - p.e*math.Atanh(p.e*sinphi))
+
+	xip := math.Atan2(t, math.Cos(dlam))
+	etap := math.Atanh(math.Sin(dlam) / math.Sqrt(1+t*t))
+
+	xi, eta = xip, etap
+	for j := range p.alpha {
+		k := 2 * float64(j+1)
+		xi += p.alpha[j] * math.Sin(k*xip) * math.Cosh(k*etap)
+		eta += p.alpha[j] * math.Cos(k*xip) * math.Sinh(k*etap)
+	}
+
+	return xi, eta
+}
+
+func (p *transverseMercator) forward(lam, phi float64) (float64, float64) {
+	xi, eta := p.xiEta(adjlon(lam-p.lon0), phi)
+
+	return p.x0 + p.ka*eta, p.y0 + p.ka*xi - p.m0
+}
+
+func (p *transverseMercator) inverse(x, y float64) (float64, float64) {
+	xi := (y - p.y0 + p.m0) / p.ka
+	eta := (x - p.x0) / p.ka
+
+	xip, etap := xi, eta
+	for j := range p.beta {
+		k := 2 * float64(j+1)
+		xip -= p.beta[j] * math.Sin(k*xi) * math.Cosh(k*eta)
+		etap -= p.beta[j] * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+
+	// conformal latitude
+	chi := math.Asin(math.Sin(xip) / math.Cosh(etap))
+
+	phi := chi
+	for j := range p.delta {
+		phi += p.delta[j] * math.Sin(2*float64(j+1)*chi)
+	}
+
+	return p.lon0 + math.Atan2(math.Sinh(etap), math.Cos(xip)), phi
+}
+
+func (p *transverseMercator) bounds() [4]float64 { return lonWindow(p.lon0, 45, -89, 89) }
+
+// lambertConformalConic implements the ellipsoidal lambert conformal conic
+// projection with one or two standard parallels (Snyder chapter 15).
+type lambertConformalConic struct {
+	a, e             float64
+	n, c, rho0       float64
+	k0, lon0, x0, y0 float64
+}
+
+func newLambertConformalConic(ell ellipsoid, lat0, lat1, lat2, lon0, k0, x0, y0 float64) *lambertConformalConic {
+	e, es := ell.e(), ell.es()
+
+	sin1, cos1 := math.Sin(lat1), math.Cos(lat1)
+	m1, t1 := msfn(sin1, cos1, es), tsfn(lat1, sin1, e)
+
+	// single standard parallel
+	n := sin1
+	if math.Abs(lat1-lat2) > 1e-10 {
+		sin2, cos2 := math.Sin(lat2), math.Cos(lat2)
+		m2, t2 := msfn(sin2, cos2, es), tsfn(lat2, sin2, e)
+		n = math.Log(m1/m2) / math.Log(t1/t2)
+	}
+
+	c := m1 / (n * math.Pow(t1, n))
+
+	var rho0 float64
+	if math.Abs(math.Abs(lat0)-math.Pi/2) > 1e-10 {
+		rho0 = ell.a * k0 * c * math.Pow(tsfn(lat0, math.Sin(lat0), e), n)
+	}
+
+	return &lambertConformalConic{
+		a:    ell.a,
+		e:    e,
+		n:    n,
+		c:    c,
+		rho0: rho0,
+		k0:   k0,
+		lon0: lon0,
+		x0:   x0,
+		y0:   y0,
+	}
+}
+
+func (p *lambertConformalConic) forward(lam, phi float64) (float64, float64) {
+	var rho float64
+	if math.Abs(math.Abs(phi)-math.Pi/2) > 1e-10 {
+		rho = p.a * p.k0 * p.c * math.Pow(tsfn(phi, math.Sin(phi), p.e), p.n)
+	}
+
+	theta := p.n * adjlon(lam-p.lon0)
+
+	return p.x0 + rho*math.Sin(theta), p.y0 + p.rho0 - rho*math.Cos(theta)
+}
+
+func (p *lambertConformalConic) inverse(x, y float64) (float64, float64) {
+	x, y = x-p.x0, p.rho0-(y-p.y0)
+
+	rho := math.Hypot(x, y)
+	if p.n < 0 {
+		rho, x, y = -rho, -x, -y
+	}
+
+	if rho == 0 {
+		return p.lon0, math.Copysign(math.Pi/2, p.n)
+	}
+
+	theta := math.Atan2(x, y)
+	ts := math.Pow(rho/(p.a*p.k0*p.c), 1/p.n)
+
+	return theta/p.n + p.lon0, phi2(ts, p.e)
+}
+
+func (p *lambertConformalConic) bounds() [4]float64 {
+	// the opposite pole of the cone is a singularity
+	if p.n < 0 {
+		return [4]float64{-180, -90, 180, 80}
+	}
+
+	return [4]float64{-180, -80, 180, 90}
+}
+
+// swissObliqueMercator implements the oblique mercator projection on a
+// conformal sphere used by the swiss CH1903 and CH1903+ grids.
+type swissObliqueMercator struct {
+	a, e, c, k, kR     float64
+	sinphi0, cosphi0   float64
+	lat0, lon0, x0, y0 float64
+}
+
+func newSwissObliqueMercator(ell ellipsoid, lat0, lon0, k0, x0, y0 float64) *swissObliqueMercator {
+	e, es := ell.e(), ell.es()
+
+	cp := math.Cos(lat0)
+	cp *= cp
+	c := math.Sqrt(1 + es*cp*cp/(1-es))
+
+	sp := math.Sin(lat0)
+	sinphi0 := sp / c
+	phip0 := math.Asin(sinphi0)
+
+	sp *= e
+	k := math.Log(math.Tan(math.Pi/4+0.5*phip0)) -
+		c*(math.Log(math.Tan(math.Pi/4+0.5*lat0))-0.5*e*math.Log((1+sp)/(1-sp)))
+
+	return &swissObliqueMercator{
+		a:       ell.a,
+		e:       e,
+		c:       c,
+		k:       k,
+		kR:      k0 * math.Sqrt(1-es) / (1 - sp*sp),
+		sinphi0: sinphi0,
+		cosphi0: math.Cos(phip0),
+		lat0:    lat0,
+		lon0:    lon0,
+		x0:      x0,
+		y0:      y0,
+	}
+}
+
+func (p *swissObliqueMercator) forward(lam, phi float64) (float64, float64) {
+	sp := p.e * math.Sin(phi)
+	phip := 2*math.Atan(math.Exp(p.c*(math.Log(math.Tan(math.Pi/4+0.5*phi))-0.5*p.e*math.Log((1+sp)/(1-sp)))+p.k)) - math.Pi/2
+	lamp := p.c * adjlon(lam-p.lon0)
+
+	cp := math.Cos(phip)
+	phipp := math.Asin(p.cosphi0*math.Sin(phip) - p.sinphi0*cp*math.Cos(lamp))
+	lampp := math.Asin(cp * math.Sin(lamp) / math.Cos(phipp))
+
+	return p.x0 + p.a*p.kR*lampp, p.y0 + p.a*p.kR*math.Log(math.Tan(math.Pi/4+0.5*phipp))
+}
+
+func (p *swissObliqueMercator) inverse(x, y float64) (float64, float64) {
+	phipp := 2 * (math.Atan(math.Exp((y-p.y0)/(p.a*p.kR))) - math.Pi/4)
+	lampp := (x - p.x0) / (p.a * p.kR)
+
+	cp := math.Cos(phipp)
+	phip := math.Asin(p.cosphi0*math.Sin(phipp) + p.sinphi0*cp*math.Cos(lampp))
+	lamp := math.Asin(cp * math.Sin(lampp) / math.Cos(phip))
+
+	con := (p.k - math.Log(math.Tan(math.Pi/4+0.5*phip))) / p.c
+	for i := 0; i < 6; i++ {
+		esp := p.e * math.Sin(phip)
+		delp := (con + math.Log(math.Tan(math.Pi/4+0.5*phip)) - 0.5*p.e*math.Log((1+esp)/(1-esp))) * (1 - esp*esp) * math.Cos(phip) / (1 - p.e*p.e)
+		phip -= delp
+		if math.Abs(delp) < 1e-12 {
+			break
+		}
+	}
+
+	return p.lon0 + lamp/p.c, phip
+}
+
+func (p *swissObliqueMercator) bounds() [4]float64 {
+	lat0 := p.lat0 * 180 / math.Pi
+	return lonWindow(p.lon0, 30, math.Max(lat0-30, -89), math.Min(lat0+30, 89))
+}
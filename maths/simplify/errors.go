@@ -0,0 +1,12 @@
+package simplify
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ErrUnknownAlgorithm string
+
+func (e ErrUnknownAlgorithm) Error() string {
+	return fmt.Sprintf("simplify: unknown algorithm (%v). supported algorithms: %v", string(e), strings.Join(Algorithms(), ", "))
+}
@@ -0,0 +1,39 @@
+/*
+Package simplify provides the line simplification algorithms which can be
+configured for a map layer. Simplifiers satisfy the planar.Simplifer interface
+so they can be used with planar.Simplify.
+*/
+package simplify
+
+import (
+	"github.com/go-spatial/geom/planar"
+	"github.com/go-spatial/geom/planar/simplify"
+)
+
+const (
+	// DouglasPeucker eliminates points closer than the tolerance to the line
+	// through their neighbours. It's the default algorithm.
+	DouglasPeucker = "douglas_peucker"
+	// Visvalingam eliminates the points which form the smallest triangles with
+	// their neighbours, see VisvalingamWhyatt.
+	Visvalingam = "visvalingam"
+)
+
+// Algorithms returns the names of the supported algorithms
+func Algorithms() []string {
+	return []string{DouglasPeucker, Visvalingam}
+}
+
+// For returns the simplifier for the named algorithm using the tolerance, in
+// the units of the geometries being simplified. An empty name returns the
+// DouglasPeucker simplifier.
+func For(algorithm string, tolerance float64) (planar.Simplifer, error) {
+	switch algorithm {
+	case "", DouglasPeucker:
+		return simplify.DouglasPeucker{Tolerance: tolerance}, nil
+	case Visvalingam:
+		return VisvalingamWhyatt{Tolerance: tolerance}, nil
+	default:
+		return nil, ErrUnknownAlgorithm(algorithm)
+	}
+}
@@ -0,0 +1,79 @@
+package simplify_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/maths/simplify"
+)
+
+func TestVisvalingamWhyatt(t *testing.T) {
+	type tcase struct {
+		tolerance float64
+		line      [][2]float64
+		closed    bool
+		expected  [][2]float64
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			vw := simplify.VisvalingamWhyatt{Tolerance: tc.tolerance}
+			got, err := vw.Simplify(context.Background(), tc.line, tc.closed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v got %v", tc.expected, got)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"zero tolerance": {
+			tolerance: 0,
+			line:      [][2]float64{{0, 0}, {1, 0.1}, {2, 0}},
+			expected:  [][2]float64{{0, 0}, {1, 0.1}, {2, 0}},
+		},
+		"remove small triangle": {
+			tolerance: 1,
+			line:      [][2]float64{{0, 0}, {1, 0.1}, {2, 0}, {3, 5}, {4, 0}},
+			expected:  [][2]float64{{0, 0}, {2, 0}, {3, 5}, {4, 0}},
+		},
+		"keep end points": {
+			tolerance: 100,
+			line:      [][2]float64{{0, 0}, {1, 5}, {2, 0}, {3, 5}, {4, 0}},
+			expected:  [][2]float64{{0, 0}, {4, 0}},
+		},
+		"ring keeps 3 points": {
+			tolerance: 100,
+			line:      [][2]float64{{0, 0}, {0, 10}, {10, 10}, {10, 0}, {5, 0.1}},
+			closed:    true,
+			expected:  [][2]float64{{0, 10}, {10, 10}, {10, 0}},
+		},
+		"ring removes collinear point": {
+			tolerance: 1,
+			line:      [][2]float64{{0, 0}, {0, 10}, {10, 10}, {10, 0}, {5, 0}},
+			closed:    true,
+			expected:  [][2]float64{{0, 0}, {0, 10}, {10, 10}, {10, 0}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestFor(t *testing.T) {
+	for _, alg := range append(simplify.Algorithms(), "") {
+		if _, err := simplify.For(alg, 1); err != nil {
+			t.Errorf("algorithm (%v) unexpected error: %v", alg, err)
+		}
+	}
+
+	if _, err := simplify.For("bogus", 1); err == nil {
+		t.Errorf("expected an error for an unknown algorithm")
+	} else if _, ok := err.(simplify.ErrUnknownAlgorithm); !ok {
+		t.Errorf("expected ErrUnknownAlgorithm got %T", err)
+	}
+}
@@ -0,0 +1,141 @@
+package simplify
+
+import (
+	"container/heap"
+	"context"
+	"math"
+)
+
+// VisvalingamWhyatt simplifies lines by repeatedly eliminating the point which
+// forms the triangle with the smallest area with its neighbours (its effective
+// area), until all remaining points have an effective area of at least
+// Tolerance². The end points of open lines are always kept.
+type VisvalingamWhyatt struct {
+	// Tolerance is the tolerance used to eliminate points, a tolerance of zero does not eliminate any points.
+	Tolerance float64
+}
+
+// Simplify adheres to the planar.Simplifer interface
+func (vw VisvalingamWhyatt) Simplify(ctx context.Context, linestring [][2]float64, isClosed bool) ([][2]float64, error) {
+	// rings need at least 3 points and lines 2
+	minPoints := 2
+	if isClosed {
+		minPoints = 3
+	}
+
+	if vw.Tolerance <= 0 || len(linestring) <= minPoints {
+		return linestring, nil
+	}
+
+	threshold := vw.Tolerance * vw.Tolerance
+	n := len(linestring)
+
+	// the neighbours of each point. rings wrap around
+	prev, next := make([]int, n), make([]int, n)
+	for i := range linestring {
+		prev[i], next[i] = i-1, i+1
+	}
+	if isClosed {
+		prev[0], next[n-1] = n-1, 0
+	}
+
+	// the end points of open lines are not candidates for elimination
+	vertices := make([]*vertex, n)
+	areas := make(vertexHeap, 0, n)
+	for i := range linestring {
+		if prev[i] < 0 || next[i] >= n {
+			continue
+		}
+
+		vertices[i] = &vertex{
+			idx:     i,
+			area:    triangleArea(linestring[prev[i]], linestring[i], linestring[next[i]]),
+			heapIdx: len(areas),
+		}
+		areas = append(areas, vertices[i])
+	}
+	heap.Init(&areas)
+
+	removed := make([]bool, n)
+	remaining := n
+
+	for areas.Len() > 0 && remaining > minPoints {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		v := areas[0]
+		if v.area >= threshold {
+			break
+		}
+
+		heap.Pop(&areas)
+		removed[v.idx] = true
+		remaining--
+
+		// link the neighbours together and update their areas
+		p, nx := prev[v.idx], next[v.idx]
+		next[p], prev[nx] = nx, p
+
+		for _, i := range [2]int{p, nx} {
+			if vertices[i] == nil {
+				continue
+			}
+
+			// a point's area can't be smaller than the area of a point
+			// eliminated before it, otherwise it'd be eliminated out of order
+			area := triangleArea(linestring[prev[i]], linestring[i], linestring[next[i]])
+			vertices[i].area = math.Max(area, v.area)
+			heap.Fix(&areas, vertices[i].heapIdx)
+		}
+	}
+
+	ret := make([][2]float64, 0, remaining)
+	for i := range linestring {
+		if !removed[i] {
+			ret = append(ret, linestring[i])
+		}
+	}
+
+	return ret, nil
+}
+
+// triangleArea returns the area of the triangle formed by a, b and c
+func triangleArea(a, b, c [2]float64) float64 {
+	return math.Abs((a[0]*(b[1]-c[1]) + b[0]*(c[1]-a[1]) + c[0]*(a[1]-b[1])) / 2)
+}
+
+// vertex is a point of the line being simplified
+type vertex struct {
+	// index of the point in the line
+	idx int
+	// effective area of the point
+	area float64
+	// index of the vertex in the heap
+	heapIdx int
+}
+
+// vertexHeap is a min heap of vertices ordered by area
+type vertexHeap []*vertex
+
+func (h vertexHeap) Len() int           { return len(h) }
+func (h vertexHeap) Less(i, j int) bool { return h[i].area < h[j].area }
+func (h vertexHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIdx = i
+	h[j].heapIdx = j
+}
+
+func (h *vertexHeap) Push(x interface{}) {
+	v := x.(*vertex)
+	v.heapIdx = len(*h)
+	*h = append(*h, v)
+}
+
+func (h *vertexHeap) Pop() interface{} {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	v.heapIdx = -1
+	return v
+}
@@ -0,0 +1,12 @@
+package tilematrix
+
+import "fmt"
+
+type ErrInvalid struct {
+	Name   string
+	Reason string
+}
+
+func (e ErrInvalid) Error() string {
+	return fmt.Sprintf("tilematrix: invalid tile matrix set (%v): %v", e.Name, e.Reason)
+}
@@ -0,0 +1,237 @@
+/*
+Package tilematrix describes the tile grids (tile matrix sets) which maps are
+served in. A tile matrix set is defined by the coordinate reference system of
+the tiles, the top left corner of the grid (origin), the size of the tiles in
+pixels and the resolution of each zoom level.
+
+Tiles are addressed by zoom (z), column (x) and row (y). Columns increase to
+the right of the origin and rows increase downwards from the origin, matching
+the slippy map tile naming used for EPSG:3857.
+*/
+package tilematrix
+
+import (
+	"math"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola/maths/proj"
+)
+
+const (
+	// DefaultTileSize is the width and height of a tile in pixels
+	DefaultTileSize = 256
+
+	// MvtTileDim is the number of pixels a vector tile is split into.
+	// Buffers and tolerances are expressed in these pixels.
+	MvtTileDim = slippy.MvtTileDim
+
+	// the size of a pixel in meters as defined by the OGC (0.28mm)
+	standardPixelSize = 0.00028
+)
+
+// TileMatrixSet is a tile grid for a coordinate reference system
+type TileMatrixSet struct {
+	// Name is used to reference the tile matrix set from a map
+	Name string
+	// SRID of the tile coordinates
+	SRID uint64
+	// Origin is the top left corner of the tile matrix set
+	Origin [2]float64
+	// TileSize is the width and height of a tile in pixels
+	TileSize uint
+	// Resolutions holds the size of a pixel in CRS units, indexed by zoom
+	Resolutions []float64
+	// Bounds is the area covered by the tile matrix set in CRS units.
+	// It's used to calculate the number of tiles at each zoom. When nil
+	// the tiles at each zoom are assumed to cover the same area as zoom 0,
+	// which is a single tile.
+	Bounds *geom.Extent
+}
+
+// the zoom 0 resolution of a web mercator tile with 256 pixels
+var webMercatorRes = slippy.WebMercatorMax * 2 / DefaultTileSize
+
+var (
+	// WebMercatorQuad is the EPSG:3857 tile matrix set used by slippy maps.
+	// Tile extents are identical to those of the slippy package.
+	WebMercatorQuad = &TileMatrixSet{
+		Name:        "WebMercatorQuad",
+		SRID:        proj.WebMercator,
+		Origin:      [2]float64{-slippy.WebMercatorMax, slippy.WebMercatorMax},
+		TileSize:    DefaultTileSize,
+		Resolutions: quadResolutions(webMercatorRes, slippy.MaxZoom),
+		Bounds:      &geom.Extent{-slippy.WebMercatorMax, -slippy.WebMercatorMax, slippy.WebMercatorMax, slippy.WebMercatorMax},
+	}
+
+	// WorldCRS84Quad is the EPSG:4326 tile matrix set with two tiles at zoom 0
+	WorldCRS84Quad = &TileMatrixSet{
+		Name:        "WorldCRS84Quad",
+		SRID:        proj.WGS84,
+		Origin:      [2]float64{-180, 90},
+		TileSize:    DefaultTileSize,
+		Resolutions: quadResolutions(180.0/DefaultTileSize, slippy.MaxZoom),
+		Bounds:      &geom.Extent{-180, -90, 180, 90},
+	}
+)
+
+// quadResolutions returns the resolutions of a grid where every zoom
+// splits the tiles of the previous zoom into 4
+func quadResolutions(res float64, maxZoom uint) []float64 {
+	resolutions := make([]float64, maxZoom+1)
+	for z := range resolutions {
+		resolutions[z] = res / math.Exp2(float64(z))
+	}
+	return resolutions
+}
+
+// Builtin returns the tile matrix sets which are always available
+func Builtin() []*TileMatrixSet {
+	return []*TileMatrixSet{WebMercatorQuad, WorldCRS84Quad}
+}
+
+// ForSRID returns the builtin tile matrix set for the SRID. WebMercatorQuad
+// is returned for SRIDs without a builtin tile matrix set.
+func ForSRID(srid uint64) *TileMatrixSet {
+	if srid == proj.WGS84 {
+		return WorldCRS84Quad
+	}
+	return WebMercatorQuad
+}
+
+// Validate checks the tile matrix set is usable
+func (tms *TileMatrixSet) Validate() error {
+	if tms.Name == "" {
+		return ErrInvalid{Reason: "name is required"}
+	}
+
+	if _, err := proj.Lookup(tms.SRID); err != nil {
+		return ErrInvalid{Name: tms.Name, Reason: err.Error()}
+	}
+
+	if tms.TileSize == 0 {
+		return ErrInvalid{Name: tms.Name, Reason: "tile_size must be greater than 0"}
+	}
+
+	if len(tms.Resolutions) == 0 {
+		return ErrInvalid{Name: tms.Name, Reason: "at least one resolution is required"}
+	}
+
+	for z, res := range tms.Resolutions {
+		if res <= 0 {
+			return ErrInvalid{Name: tms.Name, Reason: "resolutions must be greater than 0"}
+		}
+		if z > 0 && res >= tms.Resolutions[z-1] {
+			return ErrInvalid{Name: tms.Name, Reason: "resolutions must be in decreasing order"}
+		}
+	}
+
+	if tms.Bounds != nil {
+		if tms.Bounds.MinX() < tms.Origin[0] || tms.Bounds.MaxY() > tms.Origin[1] {
+			return ErrInvalid{Name: tms.Name, Reason: "bounds must be below and to the right of the origin"}
+		}
+	}
+
+	return nil
+}
+
+// MaxZoom returns the furthest in zoom of the tile matrix set
+func (tms *TileMatrixSet) MaxZoom() uint {
+	return uint(len(tms.Resolutions) - 1)
+}
+
+// TileSpan returns the width and height of a tile, in CRS units, at zoom z
+func (tms *TileMatrixSet) TileSpan(z uint) float64 {
+	return tms.Resolutions[z] * float64(tms.TileSize)
+}
+
+// Pixels2Units converts a number of vector tile pixels (see MvtTileDim) at
+// zoom z to CRS units. It's used for tile buffers and simplification tolerances.
+func (tms *TileMatrixSet) Pixels2Units(z uint, pixels float64) float64 {
+	return tms.TileSpan(z) * pixels / MvtTileDim
+}
+
+// Extent returns the extent of the tile at z, x, y in CRS units
+func (tms *TileMatrixSet) Extent(z, x, y uint) *geom.Extent {
+	span := tms.TileSpan(z)
+
+	return geom.NewExtent(
+		[2]float64{tms.Origin[0] + float64(x)*span, tms.Origin[1] - float64(y+1)*span},
+		[2]float64{tms.Origin[0] + float64(x+1)*span, tms.Origin[1] - float64(y)*span},
+	)
+}
+
+// Extent4326 returns the extent of the tile at z, x, y in WGS84 longitude / latitude degrees
+func (tms *TileMatrixSet) Extent4326(z, x, y uint) (*geom.Extent, error) {
+	if tms == WebMercatorQuad {
+		return slippy.NewTile(z, x, y).Extent4326(), nil
+	}
+
+	return proj.TransformExtent(tms.SRID, proj.WGS84, tms.Extent(z, x, y))
+}
+
+// MatrixSize returns the number of columns and rows of tiles at zoom z
+func (tms *TileMatrixSet) MatrixSize(z uint) (cols, rows uint) {
+	span := tms.TileSpan(z)
+
+	bounds := tms.Bounds
+	if bounds == nil {
+		span0 := tms.TileSpan(0)
+		bounds = &geom.Extent{tms.Origin[0], tms.Origin[1] - span0, tms.Origin[0] + span0, tms.Origin[1]}
+	}
+
+	// a small epsilon prevents floating point noise from adding a column or row
+	cols = uint(math.Ceil((bounds.MaxX()-tms.Origin[0])/span - 1e-9))
+	rows = uint(math.Ceil((tms.Origin[1]-bounds.MinY())/span - 1e-9))
+
+	return cols, rows
+}
+
+// TileRange returns the columns and rows of the tiles at zoom z which cover the
+// extent, in CRS units. The range is clipped to the tiles of the tile matrix set.
+func (tms *TileMatrixSet) TileRange(z uint, extent *geom.Extent) (minCol, minRow, maxCol, maxRow uint) {
+	span := tms.TileSpan(z)
+	cols, rows := tms.MatrixSize(z)
+
+	// clip converts a tile index to the range of the matrix. a small epsilon prevents
+	// extents ending on a tile edge from adding a column or row
+	clip := func(v float64, n uint) uint {
+		switch {
+		case v < 0 || n == 0:
+			return 0
+		case v > float64(n-1):
+			return n - 1
+		default:
+			return uint(v)
+		}
+	}
+
+	minCol = clip(math.Floor((extent.MinX()-tms.Origin[0])/span), cols)
+	maxCol = clip(math.Ceil((extent.MaxX()-tms.Origin[0])/span-1e-9)-1, cols)
+	minRow = clip(math.Floor((tms.Origin[1]-extent.MaxY())/span), rows)
+	maxRow = clip(math.Ceil((tms.Origin[1]-extent.MinY())/span-1e-9)-1, rows)
+
+	return minCol, minRow, maxCol, maxRow
+}
+
+// Contains reports if the tile at z, x, y is part of the tile matrix set
+func (tms *TileMatrixSet) Contains(z, x, y uint) bool {
+	if z > tms.MaxZoom() {
+		return false
+	}
+
+	cols, rows := tms.MatrixSize(z)
+
+	return x < cols && y < rows
+}
+
+// ScaleDenominator returns the scale denominator of zoom z using the
+// standardized rendering pixel size of 0.28mm defined by the OGC
+func (tms *TileMatrixSet) ScaleDenominator(z uint) float64 {
+	metersPerUnit := 1.0
+	if c, err := proj.Lookup(tms.SRID); err == nil {
+		metersPerUnit = c.MetersPerUnit()
+	}
+
+	return tms.Resolutions[z] * metersPerUnit / standardPixelSize
+}
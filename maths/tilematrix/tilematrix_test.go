@@ -0,0 +1,237 @@
+package tilematrix_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/geom/slippy"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+)
+
+// lv95 is the swisstopo tile matrix set for EPSG:2056 (first zooms only)
+var lv95 = tilematrix.TileMatrixSet{
+	Name:        "LV95",
+	SRID:        2056,
+	Origin:      [2]float64{2420000, 1350000},
+	TileSize:    256,
+	Resolutions: []float64{4000, 3750, 3500, 3250, 3000, 2750, 2500, 2250, 2000, 1750, 1500, 1250, 1000, 750, 650, 500, 250, 100, 50, 20},
+	Bounds:      &geom.Extent{2420000, 1030000, 2900000, 1350000},
+}
+
+func TestExtent(t *testing.T) {
+	type tcase struct {
+		tms      *tilematrix.TileMatrixSet
+		z, x, y  uint
+		expected *geom.Extent
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			ext := tc.tms.Extent(tc.z, tc.x, tc.y)
+			if *ext != *tc.expected {
+				t.Errorf("incorrect extent, expected %v got %v", tc.expected, ext)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"web mercator 0/0/0": {
+			tms:      tilematrix.WebMercatorQuad,
+			expected: slippy.NewTile(0, 0, 0).Extent3857(),
+		},
+		"web mercator 16/11241/26168": {
+			tms:      tilematrix.WebMercatorQuad,
+			z:        16,
+			x:        11241,
+			y:        26168,
+			expected: slippy.NewTile(16, 11241, 26168).Extent3857(),
+		},
+		"world crs84 0/1/0": {
+			tms:      tilematrix.WorldCRS84Quad,
+			x:        1,
+			expected: &geom.Extent{0, -90, 180, 90},
+		},
+		"world crs84 2/0/3": {
+			tms:      tilematrix.WorldCRS84Quad,
+			z:        2,
+			y:        3,
+			expected: &geom.Extent{-180, -90, -135, -45},
+		},
+		"lv95 17/0/0": {
+			tms:      &lv95,
+			z:        17,
+			expected: &geom.Extent{2420000, 1324400, 2445600, 1350000},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestMatrixSize(t *testing.T) {
+	type tcase struct {
+		tms        *tilematrix.TileMatrixSet
+		z          uint
+		cols, rows uint
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			cols, rows := tc.tms.MatrixSize(tc.z)
+			if cols != tc.cols || rows != tc.rows {
+				t.Errorf("incorrect matrix size, expected %vx%v got %vx%v", tc.cols, tc.rows, cols, rows)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"web mercator 0":  {tms: tilematrix.WebMercatorQuad, z: 0, cols: 1, rows: 1},
+		"web mercator 10": {tms: tilematrix.WebMercatorQuad, z: 10, cols: 1024, rows: 1024},
+		"world crs84 0":   {tms: tilematrix.WorldCRS84Quad, z: 0, cols: 2, rows: 1},
+		"world crs84 3":   {tms: tilematrix.WorldCRS84Quad, z: 3, cols: 16, rows: 8},
+		"lv95 0":          {tms: &lv95, z: 0, cols: 1, rows: 1},
+		"lv95 17":         {tms: &lv95, z: 17, cols: 19, rows: 13},
+		"no bounds": {
+			tms: &tilematrix.TileMatrixSet{
+				Origin:      [2]float64{0, 1024},
+				TileSize:    256,
+				Resolutions: []float64{4, 2, 1},
+			},
+			z:    2,
+			cols: 4,
+			rows: 4,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestContains(t *testing.T) {
+	if !lv95.Contains(17, 18, 12) {
+		t.Errorf("expected lv95 to contain 17/18/12")
+	}
+	if lv95.Contains(17, 19, 12) {
+		t.Errorf("expected lv95 to not contain 17/19/12")
+	}
+	if lv95.Contains(20, 0, 0) {
+		t.Errorf("expected lv95 to not contain zoom 20")
+	}
+}
+
+func TestTileRange(t *testing.T) {
+	type tcase struct {
+		tms    *tilematrix.TileMatrixSet
+		z      uint
+		extent *geom.Extent
+		// min col, min row, max col, max row
+		expected [4]uint
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			minCol, minRow, maxCol, maxRow := tc.tms.TileRange(tc.z, tc.extent)
+			if got := [4]uint{minCol, minRow, maxCol, maxRow}; got != tc.expected {
+				t.Errorf("tile range, expected %v got %v", tc.expected, got)
+			}
+		}
+	}
+
+	max := slippy.WebMercatorMax
+
+	tests := map[string]tcase{
+		"world": {
+			tms:      tilematrix.WebMercatorQuad,
+			z:        2,
+			extent:   &geom.Extent{-max, -max, max, max},
+			expected: [4]uint{0, 0, 3, 3},
+		},
+		"north east quarter": {
+			tms:      tilematrix.WebMercatorQuad,
+			z:        2,
+			extent:   &geom.Extent{1, 1, max, max},
+			expected: [4]uint{2, 0, 3, 1},
+		},
+		"outside the matrix": {
+			tms:      tilematrix.WebMercatorQuad,
+			z:        1,
+			extent:   &geom.Extent{-2 * max, -2 * max, 2 * max, 2 * max},
+			expected: [4]uint{0, 0, 1, 1},
+		},
+		"lv95": {
+			tms:      &lv95,
+			z:        17,
+			extent:   &geom.Extent{2420000, 1330000, 2420100, 1350000},
+			expected: [4]uint{0, 0, 0, 0},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestPixels2Units(t *testing.T) {
+	for z := uint(0); z <= slippy.MaxZoom; z++ {
+		if got, expected := tilematrix.WebMercatorQuad.Pixels2Units(z, 64), slippy.Pixels2Webs(z, 64); got != expected {
+			t.Errorf("zoom %v, expected %v got %v", z, expected, got)
+		}
+	}
+}
+
+func TestScaleDenominator(t *testing.T) {
+	type tcase struct {
+		tms      *tilematrix.TileMatrixSet
+		expected float64
+	}
+
+	tests := map[string]tcase{
+		// slippy truncates the web mercator bounds to the centimeter
+		"web mercator": {tms: tilematrix.WebMercatorQuad, expected: 559082263.9508929},
+		"world crs84":  {tms: tilematrix.WorldCRS84Quad, expected: 279541132.0143589},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.tms.ScaleDenominator(0); math.Abs(got-tc.expected) > 1e-3 {
+				t.Errorf("expected %v got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := lv95
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, tms := range tilematrix.Builtin() {
+		if err := tms.Validate(); err != nil {
+			t.Errorf("unexpected error for builtin (%v): %v", tms.Name, err)
+		}
+	}
+
+	tests := map[string]func(tms *tilematrix.TileMatrixSet){
+		"missing name":   func(tms *tilematrix.TileMatrixSet) { tms.Name = "" },
+		"unknown srid":   func(tms *tilematrix.TileMatrixSet) { tms.SRID = 1 },
+		"zero tile size": func(tms *tilematrix.TileMatrixSet) { tms.TileSize = 0 },
+		"no resolutions": func(tms *tilematrix.TileMatrixSet) { tms.Resolutions = nil },
+		"increasing":     func(tms *tilematrix.TileMatrixSet) { tms.Resolutions = []float64{1, 2} },
+		"negative":       func(tms *tilematrix.TileMatrixSet) { tms.Resolutions = []float64{-1} },
+		"bounds outside": func(tms *tilematrix.TileMatrixSet) { tms.Bounds = &geom.Extent{0, 0, 1, 1} },
+	}
+
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			tms := lv95
+			mutate(&tms)
+			if _, ok := tms.Validate().(tilematrix.ErrInvalid); !ok {
+				t.Errorf("expected ErrInvalid")
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
 var (
@@ -19,6 +20,60 @@ func (e ErrUnableToConvertFeatureID) Error() string {
 	return fmt.Sprintf("unable to convert feature id %+v to uint64", e.val)
 }
 
+// ErrQueryTimeout is returned when the query for a layer's features takes
+// longer than the timeout configured for the layer
+type ErrQueryTimeout struct {
+	Provider string
+	Layer    string
+	Timeout  time.Duration
+}
+
+func (err ErrQueryTimeout) Error() string {
+	return fmt.Sprintf("provider: %v layer (%v) query timed out after %v", err.Provider, err.Layer, err.Timeout)
+}
+
+// ErrInvalidQueryParameter is returned when the value of a query parameter is not allowed
+// or can't be converted to the type of the parameter
+type ErrInvalidQueryParameter struct {
+	Name   string
+	Value  string
+	Reason string
+}
+
+func (err ErrInvalidQueryParameter) Error() string {
+	return fmt.Sprintf("provider: invalid value (%v) for query parameter (%v): %v", err.Value, err.Name, err.Reason)
+}
+
+// ErrMissingQueryParameter is returned when a required query parameter is not set
+type ErrMissingQueryParameter struct {
+	Name string
+}
+
+func (err ErrMissingQueryParameter) Error() string {
+	return fmt.Sprintf("provider: query parameter (%v) is required", err.Name)
+}
+
+// ErrUnknownQueryParameterType is returned when a query parameter is not one of the QueryParameterTypes
+type ErrUnknownQueryParameterType struct {
+	Name string
+	Type string
+}
+
+func (err ErrUnknownQueryParameterType) Error() string {
+	return fmt.Sprintf("provider: unknown type (%v) for query parameter (%v). supported types: %v", err.Type, err.Name, strings.Join(QueryParameterTypes(), ", "))
+}
+
+// ErrInvalidQueryParameterRegex is returned when the regex of a query parameter does not compile
+type ErrInvalidQueryParameterRegex struct {
+	Name  string
+	Regex string
+	Err   error
+}
+
+func (err ErrInvalidQueryParameterRegex) Error() string {
+	return fmt.Sprintf("provider: invalid regex (%v) for query parameter (%v): %v", err.Regex, err.Name, err.Err)
+}
+
 // ErrProviderAlreadyExists is returned when the Provided being registered
 // already exists in the registration system
 type ErrProviderAlreadyExists struct {
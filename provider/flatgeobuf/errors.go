@@ -0,0 +1,60 @@
+package flatgeobuf
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingLayerName = errors.New("flatgeobuf: layer is missing 'name'")
+)
+
+type ErrInvalidFilePath struct {
+	FilePath string
+}
+
+func (e ErrInvalidFilePath) Error() string {
+	return fmt.Sprintf("flatgeobuf: invalid filepath: %v", e.FilePath)
+}
+
+// ErrInvalidFile is returned when the header, the index or a feature of a file
+// can't be decoded
+type ErrInvalidFile struct {
+	FilePath string
+	Reason   string
+}
+
+func (e ErrInvalidFile) Error() string {
+	return fmt.Sprintf("flatgeobuf: invalid file (%v): %v", e.FilePath, e.Reason)
+}
+
+// ErrUnsupportedGeometryType is returned for files of a geometry type without a
+// corresponding geometry (i.e. CurvePolygon)
+type ErrUnsupportedGeometryType struct {
+	FilePath     string
+	GeometryType uint8
+}
+
+func (e ErrUnsupportedGeometryType) Error() string {
+	return fmt.Sprintf("flatgeobuf: unsupported geometry type (%v) in %v", e.GeometryType, e.FilePath)
+}
+
+// ErrUnknownSRID is returned when the SRID of a layer is neither configured nor
+// an EPSG code in the header of the file
+type ErrUnknownSRID struct {
+	FilePath string
+}
+
+func (e ErrUnknownSRID) Error() string {
+	return fmt.Sprintf("flatgeobuf: unable to determine the SRID of %v. set the layer's 'srid'", e.FilePath)
+}
+
+// ErrUnknownField is returned when a configured field is not a column of the file
+type ErrUnknownField struct {
+	FilePath string
+	Field    string
+}
+
+func (e ErrUnknownField) Error() string {
+	return fmt.Sprintf("flatgeobuf: field (%v) not found in %v", e.Field, e.FilePath)
+}
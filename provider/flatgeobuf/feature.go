@@ -0,0 +1,262 @@
+package flatgeobuf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/go-spatial/geom"
+)
+
+// decodeFeature decodes the Feature table (See https://github.com/flatgeobuf/flatgeobuf/blob/master/src/fbs/feature.fbs)
+// without the size prefix. The geometry is nil for features without a geometry.
+// The properties refer to the columns of the header unless the feature declares its
+// own columns.
+func decodeFeature(buf []byte, geometryType uint8, columns []Column) (geom.Geometry, map[string]interface{}, error) {
+	t, err := rootTable(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var geo geom.Geometry
+	gt, ok, err := t.table(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ok {
+		if geo, err = decodeGeometry(gt, geometryType); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	featureColumns, err := decodeColumns(t, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(featureColumns) > 0 {
+		columns = featureColumns
+	}
+
+	props, err := t.bytes(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	properties, err := decodeProperties(props, columns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return geo, properties, nil
+}
+
+// decodeGeometry decodes the Geometry table. The geometry type of the table takes
+// precedence over geometryType, which is the type declared by the header or the
+// parent geometry. The Z and M values are ignored. Empty geometries are nil.
+func decodeGeometry(t table, geometryType uint8) (geom.Geometry, error) {
+	typ, err := t.uint8(6, geometryTypeUnknown)
+	if err != nil {
+		return nil, err
+	}
+	if typ == geometryTypeUnknown {
+		typ = geometryType
+	}
+
+	// multi polygons and collections are made of parts
+	switch typ {
+	case geometryTypeMultiPolygon:
+		parts, err := t.tables(7)
+		if err != nil {
+			return nil, err
+		}
+		mp := make(geom.MultiPolygon, 0, len(parts))
+		for _, part := range parts {
+			g, err := decodeGeometry(part, geometryTypePolygon)
+			if err != nil {
+				return nil, err
+			}
+			if p, ok := g.(geom.Polygon); ok {
+				mp = append(mp, p)
+			}
+		}
+		if len(mp) == 0 {
+			return nil, nil
+		}
+		return mp, nil
+
+	case geometryTypeCollection:
+		parts, err := t.tables(7)
+		if err != nil {
+			return nil, err
+		}
+		col := make(geom.Collection, 0, len(parts))
+		for _, part := range parts {
+			g, err := decodeGeometry(part, geometryTypeUnknown)
+			if err != nil {
+				return nil, err
+			}
+			if g != nil {
+				col = append(col, g)
+			}
+		}
+		if len(col) == 0 {
+			return nil, nil
+		}
+		return col, nil
+	}
+
+	xy, err := t.float64s(1)
+	if err != nil {
+		return nil, err
+	}
+	if len(xy)%2 != 0 {
+		return nil, fmt.Errorf("odd number of coordinates (%v)", len(xy))
+	}
+	if len(xy) == 0 {
+		return nil, nil
+	}
+
+	points := make([][2]float64, len(xy)/2)
+	for i := range points {
+		points[i] = [2]float64{xy[2*i], xy[2*i+1]}
+	}
+
+	ends, err := t.uint32s(0)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := splitPoints(points, ends)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case geometryTypePoint:
+		return geom.Point(points[0]), nil
+
+	case geometryTypeMultiPoint:
+		return geom.MultiPoint(points), nil
+
+	case geometryTypeLineString:
+		return geom.LineString(points), nil
+
+	case geometryTypeMultiLineString:
+		mls := make(geom.MultiLineString, len(parts))
+		for i := range parts {
+			mls[i] = parts[i]
+		}
+		return mls, nil
+
+	case geometryTypePolygon:
+		poly := make(geom.Polygon, len(parts))
+		for i, ring := range parts {
+			// the rings of geom polygons are not closed
+			if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+				ring = ring[:len(ring)-1]
+			}
+			poly[i] = ring
+		}
+		return poly, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported geometry type (%v)", typ)
+	}
+}
+
+// splitPoints splits the points at the ends, the index of the point following each
+// part. All the points are one part without ends.
+func splitPoints(points [][2]float64, ends []uint32) ([][][2]float64, error) {
+	if len(ends) == 0 {
+		return [][][2]float64{points}, nil
+	}
+
+	parts := make([][][2]float64, len(ends))
+	start := uint32(0)
+	for i, end := range ends {
+		if end < start || int(end) > len(points) {
+			return nil, fmt.Errorf("invalid geometry end (%v)", end)
+		}
+		parts[i] = points[start:end]
+		start = end
+	}
+	return parts, nil
+}
+
+// decodeProperties decodes the properties of a feature, a sequence of column
+// indexes each followed by the value of the column. The values are decoded to
+// int64, uint64, float64, bool or string, like the values of the other providers.
+// Binary values are not encoded as tags and skipped.
+func decodeProperties(buf []byte, columns []Column) (map[string]interface{}, error) {
+	properties := make(map[string]interface{})
+
+	// size returns the size of the value of the column type at pos
+	size := func(typ ColumnType, pos int) (int, error) {
+		switch typ {
+		case ColumnTypeByte, ColumnTypeUByte, ColumnTypeBool:
+			return 1, nil
+		case ColumnTypeShort, ColumnTypeUShort:
+			return 2, nil
+		case ColumnTypeInt, ColumnTypeUInt, ColumnTypeFloat:
+			return 4, nil
+		case ColumnTypeLong, ColumnTypeULong, ColumnTypeDouble:
+			return 8, nil
+		case ColumnTypeString, ColumnTypeJSON, ColumnTypeDateTime, ColumnTypeBinary:
+			if pos+4 > len(buf) {
+				return 0, errInvalidFlatBuffer
+			}
+			return 4 + int(binary.LittleEndian.Uint32(buf[pos:])), nil
+		default:
+			return 0, fmt.Errorf("unsupported column type (%v)", typ)
+		}
+	}
+
+	for pos := 0; pos < len(buf); {
+		if pos+2 > len(buf) {
+			return nil, fmt.Errorf("invalid properties")
+		}
+		i := int(binary.LittleEndian.Uint16(buf[pos:]))
+		pos += 2
+		if i >= len(columns) {
+			return nil, fmt.Errorf("invalid property column (%v)", i)
+		}
+		col := columns[i]
+
+		n, err := size(col.Type, pos)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || pos+n > len(buf) {
+			return nil, fmt.Errorf("invalid property (%v)", col.Name)
+		}
+		v := buf[pos : pos+n]
+		pos += n
+
+		switch col.Type {
+		case ColumnTypeByte:
+			properties[col.Name] = int64(int8(v[0]))
+		case ColumnTypeUByte:
+			properties[col.Name] = int64(v[0])
+		case ColumnTypeBool:
+			properties[col.Name] = v[0] != 0
+		case ColumnTypeShort:
+			properties[col.Name] = int64(int16(binary.LittleEndian.Uint16(v)))
+		case ColumnTypeUShort:
+			properties[col.Name] = int64(binary.LittleEndian.Uint16(v))
+		case ColumnTypeInt:
+			properties[col.Name] = int64(int32(binary.LittleEndian.Uint32(v)))
+		case ColumnTypeUInt:
+			properties[col.Name] = int64(binary.LittleEndian.Uint32(v))
+		case ColumnTypeLong:
+			properties[col.Name] = int64(binary.LittleEndian.Uint64(v))
+		case ColumnTypeULong:
+			properties[col.Name] = binary.LittleEndian.Uint64(v)
+		case ColumnTypeFloat:
+			properties[col.Name] = float64(math.Float32frombits(binary.LittleEndian.Uint32(v)))
+		case ColumnTypeDouble:
+			properties[col.Name] = math.Float64frombits(binary.LittleEndian.Uint64(v))
+		case ColumnTypeString, ColumnTypeJSON, ColumnTypeDateTime:
+			properties[col.Name] = string(v[4:])
+		}
+	}
+
+	return properties, nil
+}
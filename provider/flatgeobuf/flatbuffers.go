@@ -0,0 +1,211 @@
+package flatgeobuf
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// errInvalidFlatBuffer is returned when an offset of a FlatBuffer points outside
+// of the buffer
+var errInvalidFlatBuffer = errors.New("invalid flatbuffer")
+
+// table reads the fields of a FlatBuffers table (https://google.github.io/flatbuffers/flatbuffers_internals.html).
+// Only the parts of the format used by the FlatGeobuf schemas are supported. The
+// offsets are checked against the buffer so corrupt files result in an error rather
+// than a panic.
+type table struct {
+	buf []byte
+	pos int
+	// the position and size of the vtable
+	vtable, vtableSize int
+}
+
+// rootTable returns the root table of the buffer
+func rootTable(buf []byte) (table, error) {
+	if len(buf) < 4 {
+		return table{}, errInvalidFlatBuffer
+	}
+	return tableAt(buf, int(binary.LittleEndian.Uint32(buf)))
+}
+
+// tableAt returns the table at pos
+func tableAt(buf []byte, pos int) (table, error) {
+	if pos < 0 || pos+4 > len(buf) {
+		return table{}, errInvalidFlatBuffer
+	}
+
+	vtable := pos - int(int32(binary.LittleEndian.Uint32(buf[pos:])))
+	if vtable < 0 || vtable+4 > len(buf) {
+		return table{}, errInvalidFlatBuffer
+	}
+
+	t := table{
+		buf:        buf,
+		pos:        pos,
+		vtable:     vtable,
+		vtableSize: int(binary.LittleEndian.Uint16(buf[vtable:])),
+	}
+	if vtable+t.vtableSize > len(buf) {
+		return table{}, errInvalidFlatBuffer
+	}
+	return t, nil
+}
+
+// field returns the position of the field with the size in the buffer. ok is false
+// when the field is not set.
+func (t table) field(i, size int) (pos int, ok bool, err error) {
+	entry := 4 + 2*i
+	if entry+2 > t.vtableSize {
+		return 0, false, nil
+	}
+
+	offset := int(binary.LittleEndian.Uint16(t.buf[t.vtable+entry:]))
+	if offset == 0 {
+		return 0, false, nil
+	}
+
+	pos = t.pos + offset
+	if pos+size > len(t.buf) {
+		return 0, false, errInvalidFlatBuffer
+	}
+	return pos, true, nil
+}
+
+func (t table) uint8(i int, def uint8) (uint8, error) {
+	pos, ok, err := t.field(i, 1)
+	if !ok {
+		return def, err
+	}
+	return t.buf[pos], nil
+}
+
+func (t table) bool(i int, def bool) (bool, error) {
+	var d uint8
+	if def {
+		d = 1
+	}
+	v, err := t.uint8(i, d)
+	return v != 0, err
+}
+
+func (t table) uint16(i int, def uint16) (uint16, error) {
+	pos, ok, err := t.field(i, 2)
+	if !ok {
+		return def, err
+	}
+	return binary.LittleEndian.Uint16(t.buf[pos:]), nil
+}
+
+func (t table) int32(i int, def int32) (int32, error) {
+	pos, ok, err := t.field(i, 4)
+	if !ok {
+		return def, err
+	}
+	return int32(binary.LittleEndian.Uint32(t.buf[pos:])), nil
+}
+
+func (t table) uint64(i int, def uint64) (uint64, error) {
+	pos, ok, err := t.field(i, 8)
+	if !ok {
+		return def, err
+	}
+	return binary.LittleEndian.Uint64(t.buf[pos:]), nil
+}
+
+// indirect follows the offset of an offset field (a string, vector or table). ok
+// is false when the field is not set.
+func (t table) indirect(i int) (pos int, ok bool, err error) {
+	pos, ok, err = t.field(i, 4)
+	if !ok {
+		return 0, false, err
+	}
+
+	pos += int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	if pos+4 > len(t.buf) {
+		return 0, false, errInvalidFlatBuffer
+	}
+	return pos, true, nil
+}
+
+// vector returns the position of the first element and the length of a vector
+// with elements of the size
+func (t table) vector(i, size int) (pos, length int, err error) {
+	pos, ok, err := t.indirect(i)
+	if !ok {
+		return 0, 0, err
+	}
+
+	length = int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	pos += 4
+	if length < 0 || length > (len(t.buf)-pos)/size {
+		return 0, 0, errInvalidFlatBuffer
+	}
+	return pos, length, nil
+}
+
+func (t table) string(i int) (string, error) {
+	b, err := t.bytes(i)
+	return string(b), err
+}
+
+func (t table) bytes(i int) ([]byte, error) {
+	pos, length, err := t.vector(i, 1)
+	if err != nil || length == 0 {
+		return nil, err
+	}
+	return t.buf[pos : pos+length], nil
+}
+
+func (t table) float64s(i int) ([]float64, error) {
+	pos, length, err := t.vector(i, 8)
+	if err != nil || length == 0 {
+		return nil, err
+	}
+
+	v := make([]float64, length)
+	for j := range v {
+		v[j] = math.Float64frombits(binary.LittleEndian.Uint64(t.buf[pos+8*j:]))
+	}
+	return v, nil
+}
+
+func (t table) uint32s(i int) ([]uint32, error) {
+	pos, length, err := t.vector(i, 4)
+	if err != nil || length == 0 {
+		return nil, err
+	}
+
+	v := make([]uint32, length)
+	for j := range v {
+		v[j] = binary.LittleEndian.Uint32(t.buf[pos+4*j:])
+	}
+	return v, nil
+}
+
+// table returns the sub table of the field. ok is false when the field is not set.
+func (t table) table(i int) (sub table, ok bool, err error) {
+	pos, ok, err := t.indirect(i)
+	if !ok {
+		return table{}, false, err
+	}
+	sub, err = tableAt(t.buf, pos)
+	return sub, err == nil, err
+}
+
+// tables returns the tables of a vector of tables
+func (t table) tables(i int) ([]table, error) {
+	pos, length, err := t.vector(i, 4)
+	if err != nil || length == 0 {
+		return nil, err
+	}
+
+	tables := make([]table, length)
+	for j := range tables {
+		elem := pos + 4*j
+		if tables[j], err = tableAt(t.buf, elem+int(binary.LittleEndian.Uint32(t.buf[elem:]))); err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
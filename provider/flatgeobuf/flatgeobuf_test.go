@@ -0,0 +1,354 @@
+package flatgeobuf_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/flatgeobuf"
+)
+
+func TestNewTileProvider(t *testing.T) {
+	type tcase struct {
+		config         dict.Dict
+		expectedLayers map[string]provider.LayerInfo
+		expectedErr    error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			p, err := flatgeobuf.NewTileProvider(tc.config)
+			if tc.expectedErr != nil {
+				if !reflect.DeepEqual(err, tc.expectedErr) {
+					t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			defer p.(*flatgeobuf.Provider).Close()
+
+			ls, err := p.Layers()
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if len(ls) != len(tc.expectedLayers) {
+				t.Fatalf("layers, expected %v got %v", len(tc.expectedLayers), len(ls))
+			}
+			for _, l := range ls {
+				expected, ok := tc.expectedLayers[l.Name()]
+				if !ok {
+					t.Errorf("unexpected layer %v", l.Name())
+					continue
+				}
+				if reflect.TypeOf(l.GeomType()) != reflect.TypeOf(expected.GeomType()) {
+					t.Errorf("layer (%v) geometry type, expected %T got %T", l.Name(), expected.GeomType(), l.GeomType())
+				}
+				if l.SRID() != expected.SRID() {
+					t.Errorf("layer (%v) srid, expected %v got %v", l.Name(), expected.SRID(), l.SRID())
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"layers": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "places"},
+					{"name": "streets", "filepath": "roads.fgb"},
+					{"name": "parks", "srid": 4326},
+				},
+			},
+			expectedLayers: map[string]provider.LayerInfo{
+				"places": layerInfo{geomType: geom.Point{}, srid: tegola.WGS84},
+				// mixed geometry types
+				"streets": layerInfo{geomType: nil, srid: tegola.WGS84},
+				"parks":   layerInfo{geomType: geom.MultiPolygon{}, srid: tegola.WGS84},
+			},
+		},
+		"srid override": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "places", "srid": 3857},
+				},
+			},
+			expectedLayers: map[string]provider.LayerInfo{
+				"places": layerInfo{geomType: geom.Point{}, srid: tegola.WebMercator},
+			},
+		},
+		"missing layer name": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": ""},
+				},
+			},
+			expectedErr: flatgeobuf.ErrMissingLayerName,
+		},
+		"missing file": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "rivers"},
+				},
+			},
+			expectedErr: flatgeobuf.ErrInvalidFilePath{FilePath: "testdata/rivers.fgb"},
+		},
+		"invalid file": {
+			config: dict.Dict{
+				"layers": []map[string]interface{}{
+					{"name": "tests", "filepath": "flatgeobuf_test.go"},
+				},
+			},
+			expectedErr: flatgeobuf.ErrInvalidFile{FilePath: "flatgeobuf_test.go", Reason: "not a FlatGeobuf file or unsupported version"},
+		},
+		"unknown srid": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "parks"},
+				},
+			},
+			expectedErr: flatgeobuf.ErrUnknownSRID{FilePath: "testdata/parks.fgb"},
+		},
+		"unknown field": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "places", "fields": []string{"name", "altitude"}},
+				},
+			},
+			expectedErr: flatgeobuf.ErrUnknownField{FilePath: "testdata/places.fgb", Field: "altitude"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+type layerInfo struct {
+	geomType geom.Geometry
+	srid     uint64
+}
+
+func (l layerInfo) Name() string            { return "" }
+func (l layerInfo) GeomType() geom.Geometry { return l.geomType }
+func (l layerInfo) SRID() uint64            { return l.srid }
+
+func TestLayerSchema(t *testing.T) {
+	p, err := flatgeobuf.NewTileProvider(dict.Dict{
+		"dir": "testdata",
+		"layers": []map[string]interface{}{
+			{"name": "places"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer p.(*flatgeobuf.Provider).Close()
+
+	ls, err := p.Layers()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	layer := ls[0].(*flatgeobuf.Layer)
+
+	if layer.Title() != "Places" {
+		t.Errorf("title, expected %v got %v", "Places", layer.Title())
+	}
+	if layer.Description() != "Populated places" {
+		t.Errorf("description, expected %v got %v", "Populated places", layer.Description())
+	}
+	if layer.FeaturesCount() != 7 {
+		t.Errorf("features count, expected %v got %v", 7, layer.FeaturesCount())
+	}
+
+	expectedBounds := &geom.Extent{-122.42, -33.92, 151.21, 48.86}
+	if !reflect.DeepEqual(layer.Bounds(), expectedBounds) {
+		t.Errorf("bounds, expected %v got %v", expectedBounds, layer.Bounds())
+	}
+
+	expectedColumns := []flatgeobuf.Column{
+		{Name: "name", Type: flatgeobuf.ColumnTypeString, Nullable: true},
+		{Name: "population", Type: flatgeobuf.ColumnTypeLong, Nullable: true},
+		{Name: "area", Type: flatgeobuf.ColumnTypeDouble, Nullable: true},
+		{Name: "capital", Type: flatgeobuf.ColumnTypeBool, Nullable: true},
+		{Name: "rank", Type: flatgeobuf.ColumnTypeUByte, Nullable: true},
+		{Name: "elevation", Type: flatgeobuf.ColumnTypeShort, Nullable: true},
+		{Name: "density", Type: flatgeobuf.ColumnTypeFloat, Nullable: true},
+		{Name: "founded", Type: flatgeobuf.ColumnTypeDateTime, Nullable: true},
+		{Name: "meta", Type: flatgeobuf.ColumnTypeJSON, Nullable: true},
+		{Name: "code", Type: flatgeobuf.ColumnTypeInt, Nullable: true},
+	}
+	if !reflect.DeepEqual(layer.Columns(), expectedColumns) {
+		t.Errorf("columns, expected %v got %v", expectedColumns, layer.Columns())
+	}
+}
+
+func TestTileFeatures(t *testing.T) {
+	type tcase struct {
+		layerConfig      map[string]interface{}
+		tile             provider.Tile
+		expectedFeatures []provider.Feature
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			p, err := flatgeobuf.NewTileProvider(dict.Dict{
+				"dir":    "testdata",
+				"layers": []map[string]interface{}{tc.layerConfig},
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			defer p.(*flatgeobuf.Provider).Close()
+
+			var features []provider.Feature
+			err = p.TileFeatures(context.Background(), tc.layerConfig["name"].(string), tc.tile, func(f *provider.Feature) error {
+				features = append(features, *f)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if !reflect.DeepEqual(features, tc.expectedFeatures) {
+				t.Errorf("features, expected %v got %v", tc.expectedFeatures, features)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		// the features of indexed files are in the order of the index
+		"points": {
+			layerConfig: map[string]interface{}{"name": "places", "id_fieldname": "code"},
+			// the tile east of the antimeridian in the north
+			tile: provider.NewTile(1, 1, 0, 0, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID:       20,
+					Geometry: geom.Point{2.35, 48.86},
+					SRID:     tegola.WGS84,
+					Tags: map[string]interface{}{
+						"name":       "Paris",
+						"population": int64(2102650),
+						"capital":    true,
+						"rank":       int64(1),
+						"elevation":  int64(35),
+						"density":    20.5,
+						"founded":    "0250-01-01T00:00:00Z",
+					},
+				},
+				{
+					ID:       30,
+					Geometry: geom.Point{139.69, 35.69},
+					SRID:     tegola.WGS84,
+					Tags: map[string]interface{}{
+						"name":       "Tokyo",
+						"population": int64(13960000),
+						"capital":    true,
+						"meta":       `{"source":"osm"}`,
+					},
+				},
+			},
+		},
+		"points fields": {
+			layerConfig: map[string]interface{}{"name": "places", "fields": []string{"name", "elevation"}},
+			// the tile west of the antimeridian in the south
+			tile: provider.NewTile(1, 0, 1, 0, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID:       1,
+					Geometry: geom.Point{-43.2, -22.9},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"name": "Rio de Janeiro", "elevation": int64(-2)},
+				},
+			},
+		},
+		"mixed geometry types": {
+			layerConfig: map[string]interface{}{"name": "roads", "id_fieldname": "lanes"},
+			tile:        provider.NewTile(0, 0, 0, 0, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					// a feature without properties
+					ID:       1,
+					Geometry: geom.LineString{{-50, -50}, {-40, -40}},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{},
+				},
+				{
+					ID:       2,
+					Geometry: geom.LineString{{0, 0}, {1, 1}},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"ref": "A1"},
+				},
+				{
+					ID:       4,
+					Geometry: geom.MultiLineString{{{10, 10}, {11, 11}}, {{12, 12}, {13, 12}}},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"ref": "B2"},
+				},
+			},
+		},
+		"without index": {
+			layerConfig: map[string]interface{}{"name": "parks", "srid": 4326},
+			tile:        provider.NewTile(0, 0, 0, 64, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID: 1,
+					Geometry: geom.MultiPolygon{
+						{
+							{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+							{{2, 2}, {2, 4}, {4, 4}, {4, 2}},
+						},
+					},
+					SRID: tegola.WGS84,
+					Tags: map[string]interface{}{"name": "Central"},
+				},
+				{
+					ID: 2,
+					Geometry: geom.MultiPolygon{
+						{{{-20, -20}, {-10, -20}, {-10, -10}}},
+						{{{-30, -30}, {-25, -30}, {-25, -25}}},
+					},
+					SRID: tegola.WGS84,
+					Tags: map[string]interface{}{"name": "Twin"},
+				},
+			},
+		},
+		"without index filtered": {
+			layerConfig: map[string]interface{}{"name": "parks", "srid": 4326},
+			tile:        provider.NewTile(1, 1, 0, 0, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID: 1,
+					Geometry: geom.MultiPolygon{
+						{
+							{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+							{{2, 2}, {2, 4}, {4, 4}, {4, 2}},
+						},
+					},
+					SRID: tegola.WGS84,
+					Tags: map[string]interface{}{"name": "Central"},
+				},
+			},
+		},
+		"no features": {
+			layerConfig: map[string]interface{}{"name": "places"},
+			tile:        provider.NewTile(2, 0, 3, 0, tegola.WebMercator),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -0,0 +1,212 @@
+package flatgeobuf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// the magic bytes of FlatGeobuf files. the 4th byte is the major version of the spec
+// and the last byte the patch version
+var magicBytes = []byte{'f', 'g', 'b', 3, 'f', 'g', 'b', 0}
+
+// maxHeaderSize guards against corrupt files, in line with the reference implementation
+const maxHeaderSize = 10 * 1024 * 1024
+
+// the geometry types of the FlatGeobuf spec. curves and surfaces are not supported.
+const (
+	geometryTypeUnknown uint8 = iota
+	geometryTypePoint
+	geometryTypeLineString
+	geometryTypePolygon
+	geometryTypeMultiPoint
+	geometryTypeMultiLineString
+	geometryTypeMultiPolygon
+	geometryTypeCollection
+)
+
+// ColumnType is the type of the values of a column
+type ColumnType uint8
+
+const (
+	ColumnTypeByte ColumnType = iota
+	ColumnTypeUByte
+	ColumnTypeBool
+	ColumnTypeShort
+	ColumnTypeUShort
+	ColumnTypeInt
+	ColumnTypeUInt
+	ColumnTypeLong
+	ColumnTypeULong
+	ColumnTypeFloat
+	ColumnTypeDouble
+	ColumnTypeString
+	ColumnTypeJSON
+	ColumnTypeDateTime
+	ColumnTypeBinary
+)
+
+var columnTypeNames = []string{"Byte", "UByte", "Bool", "Short", "UShort", "Int", "UInt", "Long", "ULong", "Float", "Double", "String", "Json", "DateTime", "Binary"}
+
+func (t ColumnType) String() string {
+	if int(t) < len(columnTypeNames) {
+		return columnTypeNames[t]
+	}
+	return fmt.Sprintf("ColumnType(%d)", uint8(t))
+}
+
+// Column describes a property of the features of a FlatGeobuf file as declared in
+// the header of the file
+type Column struct {
+	Name        string
+	Type        ColumnType
+	Title       string
+	Description string
+	Nullable    bool
+}
+
+// header is the header of a FlatGeobuf file
+type header struct {
+	name         string
+	title        string
+	description  string
+	envelope     []float64
+	geometryType uint8
+	hasZ, hasM   bool
+	hasT, hasTM  bool
+	columns      []Column
+	// 0 when the number of features is unknown
+	featuresCount uint64
+	// 0 when the file has no index
+	indexNodeSize uint16
+	crsOrg        string
+	crsCode       int32
+}
+
+// readHeader reads the magic bytes and the header from the start of r. the size
+// is the number of bytes read, the features or the index follow the header.
+func readHeader(r io.ReaderAt) (hdr *header, size int64, err error) {
+	var prefix [12]byte
+	if _, err := r.ReadAt(prefix[:], 0); err != nil {
+		return nil, 0, fmt.Errorf("reading magic bytes: %v", err)
+	}
+	// the patch version may differ
+	if !bytes.Equal(prefix[:7], magicBytes[:7]) {
+		return nil, 0, fmt.Errorf("not a FlatGeobuf file or unsupported version")
+	}
+
+	headerSize := binary.LittleEndian.Uint32(prefix[8:])
+	if headerSize < 4 || headerSize > maxHeaderSize {
+		return nil, 0, fmt.Errorf("invalid header size (%v)", headerSize)
+	}
+
+	buf := make([]byte, headerSize)
+	if _, err := r.ReadAt(buf, int64(len(prefix))); err != nil {
+		return nil, 0, fmt.Errorf("reading header: %v", err)
+	}
+
+	if hdr, err = decodeHeader(buf); err != nil {
+		return nil, 0, fmt.Errorf("decoding header: %v", err)
+	}
+
+	return hdr, int64(len(prefix)) + int64(headerSize), nil
+}
+
+// decodeHeader decodes the Header table (See https://github.com/flatgeobuf/flatgeobuf/blob/master/src/fbs/header.fbs)
+func decodeHeader(buf []byte) (*header, error) {
+	t, err := rootTable(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var hdr header
+	if hdr.name, err = t.string(0); err != nil {
+		return nil, err
+	}
+	if hdr.envelope, err = t.float64s(1); err != nil {
+		return nil, err
+	}
+	if hdr.geometryType, err = t.uint8(2, geometryTypeUnknown); err != nil {
+		return nil, err
+	}
+	if hdr.hasZ, err = t.bool(3, false); err != nil {
+		return nil, err
+	}
+	if hdr.hasM, err = t.bool(4, false); err != nil {
+		return nil, err
+	}
+	if hdr.hasT, err = t.bool(5, false); err != nil {
+		return nil, err
+	}
+	if hdr.hasTM, err = t.bool(6, false); err != nil {
+		return nil, err
+	}
+	if hdr.columns, err = decodeColumns(t, 7); err != nil {
+		return nil, err
+	}
+	if hdr.featuresCount, err = t.uint64(8, 0); err != nil {
+		return nil, err
+	}
+	if hdr.indexNodeSize, err = t.uint16(9, 16); err != nil {
+		return nil, err
+	}
+
+	crs, ok, err := t.table(10)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if hdr.crsOrg, err = crs.string(0); err != nil {
+			return nil, err
+		}
+		if hdr.crsCode, err = crs.int32(1, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if hdr.title, err = t.string(11); err != nil {
+		return nil, err
+	}
+	if hdr.description, err = t.string(12); err != nil {
+		return nil, err
+	}
+
+	// an index with a node size of 1 can't be searched
+	if hdr.indexNodeSize == 1 {
+		return nil, fmt.Errorf("invalid index node size (1)")
+	}
+
+	return &hdr, nil
+}
+
+// decodeColumns decodes the vector of Column tables of the field of t. features
+// may declare their own columns, in the same way as the header.
+func decodeColumns(t table, field int) ([]Column, error) {
+	tables, err := t.tables(field)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]Column, len(tables))
+	for i, ct := range tables {
+		if columns[i].Name, err = ct.string(0); err != nil {
+			return nil, err
+		}
+		typ, err := ct.uint8(1, 0)
+		if err != nil {
+			return nil, err
+		}
+		columns[i].Type = ColumnType(typ)
+		if columns[i].Title, err = ct.string(2); err != nil {
+			return nil, err
+		}
+		if columns[i].Description, err = ct.string(3); err != nil {
+			return nil, err
+		}
+		if columns[i].Nullable, err = ct.bool(7, true); err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
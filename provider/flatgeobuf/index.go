@@ -0,0 +1,161 @@
+package flatgeobuf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/go-spatial/geom"
+)
+
+// the size of a node of the index: the bounding box and an offset
+const nodeItemSize = 40
+
+// packedRTree searches the packed Hilbert R-tree index of a FlatGeobuf file (See
+// https://github.com/flatgeobuf/flatgeobuf/blob/master/src/ts/packedrtree.ts).
+// The nodes are stored level by level starting with the root, the leaves last. The
+// nodes are read from the file as they're visited, so only the nodes intersecting
+// the searched extent are read.
+type packedRTree struct {
+	r io.ReaderAt
+	// the position of the index in the file
+	offset   int64
+	numItems uint64
+	numNodes uint64
+	nodeSize uint64
+	// the first and the last node (exclusive) of each level. the leaves are the
+	// first level and the root the last.
+	levelBounds [][2]uint64
+}
+
+// packedRTreeSize returns the size in bytes of the index of numItems with nodeSize
+func packedRTreeSize(numItems uint64, nodeSize uint16) (int64, error) {
+	if nodeSize == 0 || numItems == 0 {
+		return 0, nil
+	}
+	numNodes := uint64(0)
+	for _, b := range levelBounds(numItems, uint64(nodeSize)) {
+		numNodes += b[1] - b[0]
+	}
+	if numNodes > math.MaxInt64/nodeItemSize {
+		return 0, fmt.Errorf("invalid number of features (%v)", numItems)
+	}
+	return int64(numNodes * nodeItemSize), nil
+}
+
+func levelBounds(numItems, nodeSize uint64) [][2]uint64 {
+	// the number of nodes of each level, starting with the leaves
+	n := numItems
+	numNodes := n
+	levelNumNodes := []uint64{n}
+	for {
+		n = (n + nodeSize - 1) / nodeSize
+		numNodes += n
+		levelNumNodes = append(levelNumNodes, n)
+		if n == 1 {
+			break
+		}
+	}
+
+	bounds := make([][2]uint64, len(levelNumNodes))
+	end := numNodes
+	for i, size := range levelNumNodes {
+		bounds[i] = [2]uint64{end - size, end}
+		end -= size
+	}
+	return bounds
+}
+
+func newPackedRTree(r io.ReaderAt, offset int64, numItems uint64, nodeSize uint16) *packedRTree {
+	t := packedRTree{
+		r:           r,
+		offset:      offset,
+		numItems:    numItems,
+		nodeSize:    uint64(nodeSize),
+		levelBounds: levelBounds(numItems, uint64(nodeSize)),
+	}
+	t.numNodes = t.levelBounds[0][1]
+	return &t
+}
+
+// searchResult is a feature found in the index
+type searchResult struct {
+	// the position of the feature relative to the start of the features
+	offset uint64
+	// the position of the feature in the file, starting at 0
+	index uint64
+}
+
+// search returns the features with a bounding box intersecting ext, in the order
+// of the features in the file
+func (t *packedRTree) search(ext *geom.Extent) ([]searchResult, error) {
+	type queueItem struct {
+		node  uint64
+		level int
+	}
+
+	leavesOffset := t.levelBounds[0][0]
+	queue := []queueItem{{node: 0, level: len(t.levelBounds) - 1}}
+
+	var (
+		results []searchResult
+		buf     []byte
+	)
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		isLeaf := item.node >= leavesOffset
+		if isLeaf != (item.level == 0) {
+			return nil, fmt.Errorf("invalid index node (%v)", item.node)
+		}
+
+		// the children of a node are the nodeSize nodes starting at its offset,
+		// within the level
+		end := item.node + t.nodeSize
+		if levelEnd := t.levelBounds[item.level][1]; end > levelEnd {
+			end = levelEnd
+		}
+		if item.node >= end {
+			return nil, fmt.Errorf("invalid index node (%v)", item.node)
+		}
+
+		size := int((end - item.node) * nodeItemSize)
+		if cap(buf) < size {
+			buf = make([]byte, size)
+		}
+		buf = buf[:size]
+		if _, err := t.r.ReadAt(buf, t.offset+int64(item.node*nodeItemSize)); err != nil {
+			return nil, fmt.Errorf("reading index: %v", err)
+		}
+
+		for pos := item.node; pos < end; pos++ {
+			node := buf[(pos-item.node)*nodeItemSize:]
+			minX := math.Float64frombits(binary.LittleEndian.Uint64(node))
+			minY := math.Float64frombits(binary.LittleEndian.Uint64(node[8:]))
+			maxX := math.Float64frombits(binary.LittleEndian.Uint64(node[16:]))
+			maxY := math.Float64frombits(binary.LittleEndian.Uint64(node[24:]))
+			if maxX < ext.MinX() || maxY < ext.MinY() || minX > ext.MaxX() || minY > ext.MaxY() {
+				continue
+			}
+
+			offset := binary.LittleEndian.Uint64(node[32:])
+			if isLeaf {
+				results = append(results, searchResult{offset: offset, index: pos - leavesOffset})
+				continue
+			}
+			if offset >= t.numNodes {
+				return nil, fmt.Errorf("invalid index node (%v)", offset)
+			}
+			queue = append(queue, queueItem{node: offset, level: item.level - 1})
+		}
+	}
+
+	// the leaves are visited level by level, which is not necessarily the order of
+	// the features in the file
+	sort.Slice(results, func(i, j int) bool { return results[i].offset < results[j].offset })
+
+	return results, nil
+}
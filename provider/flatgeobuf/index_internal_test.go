@@ -0,0 +1,127 @@
+package flatgeobuf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/geom"
+)
+
+// buildIndex packs the bounding boxes into an index. the offset of each feature is
+// its position times 100.
+func buildIndex(bounds [][4]float64, nodeSize uint16) []byte {
+	levels := levelBounds(uint64(len(bounds)), uint64(nodeSize))
+	nodes := make([][4]float64, levels[0][1])
+	offsets := make([]uint64, levels[0][1])
+	for i, b := range bounds {
+		nodes[levels[0][0]+uint64(i)] = b
+		offsets[levels[0][0]+uint64(i)] = uint64(i) * 100
+	}
+
+	for l := 0; l < len(levels)-1; l++ {
+		parent := levels[l+1][0]
+		for child := levels[l][0]; child < levels[l][1]; child += uint64(nodeSize) {
+			b := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+			for c := child; c < child+uint64(nodeSize) && c < levels[l][1]; c++ {
+				b = [4]float64{math.Min(b[0], nodes[c][0]), math.Min(b[1], nodes[c][1]), math.Max(b[2], nodes[c][2]), math.Max(b[3], nodes[c][3])}
+			}
+			nodes[parent], offsets[parent] = b, child
+			parent++
+		}
+	}
+
+	var buf bytes.Buffer
+	for i := range nodes {
+		binary.Write(&buf, binary.LittleEndian, nodes[i])
+		binary.Write(&buf, binary.LittleEndian, offsets[i])
+	}
+	return buf.Bytes()
+}
+
+func TestPackedRTreeSearch(t *testing.T) {
+	type tcase struct {
+		numItems int
+		nodeSize uint16
+		ext      *geom.Extent
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			// a row of unit squares along the diagonal
+			bounds := make([][4]float64, tc.numItems)
+			for i := range bounds {
+				bounds[i] = [4]float64{float64(i), float64(i), float64(i) + 1, float64(i) + 1}
+			}
+
+			index := buildIndex(bounds, tc.nodeSize)
+			size, err := packedRTreeSize(uint64(tc.numItems), tc.nodeSize)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if size != int64(len(index)) {
+				t.Errorf("size, expected %v got %v", len(index), size)
+			}
+
+			// the index follows a header in the file
+			header := []byte("header")
+			tree := newPackedRTree(bytes.NewReader(append(header, index...)), int64(len(header)), uint64(tc.numItems), tc.nodeSize)
+
+			results, err := tree.search(tc.ext)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			var expected []searchResult
+			for i, b := range bounds {
+				if b[2] < tc.ext.MinX() || b[3] < tc.ext.MinY() || b[0] > tc.ext.MaxX() || b[1] > tc.ext.MaxY() {
+					continue
+				}
+				expected = append(expected, searchResult{offset: uint64(i) * 100, index: uint64(i)})
+			}
+
+			if !reflect.DeepEqual(results, expected) {
+				t.Errorf("results, expected %v got %v", expected, results)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"single item": {
+			numItems: 1,
+			nodeSize: 16,
+			ext:      &geom.Extent{0, 0, 10, 10},
+		},
+		"single level": {
+			numItems: 10,
+			nodeSize: 16,
+			ext:      &geom.Extent{2.5, 2.5, 4.5, 4.5},
+		},
+		"levels": {
+			numItems: 1000,
+			nodeSize: 4,
+			ext:      &geom.Extent{100.5, 100.5, 130, 130},
+		},
+		"touching": {
+			numItems: 1000,
+			nodeSize: 16,
+			ext:      &geom.Extent{500, 500, 500, 500},
+		},
+		"all": {
+			numItems: 100,
+			nodeSize: 3,
+			ext:      &geom.Extent{-1, -1, 200, 200},
+		},
+		"none": {
+			numItems: 100,
+			nodeSize: 16,
+			ext:      &geom.Extent{10, -20, 20, -10},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
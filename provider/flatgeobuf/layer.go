@@ -0,0 +1,307 @@
+package flatgeobuf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/provider"
+)
+
+type Layer struct {
+	name     string
+	geomType geom.Geometry
+	srid     uint64
+
+	path   string
+	file   *os.File
+	header *header
+	// the position of the first feature in the file
+	featuresOffset int64
+	// nil when the file has no index
+	index *packedRTree
+	// the columns encoded as tags. nil when all the columns are tags.
+	tagColumns map[string]bool
+	// the column of the feature id. the position of the feature is used when it's
+	// not configured.
+	idColumn string
+}
+
+func (l Layer) Name() string            { return l.name }
+func (l Layer) GeomType() geom.Geometry { return l.geomType }
+func (l Layer) SRID() uint64            { return l.srid }
+
+// Columns returns the columns declared in the header of the file
+func (l Layer) Columns() []Column { return l.header.columns }
+
+// Title returns the title of the dataset from the header of the file
+func (l Layer) Title() string { return l.header.title }
+
+// Description returns the description of the dataset from the header of the file
+func (l Layer) Description() string { return l.header.description }
+
+// FeaturesCount returns the number of features declared in the header of the file,
+// 0 when it's unknown
+func (l Layer) FeaturesCount() uint64 { return l.header.featuresCount }
+
+// Bounds returns the envelope of the features from the header of the file, nil
+// when the header does not declare it
+func (l Layer) Bounds() *geom.Extent {
+	if len(l.header.envelope) < 4 {
+		return nil
+	}
+	// the envelope has twice as many values when the file has Z or M values
+	dims := len(l.header.envelope) / 2
+	env := l.header.envelope
+	return &geom.Extent{env[0], env[1], env[dims], env[dims+1]}
+}
+
+// geomType returns the geometry of the geometry type declared in the header. the
+// geometry is nil for files of mixed geometry types.
+func geomType(geometryType uint8) (geom.Geometry, bool) {
+	switch geometryType {
+	case geometryTypeUnknown:
+		return nil, true
+	case geometryTypePoint:
+		return geom.Point{}, true
+	case geometryTypeLineString:
+		return geom.LineString{}, true
+	case geometryTypePolygon:
+		return geom.Polygon{}, true
+	case geometryTypeMultiPoint:
+		return geom.MultiPoint{}, true
+	case geometryTypeMultiLineString:
+		return geom.MultiLineString{}, true
+	case geometryTypeMultiPolygon:
+		return geom.MultiPolygon{}, true
+	case geometryTypeCollection:
+		return geom.Collection{}, true
+	default:
+		return nil, false
+	}
+}
+
+// openLayer opens the FlatGeobuf file at path and reads its header. fields limits
+// the tags to the listed columns, all the columns are tags when it's empty. srid is
+// used when it's not 0, otherwise the SRID is read from the header.
+func openLayer(name, path, idFieldname string, fields []string, srid uint64) (*Layer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, ErrInvalidFilePath{FilePath: path}
+	}
+
+	layer := Layer{
+		name: name,
+		path: path,
+		file: file,
+	}
+
+	var headerSize int64
+	if layer.header, headerSize, err = readHeader(file); err != nil {
+		layer.Close()
+		return nil, ErrInvalidFile{FilePath: path, Reason: err.Error()}
+	}
+
+	var ok bool
+	if layer.geomType, ok = geomType(layer.header.geometryType); !ok {
+		layer.Close()
+		return nil, ErrUnsupportedGeometryType{FilePath: path, GeometryType: layer.header.geometryType}
+	}
+
+	// the organization defaults to EPSG
+	if srid == 0 && layer.header.crsCode > 0 && (layer.header.crsOrg == "" || strings.EqualFold(layer.header.crsOrg, "EPSG")) {
+		srid = uint64(layer.header.crsCode)
+	}
+	if srid == 0 {
+		layer.Close()
+		return nil, ErrUnknownSRID{FilePath: path}
+	}
+	layer.srid = srid
+
+	// the index is only usable when the number of features is known
+	indexSize := int64(0)
+	if layer.header.indexNodeSize > 0 && layer.header.featuresCount > 0 {
+		if indexSize, err = packedRTreeSize(layer.header.featuresCount, layer.header.indexNodeSize); err != nil {
+			layer.Close()
+			return nil, ErrInvalidFile{FilePath: path, Reason: err.Error()}
+		}
+		layer.index = newPackedRTree(file, headerSize, layer.header.featuresCount, layer.header.indexNodeSize)
+	}
+	layer.featuresOffset = headerSize + indexSize
+
+	hasColumn := func(name string) bool {
+		for _, c := range layer.header.columns {
+			if c.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if idFieldname != "" {
+		if !hasColumn(idFieldname) {
+			layer.Close()
+			return nil, ErrUnknownField{FilePath: path, Field: idFieldname}
+		}
+		layer.idColumn = idFieldname
+	}
+
+	if len(fields) > 0 {
+		layer.tagColumns = make(map[string]bool, len(fields))
+	}
+	for _, f := range fields {
+		if !hasColumn(f) {
+			layer.Close()
+			return nil, ErrUnknownField{FilePath: path, Field: f}
+		}
+		layer.tagColumns[f] = true
+	}
+
+	return &layer, nil
+}
+
+// features calls fn with the features with a bounding box intersecting ext. The
+// features are found with the index of the file and read one by one. Files without
+// an index are read sequentially.
+func (l *Layer) features(ext *geom.Extent, fn func(f *provider.Feature) error) error {
+	if l.index == nil {
+		return l.scan(ext, fn)
+	}
+
+	results, err := l.index.search(ext)
+	if err != nil {
+		return ErrInvalidFile{FilePath: l.path, Reason: err.Error()}
+	}
+
+	var buf []byte
+	for _, res := range results {
+		offset := l.featuresOffset + int64(res.offset)
+
+		var size [4]byte
+		if _, err := l.file.ReadAt(size[:], offset); err != nil {
+			return ErrInvalidFile{FilePath: l.path, Reason: fmt.Sprintf("reading feature (%v): %v", res.index, err)}
+		}
+
+		n := int(binary.LittleEndian.Uint32(size[:]))
+		if cap(buf) < n {
+			buf = make([]byte, n)
+		}
+		buf = buf[:n]
+		if _, err := l.file.ReadAt(buf, offset+4); err != nil {
+			return ErrInvalidFile{FilePath: l.path, Reason: fmt.Sprintf("reading feature (%v): %v", res.index, err)}
+		}
+
+		feature, err := l.feature(buf, res.index)
+		if err != nil {
+			return err
+		}
+		if feature == nil {
+			continue
+		}
+
+		if err := fn(feature); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scan reads all the features of the file and calls fn with the features
+// intersecting ext
+func (l *Layer) scan(ext *geom.Extent, fn func(f *provider.Feature) error) error {
+	r := bufio.NewReader(io.NewSectionReader(l.file, l.featuresOffset, math.MaxInt64-l.featuresOffset))
+
+	var buf []byte
+	for i := uint64(0); ; i++ {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return ErrInvalidFile{FilePath: l.path, Reason: fmt.Sprintf("reading feature (%v): %v", i, err)}
+		}
+
+		n := int(binary.LittleEndian.Uint32(size[:]))
+		if cap(buf) < n {
+			buf = make([]byte, n)
+		}
+		buf = buf[:n]
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return ErrInvalidFile{FilePath: l.path, Reason: fmt.Sprintf("reading feature (%v): %v", i, err)}
+		}
+
+		feature, err := l.feature(buf, i)
+		if err != nil {
+			return err
+		}
+		if feature == nil {
+			continue
+		}
+
+		bbox, err := geom.NewExtentFromGeometry(feature.Geometry)
+		if err != nil {
+			return ErrInvalidFile{FilePath: l.path, Reason: fmt.Sprintf("feature (%v): %v", i, err)}
+		}
+		if bbox.MaxX() < ext.MinX() || bbox.MaxY() < ext.MinY() || bbox.MinX() > ext.MaxX() || bbox.MinY() > ext.MaxY() {
+			continue
+		}
+
+		if err := fn(feature); err != nil {
+			return err
+		}
+	}
+}
+
+// feature decodes the feature at the position index of the file. The feature is
+// nil when it has no geometry.
+func (l *Layer) feature(buf []byte, index uint64) (*provider.Feature, error) {
+	geo, properties, err := decodeFeature(buf, l.header.geometryType, l.header.columns)
+	if err != nil {
+		return nil, ErrInvalidFile{FilePath: l.path, Reason: fmt.Sprintf("feature (%v): %v", index, err)}
+	}
+	if geo == nil {
+		return nil, nil
+	}
+
+	feature := provider.Feature{
+		// features are numbered starting at 1, like the records of the other file providers
+		ID:       index + 1,
+		Geometry: geo,
+		SRID:     l.srid,
+		Tags:     map[string]interface{}{},
+	}
+
+	if l.idColumn != "" {
+		if v, ok := properties[l.idColumn]; ok {
+			if feature.ID, err = provider.ConvertFeatureID(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for k, v := range properties {
+		if l.tagColumns == nil && k == l.idColumn {
+			continue
+		}
+		if l.tagColumns != nil && !l.tagColumns[k] {
+			continue
+		}
+		feature.Tags[k] = v
+	}
+
+	return &feature, nil
+}
+
+// Close closes the file of the layer
+func (l *Layer) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,221 @@
+package geojson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/go-spatial/geom"
+)
+
+// object is a GeoJSON object (RFC 7946): a geometry, a feature or a feature collection
+type object struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometries  []object        `json:"geometries"`
+	Geometry    *object         `json:"geometry"`
+	ID          interface{}     `json:"id"`
+	// the numbers are decoded as json.Number
+	Properties map[string]interface{} `json:"properties"`
+	Features   []object               `json:"features"`
+}
+
+// feature is a decoded GeoJSON feature
+type feature struct {
+	// the id member of the feature, nil when it's not set
+	id         interface{}
+	geometry   geom.Geometry
+	properties map[string]interface{}
+}
+
+// decodeObject decodes a GeoJSON object keeping the numbers of the properties as
+// json.Number
+func decodeObject(b []byte) (obj object, err error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err = dec.Decode(&obj)
+	return obj, err
+}
+
+// decodeDocument decodes the features of a GeoJSON document. The document is a
+// feature collection, a feature or a geometry.
+func decodeDocument(b []byte) ([]feature, error) {
+	obj, err := decodeObject(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj.Type == "FeatureCollection" {
+		features := make([]feature, 0, len(obj.Features))
+		for i := range obj.Features {
+			f, err := decodeFeature(&obj.Features[i])
+			if err != nil {
+				return nil, err
+			}
+			features = append(features, f)
+		}
+		return features, nil
+	}
+
+	f, err := decodeFeature(&obj)
+	if err != nil {
+		return nil, err
+	}
+	return []feature{f}, nil
+}
+
+// decodeLines decodes newline-delimited GeoJSON, with a feature or a geometry per
+// line. Blank lines and the record separators of GeoJSON text sequences (RFC 8142)
+// are skipped. line is the line of the error.
+func decodeLines(r io.Reader) (features []feature, line int, err error) {
+	br := bufio.NewReader(r)
+
+	for {
+		b, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, line, readErr
+		}
+		line++
+
+		if b = bytes.TrimSpace(bytes.TrimLeft(b, "\x1e")); len(b) > 0 {
+			obj, err := decodeObject(b)
+			if err != nil {
+				return nil, line, err
+			}
+
+			f, err := decodeFeature(&obj)
+			if err != nil {
+				return nil, line, err
+			}
+			features = append(features, f)
+		}
+
+		if readErr == io.EOF {
+			return features, 0, nil
+		}
+	}
+}
+
+// decodeFeature decodes a feature. Geometries are decoded as features without properties.
+func decodeFeature(obj *object) (feature, error) {
+	if obj.Type != "Feature" {
+		geo, err := decodeGeometry(obj)
+		return feature{geometry: geo}, err
+	}
+
+	geo, err := decodeGeometry(obj.Geometry)
+	if err != nil {
+		return feature{}, err
+	}
+
+	return feature{
+		id:         obj.ID,
+		geometry:   geo,
+		properties: obj.Properties,
+	}, nil
+}
+
+// decodeGeometry decodes a geometry object. The geometry of a nil object (a null
+// geometry) is nil. The closing positions of the polygon rings are dropped and
+// positions are decoded as 2D.
+func decodeGeometry(obj *object) (geom.Geometry, error) {
+	if obj == nil {
+		return nil, nil
+	}
+
+	var err error
+	switch obj.Type {
+	case "Point":
+		var pt geom.Point
+		err = json.Unmarshal(obj.Coordinates, &pt)
+		return pt, err
+
+	case "MultiPoint":
+		var mp geom.MultiPoint
+		err = json.Unmarshal(obj.Coordinates, &mp)
+		return mp, err
+
+	case "LineString":
+		var ls geom.LineString
+		err = json.Unmarshal(obj.Coordinates, &ls)
+		return ls, err
+
+	case "MultiLineString":
+		var mls geom.MultiLineString
+		err = json.Unmarshal(obj.Coordinates, &mls)
+		return mls, err
+
+	case "Polygon":
+		var poly geom.Polygon
+		if err = json.Unmarshal(obj.Coordinates, &poly); err != nil {
+			return nil, err
+		}
+		return geom.Polygon(openRings(poly)), nil
+
+	case "MultiPolygon":
+		var mpoly geom.MultiPolygon
+		if err = json.Unmarshal(obj.Coordinates, &mpoly); err != nil {
+			return nil, err
+		}
+		for i := range mpoly {
+			mpoly[i] = openRings(mpoly[i])
+		}
+		return mpoly, nil
+
+	case "GeometryCollection":
+		collection := make(geom.Collection, 0, len(obj.Geometries))
+		for i := range obj.Geometries {
+			geo, err := decodeGeometry(&obj.Geometries[i])
+			if err != nil {
+				return nil, err
+			}
+			if geo != nil {
+				collection = append(collection, geo)
+			}
+		}
+		return collection, nil
+
+	default:
+		return nil, ErrUnsupportedType{Type: obj.Type}
+	}
+}
+
+// openRings drops the closing position of the rings
+func openRings(rings [][][2]float64) [][][2]float64 {
+	for i, ring := range rings {
+		if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+			rings[i] = ring[:len(ring)-1]
+		}
+	}
+	return rings
+}
+
+// tagValue converts a property to a tag value. Integers are int64 and other numbers
+// float64. Objects and arrays are encoded as JSON as vector tiles can't hold them.
+// nil is returned for null.
+func tagValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+			return i
+		}
+		f, err := val.Float64()
+		if err != nil {
+			return string(val)
+		}
+		return f
+
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(b)
+
+	default:
+		return val
+	}
+}
@@ -0,0 +1,42 @@
+package geojson
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingLayerName = errors.New("geojson: layer is missing 'name'")
+)
+
+type ErrInvalidFilePath struct {
+	FilePath string
+}
+
+func (e ErrInvalidFilePath) Error() string {
+	return fmt.Sprintf("geojson: invalid filepath: %v", e.FilePath)
+}
+
+// ErrInvalidGeoJSON is returned when a file can't be decoded. Line is the line of
+// the feature in newline-delimited files and 0 otherwise.
+type ErrInvalidGeoJSON struct {
+	FilePath string
+	Line     int
+	Err      error
+}
+
+func (e ErrInvalidGeoJSON) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("geojson: invalid GeoJSON in %v line %v: %v", e.FilePath, e.Line, e.Err)
+	}
+	return fmt.Sprintf("geojson: invalid GeoJSON in %v: %v", e.FilePath, e.Err)
+}
+
+// ErrUnsupportedType is returned for GeoJSON objects of an unknown type
+type ErrUnsupportedType struct {
+	Type string
+}
+
+func (e ErrUnsupportedType) Error() string {
+	return fmt.Sprintf("geojson: unsupported type (%v)", e.Type)
+}
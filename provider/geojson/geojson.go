@@ -0,0 +1,248 @@
+package geojson
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/maths/proj"
+	"github.com/go-spatial/tegola/provider"
+)
+
+const (
+	Name = "geojson"
+	// GeoJSON coordinates are WGS84 (RFC 7946)
+	DefaultSRID = tegola.WGS84
+)
+
+// config keys
+const (
+	ConfigKeyDir         = "dir"
+	ConfigKeyWatch       = "watch"
+	ConfigKeyLayers      = "layers"
+	ConfigKeyLayerName   = "name"
+	ConfigKeyFilePath    = "filepath"
+	ConfigKeyGeomIDField = "id_fieldname"
+	ConfigKeyFields      = "fields"
+	ConfigKeySRID        = "srid"
+)
+
+// watchInterval is how often the files are checked for changes when watching them
+var watchInterval = 5 * time.Second
+
+func init() {
+	provider.Register(Name, NewTileProvider, Cleanup)
+}
+
+// Provider serves the features of GeoJSON and newline-delimited GeoJSON files. The
+// features are loaded into memory and indexed when the provider is created.
+type Provider struct {
+	// the directory relative layer file paths are resolved against
+	Dir string
+	// map of layer name and the loaded files
+	layers map[string]*Layer
+	// closed to stop watching the files
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewTileProvider instantiates and returns a new GeoJSON provider or an error. The
+// config must contain the layers, each with a name and the path or glob of its files.
+// When watch is true the files are reloaded when they change.
+func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
+	dir, err := config.String(ConfigKeyDir, new(string))
+	if err != nil {
+		return nil, err
+	}
+
+	watch, err := config.Bool(ConfigKeyWatch, new(bool))
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := config.MapSlice(ConfigKeyLayers)
+	if err != nil {
+		return nil, err
+	}
+
+	p := Provider{
+		Dir:    dir,
+		layers: make(map[string]*Layer),
+		done:   make(chan struct{}),
+	}
+
+	lyrsSeen := make(map[string]int)
+	for i, layerConf := range layers {
+		layerName, err := layerConf.String(ConfigKeyLayerName, nil)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) we got the following error trying to get the layer's name field: %v", i, err)
+		}
+		if layerName == "" {
+			return nil, ErrMissingLayerName
+		}
+
+		// check if we have already seen this layer
+		if j, ok := lyrsSeen[layerName]; ok {
+			return nil, fmt.Errorf("layer name (%v) is duplicated in both layer %v and layer %v", layerName, i, j)
+		}
+		lyrsSeen[layerName] = i
+
+		// the file defaults to the layer name in the directory
+		pattern := layerName + ".geojson"
+		if pattern, err = layerConf.String(ConfigKeyFilePath, &pattern); err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+
+		idFieldname, err := layerConf.String(ConfigKeyGeomIDField, new(string))
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+
+		fields, err := layerConf.StringSlice(ConfigKeyFields)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v %v field had the following error: %v", i, layerName, ConfigKeyFields, err)
+		}
+
+		srid := DefaultSRID
+		if srid, err = layerConf.Int(ConfigKeySRID, &srid); err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+
+		layer := Layer{
+			name:        layerName,
+			pattern:     pattern,
+			idFieldname: idFieldname,
+			fields:      fields,
+			srid:        uint64(srid),
+		}
+		if _, err := layer.load(); err != nil {
+			return nil, err
+		}
+
+		p.layers[layerName] = &layer
+	}
+
+	if watch {
+		go p.watch()
+	}
+
+	// track the provider so we can clean it up later
+	providersMu.Lock()
+	providers = append(providers, &p)
+	providersMu.Unlock()
+
+	return &p, nil
+}
+
+func (p *Provider) Layers() ([]provider.LayerInfo, error) {
+	ls := make([]provider.LayerInfo, 0, len(p.layers))
+	for _, layer := range p.layers {
+		ls = append(ls, layer)
+	}
+	return ls, nil
+}
+
+func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	pLayer, ok := p.layers[layer]
+	if !ok {
+		return fmt.Errorf("geojson: layer (%v) not found", layer)
+	}
+
+	// read the tile extent
+	tileBBox, tileSRID := tile.BufferedExtent()
+
+	// check if the SRID of the layer differs from that of the tile
+	if pLayer.srid != tileSRID {
+		var err error
+		if tileBBox, err = proj.TransformExtent(tileSRID, pLayer.srid, tileBBox); err != nil {
+			return fmt.Errorf("error converting tile extent: %v ", err)
+		}
+	}
+
+	features := pLayer.features(tileBBox)
+	for i := range features {
+		// check if the context cancelled or timed out
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := fn(&features[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// watch reloads the layers when their files change until the provider is closed
+func (p *Provider) watch() {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+
+		case <-ticker.C:
+			for name, layer := range p.layers {
+				reloaded, err := layer.load()
+				if err != nil {
+					log.Errorf("geojson: layer (%v) not reloaded, still serving the previous features: %v", name, err)
+					continue
+				}
+				if reloaded {
+					log.Infof("geojson: layer (%v) reloaded", name)
+				}
+			}
+		}
+	}
+}
+
+// Close stops watching the files of the Provider. It's used to release the provider
+// once it's no longer in use (i.e. after a config reload)
+func (p *Provider) Close() error {
+	p.closeOnce.Do(func() { close(p.done) })
+
+	// the provider no longer needs to be cleaned up
+	providersMu.Lock()
+	for i := range providers {
+		if providers[i] == p {
+			providers = append(providers[:i], providers[i+1:]...)
+			break
+		}
+	}
+	providersMu.Unlock()
+
+	return nil
+}
+
+// reference to all instantiated providers
+var (
+	providersMu sync.Mutex
+	providers   []*Provider
+)
+
+// Cleanup will stop watching the files of all previously instantiated Provider instances
+func Cleanup() {
+	providersMu.Lock()
+	open := providers
+	providers = nil
+	providersMu.Unlock()
+
+	if len(open) > 0 {
+		log.Infof("cleaning up geojson providers")
+	}
+
+	for _, p := range open {
+		p.Close()
+	}
+}
@@ -0,0 +1,132 @@
+package geojson
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/dict"
+)
+
+func TestWatch(t *testing.T) {
+	watchInterval = 10 * time.Millisecond
+	defer func() { watchInterval = 5 * time.Second }()
+
+	dir, err := ioutil.TempDir("", "tegola-geojson")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "places.ndjson")
+	modTime := time.Now().Add(-time.Hour)
+
+	// writeFile replaces the file with a new modification time. the file is renamed
+	// into place so it's not read before it's written.
+	writeFile := func(contents string) {
+		tmp := path + ".tmp"
+		if err := ioutil.WriteFile(tmp, []byte(contents), 0644); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		modTime = modTime.Add(time.Minute)
+		if err := os.Chtimes(tmp, modTime, modTime); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	writeFile(`{"type":"Point","coordinates":[1,1]}`)
+
+	tiler, err := NewTileProvider(dict.Dict{
+		"watch": true,
+		"layers": []map[string]interface{}{
+			{"name": "places", "filepath": path},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	p := tiler.(*Provider)
+	defer p.Close()
+
+	world := &geom.Extent{-180, -90, 180, 90}
+
+	// waitFor waits for the layer to hold n features
+	waitFor := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for len(p.layers["places"].features(world)) != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("features, expected %v got %v", n, len(p.layers["places"].features(world)))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFor(1)
+
+	writeFile("{\"type\":\"Point\",\"coordinates\":[1,1]}\n{\"type\":\"Point\",\"coordinates\":[2,2]}\n")
+	waitFor(2)
+
+	// invalid files are not loaded
+	writeFile(`{"type":"Point"`)
+	time.Sleep(10 * watchInterval)
+	waitFor(2)
+
+	writeFile(`{"type":"MultiPoint","coordinates":[[1,1],[2,2],[3,3]]}`)
+	waitFor(1)
+	if _, ok := p.layers["places"].GeomType().(geom.MultiPoint); !ok {
+		t.Errorf("geometry type, expected geom.MultiPoint got %T", p.layers["places"].GeomType())
+	}
+}
+
+func TestCommonGeomType(t *testing.T) {
+	type tcase struct {
+		geoms    []geom.Geometry
+		expected geom.Geometry
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			var common geom.Geometry
+			for i, g := range tc.geoms {
+				common = commonGeomType(common, g, i == 0)
+			}
+			if reflect.TypeOf(common) != reflect.TypeOf(tc.expected) {
+				t.Errorf("geometry type, expected %T got %T", tc.expected, common)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"points": {
+			geoms:    []geom.Geometry{geom.Point{1, 1}, geom.Point{2, 2}},
+			expected: geom.Point{},
+		},
+		"single and multi": {
+			geoms:    []geom.Geometry{geom.LineString{{1, 1}, {2, 2}}, geom.MultiLineString{}, geom.LineString{}},
+			expected: geom.MultiLineString{},
+		},
+		"mixed": {
+			geoms:    []geom.Geometry{geom.Point{1, 1}, geom.Polygon{}},
+			expected: nil,
+		},
+		"mixed after multi": {
+			geoms:    []geom.Geometry{geom.Polygon{}, geom.MultiPolygon{}, geom.Point{}},
+			expected: nil,
+		},
+		"collection": {
+			geoms:    []geom.Geometry{geom.Collection{}, geom.Point{}},
+			expected: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
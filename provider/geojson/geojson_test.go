@@ -0,0 +1,254 @@
+package geojson_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/geojson"
+)
+
+func TestNewTileProvider(t *testing.T) {
+	type tcase struct {
+		config dict.Dict
+		// the expected geometry types of the layers
+		expectedLayers map[string]geom.Geometry
+		expectedErr    error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			p, err := geojson.NewTileProvider(tc.config)
+			if tc.expectedErr != nil {
+				if !reflect.DeepEqual(err, tc.expectedErr) {
+					t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			defer p.(*geojson.Provider).Close()
+
+			ls, err := p.Layers()
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if len(ls) != len(tc.expectedLayers) {
+				t.Fatalf("layers, expected %v got %v", len(tc.expectedLayers), len(ls))
+			}
+			for _, l := range ls {
+				expected, ok := tc.expectedLayers[l.Name()]
+				if !ok {
+					t.Errorf("unexpected layer %v", l.Name())
+					continue
+				}
+				if reflect.TypeOf(l.GeomType()) != reflect.TypeOf(expected) {
+					t.Errorf("layer (%v) geometry type, expected %T got %T", l.Name(), expected, l.GeomType())
+				}
+				if l.SRID() != tegola.WGS84 {
+					t.Errorf("layer (%v) srid, expected %v got %v", l.Name(), tegola.WGS84, l.SRID())
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"layers": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "places"},
+					{"name": "roads", "filepath": "roads.ndjson"},
+					{"name": "parks"},
+					{"name": "all", "filepath": "*.geojson"},
+				},
+			},
+			expectedLayers: map[string]geom.Geometry{
+				"places": geom.MultiPoint{},
+				"roads":  geom.MultiLineString{},
+				"parks":  geom.Polygon{},
+				// points and polygons
+				"all": nil,
+			},
+		},
+		"missing layer name": {
+			config: dict.Dict{
+				"layers": []map[string]interface{}{
+					{"name": ""},
+				},
+			},
+			expectedErr: geojson.ErrMissingLayerName,
+		},
+		"missing file": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "rivers"},
+				},
+			},
+			expectedErr: geojson.ErrInvalidFilePath{FilePath: "testdata/rivers.geojson"},
+		},
+		"unsupported type": {
+			config: dict.Dict{
+				"layers": []map[string]interface{}{
+					{"name": "invalid", "filepath": "testdata/invalid.ndjson"},
+				},
+			},
+			expectedErr: geojson.ErrInvalidGeoJSON{
+				FilePath: "testdata/invalid.ndjson",
+				Line:     2,
+				Err:      geojson.ErrUnsupportedType{Type: "Circle"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestTileFeatures(t *testing.T) {
+	type tcase struct {
+		layerConfig      map[string]interface{}
+		tile             provider.Tile
+		expectedFeatures []provider.Feature
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			p, err := geojson.NewTileProvider(dict.Dict{
+				"dir":    "testdata",
+				"layers": []map[string]interface{}{tc.layerConfig},
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			defer p.(*geojson.Provider).Close()
+
+			// the features handed out can be modified without affecting the layer
+			err = p.TileFeatures(context.Background(), tc.layerConfig["name"].(string), tc.tile, func(f *provider.Feature) error {
+				f.Tags["modified"] = true
+				if ls, ok := f.Geometry.(geom.LineString); ok {
+					ls[0] = [2]float64{}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			var features []provider.Feature
+			err = p.TileFeatures(context.Background(), tc.layerConfig["name"].(string), tc.tile, func(f *provider.Feature) error {
+				features = append(features, *f)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if !reflect.DeepEqual(features, tc.expectedFeatures) {
+				t.Errorf("features, expected %v got %v", tc.expectedFeatures, features)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"feature collection": {
+			layerConfig: map[string]interface{}{"name": "places"},
+			tile:        provider.NewTile(0, 0, 0, 64, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID:       10,
+					Geometry: geom.Point{-122.42, 37.77},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"name": "San Francisco", "population": int64(815201), "area": 121.4, "capital": false},
+				},
+				{
+					// the id is not a number so the feature is numbered
+					ID:       2,
+					Geometry: geom.Point{2.35, 48.86},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"name": "Paris", "population": int64(2102650), "capital": true, "districts": "[1,2,3]"},
+				},
+				{
+					ID:       40,
+					Geometry: geom.MultiPoint{{139.69, 35.69}, {139.7, 35.7}},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"name": "Tokyo", "meta": `{"source":"osm"}`},
+				},
+			},
+		},
+		"newline-delimited": {
+			layerConfig: map[string]interface{}{"name": "roads", "filepath": "roads.ndjson", "fields": []string{"ref"}},
+			// the tile east of the antimeridian in the north
+			tile: provider.NewTile(1, 1, 0, 0, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID:       1,
+					Geometry: geom.LineString{{0, 0}, {1, 1}},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"ref": "A1"},
+				},
+				{
+					ID:       2,
+					Geometry: geom.MultiLineString{{{10, 10}, {11, 11}}, {{12, 12}, {13, 12}}},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"ref": "B2"},
+				},
+			},
+		},
+		"id fieldname": {
+			layerConfig: map[string]interface{}{"name": "roads", "filepath": "roads.ndjson", "id_fieldname": "lanes"},
+			tile:        provider.NewTile(0, 0, 0, 0, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID:       2,
+					Geometry: geom.LineString{{0, 0}, {1, 1}},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"ref": "A1"},
+				},
+				{
+					ID:       4,
+					Geometry: geom.MultiLineString{{{10, 10}, {11, 11}}, {{12, 12}, {13, 12}}},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"ref": "B2"},
+				},
+				{
+					// a geometry without properties
+					ID:       3,
+					Geometry: geom.LineString{{-50, -50}, {-40, -40}},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{},
+				},
+			},
+		},
+		"polygon": {
+			layerConfig: map[string]interface{}{"name": "parks"},
+			tile:        provider.NewTile(0, 0, 0, 0, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID: 1,
+					Geometry: geom.Polygon{
+						{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+						{{2, 2}, {2, 4}, {4, 4}, {4, 2}},
+					},
+					SRID: tegola.WGS84,
+					Tags: map[string]interface{}{"name": "Central"},
+				},
+			},
+		},
+		"no features": {
+			layerConfig: map[string]interface{}{"name": "parks"},
+			tile:        provider.NewTile(2, 0, 3, 0, tegola.WebMercator),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -0,0 +1,338 @@
+package geojson
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/internal/rtree"
+	"github.com/go-spatial/tegola/provider"
+)
+
+// the extensions of newline-delimited GeoJSON files
+var lineDelimitedExts = map[string]bool{
+	".ndjson":     true,
+	".jsonl":      true,
+	".geojsonl":   true,
+	".geojsons":   true,
+	".geojsonseq": true,
+}
+
+type Layer struct {
+	name string
+	// the path or glob of the layer's files
+	pattern     string
+	idFieldname string
+	fields      []string
+	srid        uint64
+
+	// the state of the files when they were last loaded, successfully or not. It's
+	// only used while loading so it's not guarded by mu.
+	files map[string]fileState
+
+	// guards data, which is replaced when the files are reloaded
+	mu   sync.RWMutex
+	data *layerData
+}
+
+// layerData holds the features of the layer loaded from the files
+type layerData struct {
+	features []provider.Feature
+	index    *rtree.RTree
+	geomType geom.Geometry
+}
+
+// fileState is used to detect the changes of a file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func (l *Layer) Name() string { return l.name }
+func (l *Layer) SRID() uint64 { return l.srid }
+
+func (l *Layer) GeomType() geom.Geometry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.data.geomType
+}
+
+// fileStates returns the state of the files matching the pattern
+func (l *Layer) fileStates() (map[string]fileState, error) {
+	paths, err := filepath.Glob(l.pattern)
+	if err != nil || len(paths) == 0 {
+		return nil, ErrInvalidFilePath{FilePath: l.pattern}
+	}
+
+	states := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, ErrInvalidFilePath{FilePath: path}
+		}
+		if fi.IsDir() {
+			continue
+		}
+		states[path] = fileState{modTime: fi.ModTime(), size: fi.Size()}
+	}
+	if len(states) == 0 {
+		return nil, ErrInvalidFilePath{FilePath: l.pattern}
+	}
+
+	return states, nil
+}
+
+// load reads the features of the files when they differ from the files last
+// loaded. reloaded is false if the files have not changed. When loading fails
+// the previous features are kept and the files are not loaded again until they
+// change.
+func (l *Layer) load() (reloaded bool, err error) {
+	states, err := l.fileStates()
+	if err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(states, l.files) {
+		return false, nil
+	}
+	l.files = states
+
+	paths := make([]string, 0, len(states))
+	for path := range states {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var features []feature
+	for _, path := range paths {
+		fs, err := readFile(path)
+		if err != nil {
+			return false, err
+		}
+		features = append(features, fs...)
+	}
+
+	data, err := l.newLayerData(features)
+	if err != nil {
+		return false, err
+	}
+
+	l.mu.Lock()
+	l.data = data
+	l.mu.Unlock()
+
+	return true, nil
+}
+
+// readFile decodes the features of a GeoJSON or newline-delimited GeoJSON file
+func readFile(path string) ([]feature, error) {
+	if lineDelimitedExts[strings.ToLower(filepath.Ext(path))] {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, ErrInvalidFilePath{FilePath: path}
+		}
+		defer f.Close()
+
+		features, line, err := decodeLines(f)
+		if err != nil {
+			return nil, ErrInvalidGeoJSON{FilePath: path, Line: line, Err: err}
+		}
+		return features, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ErrInvalidFilePath{FilePath: path}
+	}
+
+	features, err := decodeDocument(b)
+	if err != nil {
+		return nil, ErrInvalidGeoJSON{FilePath: path, Err: err}
+	}
+	return features, nil
+}
+
+// newLayerData converts the decoded features to the features of the layer and
+// indexes them
+func (l *Layer) newLayerData(decoded []feature) (*layerData, error) {
+	data := layerData{
+		features: make([]provider.Feature, 0, len(decoded)),
+	}
+	var bounds []*geom.Extent
+
+	for i, f := range decoded {
+		// features without a geometry can't be tiled
+		if f.geometry == nil {
+			continue
+		}
+
+		feature := provider.Feature{
+			// features are numbered from 1 in the order of the files
+			ID:       uint64(i) + 1,
+			Geometry: f.geometry,
+			SRID:     l.srid,
+			Tags:     map[string]interface{}{},
+		}
+
+		switch {
+		case l.idFieldname != "":
+			if v, ok := f.properties[l.idFieldname]; ok {
+				id, err := provider.ConvertFeatureID(tagValue(v))
+				if err != nil {
+					return nil, err
+				}
+				feature.ID = id
+			}
+		case f.id != nil:
+			// string ids which are not numbers keep the feature number
+			if id, err := provider.ConvertFeatureID(tagValue(f.id)); err == nil {
+				feature.ID = id
+			}
+		}
+
+		if len(l.fields) == 0 {
+			for k, v := range f.properties {
+				if k == l.idFieldname {
+					continue
+				}
+				if v = tagValue(v); v != nil {
+					feature.Tags[k] = v
+				}
+			}
+		}
+		for _, k := range l.fields {
+			if v := tagValue(f.properties[k]); v != nil {
+				feature.Tags[k] = v
+			}
+		}
+
+		// empty geometries are kept but not indexed
+		ext, err := geom.NewExtentFromGeometry(feature.Geometry)
+		if err != nil {
+			ext = nil
+		}
+		bounds = append(bounds, ext)
+
+		data.features = append(data.features, feature)
+		data.geomType = commonGeomType(data.geomType, feature.Geometry, len(data.features) == 1)
+	}
+
+	data.index = rtree.New(bounds)
+
+	return &data, nil
+}
+
+// features returns copies of the features which intersect the extent. The features
+// are copied as the callers may modify them.
+func (l *Layer) features(ext *geom.Extent) []provider.Feature {
+	l.mu.RLock()
+	data := l.data
+	l.mu.RUnlock()
+
+	items := data.index.Search(ext)
+	features := make([]provider.Feature, len(items))
+	for i, item := range items {
+		f := data.features[item]
+
+		tags := make(map[string]interface{}, len(f.Tags))
+		for k, v := range f.Tags {
+			tags[k] = v
+		}
+		f.Tags = tags
+		f.Geometry = cloneGeometry(f.Geometry)
+
+		features[i] = f
+	}
+
+	return features
+}
+
+// commonGeomType returns the geometry type of the layer given the type of the
+// features so far and the geometry of another feature. Single and multi geometries
+// of the same kind share the multi geometry type. nil is returned for layers of
+// mixed geometry types.
+func commonGeomType(common, geo geom.Geometry, first bool) geom.Geometry {
+	var t, multi geom.Geometry
+	switch geo.(type) {
+	case geom.Point:
+		t, multi = geom.Point{}, geom.MultiPoint{}
+	case geom.MultiPoint:
+		t, multi = geom.MultiPoint{}, geom.MultiPoint{}
+	case geom.LineString:
+		t, multi = geom.LineString{}, geom.MultiLineString{}
+	case geom.MultiLineString:
+		t, multi = geom.MultiLineString{}, geom.MultiLineString{}
+	case geom.Polygon:
+		t, multi = geom.Polygon{}, geom.MultiPolygon{}
+	case geom.MultiPolygon:
+		t, multi = geom.MultiPolygon{}, geom.MultiPolygon{}
+	}
+
+	switch {
+	case first:
+		return t
+	case common == nil || t == nil:
+		return nil
+	case reflect.TypeOf(common) == reflect.TypeOf(t):
+		return common
+	}
+
+	// the multi geometry of the common type
+	switch common.(type) {
+	case geom.Point:
+		common = geom.MultiPoint{}
+	case geom.LineString:
+		common = geom.MultiLineString{}
+	case geom.Polygon:
+		common = geom.MultiPolygon{}
+	}
+	if reflect.TypeOf(common) == reflect.TypeOf(multi) {
+		return multi
+	}
+	return nil
+}
+
+// cloneGeometry returns a deep copy of the geometry
+func cloneGeometry(g geom.Geometry) geom.Geometry {
+	clonePoints := func(pts [][2]float64) [][2]float64 {
+		return append([][2]float64(nil), pts...)
+	}
+	cloneLines := func(lines [][][2]float64) [][][2]float64 {
+		clone := make([][][2]float64, len(lines))
+		for i := range lines {
+			clone[i] = clonePoints(lines[i])
+		}
+		return clone
+	}
+
+	switch geo := g.(type) {
+	case geom.MultiPoint:
+		return geom.MultiPoint(clonePoints(geo))
+	case geom.LineString:
+		return geom.LineString(clonePoints(geo))
+	case geom.MultiLineString:
+		return geom.MultiLineString(cloneLines(geo))
+	case geom.Polygon:
+		return geom.Polygon(cloneLines(geo))
+	case geom.MultiPolygon:
+		clone := make(geom.MultiPolygon, len(geo))
+		for i := range geo {
+			clone[i] = cloneLines(geo[i])
+		}
+		return clone
+	case geom.Collection:
+		clone := make(geom.Collection, len(geo))
+		for i := range geo {
+			clone[i] = cloneGeometry(geo[i])
+		}
+		return clone
+	default:
+		// points are values
+		return g
+	}
+}
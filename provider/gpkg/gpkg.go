@@ -11,8 +11,8 @@ import (
 	"github.com/go-spatial/geom"
 	"github.com/go-spatial/geom/encoding/wkb"
 	"github.com/go-spatial/tegola"
-	"github.com/go-spatial/tegola/basic"
 	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/maths/proj"
 	"github.com/go-spatial/tegola/provider"
 )
 
@@ -83,20 +83,12 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 	// read the tile extent
 	tileBBox, tileSRID := tile.BufferedExtent()
 
-	// TODO(arolek): reimplement once the geom package has reprojection
-	// check if the SRID of the layer differs from that of the tile. tileSRID is assumed to always be WebMercator
+	// check if the SRID of the layer differs from that of the tile
 	if pLayer.srid != tileSRID {
-		minGeo, err := basic.FromWebMercator(pLayer.srid, geom.Point{tileBBox.MinX(), tileBBox.MinY()})
-		if err != nil {
-			return fmt.Errorf("error converting point: %v ", err)
+		var err error
+		if tileBBox, err = proj.TransformExtent(tileSRID, pLayer.srid, tileBBox); err != nil {
+			return fmt.Errorf("error converting tile extent: %v ", err)
 		}
-
-		maxGeo, err := basic.FromWebMercator(pLayer.srid, geom.Point{tileBBox.MaxX(), tileBBox.MaxY()})
-		if err != nil {
-			return fmt.Errorf("error converting point: %v ", err)
-		}
-
-		tileBBox = geom.NewExtent(minGeo.(geom.Point), maxGeo.(geom.Point))
 	}
 
 	var qtext string
@@ -225,8 +217,19 @@ func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider
 	return nil
 }
 
-// Close will close the Provider's database connection
+// Close will close the Provider's database connection. It's used to release
+// the provider once it's no longer in use (i.e. after a config reload)
 func (p *Provider) Close() error {
+	// the provider no longer needs to be cleaned up
+	providersMu.Lock()
+	for i := range providers {
+		if providers[i].db == p.db {
+			providers = append(providers[:i], providers[i+1:]...)
+			break
+		}
+	}
+	providersMu.Unlock()
+
 	return p.db.Close()
 }
 
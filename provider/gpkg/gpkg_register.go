@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 
 	_ "github.com/mattn/go-sqlite3"
 
@@ -388,25 +389,33 @@ func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
 	}
 
 	// track the provider so we can clean it up later
+	providersMu.Lock()
 	providers = append(providers, p)
+	providersMu.Unlock()
 
 	return &p, err
 }
 
 // reference to all instantiated providers
-var providers []Provider
+var (
+	providersMu sync.Mutex
+	providers   []Provider
+)
 
 // Cleanup will close all database connections and destroy all previously instantiated Provider instances
 func Cleanup() {
-	if len(providers) > 0 {
+	providersMu.Lock()
+	open := providers
+	providers = make([]Provider, 0)
+	providersMu.Unlock()
+
+	if len(open) > 0 {
 		log.Infof("cleaning up gpkg providers")
 	}
 
-	for i := range providers {
-		if err := providers[i].Close(); err != nil {
+	for i := range open {
+		if err := open[i].db.Close(); err != nil {
 			log.Errorf("err closing connection: %v", err)
 		}
 	}
-
-	providers = make([]Provider, 0)
 }
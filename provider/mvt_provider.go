@@ -0,0 +1,17 @@
+package provider
+
+import "context"
+
+// MVTTiler is implemented by providers which are able to encode the features of
+// a layer as a Mapbox Vector Tile layer themselves (i.e. PostGIS's ST_AsMVT).
+// Layers backed by an MVTTiler skip the simplify, clip and makevalid pipeline
+// of the atlas.
+type MVTTiler interface {
+	Tiler
+
+	// MVTForLayer returns the features of the layer for the tile encoded as a
+	// protobuf vector tile containing a single layer named mvtName. extent is
+	// the number of units across a tile (i.e. 4096). An empty slice is returned
+	// when the layer has no features in the tile.
+	MVTForLayer(ctx context.Context, layer string, mvtName string, t Tile, extent uint) ([]byte, error)
+}
@@ -0,0 +1,195 @@
+package postgis
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/jackc/pgx"
+
+	"github.com/go-spatial/tegola/dict"
+)
+
+// geometryColumnsSQL lists the geometry columns of the database with the single
+// column integer primary key of their table, if it has one.
+const geometryColumnsSQL = `
+SELECT
+	gc.f_table_schema::text,
+	gc.f_table_name::text,
+	gc.f_geometry_column::text,
+	gc.srid,
+	gc.type::text,
+	COALESCE((
+		SELECT a.attname::text
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = i.indkey[0]
+		WHERE i.indrelid = c.oid AND i.indisprimary AND i.indnatts = 1 AND a.atttypid IN ('int2'::regtype, 'int4'::regtype, 'int8'::regtype)
+	), '')
+FROM geometry_columns gc
+JOIN pg_namespace n ON n.nspname = gc.f_table_schema
+JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = gc.f_table_name
+ORDER BY 1, 2, 3`
+
+// geometryColumn is a geometry column of the geometry_columns view
+type geometryColumn struct {
+	schema string
+	table  string
+	column string
+	srid   int
+	// the geometry type, i.e. MULTIPOLYGON or GEOMETRY for columns of any type
+	geomType string
+	// the primary key of the table, empty when the table has no single column
+	// integer primary key (i.e. views)
+	primaryKey string
+}
+
+// geometryColumns reads the geometry columns of the database
+func geometryColumns(pool *pgx.ConnPool) ([]geometryColumn, error) {
+	rows, err := pool.Query(geometryColumnsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []geometryColumn
+	for rows.Next() {
+		var col geometryColumn
+		if err := rows.Scan(&col.schema, &col.table, &col.column, &col.srid, &col.geomType, &col.primaryKey); err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+
+	return cols, rows.Err()
+}
+
+// layersFromGeometryColumns returns the config of a layer for each of the geometry
+// columns in the schemas with a table name matching pattern. All schemas and tables
+// match when schemas and pattern are empty. Columns of geometry types which can't be
+// decoded (i.e. curves) are skipped.
+//
+// The layers are named after their table. When a table has several geometry
+// columns the column name is appended, and when tables of several schemas share
+// a name the schema is prepended (i.e. osm_roads_geom).
+func layersFromGeometryColumns(cols []geometryColumn, schemas []string, pattern string) ([]dict.Dict, error) {
+	var matched []geometryColumn
+	for _, col := range cols {
+		if len(schemas) > 0 && !contains(schemas, col.schema) {
+			continue
+		}
+		if pattern != "" {
+			ok, err := path.Match(pattern, col.table)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %v (%v): %v", ConfigKeyAutoDiscoverPattern, pattern, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		matched = append(matched, col)
+	}
+
+	// count the geometry columns of each table and the schemas of each table name
+	columnsPerTable := make(map[[2]string]int)
+	schemasPerName := make(map[string]map[string]bool)
+	for _, col := range matched {
+		columnsPerTable[[2]string{col.schema, col.table}]++
+		if schemasPerName[col.table] == nil {
+			schemasPerName[col.table] = make(map[string]bool)
+		}
+		schemasPerName[col.table][col.schema] = true
+	}
+
+	var layers []dict.Dict
+	for _, col := range matched {
+		name := col.table
+		if len(schemasPerName[col.table]) > 1 {
+			name = col.schema + "_" + name
+		}
+		if columnsPerTable[[2]string{col.schema, col.table}] > 1 {
+			name = name + "_" + col.column
+		}
+
+		layer := dict.Dict{
+			ConfigKeyLayerName: name,
+			// the names are read from the database, they're quoted and escaped
+			ConfigKeyTablename: pgx.Identifier{col.schema, col.table}.Sanitize(),
+			ConfigKeyGeomField: col.column,
+		}
+		if col.primaryKey != "" {
+			layer[ConfigKeyGeomIDField] = col.primaryKey
+		}
+		// columns without an SRID use the provider's
+		if col.srid > 0 {
+			layer[ConfigKeySRID] = col.srid
+		}
+
+		// the M variants (i.e. POINTM) are decoded as their 2D type
+		switch geomType := strings.TrimSuffix(strings.ToUpper(col.geomType), "M"); geomType {
+		case "POINT", "LINESTRING", "POLYGON", "MULTIPOINT", "MULTILINESTRING", "MULTIPOLYGON", "GEOMETRYCOLLECTION":
+			layer[ConfigKeyGeomType] = geomType
+		case "GEOMETRY":
+			// the geometry type is inspected like the type of layers without a geometry_type
+		default:
+			log.Printf("postgis: skipping geometry column %v.%v.%v of unsupported type %v", col.schema, col.table, col.column, col.geomType)
+			continue
+		}
+
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
+
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverLayers returns the config of the layers discovered in the geometry_columns
+// view, filtered by the auto_discover_schemas and auto_discover_pattern of the
+// config. Discovered layers named like one of the configured layers are skipped,
+// so a configured layer can be used to override a discovered one.
+func (p Provider) discoverLayers(config dict.Dicter, configured []dict.Dicter) ([]dict.Dicter, error) {
+	schemas, err := config.StringSlice(ConfigKeyAutoDiscoverSchemas)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := ""
+	if pattern, err = config.String(ConfigKeyAutoDiscoverPattern, &pattern); err != nil {
+		return nil, err
+	}
+
+	cols, err := geometryColumns(p.pool)
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := layersFromGeometryColumns(cols, schemas, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(configured))
+	for _, l := range configured {
+		// errors are reported when the configured layers are loaded
+		name, _ := l.String(ConfigKeyLayerName, nil)
+		names[name] = true
+	}
+
+	var discovered []dict.Dicter
+	for _, l := range layers {
+		if names[l[ConfigKeyLayerName].(string)] {
+			continue
+		}
+		discovered = append(discovered, l)
+	}
+
+	return discovered, nil
+}
@@ -0,0 +1,206 @@
+package postgis
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/dict"
+)
+
+func TestLayersFromGeometryColumns(t *testing.T) {
+	type tcase struct {
+		cols        []geometryColumn
+		schemas     []string
+		pattern     string
+		expected    []dict.Dict
+		expectedErr string
+	}
+
+	fn := func(tc tcase) func(t *testing.T) {
+		return func(t *testing.T) {
+			layers, err := layersFromGeometryColumns(tc.cols, tc.schemas, tc.pattern)
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if !reflect.DeepEqual(layers, tc.expected) {
+				t.Errorf("layers, expected %v got %v", tc.expected, layers)
+			}
+		}
+	}
+
+	cols := []geometryColumn{
+		{schema: "osm", table: "roads", column: "geom", srid: 3857, geomType: "MULTILINESTRING", primaryKey: "osm_id"},
+		{schema: "osm", table: "buildings", column: "geom", srid: 3857, geomType: "POLYGON", primaryKey: "osm_id"},
+		{schema: "osm", table: "buildings", column: "centroid", srid: 3857, geomType: "POINTM", primaryKey: "osm_id"},
+		{schema: "public", table: "roads", column: "geom", srid: 4326, geomType: "GEOMETRY"},
+		{schema: "public", table: "arcs", column: "geom", srid: 4326, geomType: "CIRCULARSTRING"},
+		{schema: "public", table: "grid", column: "geom", srid: 0, geomType: "POLYGON", primaryKey: "id"},
+	}
+
+	tests := map[string]tcase{
+		"all": {
+			cols: cols,
+			expected: []dict.Dict{
+				{
+					ConfigKeyLayerName:   "osm_roads",
+					ConfigKeyTablename:   `"osm"."roads"`,
+					ConfigKeyGeomField:   "geom",
+					ConfigKeyGeomIDField: "osm_id",
+					ConfigKeySRID:        3857,
+					ConfigKeyGeomType:    "MULTILINESTRING",
+				},
+				{
+					ConfigKeyLayerName:   "buildings_geom",
+					ConfigKeyTablename:   `"osm"."buildings"`,
+					ConfigKeyGeomField:   "geom",
+					ConfigKeyGeomIDField: "osm_id",
+					ConfigKeySRID:        3857,
+					ConfigKeyGeomType:    "POLYGON",
+				},
+				{
+					ConfigKeyLayerName:   "buildings_centroid",
+					ConfigKeyTablename:   `"osm"."buildings"`,
+					ConfigKeyGeomField:   "centroid",
+					ConfigKeyGeomIDField: "osm_id",
+					ConfigKeySRID:        3857,
+					ConfigKeyGeomType:    "POINT",
+				},
+				{
+					// no primary key and a generic geometry type
+					ConfigKeyLayerName: "public_roads",
+					ConfigKeyTablename: `"public"."roads"`,
+					ConfigKeyGeomField: "geom",
+					ConfigKeySRID:      4326,
+				},
+				{
+					// the provider srid is used
+					ConfigKeyLayerName:   "grid",
+					ConfigKeyTablename:   `"public"."grid"`,
+					ConfigKeyGeomField:   "geom",
+					ConfigKeyGeomIDField: "id",
+					ConfigKeyGeomType:    "POLYGON",
+				},
+			},
+		},
+		"schema": {
+			cols:    cols,
+			schemas: []string{"public"},
+			expected: []dict.Dict{
+				{
+					// the roads of the osm schema are not discovered
+					ConfigKeyLayerName: "roads",
+					ConfigKeyTablename: `"public"."roads"`,
+					ConfigKeyGeomField: "geom",
+					ConfigKeySRID:      4326,
+				},
+				{
+					ConfigKeyLayerName:   "grid",
+					ConfigKeyTablename:   `"public"."grid"`,
+					ConfigKeyGeomField:   "geom",
+					ConfigKeyGeomIDField: "id",
+					ConfigKeyGeomType:    "POLYGON",
+				},
+			},
+		},
+		"pattern": {
+			cols:    cols,
+			schemas: []string{"osm", "public"},
+			pattern: "r*s",
+			expected: []dict.Dict{
+				{
+					ConfigKeyLayerName:   "osm_roads",
+					ConfigKeyTablename:   `"osm"."roads"`,
+					ConfigKeyGeomField:   "geom",
+					ConfigKeyGeomIDField: "osm_id",
+					ConfigKeySRID:        3857,
+					ConfigKeyGeomType:    "MULTILINESTRING",
+				},
+				{
+					ConfigKeyLayerName: "public_roads",
+					ConfigKeyTablename: `"public"."roads"`,
+					ConfigKeyGeomField: "geom",
+					ConfigKeySRID:      4326,
+				},
+			},
+		},
+		"quoted table name": {
+			cols: []geometryColumn{
+				{schema: "public", table: `roads"; DROP TABLE roads; --`, column: "geom", srid: 3857, geomType: "LINESTRING", primaryKey: "id"},
+			},
+			expected: []dict.Dict{
+				{
+					ConfigKeyLayerName:   `roads"; DROP TABLE roads; --`,
+					ConfigKeyTablename:   `"public"."roads""; DROP TABLE roads; --"`,
+					ConfigKeyGeomField:   "geom",
+					ConfigKeyGeomIDField: "id",
+					ConfigKeySRID:        3857,
+					ConfigKeyGeomType:    "LINESTRING",
+				},
+			},
+		},
+		"no match": {
+			cols:    cols,
+			pattern: "water*",
+		},
+		"invalid pattern": {
+			cols:        cols,
+			pattern:     "[",
+			expectedErr: "invalid auto_discover_pattern ([): syntax error in pattern",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestAutoDiscover(t *testing.T) {
+	port := GetTestPort(t)
+
+	config := dict.Dict{
+		ConfigKeyHost:                os.Getenv("PGHOST"),
+		ConfigKeyPort:                port,
+		ConfigKeyDB:                  os.Getenv("PGDATABASE"),
+		ConfigKeyUser:                os.Getenv("PGUSER"),
+		ConfigKeyPassword:            os.Getenv("PGPASSWORD"),
+		ConfigKeySSLMode:             os.Getenv("PGSSLMODE"),
+		ConfigKeySSLKey:              os.Getenv("PGSSLKEY"),
+		ConfigKeySSLCert:             os.Getenv("PGSSLCERT"),
+		ConfigKeySSLRootCert:         os.Getenv("PGSSLROOTCERT"),
+		ConfigKeyAutoDiscover:        true,
+		ConfigKeyAutoDiscoverPattern: "ne_10m_land_scale_rank",
+		ConfigKeyLayers: []map[string]interface{}{
+			{
+				ConfigKeyLayerName: "land",
+				ConfigKeyTablename: "ne_10m_land_scale_rank",
+			},
+		},
+	}
+
+	tiler, err := NewTileProvider(config)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	p := tiler.(*Provider)
+	defer p.Close()
+
+	for _, name := range []string{"land", "ne_10m_land_scale_rank"} {
+		l, ok := p.Layer(name)
+		if !ok {
+			t.Errorf("layer (%v) not found", name)
+			continue
+		}
+		if l.SRID() != tegola.WebMercator {
+			t.Errorf("layer (%v) srid, expected %v got %v", name, tegola.WebMercator, l.SRID())
+		}
+	}
+}
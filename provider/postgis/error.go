@@ -30,3 +30,25 @@ type ErrGeomFieldNotFound struct {
 func (e ErrGeomFieldNotFound) Error() string {
 	return fmt.Sprintf("postgis: geom fieldname (%v) not found for layer (%v)", e.GeomFieldName, e.LayerName)
 }
+
+type ErrInvalidQueryTimeout struct {
+	// LayerName is empty for the provider query_timeout
+	LayerName string
+	Timeout   int
+}
+
+func (e ErrInvalidQueryTimeout) Error() string {
+	if e.LayerName == "" {
+		return fmt.Sprintf("postgis: query_timeout (%v) can not be negative", e.Timeout)
+	}
+	return fmt.Sprintf("postgis: query_timeout (%v) for layer (%v) can not be negative", e.Timeout, e.LayerName)
+}
+
+type ErrInvalidZoomVariant struct {
+	LayerName string
+	Reason    string
+}
+
+func (e ErrInvalidZoomVariant) Error() string {
+	return fmt.Sprintf("postgis: invalid zoom_variants for layer (%v): %v", e.LayerName, e.Reason)
+}
@@ -1,6 +1,10 @@
 package postgis
 
-import "github.com/go-spatial/geom"
+import (
+	"time"
+
+	"github.com/go-spatial/geom"
+)
 
 // layer holds information about a query.
 type Layer struct {
@@ -16,6 +20,16 @@ type Layer struct {
 	geomType geom.Geometry
 	// The SRID that the data in the table is stored in. This will default to WebMercator
 	srid uint64
+	// The columns returned by the SQL. Only populated by the MVTProvider
+	columns []string
+	// The max duration of the layer's queries. 0 means no timeout
+	queryTimeout time.Duration
+	// The SQL with its tokens bound to parameters, prepared by the provider
+	stmt tileSQL
+	// The zoom range of a zoom variant of the layer
+	minZoom, maxZoom uint
+	// The variants of the layer for a range of zooms, sorted by min zoom
+	zoomVariants []Layer
 }
 
 func (l Layer) Name() string {
@@ -37,3 +51,19 @@ func (l Layer) GeomFieldName() string {
 func (l Layer) IDFieldName() string {
 	return l.idField
 }
+
+func (l Layer) QueryTimeout() time.Duration {
+	return l.queryTimeout
+}
+
+// forZoom returns the variant of the layer for the zoom z, or the layer itself when
+// none of its variants covers z. ok is false when there's no SQL for the zoom, which
+// happens when the layer is only configured with zoom variants.
+func (l Layer) forZoom(z uint) (lyr Layer, ok bool) {
+	for _, v := range l.zoomVariants {
+		if z >= v.minZoom && z <= v.maxZoom {
+			return v, true
+		}
+	}
+	return l, l.sql != ""
+}
@@ -0,0 +1,144 @@
+package postgis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/dict"
+)
+
+func TestParseZoomVariants(t *testing.T) {
+	type tcase struct {
+		configs     []dict.Dicter
+		expected    []zoomVariantConfig
+		expectedErr string
+	}
+
+	fn := func(tc tcase) func(t *testing.T) {
+		return func(t *testing.T) {
+			variants, err := parseZoomVariants("roads", tc.configs)
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if !reflect.DeepEqual(variants, tc.expected) {
+				t.Errorf("variants, expected %v got %v", tc.expected, variants)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"none": {},
+		"defaults": {
+			configs: []dict.Dicter{
+				dict.Dict{ConfigKeyTablename: "roads"},
+			},
+			expected: []zoomVariantConfig{
+				{minZoom: 0, maxZoom: tegola.MaxZ, tblName: "roads"},
+			},
+		},
+		"sorted": {
+			configs: []dict.Dicter{
+				dict.Dict{ConfigKeyMinZoom: uint(9), ConfigKeySQL: "SELECT gid, geom FROM roads WHERE geom && !BBOX!"},
+				dict.Dict{ConfigKeyMaxZoom: uint(8), ConfigKeyTablename: "roads_gen", ConfigKeyFields: []string{"class"}},
+			},
+			expected: []zoomVariantConfig{
+				{minZoom: 0, maxZoom: 8, tblName: "roads_gen", fields: []string{"class"}},
+				{minZoom: 9, maxZoom: tegola.MaxZ, sql: "SELECT gid, geom FROM roads WHERE geom && !BBOX!"},
+			},
+		},
+		"min zoom greater than max zoom": {
+			configs: []dict.Dicter{
+				dict.Dict{ConfigKeyMinZoom: uint(10), ConfigKeyMaxZoom: uint(5), ConfigKeyTablename: "roads"},
+			},
+			expectedErr: "postgis: invalid zoom_variants for layer (roads): variant (0) min_zoom (10) is greater than max_zoom (5)",
+		},
+		"missing sql and tablename": {
+			configs: []dict.Dicter{
+				dict.Dict{ConfigKeyMaxZoom: uint(5)},
+			},
+			expectedErr: "postgis: invalid zoom_variants for layer (roads): variant (0) requires a tablename or sql",
+		},
+		"overlapping": {
+			configs: []dict.Dicter{
+				dict.Dict{ConfigKeyMaxZoom: uint(8), ConfigKeyTablename: "roads_gen"},
+				dict.Dict{ConfigKeyMinZoom: uint(8), ConfigKeyTablename: "roads"},
+			},
+			expectedErr: "postgis: invalid zoom_variants for layer (roads): zooms 8 to 22 overlap zooms 0 to 8",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestLayerForZoom(t *testing.T) {
+	type tcase struct {
+		layer      Layer
+		z          uint
+		expectedOK bool
+		// the SQL of the expected layer
+		expected string
+	}
+
+	fn := func(tc tcase) func(t *testing.T) {
+		return func(t *testing.T) {
+			l, ok := tc.layer.forZoom(tc.z)
+			if ok != tc.expectedOK {
+				t.Fatalf("ok, expected %v got %v", tc.expectedOK, ok)
+			}
+			if l.sql != tc.expected {
+				t.Errorf("sql, expected %v got %v", tc.expected, l.sql)
+			}
+		}
+	}
+
+	variants := []Layer{
+		{sql: "low", minZoom: 0, maxZoom: 5},
+		{sql: "mid", minZoom: 6, maxZoom: 10},
+	}
+
+	tests := map[string]tcase{
+		"no variants": {
+			layer:      Layer{sql: "layer"},
+			z:          3,
+			expectedOK: true,
+			expected:   "layer",
+		},
+		"first variant": {
+			layer:      Layer{sql: "layer", zoomVariants: variants},
+			z:          5,
+			expectedOK: true,
+			expected:   "low",
+		},
+		"second variant": {
+			layer:      Layer{sql: "layer", zoomVariants: variants},
+			z:          6,
+			expectedOK: true,
+			expected:   "mid",
+		},
+		"no variant for zoom": {
+			layer:      Layer{sql: "layer", zoomVariants: variants},
+			z:          11,
+			expectedOK: true,
+			expected:   "layer",
+		},
+		"variants only": {
+			layer:      Layer{zoomVariants: variants},
+			z:          11,
+			expectedOK: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -0,0 +1,188 @@
+package postgis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/jackc/pgx"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/provider"
+)
+
+const MVTProviderName = "mvt_postgis"
+
+const (
+	// mvtSQL wraps the layer SQL so the database returns the layer encoded as a vector tile.
+	// The layer geometry is decoded from WKB, transformed into the tile SRID and passed
+	// through ST_AsMVTGeom which clips it to the tile and converts it to tile coordinates.
+	//
+	// %[1]v - the layer SQL
+	// %[2]v - the layer columns excluding the geometry column
+	// %[3]v - the ST_AsMVTGeom expression
+	// %[4]v - the quoted geometry column name
+	// %[5]v - the ST_AsMVT arguments following the row
+	mvtSQL = `SELECT ST_AsMVT(mvt_layer, %[5]v) FROM (SELECT %[2]v%[3]v AS %[4]v FROM (%[1]v) AS mvt_source) AS mvt_layer WHERE %[4]v IS NOT NULL`
+
+	// SQL to get the column names of a layer's SQL
+	columnsSQL = `SELECT * FROM (%[1]v) AS q LIMIT 0`
+)
+
+func init() {
+	// the MVTProvider is tracked in providers by NewTileProvider so the
+	// postgis Cleanup function takes care of it
+	provider.Register(MVTProviderName, NewMVTTileProvider, nil)
+}
+
+// MVTProvider is a postgis provider which has the database encode the layers of a
+// tile using ST_AsMVT. The connection and layer config are the same as the postgis
+// provider. Layers are fetched as decoded features through TileFeatures for
+// non vector tile output (i.e. GeoJSON).
+type MVTProvider struct {
+	Provider
+}
+
+// NewMVTTileProvider instantiates and returns a new mvt_postgis provider or an error.
+// See NewTileProvider for the supported config.
+func NewMVTTileProvider(config dict.Dicter) (provider.Tiler, error) {
+	tiler, err := NewTileProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	p := tiler.(*Provider)
+
+	// the columns of each layer are needed to build the ST_AsMVT query
+	for name, l := range p.layers {
+		// a layer configured with zoom variants only has no SQL of its own
+		if l.sql != "" {
+			if l.columns, err = p.layerColumns(l); err != nil {
+				return nil, fmt.Errorf("error fetching columns for layer (%v): %v", name, err)
+			}
+		}
+		for i, v := range l.zoomVariants {
+			if l.zoomVariants[i].columns, err = p.layerColumns(v); err != nil {
+				return nil, fmt.Errorf("error fetching columns for layer (%v) zooms %v to %v: %v", name, v.minZoom, v.maxZoom, err)
+			}
+		}
+		p.layers[name] = l
+	}
+
+	return &MVTProvider{Provider: *p}, nil
+}
+
+// layerColumns returns the names of the columns returned by the layer's SQL
+func (p Provider) layerColumns(l Layer) ([]string, error) {
+	// we need a tile to run our sql through the replacer
+	tile := provider.NewTile(0, 0, 0, 64, tegola.WebMercator)
+
+	sql, err := replaceTokens(fmt.Sprintf(columnsSQL, l.sql), l.srid, tile)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.pool.Query(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for _, fdesc := range rows.FieldDescriptions() {
+		columns = append(columns, fdesc.Name)
+	}
+
+	return columns, rows.Err()
+}
+
+// MVTForLayer adheres to the provider.MVTTiler interface
+func (p *MVTProvider) MVTForLayer(ctx context.Context, layer string, mvtName string, tile provider.Tile, extent uint) ([]byte, error) {
+	// fetch the provider layer
+	plyr, ok := p.Layer(layer)
+	if !ok {
+		return nil, ErrLayerNotFound{layer}
+	}
+
+	// use the variant of the layer for the tile's zoom
+	z, _, _ := tile.ZXY()
+	if plyr, ok = plyr.forZoom(z); !ok {
+		// the layer has no SQL for the zoom
+		return nil, nil
+	}
+
+	// the statement depends on the map layer, the tile grid and the query parameters
+	// of the request so it's prepared on first use
+	params, _ := provider.QueryParametersFromContext(ctx)
+	stmt := bindTokens(layerMVTSQL(plyr, mvtName, tile, extent), plyr.srid, params)
+	sql := stmt.sql
+
+	args, err := stmt.args(tile)
+	if err != nil {
+		return nil, fmt.Errorf("error replacing layer tokens for layer (%v) SQL (%v): %v", layer, sql, err)
+	}
+
+	if debugExecuteSQL {
+		log.Printf("%s:%s for layer (%v): %v %v", EnvSQLDebugName, EnvSQLDebugExecute, layer, sql, args)
+	}
+
+	// context check
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := p.prepare(stmt); err != nil {
+		return nil, fmt.Errorf("error preparing layer (%v) SQL (%v): %v", layer, sql, err)
+	}
+
+	qctx, cancel := queryContext(ctx, plyr)
+	defer cancel()
+
+	var mvtBytes []byte
+	if err := p.pool.QueryRowEx(qctx, stmt.name, nil, args...).Scan(&mvtBytes); err != nil {
+		return nil, queryError(ctx, qctx, plyr, fmt.Errorf("error running layer (%v) SQL (%v): %v", layer, sql, err))
+	}
+
+	return mvtBytes, nil
+}
+
+// layerMVTSQL returns the SQL which encodes the layer for the tile using ST_AsMVT.
+// The returned SQL contains the tokens of the layer SQL and the tileEnvelopeToken
+// so it's the same for every tile of a tile grid.
+func layerMVTSQL(l Layer, mvtName string, tile provider.Tile, extent uint) string {
+	ext, tileSRID := tile.Extent()
+	bufferedExt, _ := tile.BufferedExtent()
+
+	// the tile buffer in tile coordinates
+	buffer := math.Round((bufferedExt.MaxX() - ext.MaxX()) / (ext.MaxX() - ext.MinX()) * float64(extent))
+
+	geomField := pgx.Identifier{l.geomField}.Sanitize()
+
+	var columns string
+	for _, c := range l.columns {
+		if c == l.geomField {
+			continue
+		}
+		columns += "mvt_source." + pgx.Identifier{c}.Sanitize() + ", "
+	}
+
+	mvtGeom := fmt.Sprintf(
+		"ST_AsMVTGeom(ST_Transform(ST_GeomFromWKB(mvt_source.%v, %d), %d), %v, %d, %d, true)",
+		geomField, l.srid, tileSRID, tileEnvelopeToken,
+		extent, int64(buffer),
+	)
+
+	args := fmt.Sprintf("%v, %d, %v", quoteLiteral(mvtName), extent, quoteLiteral(l.geomField))
+	if l.idField != "" {
+		args += ", " + quoteLiteral(l.idField)
+	}
+
+	return fmt.Sprintf(mvtSQL, l.sql, columns, mvtGeom, geomField, args)
+}
+
+// quoteLiteral quotes a string for use as a SQL string literal
+func quoteLiteral(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
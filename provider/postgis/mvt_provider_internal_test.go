@@ -0,0 +1,56 @@
+package postgis
+
+import (
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/provider"
+)
+
+func TestLayerMVTSQL(t *testing.T) {
+	type tcase struct {
+		layer    Layer
+		mvtName  string
+		tile     provider.Tile
+		expected string
+	}
+
+	fn := func(tc tcase) func(t *testing.T) {
+		return func(t *testing.T) {
+			sql := layerMVTSQL(tc.layer, tc.mvtName, tc.tile, 4096)
+			if sql != tc.expected {
+				t.Errorf("incorrect sql,\n Expected \n \t%v\n Got \n \t%v", tc.expected, sql)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"web mercator": {
+			layer: Layer{
+				sql:       "SELECT gid, name, ST_AsBinary(geom) AS geom FROM foo WHERE geom && !BBOX!",
+				idField:   "gid",
+				geomField: "geom",
+				srid:      tegola.WebMercator,
+				columns:   []string{"gid", "name", "geom"},
+			},
+			mvtName:  "rivers",
+			tile:     provider.NewTile(0, 0, 0, 64, tegola.WebMercator),
+			expected: `SELECT ST_AsMVT(mvt_layer, 'rivers', 4096, 'geom', 'gid') FROM (SELECT mvt_source."gid", mvt_source."name", ST_AsMVTGeom(ST_Transform(ST_GeomFromWKB(mvt_source."geom", 3857), 3857), !TILE_ENVELOPE!, 4096, 64, true) AS "geom" FROM (SELECT gid, name, ST_AsBinary(geom) AS geom FROM foo WHERE geom && !BBOX!) AS mvt_source) AS mvt_layer WHERE "geom" IS NOT NULL`,
+		},
+		"no id field and quoted names": {
+			layer: Layer{
+				sql:       "SELECT ST_AsBinary(the_geom) AS the_geom FROM foo WHERE the_geom && !BBOX!",
+				geomField: "the_geom",
+				srid:      tegola.WGS84,
+				columns:   []string{"the_geom", `odd"name`},
+			},
+			mvtName:  "o'reilly",
+			tile:     provider.NewTile(1, 0, 0, 0, tegola.WGS84),
+			expected: `SELECT ST_AsMVT(mvt_layer, 'o''reilly', 4096, 'the_geom') FROM (SELECT mvt_source."odd""name", ST_AsMVTGeom(ST_Transform(ST_GeomFromWKB(mvt_source."the_geom", 4326), 4326), !TILE_ENVELOPE!, 4096, 0, true) AS "the_geom" FROM (SELECT ST_AsBinary(the_geom) AS the_geom FROM foo WHERE the_geom && !BBOX!) AS mvt_source) AS mvt_layer WHERE "the_geom" IS NOT NULL`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"io/ioutil"
 	"log"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jackc/pgx"
 
@@ -33,7 +36,7 @@ type Provider struct {
 
 const (
 	// We quote the field and table names to prevent colliding with postgres keywords.
-	stdSQL = `SELECT %[1]v FROM %[2]v WHERE "%[3]v" && ` + bboxToken
+	stdSQL = `SELECT %[1]v FROM %[2]v WHERE %[3]v && ` + bboxToken
 
 	// SQL to get the column names, without hitting the information_schema. Though it might be better to hit the information_schema.
 	fldsSQL = `SELECT * FROM %[1]v LIMIT 0;`
@@ -46,28 +49,38 @@ const (
 	DefaultSSLMode = "disable"
 	DefaultSSLKey  = ""
 	DefaultSSLCert = ""
+	// DefaultQueryTimeout is the query timeout, in seconds, when not configured. 0 means no timeout
+	DefaultQueryTimeout = 0
 )
 
 const (
-	ConfigKeyHost        = "host"
-	ConfigKeyPort        = "port"
-	ConfigKeyDB          = "database"
-	ConfigKeyUser        = "user"
-	ConfigKeyPassword    = "password"
-	ConfigKeySSLMode     = "ssl_mode"
-	ConfigKeySSLKey      = "ssl_key"
-	ConfigKeySSLCert     = "ssl_cert"
-	ConfigKeySSLRootCert = "ssl_root_cert"
-	ConfigKeyMaxConn     = "max_connections"
-	ConfigKeySRID        = "srid"
-	ConfigKeyLayers      = "layers"
-	ConfigKeyLayerName   = "name"
-	ConfigKeyTablename   = "tablename"
-	ConfigKeySQL         = "sql"
-	ConfigKeyFields      = "fields"
-	ConfigKeyGeomField   = "geometry_fieldname"
-	ConfigKeyGeomIDField = "id_fieldname"
-	ConfigKeyGeomType    = "geometry_type"
+	ConfigKeyHost         = "host"
+	ConfigKeyPort         = "port"
+	ConfigKeyDB           = "database"
+	ConfigKeyUser         = "user"
+	ConfigKeyPassword     = "password"
+	ConfigKeySSLMode      = "ssl_mode"
+	ConfigKeySSLKey       = "ssl_key"
+	ConfigKeySSLCert      = "ssl_cert"
+	ConfigKeySSLRootCert  = "ssl_root_cert"
+	ConfigKeyMaxConn      = "max_connections"
+	ConfigKeySRID         = "srid"
+	ConfigKeyLayers       = "layers"
+	ConfigKeyLayerName    = "name"
+	ConfigKeyTablename    = "tablename"
+	ConfigKeySQL          = "sql"
+	ConfigKeyFields       = "fields"
+	ConfigKeyGeomField    = "geometry_fieldname"
+	ConfigKeyGeomIDField  = "id_fieldname"
+	ConfigKeyGeomType     = "geometry_type"
+	ConfigKeyQueryTimeout = "query_timeout"
+	ConfigKeyZoomVariants = "zoom_variants"
+	ConfigKeyMinZoom      = "min_zoom"
+	ConfigKeyMaxZoom      = "max_zoom"
+
+	ConfigKeyAutoDiscover        = "auto_discover"
+	ConfigKeyAutoDiscoverSchemas = "auto_discover_schemas"
+	ConfigKeyAutoDiscoverPattern = "auto_discover_pattern"
 )
 
 func init() {
@@ -90,6 +103,10 @@ var isSelectQuery = regexp.MustCompile(`(?i)^((\s*)(--.*\n)?)*select`)
 // 	password (string): [Required] postgis database password
 // 	srid (int): [Optional] The default SRID for the provider. Defaults to WebMercator (3857) but also supports WGS84 (4326)
 // 	max_connections : [Optional] The max connections to maintain in the connection pool. Default is 100. 0 means no max.
+// 	query_timeout (int): [Optional] The max time, in seconds, a layer's query can run for before it's canceled. Default is 0 (no timeout).
+// 	auto_discover (bool): [Optional] Add a layer for each of the columns of the geometry_columns view. Default is false.
+// 	auto_discover_schemas ([]string): [Optional] Only discover the geometry columns of these schemas. Default is all schemas.
+// 	auto_discover_pattern (string): [Optional] Only discover the geometry columns of tables matching this glob pattern (i.e. osm_*). Default is all tables.
 // 	layers (map[string]struct{})  — This is map of layers keyed by the layer name. supports the following properties
 //
 // 		name (string): [Required] the name of the layer. This is used to reference this layer from map layers.
@@ -98,11 +115,20 @@ var isSelectQuery = regexp.MustCompile(`(?i)^((\s*)(--.*\n)?)*select`)
 // 		id_fieldname (string): [Optional] the name of the feature id field. defaults to gid
 // 		fields ([]string): [Optional] a list of fields to include alongside the feature. Can be used if sql is not defined.
 // 		srid (int): [Optional] the SRID of the layer. Supports 3857 (WebMercator) or 4326 (WGS84).
+// 		query_timeout (int): [Optional] the query timeout of the layer in seconds. Defaults to the provider's query_timeout.
 // 		sql (string): [*Required] custom SQL to use use. Required if tablename is not defined. Supports the following tokens:
 //
 // 			!BBOX! - [Required] will be replaced with the bounding box of the tile before the query is sent to the database.
 // 			!ZOOM! - [Optional] will be replaced with the "Z" (zoom) value of the requested tile.
 //
+// 		zoom_variants ([]map[string]struct{}) - [Optional] the SQL of the layer for a range of zooms. supports the following properties
+//
+// 			min_zoom (int): [Optional] the min zoom of the variant. Defaults to 0.
+// 			max_zoom (int): [Optional] the max zoom of the variant. Defaults to the max zoom supported by tegola.
+// 			tablename (string): [*Required] the name of the database table to query against. Required if sql is not defined.
+// 			fields ([]string): [Optional] a list of fields to include alongside the feature. Can be used if sql is not defined.
+// 			sql (string): [*Required] custom SQL to use use. Required if tablename is not defined. Supports the same tokens as the layer sql.
+//
 func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
 
 	host, err := config.String(ConfigKeyHost, nil)
@@ -161,6 +187,14 @@ func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
 		return nil, err
 	}
 
+	queryTimeout := DefaultQueryTimeout
+	if queryTimeout, err = config.Int(ConfigKeyQueryTimeout, &queryTimeout); err != nil {
+		return nil, err
+	}
+	if queryTimeout < 0 {
+		return nil, ErrInvalidQueryTimeout{Timeout: queryTimeout}
+	}
+
 	connConfig := pgx.ConnConfig{
 		Host:     host,
 		Port:     uint16(port),
@@ -196,6 +230,18 @@ func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
 		return nil, err
 	}
 
+	autoDiscover := false
+	if autoDiscover, err = config.Bool(ConfigKeyAutoDiscover, &autoDiscover); err != nil {
+		return nil, err
+	}
+	if autoDiscover {
+		discovered, err := p.discoverLayers(config, layers)
+		if err != nil {
+			return nil, fmt.Errorf("error discovering layers: %v", err)
+		}
+		layers = append(layers, discovered...)
+	}
+
 	lyrs := make(map[string]Layer)
 	lyrsSeen := make(map[string]int)
 
@@ -262,49 +308,38 @@ func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
 			return nil, err
 		}
 
-		l := Layer{
-			name:      lname,
-			idField:   idfld,
-			geomField: geomfld,
-			srid:      uint64(lsrid),
+		var lqueryTimeout = queryTimeout
+		if lqueryTimeout, err = layer.Int(ConfigKeyQueryTimeout, &lqueryTimeout); err != nil {
+			return nil, err
+		}
+		if lqueryTimeout < 0 {
+			return nil, ErrInvalidQueryTimeout{LayerName: lname, Timeout: lqueryTimeout}
 		}
 
-		if sql != "" && !isSelectQuery.MatchString(sql) {
-			// if it is not a SELECT query, then we assume we have a sub-query
-			// (`(select ...) as foo`) which we can handle like a tablename
-			tblName = sql
-			sql = ""
+		l := Layer{
+			name:         lname,
+			idField:      idfld,
+			geomField:    geomfld,
+			srid:         uint64(lsrid),
+			queryTimeout: time.Duration(lqueryTimeout) * time.Second,
 		}
 
-		if sql != "" {
-			// convert !BOX! (MapServer) and !bbox! (Mapnik) to !BBOX! for compatibility
-			sql := strings.Replace(strings.Replace(sql, "!BOX!", "!BBOX!", -1), "!bbox!", "!BBOX!", -1)
-			// make sure that the sql has a !BBOX! token
-			if !strings.Contains(sql, bboxToken) {
-				return nil, fmt.Errorf("SQL for layer (%v) %v is missing required token: %v", i, lname, bboxToken)
-			}
-			if !strings.Contains(sql, "*") {
-				if !strings.Contains(sql, geomfld) {
-					return nil, fmt.Errorf("SQL for layer (%v) %v does not contain the geometry field: %v", i, lname, geomfld)
-				}
-				if !strings.Contains(sql, idfld) {
-					return nil, fmt.Errorf("SQL for layer (%v) %v does not contain the id field for the geometry: %v", i, lname, sql)
-				}
-			}
+		zoomVariants, err := layer.MapSlice(ConfigKeyZoomVariants)
+		if err != nil {
+			return nil, fmt.Errorf("for layer (%v) %v %v had the following error: %v", i, lname, ConfigKeyZoomVariants, err)
+		}
 
-			l.sql = sql
-		} else {
-			// Tablename and Fields will be used to build the query.
-			// We need to do some work. We need to check to see Fields contains the geom and gid fields
-			// and if not add them to the list. If Fields list is empty/nil we will use '*' for the field list.
-			l.sql, err = genSQL(&l, p.pool, tblName, fields)
-			if err != nil {
-				return nil, fmt.Errorf("could not generate sql, for layer(%v): %v", lname, err)
+		// the tablename defaults to the layer name, so a layer with zoom variants only
+		// has SQL for the zooms without a variant when its sql or tablename is set
+		configuredTblName, _ := layer.String(ConfigKeyTablename, new(string))
+		if len(zoomVariants) == 0 || sql != "" || configuredTblName != "" {
+			if err = p.setLayerSQL(&l, i, tblName, sql, fields); err != nil {
+				return nil, err
 			}
 		}
 
-		if debugLayerSQL {
-			log.Printf("SQL for Layer(%v):\n%v\n", lname, l.sql)
+		if l.zoomVariants, err = p.layerZoomVariants(l, i, zoomVariants); err != nil {
+			return nil, err
 		}
 
 		// set the layer geom type
@@ -313,9 +348,19 @@ func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
 				return nil, fmt.Errorf("error fetching geometry type for layer (%v): %v", l.name, err)
 			}
 		} else {
-			if err = p.inspectLayerGeomType(&l); err != nil {
+			// a layer without SQL for the zooms without a variant is inspected with the
+			// SQL of its first variant
+			inspected := l
+			if inspected.sql == "" {
+				inspected = l.zoomVariants[0]
+			}
+			if err = p.inspectLayerGeomType(&inspected); err != nil {
 				return nil, fmt.Errorf("error fetching geometry type for layer (%v): %v", l.name, err)
 			}
+			l.geomType = inspected.geomType
+		}
+		for j := range l.zoomVariants {
+			l.zoomVariants[j].geomType = l.geomType
 		}
 
 		lyrs[lname] = l
@@ -323,9 +368,141 @@ func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
 	p.layers = lyrs
 
 	// track the provider so we can clean it up later
+	providersMu.Lock()
 	providers = append(providers, p)
+	providersMu.Unlock()
+
+	return &p, nil
+}
+
+// setLayerSQL sets the SQL of the layer from the sql config or, when it's empty,
+// generates it from the tablename and fields config. The SQL is prepared on the
+// provider's connections.
+func (p Provider) setLayerSQL(l *Layer, i int, tblName, sql string, fields []string) (err error) {
+	if sql != "" && !isSelectQuery.MatchString(sql) {
+		// if it is not a SELECT query, then we assume we have a sub-query
+		// (`(select ...) as foo`) which we can handle like a tablename
+		tblName = sql
+		sql = ""
+	}
+
+	if sql != "" {
+		// convert !BOX! (MapServer) and !bbox! (Mapnik) to !BBOX! for compatibility
+		sql = strings.Replace(strings.Replace(sql, "!BOX!", "!BBOX!", -1), "!bbox!", "!BBOX!", -1)
+		// make sure that the sql has a !BBOX! token
+		if !strings.Contains(sql, bboxToken) {
+			return fmt.Errorf("SQL for layer (%v) %v is missing required token: %v", i, l.name, bboxToken)
+		}
+		if !strings.Contains(sql, "*") {
+			if !strings.Contains(sql, l.geomField) {
+				return fmt.Errorf("SQL for layer (%v) %v does not contain the geometry field: %v", i, l.name, l.geomField)
+			}
+			if !strings.Contains(sql, l.idField) {
+				return fmt.Errorf("SQL for layer (%v) %v does not contain the id field for the geometry: %v", i, l.name, sql)
+			}
+		}
+
+		l.sql = sql
+	} else {
+		// Tablename and Fields will be used to build the query.
+		// We need to do some work. We need to check to see Fields contains the geom and gid fields
+		// and if not add them to the list. If Fields list is empty/nil we will use '*' for the field list.
+		l.sql, err = genSQL(l, p.pool, tblName, fields)
+		if err != nil {
+			return fmt.Errorf("could not generate sql, for layer(%v): %v", l.name, err)
+		}
+	}
+
+	if debugLayerSQL {
+		log.Printf("SQL for Layer(%v):\n%v\n", l.name, l.sql)
+	}
 
-	return p, nil
+	// the statement used for the layer's tiles
+	l.stmt = bindTokens(l.sql, l.srid, nil)
+	if err = p.prepare(l.stmt); err != nil {
+		return fmt.Errorf("error preparing SQL for layer (%v): %v", l.name, err)
+	}
+
+	return nil
+}
+
+// zoomVariantConfig is the config of a zoom variant of a layer
+type zoomVariantConfig struct {
+	minZoom, maxZoom uint
+	tblName          string
+	sql              string
+	fields           []string
+}
+
+// parseZoomVariants parses the zoom variants config of the layer lname, sorted by
+// min zoom. The zoom ranges of the variants can't overlap.
+func parseZoomVariants(lname string, configs []dict.Dicter) ([]zoomVariantConfig, error) {
+	var variants []zoomVariantConfig
+	for i, config := range configs {
+		v := zoomVariantConfig{maxZoom: tegola.MaxZ}
+
+		var err error
+		if v.minZoom, err = config.Uint(ConfigKeyMinZoom, &v.minZoom); err != nil {
+			return nil, ErrInvalidZoomVariant{LayerName: lname, Reason: fmt.Sprintf("variant (%v) %v: %v", i, ConfigKeyMinZoom, err)}
+		}
+		if v.maxZoom, err = config.Uint(ConfigKeyMaxZoom, &v.maxZoom); err != nil {
+			return nil, ErrInvalidZoomVariant{LayerName: lname, Reason: fmt.Sprintf("variant (%v) %v: %v", i, ConfigKeyMaxZoom, err)}
+		}
+		if v.minZoom > v.maxZoom {
+			return nil, ErrInvalidZoomVariant{LayerName: lname, Reason: fmt.Sprintf("variant (%v) %v (%v) is greater than %v (%v)", i, ConfigKeyMinZoom, v.minZoom, ConfigKeyMaxZoom, v.maxZoom)}
+		}
+
+		if v.tblName, err = config.String(ConfigKeyTablename, &v.tblName); err != nil {
+			return nil, ErrInvalidZoomVariant{LayerName: lname, Reason: fmt.Sprintf("variant (%v) %v: %v", i, ConfigKeyTablename, err)}
+		}
+		if v.sql, err = config.String(ConfigKeySQL, &v.sql); err != nil {
+			return nil, ErrInvalidZoomVariant{LayerName: lname, Reason: fmt.Sprintf("variant (%v) %v: %v", i, ConfigKeySQL, err)}
+		}
+		if v.tblName == "" && v.sql == "" {
+			return nil, ErrInvalidZoomVariant{LayerName: lname, Reason: fmt.Sprintf("variant (%v) requires a %v or %v", i, ConfigKeyTablename, ConfigKeySQL)}
+		}
+		if v.tblName != "" && v.sql != "" {
+			log.Printf("both %v and %v for layer (%v) zoom variant (%v) were specified. Ignoring %v.", ConfigKeyTablename, ConfigKeySQL, lname, i, ConfigKeyTablename)
+		}
+
+		if v.fields, err = config.StringSlice(ConfigKeyFields); err != nil {
+			return nil, ErrInvalidZoomVariant{LayerName: lname, Reason: fmt.Sprintf("variant (%v) %v: %v", i, ConfigKeyFields, err)}
+		}
+
+		variants = append(variants, v)
+	}
+
+	sort.Slice(variants, func(i, j int) bool { return variants[i].minZoom < variants[j].minZoom })
+	for i := 1; i < len(variants); i++ {
+		if variants[i].minZoom <= variants[i-1].maxZoom {
+			return nil, ErrInvalidZoomVariant{LayerName: lname, Reason: fmt.Sprintf("zooms %v to %v overlap zooms %v to %v", variants[i].minZoom, variants[i].maxZoom, variants[i-1].minZoom, variants[i-1].maxZoom)}
+		}
+	}
+
+	return variants, nil
+}
+
+// layerZoomVariants returns the zoom variants of the layer l, the i-th layer of the
+// config, sorted by min zoom. A variant has the config of the layer other than its
+// SQL, which is built from the sql or tablename of the variant.
+func (p Provider) layerZoomVariants(l Layer, i int, configs []dict.Dicter) ([]Layer, error) {
+	vconfigs, err := parseZoomVariants(l.name, configs)
+	if err != nil {
+		return nil, err
+	}
+
+	var variants []Layer
+	for _, vc := range vconfigs {
+		v := l
+		v.sql, v.stmt, v.zoomVariants = "", tileSQL{}, nil
+		v.minZoom, v.maxZoom = vc.minZoom, vc.maxZoom
+		if err = p.setLayerSQL(&v, i, vc.tblName, vc.sql, vc.fields); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+
+	return variants, nil
 }
 
 // derived from github.com/jackc/pgx configTLS (https://github.com/jackc/pgx/blob/master/conn.go)
@@ -513,13 +690,31 @@ func (p Provider) TileFeatures(ctx context.Context, layer string, tile provider.
 		return ErrLayerNotFound{layer}
 	}
 
-	sql, err := replaceTokens(plyr.sql, plyr.srid, tile)
+	// use the variant of the layer for the tile's zoom
+	z, _, _ := tile.ZXY()
+	if plyr, ok = plyr.forZoom(z); !ok {
+		// the layer has no SQL for the zoom
+		return nil
+	}
+
+	stmt := plyr.stmt
+	if stmt.hasQueryParams {
+		// the statement depends on the query parameters of the request so it's prepared on first use
+		params, _ := provider.QueryParametersFromContext(ctx)
+		stmt = bindTokens(plyr.sql, plyr.srid, params)
+		if err := p.prepare(stmt); err != nil {
+			return fmt.Errorf("error preparing layer (%v) SQL (%v): %v", layer, stmt.sql, err)
+		}
+	}
+
+	sql := stmt.sql
+	args, err := stmt.args(tile)
 	if err != nil {
 		return fmt.Errorf("error replacing layer tokens for layer (%v) SQL (%v): %v", layer, sql, err)
 	}
 
 	if debugExecuteSQL {
-		log.Printf("%s:%s for layer (%v): %v", EnvSQLDebugName, EnvSQLDebugExecute, layer, sql)
+		log.Printf("%s:%s for layer (%v): %v %v", EnvSQLDebugName, EnvSQLDebugExecute, layer, sql, args)
 	}
 
 	// context check
@@ -527,9 +722,12 @@ func (p Provider) TileFeatures(ctx context.Context, layer string, tile provider.
 		return err
 	}
 
-	rows, err := p.pool.Query(sql)
+	qctx, cancel := queryContext(ctx, plyr)
+	defer cancel()
+
+	rows, err := p.pool.QueryEx(qctx, stmt.name, nil, args...)
 	if err != nil {
-		return fmt.Errorf("error running layer (%v) SQL (%v): %v", layer, sql, err)
+		return queryError(ctx, qctx, plyr, fmt.Errorf("error running layer (%v) SQL (%v): %v", layer, sql, err))
 	}
 	defer rows.Close()
 
@@ -553,14 +751,14 @@ func (p Provider) TileFeatures(ctx context.Context, layer string, tile provider.
 
 	for rows.Next() {
 		// context check
-		if err := ctx.Err(); err != nil {
-			return err
+		if err := qctx.Err(); err != nil {
+			return queryError(ctx, qctx, plyr, err)
 		}
 
 		// fetch row values
 		vals, err := rows.Values()
 		if err != nil {
-			return fmt.Errorf("error running layer (%v) SQL (%v): %v", layer, sql, err)
+			return queryError(ctx, qctx, plyr, fmt.Errorf("error running layer (%v) SQL (%v): %v", layer, sql, err))
 		}
 
 		gid, geobytes, tags, err := decipherFields(ctx, plyr.GeomFieldName(), plyr.IDFieldName(), fdescs, vals)
@@ -602,24 +800,77 @@ func (p Provider) TileFeatures(ctx context.Context, layer string, tile provider.
 		}
 	}
 
-	return rows.Err()
+	if err := rows.Err(); err != nil {
+		return queryError(ctx, qctx, plyr, err)
+	}
+
+	return nil
+}
+
+// queryContext returns the context the layer's queries are run with. Queries are
+// canceled on the database when the context is done.
+func queryContext(ctx context.Context, l Layer) (context.Context, context.CancelFunc) {
+	if l.queryTimeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, l.queryTimeout)
 }
 
-// Close will close the Provider's database connectio
-func (p *Provider) Close() { p.pool.Close() }
+// queryError returns the error of a query run with qctx, derived from ctx by
+// queryContext. If the query was stopped because the request was canceled the
+// error of ctx is returned, if the layer's query timeout passed a
+// provider.ErrQueryTimeout is returned. Otherwise err is returned.
+func queryError(ctx, qctx context.Context, l Layer, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if qctx.Err() == context.DeadlineExceeded {
+		return provider.ErrQueryTimeout{
+			Provider: Name,
+			Layer:    l.name,
+			Timeout:  l.queryTimeout,
+		}
+	}
+	return err
+}
+
+// Close will close the Provider's database connection. It's used to release
+// the provider once it's no longer in use (i.e. after a config reload)
+func (p *Provider) Close() error {
+	p.pool.Close()
+
+	// the provider no longer needs to be cleaned up. providers are copied (i.e. by the
+	// MVTProvider) so they're matched by their pool
+	providersMu.Lock()
+	for i := range providers {
+		if providers[i].pool == p.pool {
+			providers = append(providers[:i], providers[i+1:]...)
+			break
+		}
+	}
+	providersMu.Unlock()
+
+	return nil
+}
 
 // reference to all instantiated providers
-var providers []Provider
+var (
+	providersMu sync.Mutex
+	providers   []Provider
+)
 
 // Cleanup will close all database connections and destroy all previously instantiated Provider instances
 func Cleanup() {
-	if len(providers) > 0 {
+	providersMu.Lock()
+	open := providers
+	providers = make([]Provider, 0)
+	providersMu.Unlock()
+
+	if len(open) > 0 {
 		log.Printf("cleaning up postgis providers")
 	}
 
-	for i := range providers {
-		providers[i].Close()
+	for i := range open {
+		open[i].pool.Close()
 	}
-
-	providers = make([]Provider, 0)
 }
@@ -81,7 +81,7 @@ func TestLayerGeomType(t *testing.T) {
 				return
 			}
 
-			p := provider.(Provider)
+			p := provider.(*Provider)
 			layer := p.layers[tc.layerName]
 
 			if !reflect.DeepEqual(tc.geom, layer.geomType) {
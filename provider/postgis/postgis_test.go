@@ -427,6 +427,50 @@ func TestTileFeatures(t *testing.T) {
 			tile:                 provider.NewTile(1, 1, 1, 64, tegola.WebMercator),
 			expectedFeatureCount: 98,
 		},
+		"zoom variant": {
+			layerConfig: map[string]interface{}{
+				postgis.ConfigKeyLayerName: "land",
+				postgis.ConfigKeyTablename: "ne_10m_land_scale_rank",
+				postgis.ConfigKeyZoomVariants: []map[string]interface{}{
+					{
+						postgis.ConfigKeyMinZoom: uint(1),
+						postgis.ConfigKeyMaxZoom: uint(2),
+						postgis.ConfigKeySQL:     "(SELECT gid, geom, featurecla FROM ne_10m_land_scale_rank LIMIT 100) AS sub",
+					},
+				},
+			},
+			tile:                 provider.NewTile(1, 1, 1, 64, tegola.WebMercator),
+			expectedFeatureCount: 100,
+			expectedTags:         []string{"featurecla"},
+		},
+		"zoom variant not covering the zoom": {
+			layerConfig: map[string]interface{}{
+				postgis.ConfigKeyLayerName: "land",
+				postgis.ConfigKeyTablename: "ne_10m_land_scale_rank",
+				postgis.ConfigKeyZoomVariants: []map[string]interface{}{
+					{
+						postgis.ConfigKeyMinZoom: uint(2),
+						postgis.ConfigKeySQL:     "(SELECT gid, geom, featurecla FROM ne_10m_land_scale_rank LIMIT 100) AS sub",
+					},
+				},
+			},
+			tile:                 provider.NewTile(1, 1, 1, 64, tegola.WebMercator),
+			expectedFeatureCount: 4032,
+			expectedTags:         []string{"scalerank", "featurecla"},
+		},
+		"zoom variants only": {
+			layerConfig: map[string]interface{}{
+				postgis.ConfigKeyLayerName: "land",
+				postgis.ConfigKeyZoomVariants: []map[string]interface{}{
+					{
+						postgis.ConfigKeyMinZoom: uint(2),
+						postgis.ConfigKeySQL:     "(SELECT gid, geom, featurecla FROM ne_10m_land_scale_rank LIMIT 100) AS sub",
+					},
+				},
+			},
+			tile:                 provider.NewTile(1, 1, 1, 64, tegola.WebMercator),
+			expectedFeatureCount: 0,
+		},
 		"decode numeric(x,x) types": {
 			layerConfig: map[string]interface{}{
 				postgis.ConfigKeyLayerName:   "buildings",
@@ -0,0 +1,237 @@
+package postgis
+
+import (
+	"crypto/sha1"
+	"fmt"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/maths/proj"
+	"github.com/go-spatial/tegola/provider"
+)
+
+// tileEnvelopeToken is replaced with the unbuffered extent of the tile in the
+// tile's SRID. It's used by the SQL generated by the MVTProvider.
+const tileEnvelopeToken = "!TILE_ENVELOPE!"
+
+// tokenParam is a value computed from the tile which is bound to a statement parameter
+type tokenParam int
+
+const (
+	paramBBoxMinX tokenParam = iota
+	paramBBoxMinY
+	paramBBoxMaxX
+	paramBBoxMaxY
+	paramZoom
+	paramScaleDenominator
+	paramPixelWidth
+	paramPixelHeight
+	paramTileMinX
+	paramTileMinY
+	paramTileMaxX
+	paramTileMaxY
+	// the value of a query parameter
+	paramQuery
+)
+
+// tokenParams are the parameters each token is replaced with
+var tokenParams = map[string][]tokenParam{
+	bboxToken:             {paramBBoxMinX, paramBBoxMinY, paramBBoxMaxX, paramBBoxMaxY},
+	zoomToken:             {paramZoom},
+	scaleDenominatorToken: {paramScaleDenominator},
+	pixelWidthToken:       {paramPixelWidth},
+	pixelHeightToken:      {paramPixelHeight},
+	tileEnvelopeToken:     {paramTileMinX, paramTileMinY, paramTileMaxX, paramTileMaxY},
+}
+
+// tileSQL is SQL with its tokens replaced by statement parameters so a single
+// prepared statement can be used for every tile
+type tileSQL struct {
+	sql string
+	// the name of the prepared statement. statements are named after their SQL
+	// so preparing a statement which has already been prepared is a no-op
+	name string
+	// the SRID of the layer, the !BBOX! is transformed into it
+	srid uint64
+	// the value bound to each parameter
+	params []tokenParam
+	// the values of the paramQuery parameters by position
+	queryValues map[int]interface{}
+	// if the SQL has query parameter tokens. the statement then depends on the query
+	// parameters of the request
+	hasQueryParams bool
+}
+
+// bindTokens replaces the tokens in the SQL with statement parameters. See
+// replaceTokens for the supported tokens. Unknown tokens are left in the SQL.
+// The parameters are cast so their types don't depend on where the tokens are used.
+//
+// Query parameter tokens (i.e. !PARAM_YEAR!) are bound to the values in params,
+// cast to the type of the value. Tokens without a value are replaced with NULL.
+func bindTokens(sql string, srid uint64, params provider.QueryParameterValues) tileSQL {
+	ts := tileSQL{srid: srid}
+
+	// the position of the first parameter of tokens which have been bound
+	bound := map[string]int{}
+
+	ts.sql = tokenRe.ReplaceAllStringFunc(uppercaseTokens(sql), func(token string) string {
+		if provider.IsQueryParameterToken(token) {
+			ts.hasQueryParams = true
+			return ts.bindQueryParam(token, params, bound)
+		}
+
+		params, ok := tokenParams[token]
+		if !ok {
+			return token
+		}
+
+		first, ok := bound[token]
+		if !ok {
+			first = len(ts.params) + 1
+			bound[token] = first
+
+			ts.params = append(ts.params, params...)
+		}
+
+		switch token {
+		case bboxToken:
+			return fmt.Sprintf("ST_MakeEnvelope($%d::float8,$%d::float8,$%d::float8,$%d::float8,%d)", first, first+1, first+2, first+3, srid)
+		case tileEnvelopeToken:
+			// used as a box2d so the SRID is not needed
+			return fmt.Sprintf("ST_MakeEnvelope($%d::float8,$%d::float8,$%d::float8,$%d::float8)", first, first+1, first+2, first+3)
+		case zoomToken:
+			return fmt.Sprintf("$%d::integer", first)
+		default:
+			return fmt.Sprintf("$%d::float8", first)
+		}
+	})
+	ts.name = fmt.Sprintf("tegola_%x", sha1.Sum([]byte(ts.sql)))
+
+	return ts
+}
+
+// bindQueryParam binds the query parameter token to its value
+func (ts *tileSQL) bindQueryParam(token string, params provider.QueryParameterValues, bound map[string]int) string {
+	val, ok := params[token]
+	if !ok {
+		return "NULL"
+	}
+
+	pos, ok := bound[token]
+	if !ok {
+		pos = len(ts.params) + 1
+		bound[token] = pos
+
+		ts.params = append(ts.params, paramQuery)
+		if ts.queryValues == nil {
+			ts.queryValues = map[int]interface{}{}
+		}
+		ts.queryValues[pos-1] = val
+	}
+
+	switch val.(type) {
+	case int64:
+		return fmt.Sprintf("$%d::bigint", pos)
+	case float64:
+		return fmt.Sprintf("$%d::float8", pos)
+	case bool:
+		return fmt.Sprintf("$%d::boolean", pos)
+	default:
+		return fmt.Sprintf("$%d::text", pos)
+	}
+}
+
+// args returns the values bound to the statement parameters for the tile
+func (ts tileSQL) args(tile provider.Tile) ([]interface{}, error) {
+	if len(ts.params) == 0 {
+		return nil, nil
+	}
+
+	vals, err := newTokenValues(ts.srid, tile)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]interface{}, len(ts.params))
+	for i, param := range ts.params {
+		switch param {
+		case paramBBoxMinX:
+			args[i] = vals.bbox.MinX()
+		case paramBBoxMinY:
+			args[i] = vals.bbox.MinY()
+		case paramBBoxMaxX:
+			args[i] = vals.bbox.MaxX()
+		case paramBBoxMaxY:
+			args[i] = vals.bbox.MaxY()
+		case paramZoom:
+			args[i] = int32(vals.zoom)
+		case paramScaleDenominator:
+			args[i] = vals.scaleDenominator
+		case paramPixelWidth:
+			args[i] = vals.pixelWidth
+		case paramPixelHeight:
+			args[i] = vals.pixelHeight
+		case paramTileMinX:
+			args[i] = vals.extent.MinX()
+		case paramTileMinY:
+			args[i] = vals.extent.MinY()
+		case paramTileMaxX:
+			args[i] = vals.extent.MaxX()
+		case paramTileMaxY:
+			args[i] = vals.extent.MaxY()
+		case paramQuery:
+			args[i] = ts.queryValues[i]
+		}
+	}
+
+	return args, nil
+}
+
+// tokenValues are the values of the tokens for a tile
+type tokenValues struct {
+	// the buffered tile extent in the layer SRID
+	bbox *geom.Extent
+	// the unbuffered tile extent in the tile SRID
+	extent           *geom.Extent
+	zoom             uint
+	scaleDenominator float64
+	pixelWidth       float64
+	pixelHeight      float64
+}
+
+func newTokenValues(srid uint64, tile provider.Tile) (tokenValues, error) {
+	bufferedExtent, tileSRID := tile.BufferedExtent()
+
+	// convert the tile extent into the SRID of the layer
+	bboxExtent, err := proj.TransformExtent(tileSRID, srid, bufferedExtent)
+	if err != nil {
+		return tokenValues{}, fmt.Errorf("Error trying to convert tile extent: %v ", err)
+	}
+
+	// the pixel size is reported in meters
+	metersPerUnit := 1.0
+	if crs, err := proj.Lookup(tileSRID); err == nil {
+		metersPerUnit = crs.MetersPerUnit()
+	}
+
+	extent, _ := tile.Extent()
+	pixelWidth := (extent.MaxX() - extent.MinX()) * metersPerUnit / 256
+	pixelHeight := (extent.MaxY() - extent.MinY()) * metersPerUnit / 256
+
+	z, _, _ := tile.ZXY()
+
+	return tokenValues{
+		bbox:             bboxExtent,
+		extent:           extent,
+		zoom:             z,
+		scaleDenominator: pixelWidth / 0.00028, /* px size in m */
+		pixelWidth:       pixelWidth,
+		pixelHeight:      pixelHeight,
+	}, nil
+}
+
+// prepare prepares the statement on the connections of the provider's pool.
+// Connections created later by the pool prepare the statement when they connect.
+func (p Provider) prepare(ts tileSQL) error {
+	_, err := p.pool.Prepare(ts.name, ts.sql)
+	return err
+}
@@ -0,0 +1,116 @@
+package postgis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/provider"
+)
+
+func TestBindTokens(t *testing.T) {
+	type tcase struct {
+		sql         string
+		srid        uint64
+		tile        provider.Tile
+		params      provider.QueryParameterValues
+		expectedSQL string
+		expected    []interface{}
+	}
+
+	fn := func(tc tcase) func(t *testing.T) {
+		return func(t *testing.T) {
+			ts := bindTokens(tc.sql, tc.srid, tc.params)
+
+			if ts.sql != tc.expectedSQL {
+				t.Errorf("incorrect sql,\n Expected \n \t%v\n Got \n \t%v", tc.expectedSQL, ts.sql)
+				return
+			}
+
+			args, err := ts.args(tc.tile)
+			if err != nil {
+				t.Errorf("unexpected error, Expected nil Got %v", err)
+				return
+			}
+
+			if !reflect.DeepEqual(args, tc.expected) {
+				t.Errorf("incorrect args, Expected %v Got %v", tc.expected, args)
+				return
+			}
+
+			// the statement must be the same for every tile
+			if other := bindTokens(tc.sql, tc.srid, tc.params); other.name != ts.name {
+				t.Errorf("incorrect name, Expected %v Got %v", ts.name, other.name)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"no tokens": {
+			sql:         "SELECT * FROM foo",
+			srid:        tegola.WebMercator,
+			tile:        provider.NewTile(2, 1, 1, 64, tegola.WebMercator),
+			expectedSQL: "SELECT * FROM foo",
+		},
+		"bind BBOX": {
+			sql:         "SELECT * FROM foo WHERE geom && !BBOX! AND bar != 42",
+			srid:        tegola.WebMercator,
+			tile:        provider.NewTile(2, 1, 1, 64, tegola.WebMercator),
+			expectedSQL: "SELECT * FROM foo WHERE geom && ST_MakeEnvelope($1::float8,$2::float8,$3::float8,$4::float8,3857) AND bar != 42",
+			expected:    []interface{}{-1.017529720390625e+07, -156543.03390625, 156543.03390625, 1.017529720390625e+07},
+		},
+		"bind BBOX and ZOOM": {
+			sql:         "SELECT id, scalerank=!zoom! FROM foo WHERE geom && !BBOX! AND !ZOOM! > 10",
+			srid:        tegola.WebMercator,
+			tile:        provider.NewTile(16, 11241, 26168, 64, tegola.WebMercator),
+			expectedSQL: "SELECT id, scalerank=$1::integer FROM foo WHERE geom && ST_MakeEnvelope($2::float8,$3::float8,$4::float8,$5::float8,3857) AND $1::integer > 10",
+			expected:    []interface{}{int32(16), -1.3163688815956049e+07, 4.0352540420407765e+06, -1.3163058210472783e+07, 4.035884647524042e+06},
+		},
+		"bind pixel_width/height and scale_denominator": {
+			sql:         "SELECT id, !pixel_width! as width, !pixel_height! as height, !scale_denominator! as scale_denom FROM foo",
+			srid:        tegola.WebMercator,
+			tile:        provider.NewTile(11, 1070, 676, 64, tegola.WebMercator),
+			expectedSQL: "SELECT id, $1::float8 as width, $2::float8 as height, $3::float8 as scale_denom FROM foo",
+			expected:    []interface{}{76.43702827453671, 76.43702827453671, 272989.38669477403},
+		},
+		"bind TILE_ENVELOPE": {
+			sql:         "SELECT ST_AsMVTGeom(geom, !TILE_ENVELOPE!, 4096, 64, true) FROM foo",
+			srid:        tegola.WebMercator,
+			tile:        provider.NewTile(0, 0, 0, 64, tegola.WebMercator),
+			expectedSQL: "SELECT ST_AsMVTGeom(geom, ST_MakeEnvelope($1::float8,$2::float8,$3::float8,$4::float8), 4096, 64, true) FROM foo",
+			expected:    []interface{}{-2.003750834e+07, -2.003750834e+07, 2.003750834e+07, 2.003750834e+07},
+		},
+		"bind query parameters": {
+			sql:         "SELECT * FROM foo WHERE geom && !BBOX! AND year = !PARAM_year! AND category = !PARAM_CATEGORY! AND year < !PARAM_YEAR! + 10",
+			srid:        tegola.WebMercator,
+			tile:        provider.NewTile(2, 1, 1, 64, tegola.WebMercator),
+			params:      provider.QueryParameterValues{"!PARAM_YEAR!": int64(2019), "!PARAM_CATEGORY!": "roads"},
+			expectedSQL: "SELECT * FROM foo WHERE geom && ST_MakeEnvelope($1::float8,$2::float8,$3::float8,$4::float8,3857) AND year = $5::bigint AND category = $6::text AND year < $5::bigint + 10",
+			expected:    []interface{}{-1.017529720390625e+07, -156543.03390625, 156543.03390625, 1.017529720390625e+07, int64(2019), "roads"},
+		},
+		"bind typed query parameters": {
+			sql:         "SELECT * FROM foo WHERE ratio > !PARAM_ratio! AND visible = !PARAM_visible!",
+			srid:        tegola.WebMercator,
+			tile:        provider.NewTile(2, 1, 1, 64, tegola.WebMercator),
+			params:      provider.QueryParameterValues{"!PARAM_RATIO!": 0.5, "!PARAM_VISIBLE!": true},
+			expectedSQL: "SELECT * FROM foo WHERE ratio > $1::float8 AND visible = $2::boolean",
+			expected:    []interface{}{0.5, true},
+		},
+		"query parameter without value": {
+			sql:         "SELECT * FROM foo WHERE year = !PARAM_year!",
+			srid:        tegola.WebMercator,
+			tile:        provider.NewTile(2, 1, 1, 64, tegola.WebMercator),
+			expectedSQL: "SELECT * FROM foo WHERE year = NULL",
+		},
+		"unknown token": {
+			sql:         "SELECT * FROM foo WHERE bar = '!FOO!'",
+			srid:        tegola.WebMercator,
+			tile:        provider.NewTile(2, 1, 1, 64, tegola.WebMercator),
+			expectedSQL: "SELECT * FROM foo WHERE bar = '!FOO!'",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
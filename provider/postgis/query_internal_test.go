@@ -0,0 +1,90 @@
+package postgis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/provider"
+)
+
+func TestQueryError(t *testing.T) {
+	queryErr := errors.New("query error")
+
+	type tcase struct {
+		layer Layer
+		// cancel the request context
+		cancel bool
+		// wait for the query timeout to pass
+		wait        bool
+		expectedErr error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			qctx, qcancel := queryContext(ctx, tc.layer)
+			defer qcancel()
+
+			if tc.cancel {
+				cancel()
+			}
+			if tc.wait {
+				<-qctx.Done()
+			}
+
+			err := queryError(ctx, qctx, tc.layer, queryErr)
+			if err != tc.expectedErr {
+				t.Errorf("error, expected %v got %v", tc.expectedErr, err)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"no timeout": {
+			layer:       Layer{name: "land"},
+			expectedErr: queryErr,
+		},
+		"timeout not passed": {
+			layer:       Layer{name: "land", queryTimeout: time.Minute},
+			expectedErr: queryErr,
+		},
+		"timeout passed": {
+			layer: Layer{name: "land", queryTimeout: time.Millisecond},
+			wait:  true,
+			expectedErr: provider.ErrQueryTimeout{
+				Provider: Name,
+				Layer:    "land",
+				Timeout:  time.Millisecond,
+			},
+		},
+		"request canceled": {
+			layer:       Layer{name: "land", queryTimeout: time.Minute},
+			cancel:      true,
+			expectedErr: context.Canceled,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestNewTileProviderInvalidQueryTimeout(t *testing.T) {
+	_, err := NewTileProvider(dict.Dict{
+		ConfigKeyHost:         "localhost",
+		ConfigKeyDB:           "tegola",
+		ConfigKeyUser:         "tegola",
+		ConfigKeyPassword:     "",
+		ConfigKeyQueryTimeout: -1,
+	})
+
+	expected := ErrInvalidQueryTimeout{Timeout: -1}
+	if err != expected {
+		t.Errorf("error, expected %v got %v", expected, err)
+	}
+}
@@ -9,9 +9,7 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/go-spatial/geom"
 	"github.com/go-spatial/tegola"
-	"github.com/go-spatial/tegola/basic"
 	"github.com/go-spatial/tegola/provider"
 	"github.com/jackc/pgx"
 	"github.com/jackc/pgx/pgtype"
@@ -73,25 +71,26 @@ func genSQL(l *Layer, pool *pgx.ConnPool, tblname string, flds []string) (sql st
 		if f == l.geomField {
 			fgeom = i
 		}
-		flds[i] = fmt.Sprintf(`"%v"`, flds[i])
+		flds[i] = pgx.Identifier{flds[i]}.Sanitize()
 	}
 
 	// to avoid field names possibly colliding with Postgres keywords,
-	// we wrap the field names in quotes
+	// we wrap the field names in quotes. quotes in the names are escaped
+	geomField := pgx.Identifier{l.geomField}.Sanitize()
 	if fgeom == -1 {
-		flds = append(flds, fmt.Sprintf(`ST_AsBinary("%v") AS "%[1]v"`, l.geomField))
+		flds = append(flds, fmt.Sprintf(`ST_AsBinary(%v) AS %[1]v`, geomField))
 	} else {
-		flds[fgeom] = fmt.Sprintf(`ST_AsBinary("%v") AS "%[1]v"`, l.geomField)
+		flds[fgeom] = fmt.Sprintf(`ST_AsBinary(%v) AS %[1]v`, geomField)
 	}
 
 	// add required id field
 	if l.idField != "" {
-		flds = append(flds, fmt.Sprintf(`"%v"`, l.idField))
+		flds = append(flds, pgx.Identifier{l.idField}.Sanitize())
 	}
 
 	selectClause := strings.Join(flds, ", ")
 
-	return fmt.Sprintf(stdSQL, selectClause, tblname, l.geomField), nil
+	return fmt.Sprintf(stdSQL, selectClause, tblname, geomField), nil
 }
 
 const (
@@ -109,45 +108,34 @@ const (
 // !SCALE_DENOMINATOR! - scale denominator, assuming 90.7 DPI (i.e. 0.28mm pixel size)
 // !PIXEL_WIDTH! - the pixel width in meters, assuming 256x256 tiles
 // !PIXEL_HEIGHT! - the pixel height in meters, assuming 256x256 tiles
+//
+// Query parameter tokens (i.e. !PARAM_YEAR!) are replaced with NULL. Their values are only
+// known when a tile is requested, see bindTokens.
 func replaceTokens(sql string, srid uint64, tile provider.Tile) (string, error) {
-
-	bufferedExtent, _ := tile.BufferedExtent()
-
-	// TODO: leverage helper functions for minx / miny to make this easier to follow
-	// TODO: it's currently assumed the tile will always be in WebMercator. Need to support different projections
-	minGeo, err := basic.FromWebMercator(srid, geom.Point{bufferedExtent.MinX(), bufferedExtent.MinY()})
-	if err != nil {
-		return "", fmt.Errorf("Error trying to convert tile point: %v ", err)
-	}
-
-	maxGeo, err := basic.FromWebMercator(srid, geom.Point{bufferedExtent.MaxX(), bufferedExtent.MaxY()})
+	vals, err := newTokenValues(srid, tile)
 	if err != nil {
-		return "", fmt.Errorf("Error trying to convert tile point: %v ", err)
+		return "", err
 	}
 
-	minPt, maxPt := minGeo.(geom.Point), maxGeo.(geom.Point)
-
-	bbox := fmt.Sprintf("ST_MakeEnvelope(%g,%g,%g,%g,%d)", minPt.X(), minPt.Y(), maxPt.X(), maxPt.Y(), srid)
-
-	extent, _ := tile.Extent()
-	// TODO: Always convert to meter if we support different projections
-	pixelWidth := (extent.MaxX() - extent.MinX()) / 256
-	pixelHeight := (extent.MaxY() - extent.MinY()) / 256
-	scaleDenominator := pixelWidth / 0.00028 /* px size in m */
+	bbox := fmt.Sprintf("ST_MakeEnvelope(%g,%g,%g,%g,%d)", vals.bbox.MinX(), vals.bbox.MinY(), vals.bbox.MaxX(), vals.bbox.MaxY(), srid)
 
 	// replace query string tokens
-	z, _, _ := tile.ZXY()
 	tokenReplacer := strings.NewReplacer(
 		bboxToken, bbox,
-		zoomToken, strconv.FormatUint(uint64(z), 10),
-		scaleDenominatorToken, strconv.FormatFloat(scaleDenominator, 'f', -1, 64),
-		pixelWidthToken, strconv.FormatFloat(pixelWidth, 'f', -1, 64),
-		pixelHeightToken, strconv.FormatFloat(pixelHeight, 'f', -1, 64),
+		zoomToken, strconv.FormatUint(uint64(vals.zoom), 10),
+		scaleDenominatorToken, strconv.FormatFloat(vals.scaleDenominator, 'f', -1, 64),
+		pixelWidthToken, strconv.FormatFloat(vals.pixelWidth, 'f', -1, 64),
+		pixelHeightToken, strconv.FormatFloat(vals.pixelHeight, 'f', -1, 64),
 	)
 
 	uppercaseTokenSQL := uppercaseTokens(sql)
 
-	return tokenReplacer.Replace(uppercaseTokenSQL), nil
+	return tokenRe.ReplaceAllStringFunc(tokenReplacer.Replace(uppercaseTokenSQL), func(token string) string {
+		if provider.IsQueryParameterToken(token) {
+			return "NULL"
+		}
+		return token
+	}), nil
 }
 
 var tokenRe = regexp.MustCompile("![a-zA-Z0-9_-]+!")
@@ -277,6 +265,8 @@ func gId(v interface{}) (gid uint64, err error) {
 		return uint64(aval), nil
 	case uint8:
 		return uint64(aval), nil
+	case int16:
+		return uint64(aval), nil
 	case uint16:
 		return uint64(aval), nil
 	case int32:
@@ -66,6 +66,12 @@ func TestReplaceTokens(t *testing.T) {
 			tile:     provider.NewTile(11, 1070, 676, 64, tegola.WebMercator),
 			expected: "SELECT id, 76.43702827453671 as width, 76.43702827453671 as height, 272989.38669477403 as scale_denom FROM foo WHERE geom && ST_MakeEnvelope(899816.6968478388,6.789748347570495e+06,919996.0723123164,6.809927723034973e+06,3857)",
 		},
+		"replace query parameters with NULL": {
+			sql:      "SELECT * FROM foo WHERE geom && !BBOX! AND year = !param_year!",
+			srid:     tegola.WebMercator,
+			tile:     provider.NewTile(2, 1, 1, 64, tegola.WebMercator),
+			expected: "SELECT * FROM foo WHERE geom && ST_MakeEnvelope(-1.017529720390625e+07,-156543.03390625,156543.03390625,1.017529720390625e+07,3857) AND year = NULL",
+		},
 	}
 
 	for name, tc := range tests {
@@ -114,6 +120,54 @@ func TestUppercaseTokens(t *testing.T) {
 	}
 }
 
+func TestGenSQL(t *testing.T) {
+	type tcase struct {
+		layer    Layer
+		tblname  string
+		fields   []string
+		expected string
+	}
+
+	fn := func(tc tcase) func(t *testing.T) {
+		return func(t *testing.T) {
+			// the fields are provided so the database is not queried
+			sql, err := genSQL(&tc.layer, nil, tc.tblname, tc.fields)
+			if err != nil {
+				t.Fatalf("unexpected error, Expected nil Got %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("incorrect sql,\n Expected \n \t%v\n Got \n \t%v", tc.expected, sql)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"fields": {
+			layer:    Layer{geomField: "geom", idField: "gid"},
+			tblname:  "roads",
+			fields:   []string{"name", "geom"},
+			expected: `SELECT "name", ST_AsBinary("geom") AS "geom", "gid" FROM roads WHERE "geom" && !BBOX!`,
+		},
+		"mixed case geometry field": {
+			layer:    Layer{geomField: "Geom"},
+			tblname:  "roads",
+			fields:   []string{"name"},
+			expected: `SELECT "name", ST_AsBinary("Geom") AS "Geom" FROM roads WHERE "Geom" && !BBOX!`,
+		},
+		"quoted field names": {
+			layer:    Layer{geomField: `geom"; DROP TABLE roads; --`, idField: `g"id`},
+			tblname:  `"public"."roads"`,
+			fields:   []string{`na"me`},
+			expected: `SELECT "na""me", ST_AsBinary("geom""; DROP TABLE roads; --") AS "geom""; DROP TABLE roads; --", "g""id" FROM "public"."roads" WHERE "geom""; DROP TABLE roads; --" && !BBOX!`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
 func TestDecipherFields(t *testing.T) {
 	ttools.ShouldSkip(t, TESTENV)
 
@@ -7,6 +7,7 @@ import (
 	"github.com/go-spatial/geom/slippy"
 	"github.com/go-spatial/tegola/dict"
 	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/maths/tilematrix"
 )
 
 // TODO(@ear7h) remove this atrocity from the code base
@@ -18,9 +19,18 @@ import (
 type tile_t struct {
 	slippy.Tile
 	buffer uint
+	matrix *tilematrix.TileMatrixSet
 }
 
+// NewTile returns a tile on the builtin tile matrix set of the srid (EPSG:3857 or EPSG:4326).
+// Use NewTileInMatrixSet for tiles on other grids.
 func NewTile(z, x, y, buf, srid uint) Tile {
+	return NewTileInMatrixSet(tilematrix.ForSRID(uint64(srid)), z, x, y, buf)
+}
+
+// NewTileInMatrixSet returns a tile on the provided tile matrix set. The buffer is in
+// vector tile pixels (see tilematrix.MvtTileDim).
+func NewTileInMatrixSet(tms *tilematrix.TileMatrixSet, z, x, y, buf uint) Tile {
 	return &tile_t{
 		Tile: slippy.Tile{
 			Z: z,
@@ -28,15 +38,17 @@ func NewTile(z, x, y, buf, srid uint) Tile {
 			Y: y,
 		},
 		buffer: buf,
+		matrix: tms,
 	}
 }
 
 func (tile *tile_t) Extent() (ext *geom.Extent, srid uint64) {
-	return tile.Extent3857(), 3857
+	return tile.matrix.Extent(tile.Z, tile.X, tile.Y), tile.matrix.SRID
 }
 
 func (tile *tile_t) BufferedExtent() (ext *geom.Extent, srid uint64) {
-	return tile.Extent3857().ExpandBy(slippy.Pixels2Webs(tile.Z, tile.buffer)), 3857
+	ext, srid = tile.Extent()
+	return ext.ExpandBy(tile.matrix.Pixels2Units(tile.Z, float64(tile.buffer))), srid
 }
 
 // Tile is an interface used by Tiler, it is an unnecessary abstraction and is
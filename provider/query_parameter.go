@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The types of query parameters. The value of a parameter is bound as an
+// int64, float64, string or bool respectively.
+const (
+	QueryParameterTypeInt    = "int"
+	QueryParameterTypeFloat  = "float"
+	QueryParameterTypeString = "string"
+	QueryParameterTypeBool   = "bool"
+)
+
+// QueryParameterTypes returns the supported query parameter types
+func QueryParameterTypes() []string {
+	return []string{
+		QueryParameterTypeInt,
+		QueryParameterTypeFloat,
+		QueryParameterTypeString,
+		QueryParameterTypeBool,
+	}
+}
+
+// QueryParameterTokenPrefix is the prefix of the SQL token of a query parameter
+const QueryParameterTokenPrefix = "!PARAM_"
+
+// QueryParameterToken returns the SQL token of the query parameter with the
+// given name (i.e. "year" -> "!PARAM_YEAR!"). Tokens are upper case as
+// providers match tokens case insensitively.
+func QueryParameterToken(name string) string {
+	return QueryParameterTokenPrefix + strings.ToUpper(name) + "!"
+}
+
+// IsQueryParameterToken reports if the upper case SQL token is the token of a query parameter
+func IsQueryParameterToken(token string) bool {
+	return strings.HasPrefix(token, QueryParameterTokenPrefix)
+}
+
+// QueryParameter is a parameter a map reads from the query string of tile
+// requests. Providers bind its value to the parameter's token in their SQL.
+type QueryParameter struct {
+	Name string
+	// one of the QueryParameterTypes
+	Type string
+	// Default is used when the request does not set the parameter.
+	// nil makes the parameter required
+	Default *string
+	// Values are the allowed values. Empty allows any value
+	Values []string
+	// Regex the whole value must match. Empty allows any value
+	Regex string
+
+	re *regexp.Regexp
+}
+
+// NewQueryParameter returns a query parameter after checking its type, regex and default value
+func NewQueryParameter(name, typ string, def *string, values []string, regex string) (QueryParameter, error) {
+	p := QueryParameter{
+		Name:    name,
+		Type:    typ,
+		Default: def,
+		Values:  values,
+		Regex:   regex,
+	}
+
+	var known bool
+	for _, t := range QueryParameterTypes() {
+		if t == typ {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return p, ErrUnknownQueryParameterType{Name: name, Type: typ}
+	}
+
+	if regex != "" {
+		var err error
+		// the regex must match the whole value
+		p.re, err = regexp.Compile("^(?:" + regex + ")$")
+		if err != nil {
+			return p, ErrInvalidQueryParameterRegex{Name: name, Regex: regex, Err: err}
+		}
+	}
+
+	if def != nil {
+		if _, err := p.Parse(*def); err != nil {
+			return p, err
+		}
+	}
+
+	return p, nil
+}
+
+// Token returns the SQL token of the parameter
+func (p QueryParameter) Token() string {
+	return QueryParameterToken(p.Name)
+}
+
+// Parse checks the raw value against the allowed values and the regex of the
+// parameter and converts it to the type of the parameter.
+func (p QueryParameter) Parse(raw string) (interface{}, error) {
+	if len(p.Values) > 0 {
+		var allowed bool
+		for _, v := range p.Values {
+			if v == raw {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, ErrInvalidQueryParameter{Name: p.Name, Value: raw, Reason: "not one of " + strings.Join(p.Values, ", ")}
+		}
+	}
+
+	if p.re != nil && !p.re.MatchString(raw) {
+		return nil, ErrInvalidQueryParameter{Name: p.Name, Value: raw, Reason: "does not match " + p.Regex}
+	}
+
+	var (
+		val interface{}
+		err error
+	)
+
+	switch p.Type {
+	case QueryParameterTypeInt:
+		val, err = strconv.ParseInt(raw, 10, 64)
+	case QueryParameterTypeFloat:
+		val, err = strconv.ParseFloat(raw, 64)
+	case QueryParameterTypeBool:
+		val, err = strconv.ParseBool(raw)
+	case QueryParameterTypeString:
+		val = raw
+	default:
+		return nil, ErrUnknownQueryParameterType{Name: p.Name, Type: p.Type}
+	}
+	if err != nil {
+		return nil, ErrInvalidQueryParameter{Name: p.Name, Value: raw, Reason: "not a valid " + p.Type}
+	}
+
+	return val, nil
+}
+
+// QueryParameterValues are the values of the query parameters of a request keyed by their token
+type QueryParameterValues map[string]interface{}
+
+type queryParametersKey struct{}
+
+// ContextWithQueryParameters returns a copy of ctx which carries the query parameter values
+func ContextWithQueryParameters(ctx context.Context, vals QueryParameterValues) context.Context {
+	return context.WithValue(ctx, queryParametersKey{}, vals)
+}
+
+// QueryParametersFromContext returns the query parameter values carried by ctx
+func QueryParametersFromContext(ctx context.Context) (vals QueryParameterValues, ok bool) {
+	vals, ok = ctx.Value(queryParametersKey{}).(QueryParameterValues)
+	return vals, ok
+}
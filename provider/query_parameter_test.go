@@ -0,0 +1,148 @@
+package provider_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/tegola/provider"
+)
+
+func TestQueryParameterParse(t *testing.T) {
+	type tcase struct {
+		typ      string
+		values   []string
+		regex    string
+		raw      string
+		expected interface{}
+		err      error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			p, err := provider.NewQueryParameter("p", tc.typ, nil, tc.values, tc.regex)
+			if err != nil {
+				t.Fatalf("error creating parameter, expected nil got %v", err)
+			}
+
+			val, err := p.Parse(tc.raw)
+			if !reflect.DeepEqual(err, tc.err) {
+				t.Errorf("error, expected %v got %v", tc.err, err)
+				return
+			}
+			if val != tc.expected {
+				t.Errorf("value, expected %v (%T) got %v (%T)", tc.expected, tc.expected, val, val)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"int": {
+			typ:      provider.QueryParameterTypeInt,
+			raw:      "2019",
+			expected: int64(2019),
+		},
+		"invalid int": {
+			typ: provider.QueryParameterTypeInt,
+			raw: "2019; DROP TABLE foo",
+			err: provider.ErrInvalidQueryParameter{Name: "p", Value: "2019; DROP TABLE foo", Reason: "not a valid int"},
+		},
+		"float": {
+			typ:      provider.QueryParameterTypeFloat,
+			raw:      "0.5",
+			expected: 0.5,
+		},
+		"bool": {
+			typ:      provider.QueryParameterTypeBool,
+			raw:      "true",
+			expected: true,
+		},
+		"string": {
+			typ:      provider.QueryParameterTypeString,
+			raw:      "roads",
+			expected: "roads",
+		},
+		"allowed value": {
+			typ:      provider.QueryParameterTypeString,
+			values:   []string{"roads", "rail"},
+			raw:      "rail",
+			expected: "rail",
+		},
+		"not allowed value": {
+			typ:    provider.QueryParameterTypeString,
+			values: []string{"roads", "rail"},
+			raw:    "water",
+			err:    provider.ErrInvalidQueryParameter{Name: "p", Value: "water", Reason: "not one of roads, rail"},
+		},
+		"regex": {
+			typ:      provider.QueryParameterTypeString,
+			regex:    "[a-z]+",
+			raw:      "tenant",
+			expected: "tenant",
+		},
+		"regex matches the whole value": {
+			typ:   provider.QueryParameterTypeString,
+			regex: "[a-z]+",
+			raw:   "tenant'--",
+			err:   provider.ErrInvalidQueryParameter{Name: "p", Value: "tenant'--", Reason: "does not match [a-z]+"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestNewQueryParameter(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	type tcase struct {
+		typ   string
+		def   *string
+		regex string
+		err   error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			p, err := provider.NewQueryParameter("year", tc.typ, tc.def, nil, tc.regex)
+			if tc.err != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tc.err) {
+					t.Errorf("error, expected %T got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error, expected nil got %v", err)
+			}
+
+			if p.Token() != "!PARAM_YEAR!" {
+				t.Errorf("token, expected !PARAM_YEAR! got %v", p.Token())
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"valid": {
+			typ: provider.QueryParameterTypeInt,
+			def: str("2019"),
+		},
+		"unknown type": {
+			typ: "date",
+			err: provider.ErrUnknownQueryParameterType{},
+		},
+		"invalid regex": {
+			typ:   provider.QueryParameterTypeString,
+			regex: "[a-z",
+			err:   provider.ErrInvalidQueryParameterRegex{},
+		},
+		"invalid default": {
+			typ: provider.QueryParameterTypeInt,
+			def: str("last year"),
+			err: provider.ErrInvalidQueryParameter{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
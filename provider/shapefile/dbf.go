@@ -0,0 +1,193 @@
+package shapefile
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// dbfField is a field descriptor of a dBASE file
+type dbfField struct {
+	name string
+	// the dBASE type: C (character), N (numeric), F (float), L (logical) or D (date)
+	typ      byte
+	offset   int
+	length   int
+	decimals int
+}
+
+// dbfFile reads the attributes of the records from a dBASE (.dbf) file
+type dbfFile struct {
+	path       string
+	file       *os.File
+	numRecords int
+	headerLen  int64
+	recordLen  int64
+	fields     []dbfField
+	// decodes the character fields to UTF-8
+	decode func([]byte) string
+}
+
+// openDbf opens the .dbf file. Character fields are decoded with the code page
+// of the .cpg file, which is read from cpgPath when it exists.
+func openDbf(path, cpgPath string) (*dbfFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, ErrInvalidFilePath{FilePath: path}
+	}
+
+	header := make([]byte, 32)
+	if _, err := io.ReadFull(f, header); err != nil {
+		f.Close()
+		return nil, ErrInvalidFile{FilePath: path, Reason: "not a dBASE file"}
+	}
+
+	dbf := dbfFile{
+		path:       path,
+		file:       f,
+		numRecords: int(binary.LittleEndian.Uint32(header[4:])),
+		headerLen:  int64(binary.LittleEndian.Uint16(header[8:])),
+		recordLen:  int64(binary.LittleEndian.Uint16(header[10:])),
+		decode:     codePage(cpgPath),
+	}
+
+	if dbf.headerLen < 33 {
+		f.Close()
+		return nil, ErrInvalidFile{FilePath: path, Reason: "not a dBASE file"}
+	}
+
+	descriptors := make([]byte, dbf.headerLen-32)
+	if _, err := io.ReadFull(f, descriptors); err != nil {
+		f.Close()
+		return nil, ErrInvalidFile{FilePath: path, Reason: "truncated header"}
+	}
+
+	// the field descriptors are 32 bytes each and terminated by 0x0D. the first byte
+	// of a record is the deletion flag.
+	offset := 1
+	for b := descriptors; len(b) >= 32 && b[0] != 0x0D; b = b[32:] {
+		name := b[:11]
+		if i := strings.IndexByte(string(name), 0); i >= 0 {
+			name = name[:i]
+		}
+
+		field := dbfField{
+			name:     strings.TrimSpace(string(name)),
+			typ:      b[11],
+			offset:   offset,
+			length:   int(b[16]),
+			decimals: int(b[17]),
+		}
+		offset += field.length
+
+		dbf.fields = append(dbf.fields, field)
+	}
+
+	if int64(offset) > dbf.recordLen {
+		f.Close()
+		return nil, ErrInvalidFile{FilePath: path, Reason: "fields exceed the record length"}
+	}
+
+	return &dbf, nil
+}
+
+func (d *dbfFile) Close() error {
+	return d.file.Close()
+}
+
+// field returns the descriptor of the named field
+func (d *dbfFile) field(name string) (dbfField, bool) {
+	for _, f := range d.fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return dbfField{}, false
+}
+
+// record reads the raw record. Deleted records are reported as deleted.
+func (d *dbfFile) record(i int) (rec []byte, deleted bool, err error) {
+	rec = make([]byte, d.recordLen)
+	if _, err := d.file.ReadAt(rec, d.headerLen+int64(i)*d.recordLen); err != nil {
+		return nil, false, ErrInvalidFile{FilePath: d.path, Reason: "truncated record"}
+	}
+	return rec, rec[0] == '*', nil
+}
+
+// value decodes a field of a record to its native type: strings for character and
+// date (formatted as YYYY-MM-DD) fields, int64 for numeric fields without decimals,
+// float64 for the other numeric fields and bool for logical fields. Blank values
+// are nil.
+func (d *dbfFile) value(rec []byte, f dbfField) interface{} {
+	raw := rec[f.offset : f.offset+f.length]
+
+	switch f.typ {
+	case 'N', 'F':
+		s := strings.TrimSpace(string(raw))
+		if s == "" || strings.Trim(s, "*") == "" {
+			return nil
+		}
+		if f.typ == 'N' && f.decimals == 0 {
+			if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return v
+			}
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil
+		}
+		return v
+
+	case 'L':
+		switch strings.TrimSpace(string(raw)) {
+		case "T", "t", "Y", "y":
+			return true
+		case "F", "f", "N", "n":
+			return false
+		default:
+			return nil
+		}
+
+	case 'D':
+		s := strings.TrimSpace(string(raw))
+		if len(s) != 8 {
+			return nil
+		}
+		return s[:4] + "-" + s[4:6] + "-" + s[6:]
+
+	default:
+		s := strings.TrimRight(d.decode(raw), " \x00")
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+}
+
+// codePage returns the decoder of the code page named by the .cpg file. UTF-8 is
+// assumed when the file does not exist. Latin-1 and Windows-1252 are decoded as
+// Latin-1.
+func codePage(cpgPath string) func([]byte) string {
+	utf8 := func(b []byte) string { return string(b) }
+
+	cpg, err := ioutil.ReadFile(cpgPath)
+	if err != nil {
+		return utf8
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(string(cpg))) {
+	case "ISO-8859-1", "ISO88591", "8859-1", "88591", "LATIN1", "1252", "CP1252", "WINDOWS-1252":
+		return func(b []byte) string {
+			r := make([]rune, len(b))
+			for i, c := range b {
+				r[i] = rune(c)
+			}
+			return string(r)
+		}
+	default:
+		return utf8
+	}
+}
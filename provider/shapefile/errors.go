@@ -0,0 +1,59 @@
+package shapefile
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingLayerName = errors.New("shapefile: layer is missing 'name'")
+)
+
+type ErrInvalidFilePath struct {
+	FilePath string
+}
+
+func (e ErrInvalidFilePath) Error() string {
+	return fmt.Sprintf("shapefile: invalid filepath: %v", e.FilePath)
+}
+
+// ErrInvalidFile is returned when a file of a shapefile can't be decoded
+type ErrInvalidFile struct {
+	FilePath string
+	Reason   string
+}
+
+func (e ErrInvalidFile) Error() string {
+	return fmt.Sprintf("shapefile: invalid file (%v): %v", e.FilePath, e.Reason)
+}
+
+// ErrUnsupportedShapeType is returned for shapefiles of a shape type without a
+// corresponding geometry (i.e. MultiPatch)
+type ErrUnsupportedShapeType struct {
+	FilePath  string
+	ShapeType int32
+}
+
+func (e ErrUnsupportedShapeType) Error() string {
+	return fmt.Sprintf("shapefile: unsupported shape type (%v) in %v", e.ShapeType, e.FilePath)
+}
+
+// ErrUnknownSRID is returned when the SRID of a layer is neither configured nor
+// found in the .prj file of the shapefile
+type ErrUnknownSRID struct {
+	FilePath string
+}
+
+func (e ErrUnknownSRID) Error() string {
+	return fmt.Sprintf("shapefile: unable to determine the SRID of %v. add a .prj file or set the layer's 'srid'", e.FilePath)
+}
+
+// ErrUnknownField is returned when a configured field is not in the .dbf file
+type ErrUnknownField struct {
+	FilePath string
+	Field    string
+}
+
+func (e ErrUnknownField) Error() string {
+	return fmt.Sprintf("shapefile: field (%v) not found in %v", e.Field, e.FilePath)
+}
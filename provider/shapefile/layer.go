@@ -0,0 +1,170 @@
+package shapefile
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/internal/rtree"
+	"github.com/go-spatial/tegola/provider"
+)
+
+type Layer struct {
+	name     string
+	geomType geom.Geometry
+	srid     uint64
+
+	shp *shpFile
+	dbf *dbfFile
+	// the fields of the .dbf file encoded as tags
+	tagFields []dbfField
+	// the field of the feature id. the record number is used when it's not configured
+	idField *dbfField
+	index   *rtree.RTree
+}
+
+func (l Layer) Name() string            { return l.name }
+func (l Layer) GeomType() geom.Geometry { return l.geomType }
+func (l Layer) SRID() uint64            { return l.srid }
+
+// openLayer opens the files of the shapefile at shpPath and indexes the bounding
+// boxes of its records. fields limits the tags to the listed fields, all the fields
+// of the .dbf file are tags when it's empty. srid is used when it's not 0, otherwise
+// the SRID is read from the .prj file.
+func openLayer(name, shpPath, idFieldname string, fields []string, srid uint64) (*Layer, error) {
+	shpExt := filepath.Ext(shpPath)
+	if !strings.EqualFold(shpExt, ".shp") {
+		return nil, ErrInvalidFilePath{FilePath: shpPath}
+	}
+
+	// the other files of the shapefile share the name and the case of the extension
+	base := strings.TrimSuffix(shpPath, shpExt)
+	sidecar := func(ext string) string {
+		if shpExt == ".SHP" {
+			return base + strings.ToUpper(ext)
+		}
+		return base + ext
+	}
+
+	if srid == 0 {
+		prj, err := ioutil.ReadFile(sidecar(".prj"))
+		if err != nil {
+			return nil, ErrUnknownSRID{FilePath: shpPath}
+		}
+		var ok bool
+		if srid, ok = sridFromPRJ(string(prj)); !ok {
+			return nil, ErrUnknownSRID{FilePath: shpPath}
+		}
+	}
+
+	shp, err := openShp(shpPath, sidecar(".shx"))
+	if err != nil {
+		return nil, err
+	}
+
+	layer := Layer{
+		name: name,
+		srid: srid,
+		shp:  shp,
+	}
+
+	var ok bool
+	if layer.geomType, ok = geomType(shp.shapeType); !ok {
+		layer.Close()
+		return nil, ErrUnsupportedShapeType{FilePath: shpPath, ShapeType: shp.shapeType}
+	}
+
+	if layer.dbf, err = openDbf(sidecar(".dbf"), sidecar(".cpg")); err != nil {
+		layer.Close()
+		return nil, err
+	}
+	if layer.dbf.numRecords != len(shp.offsets) {
+		layer.Close()
+		return nil, ErrInvalidFile{FilePath: sidecar(".dbf"), Reason: "the number of records does not match the .shp file"}
+	}
+
+	if idFieldname != "" {
+		f, ok := layer.dbf.field(idFieldname)
+		if !ok {
+			layer.Close()
+			return nil, ErrUnknownField{FilePath: sidecar(".dbf"), Field: idFieldname}
+		}
+		layer.idField = &f
+	}
+
+	if len(fields) == 0 {
+		for _, f := range layer.dbf.fields {
+			if f.name != idFieldname {
+				layer.tagFields = append(layer.tagFields, f)
+			}
+		}
+	}
+	for _, name := range fields {
+		f, ok := layer.dbf.field(name)
+		if !ok {
+			layer.Close()
+			return nil, ErrUnknownField{FilePath: sidecar(".dbf"), Field: name}
+		}
+		layer.tagFields = append(layer.tagFields, f)
+	}
+
+	bounds, err := shp.bounds()
+	if err != nil {
+		layer.Close()
+		return nil, err
+	}
+	layer.index = rtree.New(bounds)
+
+	return &layer, nil
+}
+
+// feature reads the geometry and attributes of the record. The feature is nil
+// for deleted records and null shapes.
+func (l *Layer) feature(record int) (*provider.Feature, error) {
+	rec, deleted, err := l.dbf.record(record)
+	if err != nil || deleted {
+		return nil, err
+	}
+
+	geo, err := l.shp.geometry(record)
+	if err != nil || geo == nil {
+		return nil, err
+	}
+
+	feature := provider.Feature{
+		// record numbers start at 1
+		ID:       uint64(record) + 1,
+		Geometry: geo,
+		SRID:     l.srid,
+		Tags:     map[string]interface{}{},
+	}
+
+	if l.idField != nil {
+		if feature.ID, err = provider.ConvertFeatureID(l.dbf.value(rec, *l.idField)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range l.tagFields {
+		if v := l.dbf.value(rec, f); v != nil {
+			feature.Tags[f.name] = v
+		}
+	}
+
+	return &feature, nil
+}
+
+// Close closes the files of the layer
+func (l *Layer) Close() error {
+	var err error
+	if l.shp != nil {
+		err = l.shp.Close()
+	}
+	if l.dbf != nil {
+		if dbfErr := l.dbf.Close(); err == nil {
+			err = dbfErr
+		}
+	}
+	return err
+}
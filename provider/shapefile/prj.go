@@ -0,0 +1,47 @@
+package shapefile
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-spatial/tegola"
+)
+
+// the EPSG authority of the coordinate reference system, which closes the WKT
+var prjAuthority = regexp.MustCompile(`AUTHORITY\[\s*"EPSG"\s*,\s*"?(\d+)"?\s*\]\s*\]\s*$`)
+
+// the name of the coordinate reference system
+var prjName = regexp.MustCompile(`^\s*(?:PROJCS|GEOGCS)\[\s*"([^"]*)"`)
+
+// the SRIDs of the coordinate reference systems written without an authority,
+// as is common in the ESRI flavor of WKT, by their name
+var prjNames = map[string]uint64{
+	"GCS_WGS_1984":                           tegola.WGS84,
+	"WGS 84":                                 tegola.WGS84,
+	"WGS84":                                  tegola.WGS84,
+	"WGS_1984_Web_Mercator_Auxiliary_Sphere": tegola.WebMercator,
+	"WGS_1984_Web_Mercator":                  tegola.WebMercator,
+	"WGS 84 / Pseudo-Mercator":               tegola.WebMercator,
+	"WGS_84_Pseudo_Mercator":                 tegola.WebMercator,
+	"Web_Mercator":                           tegola.WebMercator,
+	"Google Maps Global Mercator":            tegola.WebMercator,
+}
+
+// sridFromPRJ returns the SRID of the coordinate reference system of a .prj file.
+// ok is false if the SRID can't be determined.
+func sridFromPRJ(wkt string) (srid uint64, ok bool) {
+	wkt = strings.TrimSpace(wkt)
+
+	if m := prjAuthority.FindStringSubmatch(wkt); m != nil {
+		srid, err := strconv.ParseUint(m[1], 10, 64)
+		return srid, err == nil
+	}
+
+	if m := prjName.FindStringSubmatch(wkt); m != nil {
+		srid, ok := prjNames[m[1]]
+		return srid, ok
+	}
+
+	return 0, false
+}
@@ -0,0 +1,52 @@
+package shapefile
+
+import "testing"
+
+func TestSRIDFromPRJ(t *testing.T) {
+	type tcase struct {
+		wkt          string
+		expectedSRID uint64
+		expectedOK   bool
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			srid, ok := sridFromPRJ(tc.wkt)
+			if ok != tc.expectedOK {
+				t.Fatalf("ok, expected %v got %v", tc.expectedOK, ok)
+			}
+			if srid != tc.expectedSRID {
+				t.Errorf("srid, expected %v got %v", tc.expectedSRID, srid)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"epsg authority": {
+			wkt:          `PROJCS["ETRS89 / UTM zone 32N",GEOGCS["ETRS89",AUTHORITY["EPSG","4258"]],UNIT["metre",1,AUTHORITY["EPSG","9001"]],AUTHORITY["EPSG","25832"]]`,
+			expectedSRID: 25832,
+			expectedOK:   true,
+		},
+		"nested authority only": {
+			wkt: `PROJCS["unknown",GEOGCS["WGS 84",AUTHORITY["EPSG","4326"]],UNIT["metre",1,AUTHORITY["EPSG","9001"]]]`,
+		},
+		"esri geographic": {
+			wkt:          `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`,
+			expectedSRID: 4326,
+			expectedOK:   true,
+		},
+		"esri web mercator": {
+			wkt:          `PROJCS["WGS_1984_Web_Mercator_Auxiliary_Sphere",GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]]],PROJECTION["Mercator_Auxiliary_Sphere"],UNIT["Meter",1.0]]`,
+			expectedSRID: 3857,
+			expectedOK:   true,
+		},
+		"unknown name": {
+			wkt: `PROJCS["NAD_1983_StatePlane_California_III_FIPS_0403_Feet",GEOGCS["GCS_North_American_1983"]]`,
+		},
+		"empty": {},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -0,0 +1,218 @@
+package shapefile
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/maths/proj"
+	"github.com/go-spatial/tegola/provider"
+)
+
+const Name = "shapefile"
+
+// config keys
+const (
+	ConfigKeyDir         = "dir"
+	ConfigKeyLayers      = "layers"
+	ConfigKeyLayerName   = "name"
+	ConfigKeyFilePath    = "filepath"
+	ConfigKeyGeomIDField = "id_fieldname"
+	ConfigKeyFields      = "fields"
+	ConfigKeySRID        = "srid"
+)
+
+func init() {
+	provider.Register(Name, NewTileProvider, Cleanup)
+}
+
+// Provider serves the features of shapefiles. The geometries and attributes are read
+// from the files as tiles are requested. Only the bounding boxes of the records are
+// kept in memory to find the features of a tile.
+type Provider struct {
+	// the directory relative layer file paths are resolved against
+	Dir string
+	// map of layer name and the opened shapefile
+	layers map[string]*Layer
+}
+
+// NewTileProvider instantiates and returns a new shapefile provider or an error.
+// The config must contain the layers, each with a name and the path to the .shp
+// file. The .shx and .dbf files must be next to the .shp file.
+func NewTileProvider(config dict.Dicter) (provider.Tiler, error) {
+	dir, err := config.String(ConfigKeyDir, new(string))
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := config.MapSlice(ConfigKeyLayers)
+	if err != nil {
+		return nil, err
+	}
+
+	p := Provider{
+		Dir:    dir,
+		layers: make(map[string]*Layer),
+	}
+
+	lyrsSeen := make(map[string]int)
+	for i, layerConf := range layers {
+		layerName, err := layerConf.String(ConfigKeyLayerName, nil)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("for layer (%v) we got the following error trying to get the layer's name field: %v", i, err)
+		}
+		if layerName == "" {
+			p.Close()
+			return nil, ErrMissingLayerName
+		}
+
+		// check if we have already seen this layer
+		if j, ok := lyrsSeen[layerName]; ok {
+			p.Close()
+			return nil, fmt.Errorf("layer name (%v) is duplicated in both layer %v and layer %v", layerName, i, j)
+		}
+		lyrsSeen[layerName] = i
+
+		// the file defaults to the layer name in the directory
+		shpPath := layerName + ".shp"
+		if shpPath, err = layerConf.String(ConfigKeyFilePath, &shpPath); err != nil {
+			p.Close()
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+		if !filepath.IsAbs(shpPath) {
+			shpPath = filepath.Join(dir, shpPath)
+		}
+
+		idFieldname, err := layerConf.String(ConfigKeyGeomIDField, new(string))
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+
+		fields, err := layerConf.StringSlice(ConfigKeyFields)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("for layer (%v) %v %v field had the following error: %v", i, layerName, ConfigKeyFields, err)
+		}
+
+		srid, err := layerConf.Int(ConfigKeySRID, new(int))
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("for layer (%v) %v : %v", i, layerName, err)
+		}
+
+		layer, err := openLayer(layerName, shpPath, idFieldname, fields, uint64(srid))
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+
+		p.layers[layerName] = layer
+	}
+
+	// track the provider so we can clean it up later
+	providersMu.Lock()
+	providers = append(providers, &p)
+	providersMu.Unlock()
+
+	return &p, nil
+}
+
+func (p *Provider) Layers() ([]provider.LayerInfo, error) {
+	ls := make([]provider.LayerInfo, 0, len(p.layers))
+	for _, layer := range p.layers {
+		ls = append(ls, layer)
+	}
+	return ls, nil
+}
+
+func (p *Provider) TileFeatures(ctx context.Context, layer string, tile provider.Tile, fn func(f *provider.Feature) error) error {
+	pLayer, ok := p.layers[layer]
+	if !ok {
+		return fmt.Errorf("shapefile: layer (%v) not found", layer)
+	}
+
+	// read the tile extent
+	tileBBox, tileSRID := tile.BufferedExtent()
+
+	// check if the SRID of the layer differs from that of the tile
+	if pLayer.srid != tileSRID {
+		var err error
+		if tileBBox, err = proj.TransformExtent(tileSRID, pLayer.srid, tileBBox); err != nil {
+			return fmt.Errorf("error converting tile extent: %v ", err)
+		}
+	}
+
+	for _, record := range pLayer.index.Search(tileBBox) {
+		// check if the context cancelled or timed out
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		feature, err := pLayer.feature(record)
+		if err != nil {
+			return err
+		}
+		// deleted records and null shapes
+		if feature == nil {
+			continue
+		}
+
+		if err := fn(feature); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close closes the files of the Provider's layers. It's used to release the provider
+// once it's no longer in use (i.e. after a config reload)
+func (p *Provider) Close() error {
+	var err error
+	for _, layer := range p.layers {
+		if lerr := layer.Close(); lerr != nil && err == nil {
+			err = lerr
+		}
+	}
+
+	// the provider no longer needs to be cleaned up
+	providersMu.Lock()
+	for i := range providers {
+		if providers[i] == p {
+			providers = append(providers[:i], providers[i+1:]...)
+			break
+		}
+	}
+	providersMu.Unlock()
+
+	return err
+}
+
+// reference to all instantiated providers
+var (
+	providersMu sync.Mutex
+	providers   []*Provider
+)
+
+// Cleanup will close the files of all previously instantiated Provider instances
+func Cleanup() {
+	providersMu.Lock()
+	open := providers
+	providers = nil
+	providersMu.Unlock()
+
+	if len(open) > 0 {
+		log.Infof("cleaning up shapefile providers")
+	}
+
+	for _, p := range open {
+		if err := p.Close(); err != nil {
+			log.Errorf("err closing shapefile: %v", err)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package shapefile_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/shapefile"
+)
+
+func TestNewTileProvider(t *testing.T) {
+	type tcase struct {
+		config         dict.Dict
+		expectedLayers map[string]provider.LayerInfo
+		expectedErr    error
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			p, err := shapefile.NewTileProvider(tc.config)
+			if tc.expectedErr != nil {
+				if !reflect.DeepEqual(err, tc.expectedErr) {
+					t.Fatalf("error, expected %v got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			defer p.(*shapefile.Provider).Close()
+
+			ls, err := p.Layers()
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if len(ls) != len(tc.expectedLayers) {
+				t.Fatalf("layers, expected %v got %v", len(tc.expectedLayers), len(ls))
+			}
+			for _, l := range ls {
+				expected, ok := tc.expectedLayers[l.Name()]
+				if !ok {
+					t.Errorf("unexpected layer %v", l.Name())
+					continue
+				}
+				if reflect.TypeOf(l.GeomType()) != reflect.TypeOf(expected.GeomType()) {
+					t.Errorf("layer (%v) geometry type, expected %T got %T", l.Name(), expected.GeomType(), l.GeomType())
+				}
+				if l.SRID() != expected.SRID() {
+					t.Errorf("layer (%v) srid, expected %v got %v", l.Name(), expected.SRID(), l.SRID())
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"layers": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "points"},
+					{"name": "roads", "filepath": "lines.shp"},
+					{"name": "polygons", "srid": 4326},
+				},
+			},
+			expectedLayers: map[string]provider.LayerInfo{
+				"points":   layerInfo{geomType: geom.Point{}, srid: tegola.WGS84},
+				"roads":    layerInfo{geomType: geom.MultiLineString{}, srid: tegola.WebMercator},
+				"polygons": layerInfo{geomType: geom.MultiPolygon{}, srid: tegola.WGS84},
+			},
+		},
+		"missing layer name": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": ""},
+				},
+			},
+			expectedErr: shapefile.ErrMissingLayerName,
+		},
+		"invalid filepath": {
+			config: dict.Dict{
+				"layers": []map[string]interface{}{
+					{"name": "points", "filepath": "testdata/points.dbf"},
+				},
+			},
+			expectedErr: shapefile.ErrInvalidFilePath{FilePath: "testdata/points.dbf"},
+		},
+		"missing file": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "rivers", "srid": 4326},
+				},
+			},
+			expectedErr: shapefile.ErrInvalidFilePath{FilePath: "testdata/rivers.shx"},
+		},
+		"unknown srid": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "polygons"},
+				},
+			},
+			expectedErr: shapefile.ErrUnknownSRID{FilePath: "testdata/polygons.shp"},
+		},
+		"unknown field": {
+			config: dict.Dict{
+				"dir": "testdata",
+				"layers": []map[string]interface{}{
+					{"name": "points", "fields": []string{"NAME", "AREA"}},
+				},
+			},
+			expectedErr: shapefile.ErrUnknownField{FilePath: "testdata/points.dbf", Field: "AREA"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+type layerInfo struct {
+	geomType geom.Geometry
+	srid     uint64
+}
+
+func (l layerInfo) Name() string            { return "" }
+func (l layerInfo) GeomType() geom.Geometry { return l.geomType }
+func (l layerInfo) SRID() uint64            { return l.srid }
+
+func TestTileFeatures(t *testing.T) {
+	type tcase struct {
+		layerConfig      map[string]interface{}
+		tile             provider.Tile
+		expectedFeatures []provider.Feature
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			p, err := shapefile.NewTileProvider(dict.Dict{
+				"dir":    "testdata",
+				"layers": []map[string]interface{}{tc.layerConfig},
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			defer p.(*shapefile.Provider).Close()
+
+			var features []provider.Feature
+			err = p.TileFeatures(context.Background(), tc.layerConfig["name"].(string), tc.tile, func(f *provider.Feature) error {
+				features = append(features, *f)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if !reflect.DeepEqual(features, tc.expectedFeatures) {
+				t.Errorf("features, expected %v got %v", tc.expectedFeatures, features)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"points": {
+			layerConfig: map[string]interface{}{"name": "points", "id_fieldname": "ID"},
+			tile:        provider.NewTile(0, 0, 0, 64, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID:       10,
+					Geometry: geom.Point{-122.42, 37.77},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"NAME": "San Francisco", "POP": 815201.0, "CAPITAL": false, "FOUNDED": "1776-06-29"},
+				},
+				{
+					ID:       20,
+					Geometry: geom.Point{2.35, 48.86},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"NAME": "Paris", "POP": 2102650.5, "CAPITAL": true},
+				},
+				{
+					ID:       30,
+					Geometry: geom.Point{139.69, 35.69},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"NAME": "Tokyo", "CAPITAL": true, "FOUNDED": "1457-01-01"},
+				},
+			},
+		},
+		"points fields": {
+			layerConfig: map[string]interface{}{"name": "points", "fields": []string{"ID", "NAME"}},
+			// the tile east of the antimeridian in the north
+			tile: provider.NewTile(1, 1, 0, 0, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID:       2,
+					Geometry: geom.Point{2.35, 48.86},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"ID": int64(20), "NAME": "Paris"},
+				},
+				{
+					ID:       3,
+					Geometry: geom.Point{139.69, 35.69},
+					SRID:     tegola.WGS84,
+					Tags:     map[string]interface{}{"ID": int64(30), "NAME": "Tokyo"},
+				},
+			},
+		},
+		"lines": {
+			layerConfig: map[string]interface{}{"name": "lines"},
+			tile:        provider.NewTile(4, 8, 7, 64, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID: 1,
+					Geometry: geom.MultiLineString{
+						{{0, 0}, {1000, 1000}},
+						{{2000, 2000}, {3000, 2000}},
+					},
+					SRID: tegola.WebMercator,
+					Tags: map[string]interface{}{"NAME": "first", "LENGTH": 2414.214},
+				},
+			},
+		},
+		"polygons": {
+			layerConfig: map[string]interface{}{"name": "polygons", "srid": 4326},
+			tile:        provider.NewTile(0, 0, 0, 64, tegola.WebMercator),
+			expectedFeatures: []provider.Feature{
+				{
+					ID: 1,
+					Geometry: geom.Polygon{
+						{{0, 0}, {0, 10}, {10, 10}, {10, 0}},
+						{{2, 2}, {4, 2}, {4, 4}, {2, 4}},
+					},
+					SRID: tegola.WGS84,
+					Tags: map[string]interface{}{"NAME": "Zürich"},
+				},
+				{
+					ID: 3,
+					Geometry: geom.MultiPolygon{
+						{
+							{{20, 0}, {20, 5}, {25, 5}, {25, 0}},
+						},
+						{
+							{{30, 0}, {30, 5}, {35, 5}, {35, 0}},
+							{{31, 1}, {32, 1}, {32, 2}, {31, 2}},
+						},
+					},
+					SRID: tegola.WGS84,
+					Tags: map[string]interface{}{"NAME": "islands"},
+				},
+			},
+		},
+		"no features": {
+			layerConfig: map[string]interface{}{"name": "polygons", "srid": 4326},
+			tile:        provider.NewTile(2, 0, 3, 0, tegola.WebMercator),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -0,0 +1,356 @@
+package shapefile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+
+	"github.com/go-spatial/geom"
+)
+
+// The shape types of the ESRI Shapefile Technical Description
+// (https://www.esri.com/library/whitepapers/pdfs/shapefile.pdf). The Z and M variants
+// store the x and y values the same way as the plain types, so they are decoded as
+// 2D geometries.
+const (
+	shapeNull        = 0
+	shapePoint       = 1
+	shapePolyLine    = 3
+	shapePolygon     = 5
+	shapeMultiPoint  = 8
+	shapePointZ      = 11
+	shapePolyLineZ   = 13
+	shapePolygonZ    = 15
+	shapeMultiPointZ = 18
+	shapePointM      = 21
+	shapePolyLineM   = 23
+	shapePolygonM    = 25
+	shapeMultiPointM = 28
+)
+
+const (
+	// the size of the .shp and .shx file headers
+	headerSize = 100
+	// the file code of the .shp and .shx files
+	fileCode = 9994
+	// the size of a record header of the .shp file and of a record of the .shx file
+	recordHeaderSize = 8
+)
+
+// baseShapeType maps the Z and M shape types to their 2D shape type
+func baseShapeType(t int32) int32 {
+	switch t {
+	case shapePointZ, shapePointM:
+		return shapePoint
+	case shapePolyLineZ, shapePolyLineM:
+		return shapePolyLine
+	case shapePolygonZ, shapePolygonM:
+		return shapePolygon
+	case shapeMultiPointZ, shapeMultiPointM:
+		return shapeMultiPoint
+	default:
+		return t
+	}
+}
+
+// geomType returns the geometry type of the features of a shape type
+func geomType(t int32) (geom.Geometry, bool) {
+	switch baseShapeType(t) {
+	case shapePoint:
+		return geom.Point{}, true
+	case shapeMultiPoint:
+		return geom.MultiPoint{}, true
+	case shapePolyLine:
+		return geom.MultiLineString{}, true
+	case shapePolygon:
+		return geom.MultiPolygon{}, true
+	default:
+		return nil, false
+	}
+}
+
+// shpFile reads the records of a .shp file at the offsets of its .shx index
+type shpFile struct {
+	path      string
+	file      *os.File
+	shapeType int32
+	// the byte offsets and content lengths of the records
+	offsets []int64
+	lengths []int64
+}
+
+// openShp opens the .shp file and reads the record offsets from the .shx file
+func openShp(shpPath, shxPath string) (*shpFile, error) {
+	shx, err := ioutil.ReadFile(shxPath)
+	if err != nil {
+		return nil, ErrInvalidFilePath{FilePath: shxPath}
+	}
+	if len(shx) < headerSize || binary.BigEndian.Uint32(shx) != fileCode || (len(shx)-headerSize)%recordHeaderSize != 0 {
+		return nil, ErrInvalidFile{FilePath: shxPath, Reason: "not a shapefile index"}
+	}
+
+	f, err := os.Open(shpPath)
+	if err != nil {
+		return nil, ErrInvalidFilePath{FilePath: shpPath}
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(f, header); err != nil || binary.BigEndian.Uint32(header) != fileCode {
+		f.Close()
+		return nil, ErrInvalidFile{FilePath: shpPath, Reason: "not a shapefile"}
+	}
+
+	shp := shpFile{
+		path:      shpPath,
+		file:      f,
+		shapeType: int32(binary.LittleEndian.Uint32(header[32:])),
+	}
+
+	// offsets and lengths are stored in 16 bit words
+	for b := shx[headerSize:]; len(b) > 0; b = b[recordHeaderSize:] {
+		shp.offsets = append(shp.offsets, int64(binary.BigEndian.Uint32(b))*2)
+		shp.lengths = append(shp.lengths, int64(binary.BigEndian.Uint32(b[4:]))*2)
+	}
+
+	return &shp, nil
+}
+
+func (s *shpFile) Close() error {
+	return s.file.Close()
+}
+
+// bounds reads the bounding boxes of the records. Null shapes have a nil bounding box.
+func (s *shpFile) bounds() ([]*geom.Extent, error) {
+	bounds := make([]*geom.Extent, len(s.offsets))
+
+	// the records are read in order so they are buffered rather than read one by one
+	r := bufio.NewReaderSize(s.file, 1<<16)
+	var pos int64 = -1
+
+	// the shape type followed by the bounding box or the coordinates of a point
+	buf := make([]byte, recordHeaderSize+4+32)
+
+	for i, offset := range s.offsets {
+		if pos != offset {
+			if _, err := s.file.Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+			r.Reset(s.file)
+			pos = offset
+		}
+
+		n := recordHeaderSize + s.lengths[i]
+		if n > int64(len(buf)) {
+			n = int64(len(buf))
+		}
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return nil, ErrInvalidFile{FilePath: s.path, Reason: "truncated record"}
+		}
+		if _, err := r.Discard(int(recordHeaderSize + s.lengths[i] - n)); err != nil {
+			return nil, ErrInvalidFile{FilePath: s.path, Reason: "truncated record"}
+		}
+		pos += recordHeaderSize + s.lengths[i]
+
+		content := buf[recordHeaderSize:n]
+		if len(content) < 4 {
+			return nil, ErrInvalidFile{FilePath: s.path, Reason: "truncated record"}
+		}
+
+		switch baseShapeType(int32(binary.LittleEndian.Uint32(content))) {
+		case shapeNull:
+			continue
+		case shapePoint:
+			if len(content) < 20 {
+				return nil, ErrInvalidFile{FilePath: s.path, Reason: "truncated point"}
+			}
+			x, y := float64At(content, 4), float64At(content, 12)
+			bounds[i] = geom.NewExtent([2]float64{x, y})
+		default:
+			if len(content) < 36 {
+				return nil, ErrInvalidFile{FilePath: s.path, Reason: "truncated bounding box"}
+			}
+			bounds[i] = &geom.Extent{
+				float64At(content, 4), float64At(content, 12),
+				float64At(content, 20), float64At(content, 28),
+			}
+		}
+	}
+
+	return bounds, nil
+}
+
+// geometry reads the geometry of the record. The geometry of a null shape is nil.
+func (s *shpFile) geometry(i int) (geom.Geometry, error) {
+	content := make([]byte, s.lengths[i])
+	if _, err := s.file.ReadAt(content, s.offsets[i]+recordHeaderSize); err != nil {
+		return nil, ErrInvalidFile{FilePath: s.path, Reason: "truncated record"}
+	}
+
+	geo, ok := decodeShape(content)
+	if !ok {
+		return nil, ErrInvalidFile{FilePath: s.path, Reason: "invalid record"}
+	}
+	return geo, nil
+}
+
+// decodeShape decodes the content of a record. ok is false when the content is
+// truncated or the shape type is unsupported.
+func decodeShape(b []byte) (geo geom.Geometry, ok bool) {
+	if len(b) < 4 {
+		return nil, false
+	}
+
+	switch baseShapeType(int32(binary.LittleEndian.Uint32(b))) {
+	case shapeNull:
+		return nil, true
+
+	case shapePoint:
+		if len(b) < 20 {
+			return nil, false
+		}
+		return geom.Point{float64At(b, 4), float64At(b, 12)}, true
+
+	case shapeMultiPoint:
+		if len(b) < 40 {
+			return nil, false
+		}
+		numPoints := int(binary.LittleEndian.Uint32(b[36:]))
+		points, ok := pointsAt(b, 40, numPoints)
+		if !ok {
+			return nil, false
+		}
+		return geom.MultiPoint(points), true
+
+	case shapePolyLine, shapePolygon:
+		if len(b) < 44 {
+			return nil, false
+		}
+		numParts := int(binary.LittleEndian.Uint32(b[36:]))
+		numPoints := int(binary.LittleEndian.Uint32(b[40:]))
+		if numParts < 0 || numParts > (len(b)-44)/4 {
+			return nil, false
+		}
+		points, ok := pointsAt(b, 44+4*numParts, numPoints)
+		if !ok {
+			return nil, false
+		}
+
+		// split the points into the parts
+		parts := make([][][2]float64, 0, numParts)
+		for p := 0; p < numParts; p++ {
+			start := int(binary.LittleEndian.Uint32(b[44+4*p:]))
+			end := numPoints
+			if p+1 < numParts {
+				end = int(binary.LittleEndian.Uint32(b[44+4*(p+1):]))
+			}
+			if start < 0 || start > end || end > numPoints {
+				return nil, false
+			}
+			parts = append(parts, points[start:end])
+		}
+
+		if baseShapeType(int32(binary.LittleEndian.Uint32(b))) == shapePolyLine {
+			if len(parts) == 1 {
+				return geom.LineString(parts[0]), true
+			}
+			return geom.MultiLineString(parts), true
+		}
+
+		polygons := polygonsFromRings(parts)
+		if len(polygons) == 1 {
+			return geom.Polygon(polygons[0]), true
+		}
+		return geom.MultiPolygon(polygons), true
+
+	default:
+		return nil, false
+	}
+}
+
+// polygonsFromRings groups the rings of a polygon record into polygons. Outer rings
+// are clockwise and holes are counter clockwise. A hole belongs to the outer ring
+// which contains it, or the preceding outer ring if none does. The closing point
+// of the rings is dropped.
+func polygonsFromRings(rings [][][2]float64) [][][][2]float64 {
+	var polygons [][][][2]float64
+	var holes [][][2]float64
+
+	for _, ring := range rings {
+		if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+			ring = ring[:len(ring)-1]
+		}
+		if len(ring) < 3 {
+			continue
+		}
+
+		if signedArea(ring) <= 0 {
+			polygons = append(polygons, [][][2]float64{ring})
+			continue
+		}
+		holes = append(holes, ring)
+	}
+
+	// without an outer ring the winding is not to be trusted, so the rings are all
+	// treated as outer rings
+	if len(polygons) == 0 {
+		for _, hole := range holes {
+			polygons = append(polygons, [][][2]float64{hole})
+		}
+		return polygons
+	}
+
+	for _, hole := range holes {
+		owner := len(polygons) - 1
+		for i := range polygons {
+			if ringContains(polygons[i][0], hole[0]) {
+				owner = i
+				break
+			}
+		}
+		polygons[owner] = append(polygons[owner], hole)
+	}
+
+	return polygons
+}
+
+// signedArea returns twice the signed area of the ring. It's negative for clockwise rings.
+func signedArea(ring [][2]float64) (area float64) {
+	for i := range ring {
+		j := (i + 1) % len(ring)
+		area += ring[i][0]*ring[j][1] - ring[j][0]*ring[i][1]
+	}
+	return area
+}
+
+// ringContains reports if the point is inside the ring using the even-odd rule
+func ringContains(ring [][2]float64, pt [2]float64) (inside bool) {
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > pt[1]) != (b[1] > pt[1]) &&
+			pt[0] < (b[0]-a[0])*(pt[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// pointsAt decodes n points starting at offset. ok is false if b is too short.
+func pointsAt(b []byte, offset, n int) (points [][2]float64, ok bool) {
+	if n < 0 || offset > len(b) || n > (len(b)-offset)/16 {
+		return nil, false
+	}
+
+	points = make([][2]float64, n)
+	for i := range points {
+		points[i] = [2]float64{float64At(b, offset+16*i), float64At(b, offset+16*i+8)}
+	}
+	return points, true
+}
+
+// float64At decodes the little endian float64 at offset
+func float64At(b []byte, offset int) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(b[offset:]))
+}
@@ -0,0 +1,38 @@
+package test
+
+import (
+	"context"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/geom/encoding/mvt"
+	vectorTile "github.com/go-spatial/geom/encoding/mvt/vector_tile"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-spatial/tegola/provider"
+)
+
+// MVTTileProvider is a TileProvider which also satisfies the provider.MVTTiler
+// interface. MVTForLayer returns a layer with a single point in the center of the tile.
+type MVTTileProvider struct {
+	TileProvider
+}
+
+// MVTForLayer adheres to the provider.MVTTiler interface
+func (tp *MVTTileProvider) MVTForLayer(ctx context.Context, layer string, mvtName string, t provider.Tile, extent uint) ([]byte, error) {
+	l := mvt.Layer{
+		Name: mvtName,
+	}
+	l.SetExtent(int(extent))
+	l.AddFeatures(mvt.Feature{
+		Geometry: geom.Point{float64(extent) / 2, float64(extent) / 2},
+	})
+
+	vtl, err := l.VTileLayer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(&vectorTile.Tile{
+		Layers: []*vectorTile.Tile_Layer{vtl},
+	})
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Auth requires the requests for the maps which are not public to have credentials.
+// nil (default) serves all maps without credentials.
+// configurable via the tegola config.toml file (set in main.go)
+var Auth *Authenticator
+
+// ErrNoCredentials is returned when a request has no credentials
+var ErrNoCredentials = errors.New("server: no credentials")
+
+type ErrInvalidCredentials struct {
+	Reason string
+}
+
+func (e ErrInvalidCredentials) Error() string {
+	return fmt.Sprintf("server: invalid credentials: %v", e.Reason)
+}
+
+// A Grant is the maps and layers a credential can access
+type Grant struct {
+	// Maps are the names of the maps. All maps when empty
+	Maps []string
+	// Layers are the names of the map layers. All layers when empty.
+	Layers []string
+}
+
+// AllowsMap reports if the grant can access the map
+func (g Grant) AllowsMap(name string) bool {
+	return len(g.Maps) == 0 || containsString(g.Maps, name)
+}
+
+// AllowsLayer reports if the grant can access the map layer
+func (g Grant) AllowsLayer(name string) bool {
+	return len(g.Layers) == 0 || containsString(g.Layers, name)
+}
+
+func containsString(strs []string, s string) bool {
+	for i := range strs {
+		if strs[i] == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator checks the API keys and JSON Web Tokens of requests.
+//
+// Credentials are read from the Authorization header (Bearer scheme), the X-API-Key
+// header or the api_key and access_token query string parameters. Tokens are JWTs
+// if they have three dot separated parts, API keys otherwise.
+type Authenticator struct {
+	// APIKeys maps the accepted API keys to their grants
+	APIKeys map[string]Grant
+	// JWTSecret verifies HMAC (HS256, HS384 and HS512) signed tokens without a key id
+	JWTSecret []byte
+	// JWKS verifies tokens signed by its keys
+	JWKS *JWKS
+	// Issuer and Audience, when set, must match the iss and aud claims of the tokens
+	Issuer   string
+	Audience string
+}
+
+// Authenticate returns the grant of the request's credentials. ErrNoCredentials is
+// returned if the request does not have credentials.
+func (a *Authenticator) Authenticate(r *http.Request) (Grant, error) {
+	token := credentials(r)
+	if token == "" {
+		return Grant{}, ErrNoCredentials
+	}
+
+	if strings.Count(token, ".") == 2 && (len(a.JWTSecret) > 0 || a.JWKS != nil) {
+		grant, err := a.verifyJWT(token, time.Now())
+		if err != nil {
+			return Grant{}, ErrInvalidCredentials{Reason: err.Error()}
+		}
+		return grant, nil
+	}
+
+	// compare every key in constant time so the keys can't be guessed by timing requests
+	var (
+		grant Grant
+		found bool
+	)
+	for key, g := range a.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+			grant, found = g, true
+		}
+	}
+	if !found {
+		return Grant{}, ErrInvalidCredentials{Reason: "unknown API key"}
+	}
+
+	return grant, nil
+}
+
+// credentials returns the token of the request
+func credentials(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+			return strings.TrimSpace(auth[7:])
+		}
+		return ""
+	}
+
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	query := r.URL.Query()
+	if key := query.Get("api_key"); key != "" {
+		return key
+	}
+
+	return query.Get("access_token")
+}
+
+// ReadAPIKeys reads API keys, one per line. A key can be followed by a comma separated
+// list of the maps and a comma separated list of the layers it can access, or "*" for
+// all of them. Empty lines and lines starting with # are skipped.
+//
+//	key1
+//	key2 osm,natural_earth
+//	key3 osm roads,water
+func ReadAPIKeys(r io.Reader) (map[string]Grant, error) {
+	keys := map[string]Grant{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("API keys line %v: expected a key, maps and layers got %v fields", line, len(fields))
+		}
+
+		var grant Grant
+		if len(fields) > 1 {
+			grant.Maps = splitList(fields[1])
+		}
+		if len(fields) > 2 {
+			grant.Layers = splitList(fields[2])
+		}
+		keys[fields[0]] = grant
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// splitList splits a comma separated list, "*" is all values
+func splitList(s string) []string {
+	if s == "*" {
+		return nil
+	}
+
+	var vals []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
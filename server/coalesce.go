@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-spatial/tegola/cache"
+)
+
+// renderLockPollInterval is how often the cache is checked for a tile being
+// rendered by another tegola instance
+const renderLockPollInterval = 100 * time.Millisecond
+
+// tileFlight de-duplicates concurrent renders of the same tile. The first request
+// for a key renders the tile while the requests which arrive before it's done wait
+// for and share its result.
+type tileFlight struct {
+	sync.Mutex
+	calls map[string]*tileCall
+}
+
+// tileCall is a render in progress
+type tileCall struct {
+	done chan struct{}
+	// the rendered tile, nil if the render failed or was canceled
+	tile []byte
+	// canceled is true if the context of the rendering request was done
+	canceled bool
+}
+
+// renders is the tileFlight used by the TileCacheHandler
+var renders = tileFlight{calls: map[string]*tileCall{}}
+
+// do calls render if there isn't a render in progress for the key, otherwise it
+// waits for the render in progress and returns its tile with shared set to true.
+// A nil tile is returned if the shared render failed or ctx is done while waiting.
+// If the shared render is canceled by its request one of the waiting callers
+// renders the tile for the others.
+func (f *tileFlight) do(ctx context.Context, key string, render func() []byte) (tile []byte, shared bool) {
+	f.Lock()
+	for {
+		call, ok := f.calls[key]
+		if !ok {
+			break
+		}
+		f.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, true
+		}
+
+		if call.tile != nil || !call.canceled {
+			return call.tile, true
+		}
+
+		// the request rendering the tile was canceled, try to take over the render
+		f.Lock()
+	}
+
+	call := &tileCall{done: make(chan struct{})}
+	f.calls[key] = call
+	f.Unlock()
+
+	// release the waiters even if render panics
+	defer func() {
+		f.Lock()
+		delete(f.calls, key)
+		f.Unlock()
+		close(call.done)
+	}()
+
+	call.tile = render()
+	call.canceled = ctx.Err() != nil
+	return call.tile, false
+}
+
+// waitForTile polls the cache until the tile is cached by the instance holding the
+// render lock, the lock expires or ctx is done. nil is returned if the tile was not cached.
+func waitForTile(ctx context.Context, cacher cache.Interface, key *cache.Key, ttl time.Duration) []byte {
+	ticker := time.NewTicker(renderLockPollInterval)
+	defer ticker.Stop()
+
+	timeout := time.NewTimer(ttl)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timeout.C:
+			return nil
+		case <-ticker.C:
+			tile, hit, err := cacher.Get(key)
+			if err != nil {
+				return nil
+			}
+			if hit {
+				return tile
+			}
+		}
+	}
+}
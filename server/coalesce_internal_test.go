@@ -0,0 +1,301 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache"
+	"github.com/go-spatial/tegola/cache/memory"
+)
+
+func TestTileFlight(t *testing.T) {
+	type tcase struct {
+		// the tile returned by the render
+		tile []byte
+		// number of concurrent callers
+		callers int
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			f := tileFlight{calls: map[string]*tileCall{}}
+
+			var renderCount int32
+			release := make(chan struct{})
+			render := func() []byte {
+				atomic.AddInt32(&renderCount, 1)
+				<-release
+				return tc.tile
+			}
+
+			var wg sync.WaitGroup
+			var sharedCount int32
+			results := make([][]byte, tc.callers)
+
+			// start the leader and wait for it to register the call
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[0], _ = f.do(context.Background(), "key", render)
+			}()
+			for {
+				f.Lock()
+				_, ok := f.calls["key"]
+				f.Unlock()
+				if ok {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			for i := 1; i < tc.callers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					var shared bool
+					results[i], shared = f.do(context.Background(), "key", render)
+					if shared {
+						atomic.AddInt32(&sharedCount, 1)
+					}
+				}(i)
+			}
+
+			// give the followers a chance to join the call
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if renderCount != 1 {
+				t.Errorf("renders, expected 1 got %v", renderCount)
+			}
+			if int(sharedCount) != tc.callers-1 {
+				t.Errorf("shared, expected %v got %v", tc.callers-1, sharedCount)
+			}
+			for i, got := range results {
+				if !bytes.Equal(got, tc.tile) {
+					t.Errorf("caller %v tile, expected %s got %s", i, tc.tile, got)
+				}
+			}
+
+			if len(f.calls) != 0 {
+				t.Errorf("calls, expected the call to be removed got %v", len(f.calls))
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"rendered": {
+			tile:    []byte("tile"),
+			callers: 10,
+		},
+		"render failed": {
+			tile:    nil,
+			callers: 10,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestTileFlightCanceled(t *testing.T) {
+	f := tileFlight{calls: map[string]*tileCall{}}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	go f.do(context.Background(), "key", func() []byte {
+		close(started)
+		<-release
+		return []byte("tile")
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tile, shared := f.do(ctx, "key", func() []byte {
+		t.Error("render called for a coalesced request")
+		return nil
+	})
+	if !shared {
+		t.Errorf("shared, expected true got false")
+	}
+	if tile != nil {
+		t.Errorf("tile, expected nil got %s", tile)
+	}
+}
+
+// lockingCache is a memory cache which reports its keys as locked by another instance
+type lockingCache struct {
+	cache.Interface
+}
+
+func (lockingCache) Lock(key *cache.Key) (func(), bool, error) { return nil, false, nil }
+func (lockingCache) LockTTL() time.Duration                    { return time.Second }
+
+func TestTileCacheHandlerCoalesce(t *testing.T) {
+	type tcase struct {
+		// the cache is locked by another instance which sets the tile after a delay
+		locked bool
+		// number of concurrent requests
+		requests int
+		// expected renders by this instance
+		expRenders int32
+	}
+
+	tile := []byte("rendered tile")
+	uri := "/maps/test-map/3/1/2.pbf"
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			URIPrefix = "/"
+
+			mem, _ := memory.New(nil)
+			var cacher cache.Interface = mem
+			if tc.locked {
+				cacher = lockingCache{mem}
+
+				// the instance holding the lock caches the tile
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					key, _ := cache.ParseKey("/test-map/3/1/2.pbf")
+					mem.Set(key, tile)
+				}()
+			}
+
+			a := &atlas.Atlas{}
+			a.SetCache(cacher)
+
+			var renderCount int32
+			release := make(chan struct{})
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&renderCount, 1)
+				<-release
+				w.WriteHeader(http.StatusOK)
+				w.Write(tile)
+			})
+			h := TileCacheHandler(a, next)
+
+			var wg sync.WaitGroup
+			recorders := make([]*httptest.ResponseRecorder, tc.requests)
+			for i := range recorders {
+				recorders[i] = httptest.NewRecorder()
+
+				wg.Add(1)
+				go func(w *httptest.ResponseRecorder) {
+					defer wg.Done()
+					h.ServeHTTP(w, httptest.NewRequest("GET", uri, nil))
+				}(recorders[i])
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if renderCount != tc.expRenders {
+				t.Errorf("renders, expected %v got %v", tc.expRenders, renderCount)
+			}
+			for i, w := range recorders {
+				if !bytes.Equal(w.Body.Bytes(), tile) {
+					t.Errorf("request %v body, expected %s got %s", i, tile, w.Body.Bytes())
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"coalesced": {
+			requests:   10,
+			expRenders: 1,
+		},
+		"locked by another instance": {
+			locked:     true,
+			requests:   5,
+			expRenders: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestTileCacheHandlerCoalesceLeaderCanceled(t *testing.T) {
+	const waiters = 10
+
+	tile := []byte("rendered tile")
+	uri := "/maps/test-map/3/1/2.pbf"
+
+	URIPrefix = "/"
+
+	mem, _ := memory.New(nil)
+	a := &atlas.Atlas{}
+	a.SetCache(mem)
+
+	var renderCount int32
+	release := make(chan struct{})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&renderCount, 1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(tile)
+	})
+	h := TileCacheHandler(a, next)
+
+	// start the leader and wait for it to render
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", uri, nil).WithContext(ctx))
+	}()
+	for atomic.LoadInt32(&renderCount) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	recorders := make([]*httptest.ResponseRecorder, waiters)
+	for i := range recorders {
+		recorders[i] = httptest.NewRecorder()
+
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			h.ServeHTTP(w, httptest.NewRequest("GET", uri, nil))
+		}(recorders[i])
+	}
+
+	// give the waiters a chance to join the call before its request is canceled
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-leaderDone
+
+	// give a waiter a chance to take over the render
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// the canceled render and the render of the waiter which took it over
+	if renderCount != 2 {
+		t.Errorf("renders, expected 2 got %v", renderCount)
+	}
+	for i, w := range recorders {
+		if !bytes.Equal(w.Body.Bytes(), tile) {
+			t.Errorf("request %v body, expected %s got %s", i, tile, w.Body.Bytes())
+		}
+	}
+}
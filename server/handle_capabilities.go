@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-spatial/geom"
 	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/maths/tilematrix"
 )
 
 type Capabilities struct {
@@ -22,6 +23,17 @@ type CapabilitiesMap struct {
 	Tiles        []string            `json:"tiles"`
 	Capabilities string              `json:"capabilities"`
 	Layers       []CapabilitiesLayer `json:"layers"`
+	// TileMatrixSet is only reported for maps which are not served in WebMercatorQuad
+	TileMatrixSet *CapabilitiesTileMatrixSet `json:"tile_matrix_set,omitempty"`
+}
+
+type CapabilitiesTileMatrixSet struct {
+	Name        string       `json:"name"`
+	SRID        uint64       `json:"srid"`
+	Origin      [2]float64   `json:"origin"`
+	TileSize    uint         `json:"tile_size"`
+	Resolutions []float64    `json:"resolutions"`
+	Bounds      *geom.Extent `json:"bounds,omitempty"`
 }
 
 type CapabilitiesLayer struct {
@@ -42,8 +54,15 @@ func (req HandleCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	// parse our query string
 	var query = r.URL.Query()
 
+	// only list the maps the request can access
+	allowed := allowedMaps(r)
+
 	// iterate our registered maps
 	for _, m := range atlas.AllMaps() {
+		if !allowed(m) {
+			continue
+		}
+
 		debugQuery := url.Values{}
 
 		// if we have a debug param add it to our URLs
@@ -66,6 +85,18 @@ func (req HandleCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request)
 			Capabilities: buildCapabilitiesURL(r, []string{"capabilities", m.Name + ".json"}, debugQuery),
 		}
 
+		tms := m.Matrix()
+		if tms != tilematrix.WebMercatorQuad {
+			cMap.TileMatrixSet = &CapabilitiesTileMatrixSet{
+				Name:        tms.Name,
+				SRID:        tms.SRID,
+				Origin:      tms.Origin,
+				TileSize:    tms.TileSize,
+				Resolutions: tms.Resolutions,
+				Bounds:      tms.Bounds,
+			}
+		}
+
 		for i := range m.Layers {
 			// check if the layer already exists in our slice. this can happen if the config
 			// is using the "name" param for a layer to override the providerLayerName
@@ -78,7 +109,7 @@ func (req HandleCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request)
 					}
 
 					if cMap.Layers[j].MaxZoom < m.Layers[i].MaxZoom {
-						cMap.Layers[j].MaxZoom = m.Layers[i].MaxZoom
+						cMap.Layers[j].MaxZoom = clampZoom(m.Layers[i].MaxZoom, tms)
 					}
 
 					skip = true
@@ -97,7 +128,7 @@ func (req HandleCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request)
 					buildCapabilitiesURL(r, []string{"maps", m.Name, m.Layers[i].MVTName(), "{z}/{x}/{y}.pbf"}, debugQuery),
 				},
 				MinZoom: m.Layers[i].MinZoom,
-				MaxZoom: m.Layers[i].MaxZoom,
+				MaxZoom: clampZoom(m.Layers[i].MaxZoom, tms),
 			}
 
 			// add the layer to the map
@@ -119,3 +150,52 @@ func (req HandleCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	// setup a new json encoder and encode our capabilities
 	json.NewEncoder(w).Encode(capabilities)
 }
+
+// clampZoom limits a zoom to the zooms available in the tile matrix set
+func clampZoom(zoom uint, tms *tilematrix.TileMatrixSet) uint {
+	if zoom > tms.MaxZoom() {
+		return tms.MaxZoom()
+	}
+	return zoom
+}
+
+// tileLayer is a layer of the tiles of a map
+type tileLayer struct {
+	name     string
+	geomType geom.Geometry
+	minZoom  uint
+	maxZoom  uint
+}
+
+// tileLayers returns the layers of the map's tiles. Map layers with the same name are
+// encoded as a single layer so their zoom ranges are merged.
+func tileLayers(m atlas.Map) []tileLayer {
+	tms := m.Matrix()
+
+	var layers []tileLayer
+	index := map[string]int{}
+	for _, l := range m.Layers {
+		minZoom, maxZoom := clampZoom(l.MinZoom, tms), clampZoom(l.MaxZoom, tms)
+
+		i, ok := index[l.MVTName()]
+		if !ok {
+			index[l.MVTName()] = len(layers)
+			layers = append(layers, tileLayer{
+				name:     l.MVTName(),
+				geomType: l.GeomType,
+				minZoom:  minZoom,
+				maxZoom:  maxZoom,
+			})
+			continue
+		}
+
+		if layers[i].minZoom > minZoom {
+			layers[i].minZoom = minZoom
+		}
+		if layers[i].maxZoom < maxZoom {
+			layers[i].maxZoom = maxZoom
+		}
+	}
+
+	return layers
+}
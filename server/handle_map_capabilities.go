@@ -75,7 +75,11 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		m = m.AddDebugLayers()
 	}
 
+	tms := m.Matrix()
+
 	for i := range m.Layers {
+		layerMaxZoom := clampZoom(m.Layers[i].MaxZoom, tms)
+
 		// check if the layer already exists in our slice. this can happen if the config
 		// is using the "name" param for a layer to override the providerLayerName
 		var skip bool
@@ -86,8 +90,8 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 					tileJSON.VectorLayers[j].MinZoom = m.Layers[i].MinZoom
 				}
 
-				if tileJSON.VectorLayers[j].MaxZoom < m.Layers[i].MaxZoom {
-					tileJSON.VectorLayers[j].MaxZoom = m.Layers[i].MaxZoom
+				if tileJSON.VectorLayers[j].MaxZoom < layerMaxZoom {
+					tileJSON.VectorLayers[j].MaxZoom = layerMaxZoom
 				}
 
 				skip = true
@@ -98,7 +102,7 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		// the first layer sets the initial min / max otherwise they default to 0/0
 		if len(tileJSON.VectorLayers) == 0 {
 			tileJSON.MinZoom = m.Layers[i].MinZoom
-			tileJSON.MaxZoom = m.Layers[i].MaxZoom
+			tileJSON.MaxZoom = layerMaxZoom
 		}
 
 		// check if we have a min zoom lower then our current min
@@ -107,8 +111,8 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		}
 
 		// check if we have a max zoom higher then our current max
-		if tileJSON.MaxZoom < m.Layers[i].MaxZoom {
-			tileJSON.MaxZoom = m.Layers[i].MaxZoom
+		if tileJSON.MaxZoom < layerMaxZoom {
+			tileJSON.MaxZoom = layerMaxZoom
 		}
 
 		//	entry for layer already exists. move on
@@ -123,7 +127,7 @@ func (req HandleMapCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Reques
 			ID:      m.Layers[i].MVTName(),
 			Name:    m.Layers[i].MVTName(),
 			MinZoom: m.Layers[i].MinZoom,
-			MaxZoom: m.Layers[i].MaxZoom,
+			MaxZoom: layerMaxZoom,
 			Tiles: []string{
 				buildCapabilitiesURL(r, []string{"maps", req.mapName, m.Layers[i].MVTName(), "{z}/{x}/{y}.pbf"}, debugQuery),
 			},
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dimfeld/httptreemux"
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/provider"
+)
+
+// DefaultFeatureTolerance is the distance, in display pixels, around the queried
+// point that features are returned for when the request does not set a tolerance
+const DefaultFeatureTolerance = 5.0
+
+// MaxFeatureTolerance limits the area a feature query can cover
+const MaxFeatureTolerance = 256.0
+
+type HandleMapFeatures struct {
+	// required
+	mapName string
+	// optional
+	layerName string
+	// zoom
+	z uint
+	// WGS84 point
+	lng, lat float64
+	// display pixels around the point
+	tolerance float64
+	// the Atlas to use, nil (default) is the default atlas
+	Atlas *atlas.Atlas
+}
+
+// parseRequest reads the map and layer names from the URI and the point, zoom and
+// tolerance from the query string
+func (req *HandleMapFeatures) parseRequest(r *http.Request) error {
+	params := httptreemux.ContextParams(r.Context())
+
+	req.mapName = params["map_name"]
+	req.layerName = params["layer_name"]
+
+	query := r.URL.Query()
+
+	z, err := strconv.ParseUint(query.Get("z"), 10, 32)
+	if err != nil || z > tegola.MaxZ {
+		return fmt.Errorf("invalid z value (%v)", query.Get("z"))
+	}
+	req.z = uint(z)
+
+	req.lng, err = strconv.ParseFloat(query.Get("lng"), 64)
+	if err != nil || req.lng < -180 || req.lng > 180 {
+		return fmt.Errorf("invalid lng value (%v)", query.Get("lng"))
+	}
+
+	req.lat, err = strconv.ParseFloat(query.Get("lat"), 64)
+	if err != nil || req.lat < -90 || req.lat > 90 {
+		return fmt.Errorf("invalid lat value (%v)", query.Get("lat"))
+	}
+
+	req.tolerance = DefaultFeatureTolerance
+	if v := query.Get("tolerance"); v != "" {
+		req.tolerance, err = strconv.ParseFloat(v, 64)
+		if err != nil || req.tolerance < 0 || req.tolerance > MaxFeatureTolerance {
+			return fmt.Errorf("invalid tolerance value (%v)", v)
+		}
+	}
+
+	return nil
+}
+
+// URI scheme: /maps/:map_name/features and /maps/:map_name/:layer_name/features
+// map_name - map name in the config file
+// layer_name - name of the single map layer to query
+//
+// Query string:
+//
+//	lng, lat - WGS84 point to query the features at
+//	z - zoom level, the layers of the map at the zoom are queried
+//	tolerance - optional distance around the point in display pixels (256 per tile)
+//
+// The features are returned as a GeoJSON FeatureCollection with all their properties
+// and unsimplified geometries.
+func (req HandleMapFeatures) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := req.parseRequest(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// lookup our Map
+	m, err := req.Atlas.Map(req.mapName)
+	if err != nil {
+		errMsg := fmt.Sprintf("map (%v) not configured. check your config file", req.mapName)
+		log.Errorf(errMsg)
+		http.Error(w, errMsg, http.StatusNotFound)
+		return
+	}
+
+	// the values of the map's query parameters are passed to the providers in the request context
+	ctx := r.Context()
+	if len(m.Params) > 0 {
+		vals, err := m.QueryParameterValues(r.URL.Query())
+		if err != nil {
+			logAndError(w, http.StatusBadRequest, "map (%v): %v", req.mapName, err)
+			return
+		}
+		ctx = provider.ContextWithQueryParameters(ctx, vals)
+	}
+
+	// filter down the layers we need for this zoom
+	m = m.FilterLayersByZoom(req.z)
+	if len(m.Layers) == 0 {
+		logAndError(w, http.StatusNotFound, "map (%v) has no layers, at zoom %v", req.mapName, req.z)
+		return
+	}
+
+	if req.layerName != "" {
+		m = m.FilterLayersByName(req.layerName)
+		if len(m.Layers) == 0 {
+			logAndError(w, http.StatusNotFound, "map (%v) has no layers, for LayerName %v at zoom %v", req.mapName, req.layerName, req.z)
+			return
+		}
+	}
+
+	features, err := m.QueryFeatures(ctx, req.z, req.lng, req.lat, req.tolerance)
+	if err != nil {
+		switch e := err.(type) {
+		case atlas.ErrInvalidFeatureQuery:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+			return
+		case provider.ErrQueryTimeout:
+			errMsg := fmt.Sprintf("error querying features: %v", err)
+			log.Error(errMsg)
+			http.Error(w, errMsg, http.StatusGatewayTimeout)
+			return
+		}
+
+		switch err {
+		case context.Canceled:
+			return
+		default:
+			errMsg := fmt.Sprintf("error querying features: %v", err)
+			log.Error(errMsg)
+			http.Error(w, errMsg, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Add("Content-Type", GeoJSONMimeType)
+	w.Header().Add("Content-Length", fmt.Sprintf("%d", len(features)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(features)
+}
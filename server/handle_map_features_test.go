@@ -0,0 +1,172 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/geom"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/server"
+)
+
+func TestHandleMapFeatures(t *testing.T) {
+	type tcase struct {
+		uri          string
+		expectedCode int
+		expectedBody string
+		// layers of the returned features
+		expectedLayers []string
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			a := newTestMapWithLayers(testLayer1, testLayer2, testLayer3)
+
+			w, _, err := doRequest(a, http.MethodGet, tc.uri, nil)
+			if err != nil {
+				t.Fatalf("request, expected nil got %v", err)
+			}
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+
+			if tc.expectedCode != http.StatusOK {
+				if body := strings.TrimSpace(w.Body.String()); body != tc.expectedBody {
+					t.Errorf("body, expected %v got %v", tc.expectedBody, body)
+				}
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != server.GeoJSONMimeType {
+				t.Errorf("content type, expected %v got %v", server.GeoJSONMimeType, ct)
+			}
+
+			var fc struct {
+				Type     string `json:"type"`
+				Features []struct {
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"features"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &fc); err != nil {
+				t.Fatalf("decoding body, expected nil got %v", err)
+			}
+			if fc.Type != "FeatureCollection" {
+				t.Errorf("type, expected FeatureCollection got %v", fc.Type)
+			}
+
+			layers := []string{}
+			for _, f := range fc.Features {
+				layers = append(layers, f.Properties[atlas.GeoJSONLayerProperty].(string))
+			}
+			if !reflect.DeepEqual(layers, tc.expectedLayers) {
+				t.Errorf("layers, expected %v got %v", tc.expectedLayers, layers)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"map": {
+			uri:            "/maps/test-map/features?lng=1&lat=1&z=10",
+			expectedCode:   http.StatusOK,
+			expectedLayers: []string{"test-layer-2-name", "test-layer"},
+		},
+		"layer": {
+			uri:            "/maps/test-map/test-layer/features?lng=1&lat=1&z=5&tolerance=10",
+			expectedCode:   http.StatusOK,
+			expectedLayers: []string{"test-layer"},
+		},
+		"invalid lng": {
+			uri:          "/maps/test-map/features?lng=181&lat=1&z=5",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid lng value (181)",
+		},
+		"missing z": {
+			uri:          "/maps/test-map/features?lng=1&lat=1",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid z value ()",
+		},
+		"invalid tolerance": {
+			uri:          "/maps/test-map/features?lng=1&lat=1&z=5&tolerance=1000",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid tolerance value (1000)",
+		},
+		"outside of the tile matrix set": {
+			uri:          "/maps/test-map/features?lng=1&lat=89&z=5",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "atlas: invalid feature query: point (1, 89) is outside of the tile matrix set (WebMercatorQuad)",
+		},
+		"unknown map": {
+			uri:          "/maps/no-map/features?lng=1&lat=1&z=5",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "map (no-map) not configured. check your config file",
+		},
+		"unknown layer": {
+			uri:          "/maps/test-map/no-layer/features?lng=1&lat=1&z=5",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "map (test-map) has no layers, for LayerName no-layer at zoom 5",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestHandleMapFeaturesTracked(t *testing.T) {
+	server.URIPrefix = "/"
+
+	p := &blockingProvider{
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+
+	m := atlas.NewWebMercatorMap(testMapName)
+	m.Layers = append(m.Layers, atlas.Layer{
+		Name:              "blocking-layer",
+		ProviderLayerName: "blocking-layer",
+		MinZoom:           0,
+		MaxZoom:           20,
+		Provider:          p,
+		GeomType:          geom.Point{},
+	})
+
+	a := &atlas.Atlas{}
+	a.AddMap(m)
+	router := server.NewRouter(a)
+
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/maps/test-map/features?lng=1&lat=1&z=10", nil))
+	}()
+	<-p.started
+
+	// the providers of the replaced maps are in use until the query is done
+	waited := make(chan struct{})
+	go func() {
+		a.ReplaceMaps(nil)()
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+		t.Fatal("maps replaced while the features query is in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(p.release)
+	<-served
+
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("maps not replaced after the features query is done")
+	}
+}
@@ -13,7 +13,8 @@ import (
 	"github.com/go-spatial/tegola"
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/internal/log"
-	"github.com/go-spatial/tegola/maths"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+	"github.com/go-spatial/tegola/provider"
 )
 
 type HandleMapLayerZXY struct {
@@ -21,6 +22,8 @@ type HandleMapLayerZXY struct {
 	mapName string
 	// optional
 	layerName string
+	// the tile matrix set named by OGC API and WMTS tile requests
+	tileMatrixSet string
 	// zoom
 	z uint
 	// row
@@ -28,7 +31,7 @@ type HandleMapLayerZXY struct {
 	// column
 	y uint
 	// the requests extension (i.e. pbf or json)
-	// defaults to "pbf"
+	// defaults to "pbf". json and geojson requests are encoded as GeoJSON
 	extension string
 	// debug
 	debug bool
@@ -45,6 +48,7 @@ func (req *HandleMapLayerZXY) parseURI(r *http.Request) error {
 	// set map name
 	req.mapName = params["map_name"]
 	req.layerName = params["layer_name"]
+	req.tileMatrixSet = params["tile_matrix_set"]
 
 	var placeholder uint64
 
@@ -56,11 +60,16 @@ func (req *HandleMapLayerZXY) parseURI(r *http.Request) error {
 	}
 	req.z = uint(placeholder)
 
-	maxXYatZ := maths.Exp2(placeholder) - 1
-
+	// the range of x and y depends on the map's tile matrix set and is checked by validateTile.
+	// WMTS requests end with x and an extension
 	x := params["x"]
+	var xParts []string
+	if req.tileMatrixSet != "" {
+		xParts = strings.Split(x, ".")
+		x = xParts[0]
+	}
 	placeholder, err = strconv.ParseUint(x, 10, 32)
-	if err != nil || placeholder > maxXYatZ {
+	if err != nil {
 		log.Warnf("invalid X value (%v)", x)
 		return fmt.Errorf("invalid X value (%v)", x)
 	}
@@ -70,17 +79,25 @@ func (req *HandleMapLayerZXY) parseURI(r *http.Request) error {
 	y := params["y"]
 	yParts := strings.Split(y, ".")
 	placeholder, err = strconv.ParseUint(yParts[0], 10, 32)
-	if err != nil || placeholder > maxXYatZ {
+	if err != nil {
 		log.Warnf("invalid Y value (%v)", yParts[0])
 		return fmt.Errorf("invalid Y value (%v)", yParts[0])
 	}
 
 	req.y = uint(placeholder)
 
-	// check if we have a file extension
-	if len(yParts) > 2 {
+	// check if we have a file extension. OGC API requests use the f query parameter instead
+	switch {
+	case len(xParts) > 1:
+		req.extension = xParts[len(xParts)-1]
+	case req.tileMatrixSet != "":
+		if req.extension, err = ogcTileFormat(r); err != nil {
+			log.Warn(err)
+			return err
+		}
+	case len(yParts) > 1:
 		req.extension = yParts[len(yParts)-1]
-	} else {
+	default:
 		req.extension = "pbf"
 	}
 
@@ -92,6 +109,52 @@ func (req *HandleMapLayerZXY) parseURI(r *http.Request) error {
 	return nil
 }
 
+// validateTile confirms the requested tile is part of the tile matrix set
+func (req *HandleMapLayerZXY) validateTile(tms *tilematrix.TileMatrixSet) error {
+	if req.z > tms.MaxZoom() {
+		log.Warnf("invalid Z value (%v)", req.z)
+		return fmt.Errorf("invalid Z value (%v)", req.z)
+	}
+
+	cols, rows := tms.MatrixSize(req.z)
+	if req.x >= cols {
+		log.Warnf("invalid X value (%v)", req.x)
+		return fmt.Errorf("invalid X value (%v)", req.x)
+	}
+	if req.y >= rows {
+		log.Warnf("invalid Y value (%v)", req.y)
+		return fmt.Errorf("invalid Y value (%v)", req.y)
+	}
+
+	return nil
+}
+
+// ogcTileFormat returns the extension of the format requested by an OGC API tile request,
+// read from the f query parameter or the Accept header. Tiles default to MVT.
+func ogcTileFormat(r *http.Request) (string, error) {
+	switch f := r.URL.Query().Get("f"); f {
+	case "":
+		if strings.Contains(r.Header.Get("Accept"), GeoJSONMimeType) {
+			return "geojson", nil
+		}
+		return "pbf", nil
+	case "mvt", "pbf":
+		return "pbf", nil
+	case "json", "geojson":
+		return "geojson", nil
+	default:
+		return "", fmt.Errorf("unsupported f value (%v), mvt or geojson are supported", f)
+	}
+}
+
+// GeoJSONMimeType is the mimetype of GeoJSON tiles (RFC 7946)
+const GeoJSONMimeType = "application/geo+json"
+
+// isGeoJSONExtension reports if a tile request extension asks for GeoJSON
+func isGeoJSONExtension(ext string) bool {
+	return ext == "json" || ext == "geojson"
+}
+
 func logAndError(w http.ResponseWriter, code int, format string, vals ...interface{}) {
 	msg := fmt.Sprintf(format, vals...)
 	log.Info(msg)
@@ -99,6 +162,8 @@ func logAndError(w http.ResponseWriter, code int, format string, vals ...interfa
 }
 
 // URI scheme: /maps/:map_name/:layer_name/:z/:x/:y
+// or the OGC API scheme: /collections/:map_name/tiles/:tile_matrix_set/:z/:y/:x
+// or the WMTS scheme: /wmts/:map_name/1.0.0/:layer_name/default/:tile_matrix_set/:z/:y/:x.pbf
 // map_name - map name in the config file
 // layer_name - name of the single map layer to render
 // z, x, y - tile coordinates as described in the Slippy Map Tilenames specification
@@ -121,6 +186,28 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tms := m.Matrix()
+	if req.tileMatrixSet != "" && req.tileMatrixSet != tms.Name {
+		logAndError(w, http.StatusNotFound, "map (%v) is not served in the tile matrix set (%v)", req.mapName, req.tileMatrixSet)
+		return
+	}
+
+	if err := req.validateTile(tms); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the values of the map's query parameters are passed to the providers in the request context
+	ctx := r.Context()
+	if len(m.Params) > 0 {
+		vals, err := m.QueryParameterValues(r.URL.Query())
+		if err != nil {
+			logAndError(w, http.StatusBadRequest, "map (%v): %v", req.mapName, err)
+			return
+		}
+		ctx = provider.ContextWithQueryParameters(ctx, vals)
+	}
+
 	// filter down the layers we need for this zoom
 	m = m.FilterLayersByZoom(req.z)
 	if len(m.Layers) == 0 {
@@ -142,7 +229,11 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// Check to see that the zxy is within the bounds of the map.
 		// TODO(@ear7h): use a more efficient version of Intersect that doesn't
 		// make a new extent
-		textent := tile.Extent4326()
+		textent, err := tms.Extent4326(req.z, req.x, req.y)
+		if err != nil {
+			logAndError(w, http.StatusInternalServerError, "unable to calculate the extent of tile %v/%v/%v: %v", req.z, req.x, req.y, err)
+			return
+		}
 		if _, intersect := m.Bounds.Intersect(textent); !intersect {
 			logAndError(w, http.StatusNotFound, "map (%v -- %v) does not contains tile at %v/%v/%v -- %v", req.mapName, m.Bounds, req.z, req.x, req.y, textent)
 			return
@@ -154,8 +245,20 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		m = m.AddDebugLayers()
 	}
 
-	pbyte, err := m.Encode(r.Context(), tile)
+	encode, contentType := m.Encode, mvt.MimeType
+	if isGeoJSONExtension(req.extension) {
+		encode, contentType = m.EncodeGeoJSON, GeoJSONMimeType
+	}
+
+	pbyte, err := encode(ctx, tile)
 	if err != nil {
+		if _, ok := err.(provider.ErrQueryTimeout); ok {
+			errMsg := fmt.Sprintf("error fetching tile: %v", err)
+			log.Error(errMsg)
+			http.Error(w, errMsg, http.StatusGatewayTimeout)
+			return
+		}
+
 		switch err {
 		case context.Canceled:
 			// TODO: add debug logs
@@ -168,13 +271,16 @@ func (req HandleMapLayerZXY) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// mimetype for mapbox vector tiles
+	// mimetype for mapbox vector tiles or GeoJSON
 	// https://www.iana.org/assignments/media-types/application/vnd.mapbox-vector-tile
-	w.Header().Add("Content-Type", mvt.MimeType)
+	w.Header().Add("Content-Type", contentType)
 	w.Header().Add("Content-Length", fmt.Sprintf("%d", len(pbyte)))
 	w.WriteHeader(http.StatusOK)
 	w.Write(pbyte)
 
+	mapLabel, layerLabel, zLabel := tileLabels(req.Atlas, req.mapName, req.layerName, strconv.FormatUint(uint64(req.z), 10))
+	tileSize.Observe(float64(len(pbyte)), mapLabel, layerLabel, zLabel)
+
 	// check for tile size warnings
 	if len(pbyte) > MaxTileSize {
 		log.Infof("tile z:%v, x:%v, y:%v is rather large - %vKb", req.z, req.x, req.y, len(pbyte)/1024)
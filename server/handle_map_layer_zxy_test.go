@@ -1,14 +1,22 @@
 package server_test
 
 import (
+	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-spatial/geom"
 	"github.com/go-spatial/geom/encoding/mvt/vector_tile"
 	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/test"
+	"github.com/go-spatial/tegola/server"
 	"github.com/golang/protobuf/proto"
 )
 
@@ -136,6 +144,19 @@ func TestHandleMapLayerZXY(t *testing.T) {
 			expectedCode: http.StatusOK,
 			expectedLayers: []string{"test-layer"},
 		},
+		"world crs84 quad": {
+			// zoom 4 of WorldCRS84Quad has 32 columns and 16 rows
+			uri:            "/maps/test-map/test-layer/4/31/15.pbf",
+			atlas:          newTestMapWithTileMatrixSet(tilematrix.WorldCRS84Quad),
+			expectedCode:   http.StatusOK,
+			expectedLayers: []string{"test-layer"},
+		},
+		"world crs84 quad invalid y": {
+			uri:          "/maps/test-map/test-layer/4/31/16.pbf",
+			atlas:        newTestMapWithTileMatrixSet(tilematrix.WorldCRS84Quad),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid Y value (16)",
+		},
 		"options": {
 			//  With empty hostname and no port specified in config, urls should have host:port matching request uri.
 			uri:          "/maps/test-map/test-layer/4/2/3.pbf",
@@ -201,6 +222,36 @@ func TestHandleMapZXY(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: "invalid Y value (4)",
 		},
+		"query timeout": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			atlas:        newTestMapWithLayers(testLayer2, timeoutLayer),
+			expectedCode: http.StatusGatewayTimeout,
+			expectedBody: "error fetching tile: provider: test layer (timeout-layer) query timed out after 1s",
+		},
+		"query parameter": {
+			uri:            "/maps/test-map/10/2/3.pbf?year=2020",
+			atlas:          newTestMapWithParams(yearParam),
+			expectedCode:   http.StatusOK,
+			expectedLayers: []string{"test-layer-2-name", "test-layer"},
+		},
+		"query parameter default": {
+			uri:            "/maps/test-map/10/2/3.pbf",
+			atlas:          newTestMapWithParams(yearParam),
+			expectedCode:   http.StatusOK,
+			expectedLayers: []string{"test-layer-2-name", "test-layer"},
+		},
+		"invalid query parameter": {
+			uri:          "/maps/test-map/10/2/3.pbf?year=2020'",
+			atlas:        newTestMapWithParams(yearParam),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "map (test-map): provider: invalid value (2020') for query parameter (year): not a valid int",
+		},
+		"missing query parameter": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			atlas:        newTestMapWithParams(categoryParam),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "map (test-map): provider: query parameter (category) is required",
+		},
 	}
 	for name, tc := range tests {
 		tc := tc
@@ -208,6 +259,30 @@ func TestHandleMapZXY(t *testing.T) {
 	}
 }
 
+var (
+	yearDefault      = "2019"
+	yearParam, _     = provider.NewQueryParameter("year", provider.QueryParameterTypeInt, &yearDefault, nil, "")
+	categoryParam, _ = provider.NewQueryParameter("category", provider.QueryParameterTypeString, nil, []string{"roads", "rail"}, "")
+)
+
+// timeoutProvider is a provider whose layer queries time out
+type timeoutProvider struct {
+	test.TileProvider
+}
+
+func (timeoutProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	return provider.ErrQueryTimeout{Provider: "test", Layer: layer, Timeout: time.Second}
+}
+
+var timeoutLayer = atlas.Layer{
+	Name:              "timeout-layer",
+	ProviderLayerName: "timeout-layer",
+	MinZoom:           10,
+	MaxZoom:           20,
+	Provider:          &timeoutProvider{},
+	GeomType:          geom.Point{},
+}
+
 func TestHandleMapLayerCORS(t *testing.T) {
 	tests := map[string]CORSTestCase{
 		"map": {
@@ -223,3 +298,70 @@ func TestHandleMapLayerCORS(t *testing.T) {
 		t.Run(name, func(t *testing.T) { CORSTest(t, tc) })
 	}
 }
+
+func TestHandleMapZXYGeoJSON(t *testing.T) {
+	type tcase struct {
+		uri            string
+		expectedLayers []string
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			a := newTestMapWithLayers(testLayer1, testLayer2, testLayer3)
+			w, _, err := doRequest(a, "GET", tc.uri, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status code, expected %v got %v: %v", http.StatusOK, w.Code, w.Body.String())
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != server.GeoJSONMimeType {
+				t.Errorf("content type, expected %v got %v", server.GeoJSONMimeType, ct)
+			}
+
+			var fc struct {
+				Type     string `json:"type"`
+				Features []struct {
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"features"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &fc); err != nil {
+				t.Fatalf("unmarshalling response body, expected nil got %v", err)
+			}
+
+			if fc.Type != "FeatureCollection" {
+				t.Errorf("type, expected FeatureCollection got %v", fc.Type)
+			}
+
+			var layers []string
+			for _, f := range fc.Features {
+				layers = append(layers, f.Properties[atlas.GeoJSONLayerProperty].(string))
+			}
+
+			if !reflect.DeepEqual(tc.expectedLayers, layers) {
+				t.Errorf("layers, expected %v got %v", tc.expectedLayers, layers)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"json": {
+			uri:            "/maps/test-map/10/2/3.json",
+			expectedLayers: []string{"test-layer-2-name", "test-layer"},
+		},
+		"geojson": {
+			uri:            "/maps/test-map/10/2/3.geojson",
+			expectedLayers: []string{"test-layer-2-name", "test-layer"},
+		},
+		"map layer": {
+			uri:            "/maps/test-map/test-layer/4/2/3.geojson",
+			expectedLayers: []string{"test-layer"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
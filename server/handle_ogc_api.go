@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-spatial/tegola/maths/proj"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+)
+
+// The OGC API – Tiles (https://docs.ogc.org/is/20-057/20-057.html) endpoints serve the
+// maps as collections of vector tiles. Each map is a collection with a single tileset in
+// the map's tile matrix set. The layers of the map are the layers of the tileset.
+
+// link relations defined by the OGC API specifications
+const (
+	ogcRelConformance    = "http://www.opengis.net/def/rel/ogc/1.0/conformance"
+	ogcRelData           = "http://www.opengis.net/def/rel/ogc/1.0/data"
+	ogcRelTilingSchemes  = "http://www.opengis.net/def/rel/ogc/1.0/tiling-schemes"
+	ogcRelTilingScheme   = "http://www.opengis.net/def/rel/ogc/1.0/tiling-scheme"
+	ogcRelTileSetsVector = "http://www.opengis.net/def/rel/ogc/1.0/tilesets-vector"
+)
+
+// OGCConformanceClasses are the conformance classes of the OGC API implemented by tegola
+var OGCConformanceClasses = []string{
+	"http://www.opengis.net/spec/ogcapi-common-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-common-1/1.0/conf/json",
+	"http://www.opengis.net/spec/ogcapi-common-2/1.0/conf/collections",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/tileset",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/tilesets-list",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/geodata-tilesets",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/mvt",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/geojson",
+	"http://www.opengis.net/spec/tms/2.0/conf/tilematrixset",
+	"http://www.opengis.net/spec/tms/2.0/conf/json-tilematrixset",
+}
+
+// OGCLink is a link of an OGC API document
+type OGCLink struct {
+	Href      string `json:"href"`
+	Rel       string `json:"rel"`
+	Type      string `json:"type,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Templated bool   `json:"templated,omitempty"`
+}
+
+// OGCLandingPage is the root document of the OGC API
+type OGCLandingPage struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Links       []OGCLink `json:"links"`
+}
+
+// OGCConformance lists the conformance classes of the OGC API
+type OGCConformance struct {
+	ConformsTo []string `json:"conformsTo"`
+}
+
+// ogcURL builds the URL of an OGC API resource
+func ogcURL(r *http.Request, uriParts ...string) string {
+	return buildCapabilitiesURL(r, uriParts, url.Values{})
+}
+
+// ogcCRS returns the OGC URI of the SRID's coordinate reference system
+func ogcCRS(srid uint64) string {
+	if srid == proj.WGS84 {
+		return "http://www.opengis.net/def/crs/OGC/1.3/CRS84"
+	}
+	return fmt.Sprintf("http://www.opengis.net/def/crs/EPSG/0/%d", srid)
+}
+
+// ogcTileMatrixSetURI returns the registered URI of the builtin tile matrix sets,
+// custom tile matrix sets don't have one
+func ogcTileMatrixSetURI(tms *tilematrix.TileMatrixSet) string {
+	for _, builtin := range tilematrix.Builtin() {
+		if tms == builtin {
+			return "http://www.opengis.net/def/tilematrixset/OGC/1.0/" + tms.Name
+		}
+	}
+	return ""
+}
+
+// acceptsJSON checks the f query parameter of requests for the JSON documents of the
+// OGC API. JSON is the only encoding of the documents.
+func acceptsJSON(w http.ResponseWriter, r *http.Request) bool {
+	if f := r.URL.Query().Get("f"); f != "" && f != "json" {
+		http.Error(w, fmt.Sprintf("unsupported f value (%v), only json is supported", f), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeOGCJSON encodes v as the response
+func writeOGCJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// HandleOGCLandingPage serves the landing page of the OGC API
+//
+// URI scheme: / with the f=json query parameter or an Accept header preferring JSON,
+// otherwise the built in viewer is served
+type HandleOGCLandingPage struct{}
+
+func (req HandleOGCLandingPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	writeOGCJSON(w, OGCLandingPage{
+		Title:       "tegola",
+		Description: "tegola vector tile server",
+		Links: []OGCLink{
+			{Href: ogcURL(r, "/") + "?f=json", Rel: "self", Type: "application/json", Title: "This document"},
+			{Href: ogcURL(r, "conformance"), Rel: ogcRelConformance, Type: "application/json", Title: "Conformance classes"},
+			{Href: ogcURL(r, "collections"), Rel: ogcRelData, Type: "application/json", Title: "Collections"},
+			{Href: ogcURL(r, "tileMatrixSets"), Rel: ogcRelTilingSchemes, Type: "application/json", Title: "Tile matrix sets"},
+		},
+	})
+}
+
+// wantsOGCLandingPage reports if a request for the root asks for the OGC API landing page
+// rather than the viewer
+func wantsOGCLandingPage(r *http.Request) bool {
+	if f := r.URL.Query().Get("f"); f != "" {
+		return f != "html"
+	}
+
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
+
+// HandleOGCConformance serves the conformance classes of the OGC API
+//
+// URI scheme: /conformance
+type HandleOGCConformance struct{}
+
+func (req HandleOGCConformance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	writeOGCJSON(w, OGCConformance{ConformsTo: OGCConformanceClasses})
+}
@@ -0,0 +1,486 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-spatial/geom/encoding/mvt"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+	"github.com/go-spatial/tegola/server"
+)
+
+// doOGCRequest requests the uri with the Accept header and decodes the JSON response into v
+func doOGCRequest(t *testing.T, a *atlas.Atlas, uri string, accept string, v interface{}) *httptest.ResponseRecorder {
+	server.HostName = serverHostName
+
+	r, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		t.Fatalf("request, expected nil got %v", err)
+	}
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+
+	w := httptest.NewRecorder()
+	server.NewRouter(a).ServeHTTP(w, r)
+
+	if w.Code == http.StatusOK && v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("decoding response, expected nil got %v", err)
+		}
+	}
+
+	return w
+}
+
+func TestHandleOGCLandingPage(t *testing.T) {
+	type tcase struct {
+		uri          string
+		accept       string
+		expectedCode int
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			var landing server.OGCLandingPage
+			w := doOGCRequest(t, nil, tc.uri, tc.accept, &landing)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			rels := map[string]string{}
+			for _, l := range landing.Links {
+				rels[l.Rel] = l.Href
+			}
+
+			expected := map[string]string{
+				"self": "http://tegola.io/?f=json",
+				"http://www.opengis.net/def/rel/ogc/1.0/conformance":    "http://tegola.io/conformance",
+				"http://www.opengis.net/def/rel/ogc/1.0/data":           "http://tegola.io/collections",
+				"http://www.opengis.net/def/rel/ogc/1.0/tiling-schemes": "http://tegola.io/tileMatrixSets",
+			}
+			if !reflect.DeepEqual(rels, expected) {
+				t.Errorf("links, expected %v got %v", expected, rels)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"f json": {
+			uri:          "/?f=json",
+			expectedCode: http.StatusOK,
+		},
+		"accept json": {
+			uri:          "/",
+			accept:       "application/json",
+			expectedCode: http.StatusOK,
+		},
+		"unsupported f": {
+			uri:          "/?f=xml",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestHandleOGCConformance(t *testing.T) {
+	var conformance server.OGCConformance
+	w := doOGCRequest(t, nil, "/conformance", "", &conformance)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code, expected %v got %v", http.StatusOK, w.Code)
+	}
+	if !reflect.DeepEqual(conformance.ConformsTo, server.OGCConformanceClasses) {
+		t.Errorf("conformance classes, expected %v got %v", server.OGCConformanceClasses, conformance.ConformsTo)
+	}
+}
+
+func TestHandleOGCCollections(t *testing.T) {
+	type tcase struct {
+		uri          string
+		expectedCode int
+		expectedIDs  []string
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			a := newTestMapWithLayers(testLayer1, testLayer2, testLayer3)
+			other := atlas.NewWebMercatorMap("other-map")
+			a.AddMap(other)
+
+			var collections server.OGCCollections
+			w := doOGCRequest(t, a, tc.uri, "", &collections)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var ids []string
+			for _, c := range collections.Collections {
+				ids = append(ids, c.ID)
+
+				if c.Extent == nil || c.Extent.Spatial.CRS != "http://www.opengis.net/def/crs/OGC/1.3/CRS84" {
+					t.Errorf("collection (%v) extent, expected a CRS84 extent got %+v", c.ID, c.Extent)
+				}
+			}
+			sort.Strings(ids)
+			if !reflect.DeepEqual(ids, tc.expectedIDs) {
+				t.Errorf("collections, expected %v got %v", tc.expectedIDs, ids)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"collections": {
+			uri:          "/collections",
+			expectedCode: http.StatusOK,
+			expectedIDs:  []string{"other-map", testMapName},
+		},
+		"unsupported f": {
+			uri:          "/collections?f=html",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestHandleOGCCollection(t *testing.T) {
+	type tcase struct {
+		uri          string
+		expectedCode int
+		expected     server.OGCCollection
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			a := newTestMapWithBounds(-10, -20, 30, 40)
+
+			var collection server.OGCCollection
+			w := doOGCRequest(t, a, tc.uri, "", &collection)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			if !reflect.DeepEqual(collection, tc.expected) {
+				t.Errorf("collection, expected %+v got %+v", tc.expected, collection)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"collection": {
+			uri:          "/collections/test-map",
+			expectedCode: http.StatusOK,
+			expected: server.OGCCollection{
+				ID:          testMapName,
+				Title:       testMapName,
+				Attribution: testMapAttribution,
+				Extent: &server.OGCExtent{
+					Spatial: server.OGCSpatialExtent{
+						BBox: [][4]float64{{-10, -20, 30, 40}},
+						CRS:  "http://www.opengis.net/def/crs/OGC/1.3/CRS84",
+					},
+				},
+				DataType: "vector",
+				Links: []server.OGCLink{
+					{Href: "http://tegola.io/collections/test-map", Rel: "self", Type: "application/json", Title: "This collection"},
+					{Href: "http://tegola.io/collections/test-map/tiles", Rel: "http://www.opengis.net/def/rel/ogc/1.0/tilesets-vector", Type: "application/json", Title: "Vector tilesets"},
+				},
+			},
+		},
+		"unknown map": {
+			uri:          "/collections/no-map",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestHandleOGCTileSet(t *testing.T) {
+	point, line := 0, 1
+
+	type tcase struct {
+		atlas        *atlas.Atlas
+		uri          string
+		expectedCode int
+		expectedCRS  string
+		expectedURI  string
+		// the expected layers, nil for the tilesets list
+		expectedLayers []server.OGCTileSetLayer
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			var tileSet server.OGCTileSet
+
+			var tileSets server.OGCTileSets
+			v := interface{}(&tileSet)
+			if tc.expectedLayers == nil {
+				v = &tileSets
+			}
+
+			w := doOGCRequest(t, tc.atlas, tc.uri, "", v)
+			if w.Code != tc.expectedCode {
+				t.Fatalf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			if tc.expectedLayers == nil {
+				if len(tileSets.TileSets) != 1 {
+					t.Fatalf("tilesets, expected 1 got %v", len(tileSets.TileSets))
+				}
+				tileSet = tileSets.TileSets[0]
+			}
+
+			if tileSet.CRS != tc.expectedCRS {
+				t.Errorf("crs, expected %v got %v", tc.expectedCRS, tileSet.CRS)
+			}
+			if tileSet.TileMatrixSetURI != tc.expectedURI {
+				t.Errorf("tile matrix set uri, expected %v got %v", tc.expectedURI, tileSet.TileMatrixSetURI)
+			}
+			if tc.expectedLayers != nil && !reflect.DeepEqual(tileSet.Layers, tc.expectedLayers) {
+				t.Errorf("layers, expected %+v got %+v", tc.expectedLayers, tileSet.Layers)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"tilesets": {
+			atlas:        newTestMapWithLayers(testLayer1, testLayer2, testLayer3),
+			uri:          "/collections/test-map/tiles",
+			expectedCode: http.StatusOK,
+			expectedCRS:  "http://www.opengis.net/def/crs/EPSG/0/3857",
+			expectedURI:  "http://www.opengis.net/def/tilematrixset/OGC/1.0/WebMercatorQuad",
+		},
+		"tileset": {
+			atlas:        newTestMapWithLayers(testLayer1, testLayer2, testLayer3),
+			uri:          "/collections/test-map/tiles/WebMercatorQuad",
+			expectedCode: http.StatusOK,
+			expectedCRS:  "http://www.opengis.net/def/crs/EPSG/0/3857",
+			expectedURI:  "http://www.opengis.net/def/tilematrixset/OGC/1.0/WebMercatorQuad",
+			expectedLayers: []server.OGCTileSetLayer{
+				{ID: "test-layer", DataType: "vector", GeometryDimension: &point, MinTileMatrix: "4", MaxTileMatrix: "20"},
+				{ID: "test-layer-2-name", DataType: "vector", GeometryDimension: &line, MinTileMatrix: "10", MaxTileMatrix: "15"},
+			},
+		},
+		"tileset WorldCRS84Quad": {
+			atlas:        newTestMapWithTileMatrixSet(tilematrix.WorldCRS84Quad),
+			uri:          "/collections/test-map/tiles/WorldCRS84Quad",
+			expectedCode: http.StatusOK,
+			expectedCRS:  "http://www.opengis.net/def/crs/OGC/1.3/CRS84",
+			expectedURI:  "http://www.opengis.net/def/tilematrixset/OGC/1.0/WorldCRS84Quad",
+			expectedLayers: []server.OGCTileSetLayer{
+				{ID: "test-layer", DataType: "vector", GeometryDimension: &point, MinTileMatrix: "4", MaxTileMatrix: "9"},
+			},
+		},
+		"tileset other tile matrix set": {
+			atlas:        newTestMapWithLayers(testLayer1),
+			uri:          "/collections/test-map/tiles/WorldCRS84Quad",
+			expectedCode: http.StatusNotFound,
+		},
+		"unknown map": {
+			atlas:        newTestMapWithLayers(testLayer1),
+			uri:          "/collections/no-map/tiles",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestHandleOGCTileMatrixSets(t *testing.T) {
+	custom := &tilematrix.TileMatrixSet{
+		Name:        "Custom",
+		SRID:        3857,
+		Origin:      [2]float64{0, 100},
+		TileSize:    512,
+		Resolutions: []float64{10, 5},
+	}
+	a := newTestMapWithTileMatrixSet(custom)
+
+	var sets server.OGCTileMatrixSets
+	w := doOGCRequest(t, a, "/tileMatrixSets", "", &sets)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code, expected %v got %v", http.StatusOK, w.Code)
+	}
+
+	var ids []string
+	for _, tms := range sets.TileMatrixSets {
+		ids = append(ids, tms.ID)
+	}
+	if expected := []string{"WebMercatorQuad", "WorldCRS84Quad", "Custom"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("tile matrix sets, expected %v got %v", expected, ids)
+	}
+}
+
+func TestHandleOGCTileMatrixSet(t *testing.T) {
+	type tcase struct {
+		uri          string
+		expectedCode int
+		expectedCRS  string
+		// the expected matrix width and height by zoom
+		expectedSizes map[int][2]uint
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			var tms server.OGCTileMatrixSet
+			w := doOGCRequest(t, nil, tc.uri, "", &tms)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			if tms.CRS != tc.expectedCRS {
+				t.Errorf("crs, expected %v got %v", tc.expectedCRS, tms.CRS)
+			}
+
+			for z, size := range tc.expectedSizes {
+				matrix := tms.TileMatrices[z]
+				if got := [2]uint{matrix.MatrixWidth, matrix.MatrixHeight}; got != size {
+					t.Errorf("zoom %v matrix size, expected %v got %v", z, size, got)
+				}
+				if matrix.CornerOfOrigin != "topLeft" || matrix.TileWidth != 256 {
+					t.Errorf("zoom %v matrix, expected a top left origin and 256 pixel tiles got %+v", z, matrix)
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"WebMercatorQuad": {
+			uri:           "/tileMatrixSets/WebMercatorQuad",
+			expectedCode:  http.StatusOK,
+			expectedCRS:   "http://www.opengis.net/def/crs/EPSG/0/3857",
+			expectedSizes: map[int][2]uint{0: {1, 1}, 1: {2, 2}, 10: {1024, 1024}},
+		},
+		"WorldCRS84Quad": {
+			uri:           "/tileMatrixSets/WorldCRS84Quad",
+			expectedCode:  http.StatusOK,
+			expectedCRS:   "http://www.opengis.net/def/crs/OGC/1.3/CRS84",
+			expectedSizes: map[int][2]uint{0: {2, 1}, 1: {4, 2}},
+		},
+		"unknown": {
+			uri:          "/tileMatrixSets/Unknown",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestHandleOGCTiles(t *testing.T) {
+	type tcase struct {
+		uri                 string
+		accept              string
+		expectedCode        int
+		expectedContentType string
+		// the format is negotiated from the Accept header
+		expectedVaryAccept bool
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			a := newTestMapWithLayers(testLayer1, testLayer2, testLayer3)
+
+			w := doOGCRequest(t, a, tc.uri, tc.accept, nil)
+			if w.Code != tc.expectedCode {
+				t.Fatalf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if vary := w.Header().Get("Vary") == "Accept"; vary != tc.expectedVaryAccept {
+				t.Errorf("Vary: Accept, expected %v got %v", tc.expectedVaryAccept, vary)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != tc.expectedContentType {
+				t.Errorf("content type, expected %v got %v", tc.expectedContentType, ct)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"mvt": {
+			uri:                 "/collections/test-map/tiles/WebMercatorQuad/10/3/2",
+			expectedCode:        http.StatusOK,
+			expectedContentType: mvt.MimeType,
+			expectedVaryAccept:  true,
+		},
+		"f mvt": {
+			uri:                 "/collections/test-map/tiles/WebMercatorQuad/10/3/2?f=mvt",
+			expectedCode:        http.StatusOK,
+			expectedContentType: mvt.MimeType,
+		},
+		"f geojson": {
+			uri:                 "/collections/test-map/tiles/WebMercatorQuad/10/3/2?f=geojson",
+			expectedCode:        http.StatusOK,
+			expectedContentType: server.GeoJSONMimeType,
+		},
+		"accept geojson": {
+			uri:                 "/collections/test-map/tiles/WebMercatorQuad/10/3/2",
+			accept:              server.GeoJSONMimeType,
+			expectedCode:        http.StatusOK,
+			expectedContentType: server.GeoJSONMimeType,
+			expectedVaryAccept:  true,
+		},
+		"unsupported f": {
+			uri:          "/collections/test-map/tiles/WebMercatorQuad/10/3/2?f=png",
+			expectedCode: http.StatusBadRequest,
+		},
+		"other tile matrix set": {
+			uri:                "/collections/test-map/tiles/WorldCRS84Quad/10/3/2",
+			expectedCode:       http.StatusNotFound,
+			expectedVaryAccept: true,
+		},
+		"row outside the tile matrix": {
+			uri:                "/collections/test-map/tiles/WebMercatorQuad/1/2/0",
+			expectedCode:       http.StatusBadRequest,
+			expectedVaryAccept: true,
+		},
+		"unknown map": {
+			uri:                "/collections/no-map/tiles/WebMercatorQuad/10/3/2",
+			expectedCode:       http.StatusNotFound,
+			expectedVaryAccept: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
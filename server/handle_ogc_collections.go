@@ -0,0 +1,300 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dimfeld/httptreemux"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/geom/encoding/mvt"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/maths/proj"
+)
+
+// OGCCollections lists the maps as OGC API collections
+type OGCCollections struct {
+	Links       []OGCLink       `json:"links"`
+	Collections []OGCCollection `json:"collections"`
+}
+
+// OGCCollection describes a map
+type OGCCollection struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Attribution string     `json:"attribution,omitempty"`
+	Extent      *OGCExtent `json:"extent,omitempty"`
+	DataType    string     `json:"dataType"`
+	Links       []OGCLink  `json:"links"`
+}
+
+// OGCExtent is the spatial extent of a collection
+type OGCExtent struct {
+	Spatial OGCSpatialExtent `json:"spatial"`
+}
+
+// OGCSpatialExtent holds the bounding box of a collection in CRS84
+type OGCSpatialExtent struct {
+	BBox [][4]float64 `json:"bbox"`
+	CRS  string       `json:"crs"`
+}
+
+// OGCTileSets lists the tilesets of a collection
+type OGCTileSets struct {
+	TileSets []OGCTileSet `json:"tilesets"`
+}
+
+// OGCTileSet is the metadata of a tileset according to the OGC Two Dimensional Tile
+// Matrix Set and Tile Set Metadata standard (https://docs.ogc.org/is/17-083r4/17-083r4.html)
+type OGCTileSet struct {
+	Title            string            `json:"title,omitempty"`
+	DataType         string            `json:"dataType"`
+	CRS              string            `json:"crs"`
+	TileMatrixSetURI string            `json:"tileMatrixSetURI,omitempty"`
+	Links            []OGCLink         `json:"links"`
+	Layers           []OGCTileSetLayer `json:"layers,omitempty"`
+	BoundingBox      *OGCBoundingBox   `json:"boundingBox,omitempty"`
+}
+
+// OGCTileSetLayer describes a layer of the vector tiles of a tileset
+type OGCTileSetLayer struct {
+	ID       string `json:"id"`
+	DataType string `json:"dataType"`
+	// GeometryDimension is 0 for points, 1 for lines and 2 for polygons. nil when the
+	// geometry type of the layer is not known
+	GeometryDimension *int   `json:"geometryDimension,omitempty"`
+	MinTileMatrix     string `json:"minTileMatrix"`
+	MaxTileMatrix     string `json:"maxTileMatrix"`
+}
+
+// OGCBoundingBox is a bounding box in the crs
+type OGCBoundingBox struct {
+	LowerLeft  [2]float64 `json:"lowerLeft"`
+	UpperRight [2]float64 `json:"upperRight"`
+	CRS        string     `json:"crs"`
+}
+
+// the tiles of the maps are vector tiles
+const ogcDataTypeVector = "vector"
+
+// HandleOGCCollections lists the maps the request can access as collections
+//
+// URI scheme: /collections
+type HandleOGCCollections struct {
+	// the Atlas to use, nil (default) is the default atlas
+	Atlas *atlas.Atlas
+}
+
+func (req HandleOGCCollections) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	collections := OGCCollections{
+		Links: []OGCLink{
+			{Href: ogcURL(r, "collections"), Rel: "self", Type: "application/json", Title: "This document"},
+		},
+		Collections: []OGCCollection{},
+	}
+
+	allowed := allowedMaps(r)
+	for _, m := range req.Atlas.AllMaps() {
+		if !allowed(m) {
+			continue
+		}
+		collections.Collections = append(collections.Collections, ogcCollection(r, m))
+	}
+
+	writeOGCJSON(w, collections)
+}
+
+// HandleOGCCollection describes a map as a collection
+//
+// URI scheme: /collections/:map_name
+// map_name - map name in the config file
+type HandleOGCCollection struct {
+	// the Atlas to use, nil (default) is the default atlas
+	Atlas *atlas.Atlas
+}
+
+func (req HandleOGCCollection) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	m, ok := ogcMap(w, r, req.Atlas)
+	if !ok {
+		return
+	}
+
+	writeOGCJSON(w, ogcCollection(r, m))
+}
+
+// HandleOGCTileSets lists the tilesets of a map. Maps have a single tileset in the
+// map's tile matrix set.
+//
+// URI scheme: /collections/:map_name/tiles
+// map_name - map name in the config file
+type HandleOGCTileSets struct {
+	// the Atlas to use, nil (default) is the default atlas
+	Atlas *atlas.Atlas
+}
+
+func (req HandleOGCTileSets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	m, ok := ogcMap(w, r, req.Atlas)
+	if !ok {
+		return
+	}
+
+	// the list only holds the summary of the tileset
+	tileSet := ogcTileSet(r, m)
+	tileSet.Layers, tileSet.BoundingBox = nil, nil
+	tileSet.Links = tileSet.Links[:2]
+
+	writeOGCJSON(w, OGCTileSets{TileSets: []OGCTileSet{tileSet}})
+}
+
+// HandleOGCTileSet serves the metadata of the tileset of a map
+//
+// URI scheme: /collections/:map_name/tiles/:tile_matrix_set
+// map_name - map name in the config file
+// tile_matrix_set - the name of the map's tile matrix set (i.e. WebMercatorQuad)
+type HandleOGCTileSet struct {
+	// the Atlas to use, nil (default) is the default atlas
+	Atlas *atlas.Atlas
+}
+
+func (req HandleOGCTileSet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	m, ok := ogcMap(w, r, req.Atlas)
+	if !ok {
+		return
+	}
+
+	tmsName := httptreemux.ContextParams(r.Context())["tile_matrix_set"]
+	if tmsName != m.Matrix().Name {
+		logAndError(w, http.StatusNotFound, "map (%v) is not served in the tile matrix set (%v)", m.Name, tmsName)
+		return
+	}
+
+	writeOGCJSON(w, ogcTileSet(r, m))
+}
+
+// ogcMap looks up the map of the request. If the map is not found an error is written
+// and ok is false.
+func ogcMap(w http.ResponseWriter, r *http.Request, a *atlas.Atlas) (m atlas.Map, ok bool) {
+	mapName := httptreemux.ContextParams(r.Context())["map_name"]
+
+	m, err := a.Map(mapName)
+	if err != nil {
+		errMsg := fmt.Sprintf("map (%v) not configured. check your config file", mapName)
+		log.Errorf(errMsg)
+		http.Error(w, errMsg, http.StatusNotFound)
+		return m, false
+	}
+
+	return m, true
+}
+
+// ogcCollection describes the map as a collection
+func ogcCollection(r *http.Request, m atlas.Map) OGCCollection {
+	collection := OGCCollection{
+		ID:          m.Name,
+		Title:       m.Name,
+		Attribution: m.Attribution,
+		DataType:    ogcDataTypeVector,
+		Links: []OGCLink{
+			{Href: ogcURL(r, "collections", m.Name), Rel: "self", Type: "application/json", Title: "This collection"},
+			{Href: ogcURL(r, "collections", m.Name, "tiles"), Rel: ogcRelTileSetsVector, Type: "application/json", Title: "Vector tilesets"},
+		},
+	}
+
+	if m.Bounds != nil {
+		collection.Extent = &OGCExtent{
+			Spatial: OGCSpatialExtent{
+				BBox: [][4]float64{m.Bounds.Extent()},
+				CRS:  ogcCRS(proj.WGS84),
+			},
+		}
+	}
+
+	return collection
+}
+
+// ogcTileSet describes the tileset of the map. The first two links are the self and
+// tiling scheme links.
+func ogcTileSet(r *http.Request, m atlas.Map) OGCTileSet {
+	tms := m.Matrix()
+	tilesURI := []string{"collections", m.Name, "tiles", tms.Name}
+
+	tileSet := OGCTileSet{
+		Title:            m.Name,
+		DataType:         ogcDataTypeVector,
+		CRS:              ogcCRS(tms.SRID),
+		TileMatrixSetURI: ogcTileMatrixSetURI(tms),
+		Links: []OGCLink{
+			{Href: ogcURL(r, tilesURI...), Rel: "self", Type: "application/json", Title: "This tileset"},
+			{Href: ogcURL(r, "tileMatrixSets", tms.Name), Rel: ogcRelTilingScheme, Type: "application/json", Title: "The tile matrix set of the tileset"},
+			{
+				Href:      ogcURL(r, append(tilesURI, "{tileMatrix}/{tileRow}/{tileCol}")...) + "?f=mvt",
+				Rel:       "item",
+				Type:      mvt.MimeType,
+				Title:     "Mapbox vector tiles",
+				Templated: true,
+			},
+			{
+				Href:      ogcURL(r, append(tilesURI, "{tileMatrix}/{tileRow}/{tileCol}")...) + "?f=geojson",
+				Rel:       "item",
+				Type:      GeoJSONMimeType,
+				Title:     "GeoJSON tiles",
+				Templated: true,
+			},
+		},
+	}
+
+	if m.Bounds != nil {
+		tileSet.BoundingBox = &OGCBoundingBox{
+			LowerLeft:  [2]float64{m.Bounds.MinX(), m.Bounds.MinY()},
+			UpperRight: [2]float64{m.Bounds.MaxX(), m.Bounds.MaxY()},
+			CRS:        ogcCRS(proj.WGS84),
+		}
+	}
+
+	for _, l := range tileLayers(m) {
+		tileSet.Layers = append(tileSet.Layers, OGCTileSetLayer{
+			ID:                l.name,
+			DataType:          ogcDataTypeVector,
+			GeometryDimension: geometryDimension(l.geomType),
+			MinTileMatrix:     strconv.FormatUint(uint64(l.minZoom), 10),
+			MaxTileMatrix:     strconv.FormatUint(uint64(l.maxZoom), 10),
+		})
+	}
+
+	return tileSet
+}
+
+// geometryDimension returns the dimension of the layer's geometry type, nil if the
+// geometry type is not known
+func geometryDimension(geo geom.Geometry) *int {
+	var dim int
+	switch geo.(type) {
+	case geom.Point, geom.MultiPoint:
+		dim = 0
+	case geom.Line, geom.LineString, geom.MultiLineString:
+		dim = 1
+	case geom.Polygon, geom.MultiPolygon:
+		dim = 2
+	default:
+		return nil
+	}
+	return &dim
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/dimfeld/httptreemux"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/maths/proj"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+)
+
+// OGCTileMatrixSets lists the tile matrix sets
+type OGCTileMatrixSets struct {
+	TileMatrixSets []OGCTileMatrixSetRef `json:"tileMatrixSets"`
+}
+
+// OGCTileMatrixSetRef is the summary of a tile matrix set in the list of tile matrix sets
+type OGCTileMatrixSetRef struct {
+	ID    string    `json:"id"`
+	Title string    `json:"title"`
+	URI   string    `json:"uri,omitempty"`
+	CRS   string    `json:"crs"`
+	Links []OGCLink `json:"links"`
+}
+
+// OGCTileMatrixSet is a tile matrix set encoded according to the OGC Two Dimensional
+// Tile Matrix Set standard (https://docs.ogc.org/is/17-083r4/17-083r4.html)
+type OGCTileMatrixSet struct {
+	ID           string          `json:"id"`
+	Title        string          `json:"title"`
+	URI          string          `json:"uri,omitempty"`
+	CRS          string          `json:"crs"`
+	OrderedAxes  []string        `json:"orderedAxes"`
+	TileMatrices []OGCTileMatrix `json:"tileMatrices"`
+}
+
+// OGCTileMatrix is a zoom of a tile matrix set
+type OGCTileMatrix struct {
+	ID               string     `json:"id"`
+	ScaleDenominator float64    `json:"scaleDenominator"`
+	CellSize         float64    `json:"cellSize"`
+	CornerOfOrigin   string     `json:"cornerOfOrigin"`
+	PointOfOrigin    [2]float64 `json:"pointOfOrigin"`
+	TileWidth        uint       `json:"tileWidth"`
+	TileHeight       uint       `json:"tileHeight"`
+	MatrixWidth      uint       `json:"matrixWidth"`
+	MatrixHeight     uint       `json:"matrixHeight"`
+}
+
+// HandleOGCTileMatrixSets lists the builtin tile matrix sets and the tile matrix sets
+// of the maps
+//
+// URI scheme: /tileMatrixSets
+type HandleOGCTileMatrixSets struct {
+	// the Atlas to use, nil (default) is the default atlas
+	Atlas *atlas.Atlas
+}
+
+func (req HandleOGCTileMatrixSets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	sets := OGCTileMatrixSets{TileMatrixSets: []OGCTileMatrixSetRef{}}
+	for _, tms := range ogcTileMatrixSets(req.Atlas) {
+		sets.TileMatrixSets = append(sets.TileMatrixSets, OGCTileMatrixSetRef{
+			ID:    tms.Name,
+			Title: tms.Name,
+			URI:   ogcTileMatrixSetURI(tms),
+			CRS:   ogcCRS(tms.SRID),
+			Links: []OGCLink{
+				{Href: ogcURL(r, "tileMatrixSets", tms.Name), Rel: ogcRelTilingScheme, Type: "application/json", Title: "The tile matrix set"},
+			},
+		})
+	}
+
+	writeOGCJSON(w, sets)
+}
+
+// HandleOGCTileMatrixSet serves the definition of a tile matrix set
+//
+// URI scheme: /tileMatrixSets/:tile_matrix_set
+// tile_matrix_set - the name of a builtin tile matrix set or of a map's tile matrix set
+type HandleOGCTileMatrixSet struct {
+	// the Atlas to use, nil (default) is the default atlas
+	Atlas *atlas.Atlas
+}
+
+func (req HandleOGCTileMatrixSet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	name := httptreemux.ContextParams(r.Context())["tile_matrix_set"]
+
+	for _, tms := range ogcTileMatrixSets(req.Atlas) {
+		if tms.Name == name {
+			writeOGCJSON(w, ogcTileMatrixSet(tms))
+			return
+		}
+	}
+
+	logAndError(w, http.StatusNotFound, "tile matrix set (%v) not found", name)
+}
+
+// ogcTileMatrixSets returns the builtin tile matrix sets followed by the tile matrix
+// sets of the maps, ordered by name
+func ogcTileMatrixSets(a *atlas.Atlas) []*tilematrix.TileMatrixSet {
+	sets := tilematrix.Builtin()
+
+	seen := map[string]bool{}
+	for _, tms := range sets {
+		seen[tms.Name] = true
+	}
+
+	var custom []*tilematrix.TileMatrixSet
+	for _, m := range a.AllMaps() {
+		if tms := m.Matrix(); !seen[tms.Name] {
+			seen[tms.Name] = true
+			custom = append(custom, tms)
+		}
+	}
+	sort.Slice(custom, func(i, j int) bool { return custom[i].Name < custom[j].Name })
+
+	return append(sets, custom...)
+}
+
+// ogcTileMatrixSet encodes the tile matrix set
+func ogcTileMatrixSet(tms *tilematrix.TileMatrixSet) OGCTileMatrixSet {
+	set := OGCTileMatrixSet{
+		ID:           tms.Name,
+		Title:        tms.Name,
+		URI:          ogcTileMatrixSetURI(tms),
+		CRS:          ogcCRS(tms.SRID),
+		OrderedAxes:  []string{"X", "Y"},
+		TileMatrices: make([]OGCTileMatrix, 0, len(tms.Resolutions)),
+	}
+
+	// the axes of CRS84 are named by the CRS
+	if tms.SRID == proj.WGS84 {
+		set.OrderedAxes = []string{"Lon", "Lat"}
+	}
+
+	for z := range tms.Resolutions {
+		cols, rows := tms.MatrixSize(uint(z))
+
+		set.TileMatrices = append(set.TileMatrices, OGCTileMatrix{
+			ID:               strconv.Itoa(z),
+			ScaleDenominator: tms.ScaleDenominator(uint(z)),
+			CellSize:         tms.Resolutions[z],
+			CornerOfOrigin:   "topLeft",
+			PointOfOrigin:    tms.Origin,
+			TileWidth:        tms.TileSize,
+			TileHeight:       tms.TileSize,
+			MatrixWidth:      cols,
+			MatrixHeight:     rows,
+		})
+	}
+
+	return set
+}
@@ -0,0 +1,298 @@
+package server
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-spatial/geom/encoding/mvt"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/maths/proj"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+)
+
+// The WMTS (https://www.ogc.org/standards/wmts) endpoints serve the layers of a map
+// with the RESTful encoding of WMTS 1.0.0. Each map is a WMTS service with a layer for
+// each of the map's layers and the map's tile matrix set.
+
+// WMTSVersion is the version of the WMTS standard implemented
+const WMTSVersion = "1.0.0"
+
+// WMTSStyle is the identifier of the single style of the layers
+const WMTSStyle = "default"
+
+// WMTSCapabilities is the capabilities document of a map's WMTS service
+type WMTSCapabilities struct {
+	XMLName               xml.Name                  `xml:"Capabilities"`
+	Xmlns                 string                    `xml:"xmlns,attr"`
+	XmlnsOWS              string                    `xml:"xmlns:ows,attr"`
+	XmlnsXlink            string                    `xml:"xmlns:xlink,attr"`
+	Version               string                    `xml:"version,attr"`
+	ServiceIdentification WMTSServiceIdentification `xml:"ows:ServiceIdentification"`
+	OperationsMetadata    []WMTSOperation           `xml:"ows:OperationsMetadata>ows:Operation"`
+	Contents              WMTSContents              `xml:"Contents"`
+	ServiceMetadataURL    WMTSServiceMetadataURL    `xml:"ServiceMetadataURL"`
+}
+
+// WMTSServiceIdentification identifies the service
+type WMTSServiceIdentification struct {
+	Title              string `xml:"ows:Title"`
+	ServiceType        string `xml:"ows:ServiceType"`
+	ServiceTypeVersion string `xml:"ows:ServiceTypeVersion"`
+}
+
+// WMTSOperation is an operation of the service and the URL of its RESTful encoding
+type WMTSOperation struct {
+	Name string  `xml:"name,attr"`
+	Get  WMTSGet `xml:"ows:DCP>ows:HTTP>ows:Get"`
+}
+
+// WMTSGet is the HTTP GET URL of an operation
+type WMTSGet struct {
+	Href       string         `xml:"xlink:href,attr"`
+	Constraint WMTSConstraint `xml:"ows:Constraint"`
+}
+
+// WMTSConstraint lists the allowed values of a constraint of an operation
+type WMTSConstraint struct {
+	Name   string   `xml:"name,attr"`
+	Values []string `xml:"ows:AllowedValues>ows:Value"`
+}
+
+// WMTSServiceMetadataURL links to the capabilities document
+type WMTSServiceMetadataURL struct {
+	Href string `xml:"xlink:href,attr"`
+}
+
+// WMTSContents holds the layers and tile matrix sets of the service
+type WMTSContents struct {
+	Layers         []WMTSLayer         `xml:"Layer"`
+	TileMatrixSets []WMTSTileMatrixSet `xml:"TileMatrixSet"`
+}
+
+// WMTSLayer is a layer of a map
+type WMTSLayer struct {
+	Title             string                `xml:"ows:Title"`
+	BoundingBox       WMTSBoundingBox       `xml:"ows:WGS84BoundingBox"`
+	Identifier        string                `xml:"ows:Identifier"`
+	Style             WMTSLayerStyle        `xml:"Style"`
+	Formats           []string              `xml:"Format"`
+	TileMatrixSetLink WMTSTileMatrixSetLink `xml:"TileMatrixSetLink"`
+	ResourceURLs      []WMTSResourceURL     `xml:"ResourceURL"`
+}
+
+// WMTSBoundingBox is a bounding box with the corners encoded as "x y"
+type WMTSBoundingBox struct {
+	LowerCorner string `xml:"ows:LowerCorner"`
+	UpperCorner string `xml:"ows:UpperCorner"`
+}
+
+// WMTSLayerStyle is a style of a layer
+type WMTSLayerStyle struct {
+	IsDefault  bool   `xml:"isDefault,attr"`
+	Identifier string `xml:"ows:Identifier"`
+}
+
+// WMTSTileMatrixSetLink links a layer to a tile matrix set and limits the tiles
+// of the layer to the zooms and area it has features at
+type WMTSTileMatrixSetLink struct {
+	TileMatrixSet string                 `xml:"TileMatrixSet"`
+	Limits        []WMTSTileMatrixLimits `xml:"TileMatrixSetLimits>TileMatrixLimits"`
+}
+
+// WMTSTileMatrixLimits are the tiles of a layer at a zoom
+type WMTSTileMatrixLimits struct {
+	TileMatrix string `xml:"TileMatrix"`
+	MinTileRow uint   `xml:"MinTileRow"`
+	MaxTileRow uint   `xml:"MaxTileRow"`
+	MinTileCol uint   `xml:"MinTileCol"`
+	MaxTileCol uint   `xml:"MaxTileCol"`
+}
+
+// WMTSResourceURL is the RESTful URL template of a layer's tiles
+type WMTSResourceURL struct {
+	Format       string `xml:"format,attr"`
+	ResourceType string `xml:"resourceType,attr"`
+	Template     string `xml:"template,attr"`
+}
+
+// WMTSTileMatrixSet is a tile matrix set of the service
+type WMTSTileMatrixSet struct {
+	Identifier        string           `xml:"ows:Identifier"`
+	SupportedCRS      string           `xml:"ows:SupportedCRS"`
+	WellKnownScaleSet string           `xml:"WellKnownScaleSet,omitempty"`
+	TileMatrices      []WMTSTileMatrix `xml:"TileMatrix"`
+}
+
+// WMTSTileMatrix is a zoom of a tile matrix set
+type WMTSTileMatrix struct {
+	Identifier       string  `xml:"ows:Identifier"`
+	ScaleDenominator float64 `xml:"ScaleDenominator"`
+	TopLeftCorner    string  `xml:"TopLeftCorner"`
+	TileWidth        uint    `xml:"TileWidth"`
+	TileHeight       uint    `xml:"TileHeight"`
+	MatrixWidth      uint    `xml:"MatrixWidth"`
+	MatrixHeight     uint    `xml:"MatrixHeight"`
+}
+
+// HandleWMTSCapabilities serves the WMTS capabilities document of a map
+//
+// URI scheme: /wmts/:map_name/1.0.0/WMTSCapabilities.xml
+// map_name - map name in the config file
+//
+// The tiles of the layers are served by the HandleMapLayerZXY at
+// /wmts/:map_name/1.0.0/:layer_name/default/:tile_matrix_set/:z/:y/:x.pbf
+type HandleWMTSCapabilities struct {
+	// the Atlas to use, nil (default) is the default atlas
+	Atlas *atlas.Atlas
+}
+
+func (req HandleWMTSCapabilities) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m, ok := ogcMap(w, r, req.Atlas)
+	if !ok {
+		return
+	}
+
+	tms := m.Matrix()
+	serviceURI := []string{"wmts", m.Name, WMTSVersion}
+	restful := WMTSConstraint{Name: "GetEncoding", Values: []string{"RESTful"}}
+
+	capabilities := WMTSCapabilities{
+		Xmlns:      "http://www.opengis.net/wmts/1.0",
+		XmlnsOWS:   "http://www.opengis.net/ows/1.1",
+		XmlnsXlink: "http://www.w3.org/1999/xlink",
+		Version:    WMTSVersion,
+		ServiceIdentification: WMTSServiceIdentification{
+			Title:              m.Name,
+			ServiceType:        "OGC WMTS",
+			ServiceTypeVersion: WMTSVersion,
+		},
+		OperationsMetadata: []WMTSOperation{
+			{
+				Name: "GetCapabilities",
+				Get: WMTSGet{
+					Href:       buildCapabilitiesURL(r, append(serviceURI, "WMTSCapabilities.xml"), url.Values{}),
+					Constraint: restful,
+				},
+			},
+			{
+				Name: "GetTile",
+				Get: WMTSGet{
+					Href:       buildCapabilitiesURL(r, serviceURI, url.Values{}),
+					Constraint: restful,
+				},
+			},
+		},
+		Contents: WMTSContents{
+			TileMatrixSets: []WMTSTileMatrixSet{wmtsTileMatrixSet(tms)},
+		},
+		ServiceMetadataURL: WMTSServiceMetadataURL{
+			Href: buildCapabilitiesURL(r, append(serviceURI, "WMTSCapabilities.xml"), url.Values{}),
+		},
+	}
+
+	// the bounds of the map in the CRS of the tile matrix set limit the tiles of the layers
+	bounds, err := proj.TransformExtent(proj.WGS84, tms.SRID, m.Bounds)
+	if err != nil {
+		log.Warnf("map (%v): unable to transform the bounds to the tile matrix set: %v", m.Name, err)
+		bounds = nil
+	}
+
+	for _, l := range tileLayers(m) {
+		layer := WMTSLayer{
+			Title:      l.name,
+			Identifier: l.name,
+			Style: WMTSLayerStyle{
+				IsDefault:  true,
+				Identifier: WMTSStyle,
+			},
+			Formats: []string{mvt.MimeType},
+			TileMatrixSetLink: WMTSTileMatrixSetLink{
+				TileMatrixSet: tms.Name,
+			},
+			ResourceURLs: []WMTSResourceURL{
+				{
+					Format:       mvt.MimeType,
+					ResourceType: "tile",
+					Template:     buildCapabilitiesURL(r, append(serviceURI, l.name, WMTSStyle, "{TileMatrixSet}/{TileMatrix}/{TileRow}/{TileCol}.pbf"), url.Values{}),
+				},
+			},
+		}
+
+		if m.Bounds != nil {
+			layer.BoundingBox = WMTSBoundingBox{
+				LowerCorner: wmtsCorner(m.Bounds.MinX(), m.Bounds.MinY()),
+				UpperCorner: wmtsCorner(m.Bounds.MaxX(), m.Bounds.MaxY()),
+			}
+		}
+
+		for z := l.minZoom; z <= l.maxZoom; z++ {
+			limits := WMTSTileMatrixLimits{TileMatrix: strconv.FormatUint(uint64(z), 10)}
+
+			if bounds != nil {
+				limits.MinTileCol, limits.MinTileRow, limits.MaxTileCol, limits.MaxTileRow = tms.TileRange(z, bounds)
+			} else {
+				cols, rows := tms.MatrixSize(z)
+				limits.MaxTileCol, limits.MaxTileRow = cols-1, rows-1
+			}
+
+			layer.TileMatrixSetLink.Limits = append(layer.TileMatrixSetLink.Limits, limits)
+		}
+
+		capabilities.Contents.Layers = append(capabilities.Contents.Layers, layer)
+	}
+
+	w.Header().Add("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(capabilities); err != nil {
+		log.Errorf("map (%v): error encoding WMTS capabilities: %v", m.Name, err)
+	}
+}
+
+// wmtsCRS returns the URN of the SRID's coordinate reference system
+func wmtsCRS(srid uint64) string {
+	if srid == proj.WGS84 {
+		return "urn:ogc:def:crs:OGC:1.3:CRS84"
+	}
+	return fmt.Sprintf("urn:ogc:def:crs:EPSG::%d", srid)
+}
+
+// wmtsCorner encodes a corner of a bounding box
+func wmtsCorner(x, y float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64) + " " + strconv.FormatFloat(y, 'f', -1, 64)
+}
+
+// wmtsTileMatrixSet encodes the tile matrix set
+func wmtsTileMatrixSet(tms *tilematrix.TileMatrixSet) WMTSTileMatrixSet {
+	set := WMTSTileMatrixSet{
+		Identifier:   tms.Name,
+		SupportedCRS: wmtsCRS(tms.SRID),
+	}
+
+	// WebMercatorQuad is the well known scale set of slippy maps
+	if tms == tilematrix.WebMercatorQuad {
+		set.WellKnownScaleSet = "urn:ogc:def:wkss:OGC:1.0:GoogleMapsCompatible"
+	}
+
+	for z := range tms.Resolutions {
+		cols, rows := tms.MatrixSize(uint(z))
+
+		set.TileMatrices = append(set.TileMatrices, WMTSTileMatrix{
+			Identifier:       strconv.Itoa(z),
+			ScaleDenominator: tms.ScaleDenominator(uint(z)),
+			TopLeftCorner:    wmtsCorner(tms.Origin[0], tms.Origin[1]),
+			TileWidth:        tms.TileSize,
+			TileHeight:       tms.TileSize,
+			MatrixWidth:      cols,
+			MatrixHeight:     rows,
+		})
+	}
+
+	return set
+}
@@ -0,0 +1,194 @@
+package server_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-spatial/geom/encoding/mvt"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/server"
+)
+
+// wmtsCapabilities decodes the parts of the WMTS capabilities checked by the tests.
+// The names are matched without their namespace.
+type wmtsCapabilities struct {
+	Layers []struct {
+		Identifier string `xml:"Identifier"`
+		Format     string `xml:"Format"`
+		Set        string `xml:"TileMatrixSetLink>TileMatrixSet"`
+		Limits     []struct {
+			TileMatrix string `xml:"TileMatrix"`
+			MinTileRow uint   `xml:"MinTileRow"`
+			MaxTileRow uint   `xml:"MaxTileRow"`
+			MinTileCol uint   `xml:"MinTileCol"`
+			MaxTileCol uint   `xml:"MaxTileCol"`
+		} `xml:"TileMatrixSetLink>TileMatrixSetLimits>TileMatrixLimits"`
+		ResourceURL struct {
+			Template string `xml:"template,attr"`
+		} `xml:"ResourceURL"`
+	} `xml:"Contents>Layer"`
+	TileMatrixSets []struct {
+		Identifier   string `xml:"Identifier"`
+		SupportedCRS string `xml:"SupportedCRS"`
+		TileMatrices []struct {
+			Identifier  string `xml:"Identifier"`
+			MatrixWidth uint   `xml:"MatrixWidth"`
+		} `xml:"TileMatrix"`
+	} `xml:"Contents>TileMatrixSet"`
+}
+
+func TestHandleWMTSCapabilities(t *testing.T) {
+	type tcase struct {
+		atlas        *atlas.Atlas
+		uri          string
+		expectedCode int
+		// the expected layer identifiers and their first and last zooms
+		expectedLayers map[string][2]string
+		// the expected tile limits of the first layer at its first zoom:
+		// min row, max row, min col, max col
+		expectedLimits [4]uint
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			server.HostName = serverHostName
+
+			w, _, err := doRequest(tc.atlas, http.MethodGet, tc.uri, nil)
+			if err != nil {
+				t.Fatalf("request, expected nil got %v", err)
+			}
+			if w.Code != tc.expectedCode {
+				t.Fatalf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var capabilities wmtsCapabilities
+			if err := xml.Unmarshal(w.Body.Bytes(), &capabilities); err != nil {
+				t.Fatalf("decoding capabilities, expected nil got %v", err)
+			}
+
+			layers := map[string][2]string{}
+			for _, l := range capabilities.Layers {
+				layers[l.Identifier] = [2]string{l.Limits[0].TileMatrix, l.Limits[len(l.Limits)-1].TileMatrix}
+
+				if l.Format != mvt.MimeType {
+					t.Errorf("layer (%v) format, expected %v got %v", l.Identifier, mvt.MimeType, l.Format)
+				}
+				if l.Set != "WebMercatorQuad" {
+					t.Errorf("layer (%v) tile matrix set, expected WebMercatorQuad got %v", l.Identifier, l.Set)
+				}
+
+				expected := "http://tegola.io/wmts/test-map/1.0.0/" + l.Identifier + "/default/{TileMatrixSet}/{TileMatrix}/{TileRow}/{TileCol}.pbf"
+				if l.ResourceURL.Template != expected {
+					t.Errorf("layer (%v) template, expected %v got %v", l.Identifier, expected, l.ResourceURL.Template)
+				}
+			}
+			if !reflect.DeepEqual(layers, tc.expectedLayers) {
+				t.Errorf("layers, expected %v got %v", tc.expectedLayers, layers)
+			}
+
+			limits := capabilities.Layers[0].Limits[0]
+			if got := [4]uint{limits.MinTileRow, limits.MaxTileRow, limits.MinTileCol, limits.MaxTileCol}; got != tc.expectedLimits {
+				t.Errorf("limits, expected %v got %v", tc.expectedLimits, got)
+			}
+
+			if len(capabilities.TileMatrixSets) != 1 {
+				t.Fatalf("tile matrix sets, expected 1 got %v", len(capabilities.TileMatrixSets))
+			}
+			tms := capabilities.TileMatrixSets[0]
+			if tms.Identifier != "WebMercatorQuad" || tms.SupportedCRS != "urn:ogc:def:crs:EPSG::3857" {
+				t.Errorf("tile matrix set, expected WebMercatorQuad in EPSG:3857 got %v in %v", tms.Identifier, tms.SupportedCRS)
+			}
+			if tms.TileMatrices[3].MatrixWidth != 8 {
+				t.Errorf("tile matrix 3 width, expected 8 got %v", tms.TileMatrices[3].MatrixWidth)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"layers": {
+			atlas:        newTestMapWithLayers(testLayer1, testLayer2, testLayer3),
+			uri:          "/wmts/test-map/1.0.0/WMTSCapabilities.xml",
+			expectedCode: http.StatusOK,
+			expectedLayers: map[string][2]string{
+				"test-layer":        {"4", "20"},
+				"test-layer-2-name": {"10", "15"},
+			},
+			expectedLimits: [4]uint{0, 15, 0, 15},
+		},
+		"bounds": {
+			atlas:        newTestMapWithBounds(-10, -20, 30, 40),
+			uri:          "/wmts/test-map/1.0.0/WMTSCapabilities.xml",
+			expectedCode: http.StatusOK,
+			expectedLayers: map[string][2]string{
+				"test-layer": {"4", "9"},
+			},
+			expectedLimits: [4]uint{6, 8, 7, 9},
+		},
+		"unknown map": {
+			atlas:        newTestMapWithLayers(testLayer1),
+			uri:          "/wmts/no-map/1.0.0/WMTSCapabilities.xml",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestHandleWMTSTile(t *testing.T) {
+	type tcase struct {
+		uri          string
+		expectedCode int
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			a := newTestMapWithLayers(testLayer1, testLayer2, testLayer3)
+
+			w, _, err := doRequest(a, http.MethodGet, tc.uri, nil)
+			if err != nil {
+				t.Fatalf("request, expected nil got %v", err)
+			}
+			if w.Code != tc.expectedCode {
+				t.Fatalf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != mvt.MimeType {
+				t.Errorf("content type, expected %v got %v", mvt.MimeType, ct)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"tile": {
+			uri:          "/wmts/test-map/1.0.0/test-layer/default/WebMercatorQuad/10/3/2.pbf",
+			expectedCode: http.StatusOK,
+		},
+		"unknown layer": {
+			uri:          "/wmts/test-map/1.0.0/no-layer/default/WebMercatorQuad/10/3/2.pbf",
+			expectedCode: http.StatusNotFound,
+		},
+		"other tile matrix set": {
+			uri:          "/wmts/test-map/1.0.0/test-layer/default/WorldCRS84Quad/10/3/2.pbf",
+			expectedCode: http.StatusNotFound,
+		},
+		"invalid col": {
+			uri:          "/wmts/test-map/1.0.0/test-layer/default/WebMercatorQuad/10/3/a.pbf",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -0,0 +1,323 @@
+package server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// JWK is a key of a JSON Web Key Set (RFC 7517). RSA, EC (P-256, P-384 and P-521)
+// and symmetric (oct) keys are supported.
+type JWK struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Alg     string `json:"alg"`
+	Use     string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+
+	// oct
+	K string `json:"k"`
+
+	// the parsed key, a *rsa.PublicKey, *ecdsa.PublicKey or []byte
+	key interface{}
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ReadJWKS reads and parses the keys of a JSON Web Key Set
+func ReadJWKS(r io.Reader) (*JWKS, error) {
+	var set JWKS
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return nil, fmt.Errorf("error decoding JWKS: %v", err)
+	}
+
+	for i := range set.Keys {
+		if err := set.Keys[i].parse(); err != nil {
+			return nil, fmt.Errorf("JWKS key %v (%v): %v", i, set.Keys[i].KeyID, err)
+		}
+	}
+
+	return &set, nil
+}
+
+func (k *JWK) parse() error {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return fmt.Errorf("invalid n: %v", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return fmt.Errorf("invalid e: %v", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return errors.New("invalid e")
+		}
+		k.key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return fmt.Errorf("unsupported curve (%v)", k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return fmt.Errorf("invalid x: %v", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return fmt.Errorf("invalid y: %v", err)
+		}
+		k.key = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+
+	case "oct":
+		key, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return fmt.Errorf("invalid k: %v", err)
+		}
+		k.key = key
+
+	default:
+		return fmt.Errorf("unsupported key type (%v)", k.KeyType)
+	}
+
+	return nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// jwtHeader is the JOSE header of a token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims are the registered claims checked by tegola and the claims restricting the
+// maps and layers a token can access
+type jwtClaims struct {
+	Issuer    string        `json:"iss"`
+	Audience  stringOrSlice `json:"aud"`
+	ExpiresAt *float64      `json:"exp"`
+	NotBefore *float64      `json:"nbf"`
+	Maps      stringOrSlice `json:"maps"`
+	Layers    stringOrSlice `json:"layers"`
+}
+
+// stringOrSlice is a claim which can be a single string, space separated for the maps
+// and layers claims, or an array of strings
+type stringOrSlice []string
+
+func (s *stringOrSlice) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*s = strings.Fields(str)
+		return nil
+	}
+
+	var strs []string
+	if err := json.Unmarshal(b, &strs); err != nil {
+		return err
+	}
+	*s = strs
+	return nil
+}
+
+// jwtHashes are the hashes of the supported signing algorithms
+var jwtHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// verifyJWT checks the signature and the registered claims of the token and returns
+// its grant
+func (a *Authenticator) verifyJWT(token string, now time.Time) (Grant, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Grant{}, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return Grant{}, fmt.Errorf("invalid token header: %v", err)
+	}
+
+	hash, ok := jwtHashes[header.Alg]
+	if !ok {
+		return Grant{}, fmt.Errorf("unsupported token algorithm (%v)", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Grant{}, fmt.Errorf("invalid token signature: %v", err)
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	if !a.verifySignature(header, hash, signed, sig) {
+		return Grant{}, errors.New("invalid token signature")
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return Grant{}, fmt.Errorf("invalid token claims: %v", err)
+	}
+
+	unix := float64(now.Unix())
+	if claims.ExpiresAt != nil && unix >= *claims.ExpiresAt {
+		return Grant{}, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && unix < *claims.NotBefore {
+		return Grant{}, errors.New("token not valid yet")
+	}
+	if a.Issuer != "" && claims.Issuer != a.Issuer {
+		return Grant{}, fmt.Errorf("invalid token issuer (%v)", claims.Issuer)
+	}
+	if a.Audience != "" && !claims.Audience.contains(a.Audience) {
+		return Grant{}, errors.New("invalid token audience")
+	}
+
+	return Grant{Maps: claims.Maps, Layers: claims.Layers}, nil
+}
+
+func (s stringOrSlice) contains(v string) bool {
+	for i := range s {
+		if s[i] == v {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// verifySignature checks the signature with the secret, for HMAC algorithms, and the
+// keys of the JWKS. If the token header has a key id only the key with the id is used.
+func (a *Authenticator) verifySignature(header jwtHeader, hash crypto.Hash, signed, sig []byte) bool {
+	if strings.HasPrefix(header.Alg, "HS") && len(a.JWTSecret) > 0 && header.Kid == "" {
+		if verifyHMAC(a.JWTSecret, hash, signed, sig) {
+			return true
+		}
+	}
+
+	if a.JWKS == nil {
+		return false
+	}
+
+	for _, k := range a.JWKS.Keys {
+		if header.Kid != "" && k.KeyID != header.Kid {
+			continue
+		}
+		if k.Alg != "" && k.Alg != header.Alg {
+			continue
+		}
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch key := k.key.(type) {
+		case []byte:
+			if strings.HasPrefix(header.Alg, "HS") && verifyHMAC(key, hash, signed, sig) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if strings.HasPrefix(header.Alg, "RS") && rsa.VerifyPKCS1v15(key, hash, digest(hash, signed), sig) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			if strings.HasPrefix(header.Alg, "ES") && verifyECDSA(key, hash, signed, sig) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func digest(hash crypto.Hash, b []byte) []byte {
+	switch hash {
+	case crypto.SHA384:
+		d := sha512.Sum384(b)
+		return d[:]
+	case crypto.SHA512:
+		d := sha512.Sum512(b)
+		return d[:]
+	default:
+		d := sha256.Sum256(b)
+		return d[:]
+	}
+}
+
+func verifyHMAC(key []byte, hash crypto.Hash, signed, sig []byte) bool {
+	var mac = hmac.New(sha256.New, key)
+	switch hash {
+	case crypto.SHA384:
+		mac = hmac.New(sha512.New384, key)
+	case crypto.SHA512:
+		mac = hmac.New(sha512.New, key)
+	}
+	mac.Write(signed)
+	return hmac.Equal(mac.Sum(nil), sig)
+}
+
+// verifyECDSA checks a JWS ECDSA signature, which is the concatenation of r and s
+func verifyECDSA(key *ecdsa.PublicKey, hash crypto.Hash, signed, sig []byte) bool {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	if len(sig) != 2*size {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:size])
+	s := new(big.Int).SetBytes(sig[size:])
+	return ecdsa.Verify(key, digest(hash, signed), r, s)
+}
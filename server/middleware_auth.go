@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dimfeld/httptreemux"
+
+	"github.com/go-spatial/tegola/atlas"
+)
+
+// AuthHandler checks the credentials of the requests for a map's tiles or features
+// before any cache or provider work is done. Requests for maps which are not public
+// are answered with 401 Unauthorized if they have no valid credentials and with
+// 403 Forbidden if the credentials can't access the map or the layer.
+//
+// As tiles are cached per map, credentials which can only access some layers of the
+// map are only allowed to request the layer endpoints.
+func AuthHandler(a *atlas.Atlas, next http.Handler) http.Handler {
+	return authHandler(a, true, routeMapName, next)
+}
+
+// MapAuthHandler checks the credentials of the requests for a map's metadata (i.e. its
+// capabilities or style) like the AuthHandler, without checking the layers.
+func MapAuthHandler(a *atlas.Atlas, next http.Handler) http.Handler {
+	return authHandler(a, false, routeMapName, next)
+}
+
+// MapCapabilitiesAuthHandler is the MapAuthHandler of the map capabilities requests,
+// which name the map with an extension (i.e. /capabilities/osm.json)
+func MapCapabilitiesAuthHandler(a *atlas.Atlas, next http.Handler) http.Handler {
+	return authHandler(a, false, func(mapName string) string {
+		return strings.Split(mapName, ".")[0]
+	}, next)
+}
+
+// routeMapName is the map name of routes with a plain :map_name
+func routeMapName(mapName string) string { return mapName }
+
+func authHandler(a *atlas.Atlas, layers bool, parseMapName func(string) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Auth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		params := httptreemux.ContextParams(r.Context())
+		mapName, layerName := parseMapName(params["map_name"]), params["layer_name"]
+
+		if m, err := a.Map(mapName); err == nil && m.Public {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		grant, err := Auth.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tegola"`)
+			logAndError(w, http.StatusUnauthorized, "map (%v): %v", mapName, err)
+			return
+		}
+
+		if !grant.AllowsMap(mapName) {
+			logAndError(w, http.StatusForbidden, "map (%v): access denied", mapName)
+			return
+		}
+
+		if layers {
+			switch {
+			case layerName == "" && len(grant.Layers) > 0:
+				logAndError(w, http.StatusForbidden, "map (%v): access is restricted to the layers %v", mapName, grant.Layers)
+				return
+			case layerName != "" && !grant.AllowsLayer(layerName):
+				logAndError(w, http.StatusForbidden, "map (%v) layer (%v): access denied", mapName, layerName)
+				return
+			}
+		}
+
+		// the credentials identify the client for the RateLimitHandler
+		next.ServeHTTP(w, r.WithContext(withClient(r.Context(), credentials(r))))
+	})
+}
+
+// allowedMaps returns a func reporting if the request can access a map, for the
+// endpoints listing the maps
+func allowedMaps(r *http.Request) func(m atlas.Map) bool {
+	if Auth == nil {
+		return func(atlas.Map) bool { return true }
+	}
+
+	grant, err := Auth.Authenticate(r)
+	return func(m atlas.Map) bool {
+		return m.Public || (err == nil && grant.AllowsMap(m.Name))
+	}
+}
@@ -0,0 +1,349 @@
+package server_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/server"
+)
+
+// signJWT returns a token with the claims signed with the key, a []byte for HS256,
+// an *rsa.PrivateKey for RS256 or an *ecdsa.PrivateKey (P-256) for ES256
+func signJWT(t *testing.T, key interface{}, kid string, claims map[string]interface{}) string {
+	header := map[string]string{"typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	switch key.(type) {
+	case []byte:
+		header["alg"] = "HS256"
+	case *rsa.PrivateKey:
+		header["alg"] = "RS256"
+	case *ecdsa.PrivateKey:
+		header["alg"] = "ES256"
+	}
+
+	encode := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("encoding token, expected nil got %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("signing token, expected nil got %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("signing token, expected nil got %v", err)
+		}
+		// r and s are left padded to the size of the curve
+		sig = make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):32], rb)
+		copy(sig[64-len(sb):], sb)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func b64(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func TestAuthHandler(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key, expected nil got %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating EC key, expected nil got %v", err)
+	}
+
+	jwks, err := server.ReadJWKS(strings.NewReader(fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q}
+	]}`, b64(rsaKey.N), b64(big.NewInt(int64(rsaKey.E))), b64(ecKey.X), b64(ecKey.Y))))
+	if err != nil {
+		t.Fatalf("reading JWKS, expected nil got %v", err)
+	}
+
+	secret := []byte("secret")
+	exp := time.Now().Add(time.Hour).Unix()
+	expired := time.Now().Add(-time.Hour).Unix()
+
+	auth := &server.Authenticator{
+		APIKeys: map[string]server.Grant{
+			"all":   {},
+			"other": {Maps: []string{"other-map"}},
+			"layer": {Maps: []string{testMapName}, Layers: []string{"test-layer"}},
+		},
+		JWTSecret: secret,
+		JWKS:      jwks,
+		Issuer:    "tegola",
+	}
+
+	type tcase struct {
+		uri          string
+		header       http.Header
+		public       bool
+		expectedCode int
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			server.Auth = auth
+			defer func() { server.Auth = nil }()
+
+			m := atlas.NewWebMercatorMap(testMapName)
+			m.Layers = []atlas.Layer{testLayer1, testLayer2, testLayer3}
+			m.Public = tc.public
+			a := &atlas.Atlas{}
+			a.AddMap(m)
+
+			r, err := http.NewRequest(http.MethodGet, tc.uri, nil)
+			if err != nil {
+				t.Fatalf("request, expected nil got %v", err)
+			}
+			for k := range tc.header {
+				r.Header.Set(k, tc.header.Get(k))
+			}
+
+			w := httptest.NewRecorder()
+			server.NewRouter(a).ServeHTTP(w, r)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("status code, expected %v got %v: %v", tc.expectedCode, w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("WWW-Authenticate header, expected a challenge got none")
+			}
+		}
+	}
+
+	bearer := func(token string) http.Header {
+		return http.Header{"Authorization": []string{"Bearer " + token}}
+	}
+
+	tests := map[string]tcase{
+		"no credentials": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			expectedCode: http.StatusUnauthorized,
+		},
+		"public map": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			public:       true,
+			expectedCode: http.StatusOK,
+		},
+		"api key query": {
+			uri:          "/maps/test-map/10/2/3.pbf?api_key=all",
+			expectedCode: http.StatusOK,
+		},
+		"api key header": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			header:       http.Header{"X-Api-Key": []string{"all"}},
+			expectedCode: http.StatusOK,
+		},
+		"unknown api key": {
+			uri:          "/maps/test-map/10/2/3.pbf?api_key=nope",
+			expectedCode: http.StatusUnauthorized,
+		},
+		"api key for another map": {
+			uri:          "/maps/test-map/10/2/3.pbf?api_key=other",
+			expectedCode: http.StatusForbidden,
+		},
+		"api key for a layer": {
+			uri:          "/maps/test-map/test-layer/10/2/3.pbf?api_key=layer",
+			expectedCode: http.StatusOK,
+		},
+		"api key for a layer requesting the map": {
+			uri:          "/maps/test-map/10/2/3.pbf?api_key=layer",
+			expectedCode: http.StatusForbidden,
+		},
+		"api key for a layer requesting another layer": {
+			uri:          "/maps/test-map/test-layer-2-name/10/2/3.pbf?api_key=layer",
+			expectedCode: http.StatusForbidden,
+		},
+		"api key for a layer requesting the style": {
+			uri:          "/maps/test-map/style.json?api_key=layer",
+			expectedCode: http.StatusOK,
+		},
+		"api key bearer": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			header:       bearer("all"),
+			expectedCode: http.StatusOK,
+		},
+		"HS256 token": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			header:       bearer(signJWT(t, secret, "", map[string]interface{}{"iss": "tegola", "exp": exp})),
+			expectedCode: http.StatusOK,
+		},
+		"HS256 token query": {
+			uri:          "/maps/test-map/10/2/3.pbf?access_token=" + signJWT(t, secret, "", map[string]interface{}{"iss": "tegola", "exp": exp}),
+			expectedCode: http.StatusOK,
+		},
+		"HS256 token wrong secret": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			header:       bearer(signJWT(t, []byte("wrong"), "", map[string]interface{}{"iss": "tegola", "exp": exp})),
+			expectedCode: http.StatusUnauthorized,
+		},
+		"expired token": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			header:       bearer(signJWT(t, secret, "", map[string]interface{}{"iss": "tegola", "exp": expired})),
+			expectedCode: http.StatusUnauthorized,
+		},
+		"token wrong issuer": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			header:       bearer(signJWT(t, secret, "", map[string]interface{}{"iss": "other", "exp": exp})),
+			expectedCode: http.StatusUnauthorized,
+		},
+		"RS256 token": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			header:       bearer(signJWT(t, rsaKey, "rsa", map[string]interface{}{"iss": "tegola", "maps": []string{"test-map"}})),
+			expectedCode: http.StatusOK,
+		},
+		"RS256 token wrong key id": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			header:       bearer(signJWT(t, rsaKey, "ec", map[string]interface{}{"iss": "tegola"})),
+			expectedCode: http.StatusUnauthorized,
+		},
+		"ES256 token maps claim": {
+			uri:          "/maps/test-map/10/2/3.pbf",
+			header:       bearer(signJWT(t, ecKey, "ec", map[string]interface{}{"iss": "tegola", "maps": "other-map"})),
+			expectedCode: http.StatusForbidden,
+		},
+		"ES256 token layers claim": {
+			uri:          "/maps/test-map/test-layer/features?lng=1&lat=1&z=5",
+			header:       bearer(signJWT(t, ecKey, "ec", map[string]interface{}{"iss": "tegola", "layers": []string{"test-layer"}})),
+			expectedCode: http.StatusOK,
+		},
+		"features no credentials": {
+			uri:          "/maps/test-map/features?lng=1&lat=1&z=5",
+			expectedCode: http.StatusUnauthorized,
+		},
+		"api key for a map requesting the capabilities": {
+			uri:          "/capabilities/test-map.json?api_key=layer",
+			expectedCode: http.StatusOK,
+		},
+		"OGC collection no credentials": {
+			uri:          "/collections/test-map",
+			expectedCode: http.StatusUnauthorized,
+		},
+		"OGC tile api key": {
+			uri:          "/collections/test-map/tiles/WebMercatorQuad/10/3/2?api_key=all",
+			expectedCode: http.StatusOK,
+		},
+		"OGC tile api key for another map": {
+			uri:          "/collections/test-map/tiles/WebMercatorQuad/10/3/2?api_key=other",
+			expectedCode: http.StatusForbidden,
+		},
+		"unknown map no credentials": {
+			uri:          "/maps/no-map/10/2/3.pbf",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestCapabilitiesAuth(t *testing.T) {
+	type tcase struct {
+		uri          string
+		expectedMaps []string
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			server.Auth = &server.Authenticator{
+				APIKeys: map[string]server.Grant{
+					"all":     {},
+					"private": {Maps: []string{"private"}},
+				},
+			}
+			defer func() { server.Auth = nil }()
+
+			public := atlas.NewWebMercatorMap("public")
+			public.Public = true
+			private := atlas.NewWebMercatorMap("private")
+			other := atlas.NewWebMercatorMap("other")
+
+			// the capabilities handler lists the maps of the default atlas
+			prev := atlas.AllMaps()
+			atlas.ReplaceMaps([]atlas.Map{public, private, other})
+			defer atlas.ReplaceMaps(prev)
+
+			w, _, err := doRequest(nil, http.MethodGet, tc.uri, nil)
+			if err != nil {
+				t.Fatalf("request, expected nil got %v", err)
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("status code, expected %v got %v", http.StatusOK, w.Code)
+			}
+
+			var capabilities server.Capabilities
+			if err := json.Unmarshal(w.Body.Bytes(), &capabilities); err != nil {
+				t.Fatalf("decoding capabilities, expected nil got %v", err)
+			}
+
+			var maps []string
+			for _, m := range capabilities.Maps {
+				maps = append(maps, m.Name)
+			}
+			sort.Strings(maps)
+			if !reflect.DeepEqual(maps, tc.expectedMaps) {
+				t.Errorf("maps, expected %v got %v", tc.expectedMaps, maps)
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"no credentials": {
+			uri:          "/capabilities",
+			expectedMaps: []string{"public"},
+		},
+		"map api key": {
+			uri:          "/capabilities?api_key=private",
+			expectedMaps: []string{"private", "public"},
+		},
+		"api key": {
+			uri:          "/capabilities?api_key=all",
+			expectedMaps: []string{"other", "private", "public"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dimfeld/httptreemux"
+
+	"github.com/go-spatial/tegola/atlas"
+)
+
+// TileConditionalHandler adds an ETag, computed from the content of the tile, and the
+// Cache-Control header configured for the map to successful tile responses. Requests
+// with an If-None-Match header matching the ETag are answered with 304 Not Modified.
+//
+// The handler buffers the tile so it must wrap the GZipHandler, the ETag then identifies
+// the encoding the tile is sent in. Tiles are encoded with their feature tags in order so
+// rendering the same features again results in the same ETag.
+func TileConditionalHandler(a *atlas.Atlas, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buff bytes.Buffer
+
+		cw := &conditionalResponseWriter{
+			resp: w,
+			body: &buff,
+		}
+		next.ServeHTTP(cw, r)
+
+		// errors have been written to the client. a canceled request writes nothing
+		if cw.status != http.StatusOK {
+			return
+		}
+
+		params := httptreemux.ContextParams(r.Context())
+		if m, err := a.Map(params["map_name"]); err == nil && m.CacheControl != "" {
+			w.Header().Set("Cache-Control", m.CacheControl)
+		}
+
+		etag := tileETag(buff.Bytes())
+		w.Header().Set("ETag", etag)
+
+		if etagMatch(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", buff.Len()))
+		w.WriteHeader(http.StatusOK)
+		w.Write(buff.Bytes())
+	})
+}
+
+// tileETag returns a strong ETag for the tile
+func tileETag(tile []byte) string {
+	return fmt.Sprintf(`"%x"`, sha1.Sum(tile))
+}
+
+// etagMatch reports if the If-None-Match header value matches the etag
+// using the weak comparison (https://tools.ietf.org/html/rfc7232#section-3.2)
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// conditionalResponseWriter buffers the body of successful responses and writes
+// other responses through
+type conditionalResponseWriter struct {
+	// status response code
+	status int
+	resp   http.ResponseWriter
+	body   *bytes.Buffer
+}
+
+func (w *conditionalResponseWriter) Header() http.Header {
+	return w.resp.Header()
+}
+
+func (w *conditionalResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.status == http.StatusOK {
+		return w.body.Write(b)
+	}
+
+	return w.resp.Write(b)
+}
+
+func (w *conditionalResponseWriter) WriteHeader(i int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = i
+
+	// successful responses are written once the tile has been buffered
+	if i == http.StatusOK {
+		return
+	}
+
+	w.resp.WriteHeader(i)
+}
@@ -0,0 +1,139 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/server"
+)
+
+func TestTileConditionalHandler(t *testing.T) {
+	type tcase struct {
+		uri          string
+		cacheControl string
+		// ifNoneMatch returns the If-None-Match header of the second request given the ETag of the first
+		ifNoneMatch func(etag string) string
+
+		expStatus       int
+		expCacheControl string
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			server.URIPrefix = "/"
+
+			testMap := atlas.NewWebMercatorMap(testMapName)
+			testMap.Layers = append(testMap.Layers, testLayer1, testLayer2, testLayer3)
+			testMap.CacheControl = tc.cacheControl
+
+			// the tile cache serves the second request the tile rendered by the first
+			cacher, _ := memory.New(nil)
+			a := &atlas.Atlas{}
+			a.AddMap(testMap)
+			a.SetCache(cacher)
+
+			w, router, err := doRequest(a, "GET", tc.uri, nil)
+			if err != nil {
+				t.Fatalf("error making request, expected nil got %v", err)
+			}
+			if w.Code != http.StatusOK {
+				if w.Code != tc.expStatus {
+					t.Errorf("status, expected %v got %v", tc.expStatus, w.Code)
+				}
+				if etag := w.Header().Get("ETag"); etag != "" {
+					t.Errorf("ETag, expected none on error got %v", etag)
+				}
+				return
+			}
+
+			etag := w.Header().Get("ETag")
+			if etag == "" {
+				t.Fatalf("ETag, expected a value got none")
+			}
+
+			r, err := http.NewRequest("GET", tc.uri, nil)
+			if err != nil {
+				t.Fatalf("error making request, expected nil got %v", err)
+			}
+			if tc.ifNoneMatch != nil {
+				r.Header.Set("If-None-Match", tc.ifNoneMatch(etag))
+			}
+
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tc.expStatus {
+				t.Errorf("status, expected %v got %v", tc.expStatus, w.Code)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag, expected %v got %v", etag, got)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tc.expCacheControl {
+				t.Errorf("Cache-Control, expected %q got %q", tc.expCacheControl, got)
+			}
+
+			if tc.expStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("body, expected empty got %v bytes", w.Body.Len())
+			}
+			if tc.expStatus == http.StatusOK && w.Body.Len() == 0 {
+				t.Errorf("body, expected the tile got empty")
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"no if-none-match": {
+			uri:       "/maps/test-map/10/2/3.pbf",
+			expStatus: http.StatusOK,
+		},
+		"matching etag": {
+			uri:         "/maps/test-map/10/2/3.pbf",
+			ifNoneMatch: func(etag string) string { return etag },
+			expStatus:   http.StatusNotModified,
+		},
+		"matching weak etag in list": {
+			uri:         "/maps/test-map/10/2/3.pbf",
+			ifNoneMatch: func(etag string) string { return `"abc", W/` + etag },
+			expStatus:   http.StatusNotModified,
+		},
+		"wildcard": {
+			uri:         "/maps/test-map/10/2/3.pbf",
+			ifNoneMatch: func(etag string) string { return "*" },
+			expStatus:   http.StatusNotModified,
+		},
+		"stale etag": {
+			uri:         "/maps/test-map/10/2/3.pbf",
+			ifNoneMatch: func(etag string) string { return `"abc"` },
+			expStatus:   http.StatusOK,
+		},
+		"map layer matching etag": {
+			uri:         "/maps/test-map/test-layer/4/2/3.pbf",
+			ifNoneMatch: func(etag string) string { return etag },
+			expStatus:   http.StatusNotModified,
+		},
+		"map cache control": {
+			uri:             "/maps/test-map/10/2/3.pbf",
+			cacheControl:    "public, max-age=3600",
+			expStatus:       http.StatusOK,
+			expCacheControl: "public, max-age=3600",
+		},
+		"map cache control on not modified": {
+			uri:             "/maps/test-map/10/2/3.pbf",
+			cacheControl:    "public, max-age=3600, stale-while-revalidate=60",
+			ifNoneMatch:     func(etag string) string { return etag },
+			expStatus:       http.StatusNotModified,
+			expCacheControl: "public, max-age=3600, stale-while-revalidate=60",
+		},
+		"unknown map": {
+			uri:       "/maps/unknown-map/10/2/3.pbf",
+			expStatus: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
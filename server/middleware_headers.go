@@ -12,3 +12,17 @@ func HeadersHandler(next http.Handler) http.Handler {
 		return
 	})
 }
+
+// VaryAcceptHandler is middleware for the OGC API tile requests which adds Accept to
+// the Vary header when the tile format is negotiated from the Accept header (see
+// ogcTileFormat), so shared caches don't serve a tile in the format negotiated for
+// another client.
+func VaryAcceptHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("f") == "" {
+			w.Header().Add("Vary", "Accept")
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
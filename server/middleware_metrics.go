@@ -0,0 +1,150 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dimfeld/httptreemux"
+
+	"github.com/go-spatial/tegola"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/internal/metrics"
+)
+
+// cache results reported by the tile cache metrics
+const (
+	cacheHit   = "hit"
+	cacheMiss  = "miss"
+	cacheError = "error"
+	// the request shared the render of a concurrent request
+	cacheCoalesced = "coalesced"
+)
+
+// limits reported by the rate limited requests metric
+const (
+	// the client was over the rate limit
+	rateLimitClient = "client"
+	// the map was rendering the max concurrent renders
+	rateLimitRenders = "renders"
+)
+
+var (
+	tileRequests = metrics.NewCounterVec(
+		"tegola_tile_requests_total",
+		"Number of tile requests by map, layer, zoom and response status code.",
+		"map", "layer", "z", "code",
+	)
+
+	tileRequestDuration = metrics.NewHistogramVec(
+		"tegola_tile_request_duration_seconds",
+		"Time spent serving tile requests, including cache hits.",
+		metrics.DefaultDurationBuckets,
+		"map", "layer", "z",
+	)
+
+	tileSize = metrics.NewHistogramVec(
+		"tegola_tile_size_bytes",
+		"Size in bytes of the encoded tiles.",
+		metrics.DefaultSizeBuckets,
+		"map", "layer", "z",
+	)
+
+	tileCacheRequests = metrics.NewCounterVec(
+		"tegola_tile_cache_requests_total",
+		"Number of tile cache lookups by map and result (hit, miss, coalesced or error).",
+		"map", "result",
+	)
+
+	rateLimitedRequests = metrics.NewCounterVec(
+		"tegola_rate_limited_requests_total",
+		"Number of requests answered with 429 Too Many Requests by map and limit (client or renders).",
+		"map", "limit",
+	)
+)
+
+// unknownLabel is the label value of the map and layer names which are not in the
+// atlas and of invalid zooms. The route params are whatever the client requested,
+// so only names of the config are used as label values to bound the number of series.
+const unknownLabel = "unknown"
+
+// MetricsHandler records the count and duration of the tile requests it serves.
+// The map, layer and zoom are read from the route params.
+func MetricsHandler(a *atlas.Atlas, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		mw := &metricsResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+		next.ServeHTTP(mw, r)
+
+		params := httptreemux.ContextParams(r.Context())
+		mapName, layerName, z := tileLabels(a, params["map_name"], params["layer_name"], params["z"])
+
+		tileRequests.Inc(mapName, layerName, z, strconv.Itoa(mw.status))
+		tileRequestDuration.ObserveDuration(start, mapName, layerName, z)
+	})
+}
+
+// mapLabel returns the map label of a request for the map mapName
+func mapLabel(a *atlas.Atlas, mapName string) string {
+	if _, err := a.Map(mapName); err != nil {
+		return unknownLabel
+	}
+	return mapName
+}
+
+// tileLabels returns the map, layer and zoom labels of a tile request. The layer is
+// empty for requests of all the layers of a map.
+func tileLabels(a *atlas.Atlas, mapName, layerName, z string) (string, string, string) {
+	maxZoom := uint64(tegola.MaxZ)
+
+	m, err := a.Map(mapName)
+	switch {
+	case err != nil:
+		mapName = unknownLabel
+		if layerName != "" {
+			layerName = unknownLabel
+		}
+	case layerName != "" && !hasLayer(m, layerName):
+		layerName = unknownLabel
+		fallthrough
+	default:
+		maxZoom = uint64(m.Matrix().MaxZoom())
+	}
+
+	if zoom, err := strconv.ParseUint(z, 10, 32); err != nil || zoom > maxZoom {
+		z = unknownLabel
+	} else {
+		// formatted so i.e. 04 and 4 are the same series
+		z = strconv.FormatUint(zoom, 10)
+	}
+
+	return mapName, layerName, z
+}
+
+// hasLayer reports if the map has a layer named layerName, either by its name or
+// its provider layer name
+func hasLayer(m atlas.Map, layerName string) bool {
+	for i := range m.Layers {
+		if m.Layers[i].Name == layerName || m.Layers[i].ProviderLayerName == layerName {
+			return true
+		}
+	}
+	return false
+}
+
+// metricsResponseWriter wraps http.ResponseWriter (https://golang.org/pkg/net/http/#ResponseWriter)
+// to record the response status code
+type metricsResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *metricsResponseWriter) WriteHeader(i int) {
+	w.status = i
+
+	w.ResponseWriter.WriteHeader(i)
+}
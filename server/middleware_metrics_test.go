@@ -0,0 +1,124 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/internal/metrics"
+	"github.com/go-spatial/tegola/server"
+)
+
+func TestMiddlewareMetricsHandler(t *testing.T) {
+	// the metrics are global, a map name unique to this test keeps the counts predictable
+	const mapName = "metrics-map"
+
+	server.URIPrefix = "/"
+	server.Metrics = true
+	defer func() { server.Metrics = false }()
+
+	m := atlas.NewWebMercatorMap(mapName)
+	m.Layers = append(m.Layers, testLayer1)
+
+	a := &atlas.Atlas{}
+	a.AddMap(m)
+	cacher, _ := memory.New(nil)
+	a.SetCache(cacher)
+
+	router := server.NewRouter(a)
+
+	// the first request is a cache miss, the second a hit
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/maps/metrics-map/4/2/3.pbf", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status code, expected %v got %v", http.StatusOK, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code, expected %v got %v", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != metrics.ContentType {
+		t.Errorf("content type, expected %v got %v", metrics.ContentType, ct)
+	}
+
+	body := w.Body.String()
+	for _, line := range []string{
+		`tegola_tile_requests_total{map="metrics-map",layer="",z="4",code="200"} 2`,
+		`tegola_tile_request_duration_seconds_count{map="metrics-map",layer="",z="4"} 2`,
+		`tegola_tile_size_bytes_count{map="metrics-map",layer="",z="4"} 1`,
+		`tegola_tile_cache_requests_total{map="metrics-map",result="hit"} 1`,
+		`tegola_tile_cache_requests_total{map="metrics-map",result="miss"} 1`,
+		`tegola_provider_query_duration_seconds_count{map="metrics-map",layer="test-layer"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics, expected line %v in\n%v", line, body)
+		}
+	}
+}
+
+func TestMiddlewareMetricsHandlerUnknownLabels(t *testing.T) {
+	// the metrics are global, a map name unique to this test keeps the counts predictable
+	const mapName = "metrics-labels-map"
+
+	server.URIPrefix = "/"
+	server.Metrics = true
+	defer func() { server.Metrics = false }()
+
+	m := atlas.NewWebMercatorMap(mapName)
+	m.Layers = append(m.Layers, testLayer1)
+
+	a := &atlas.Atlas{}
+	a.AddMap(m)
+
+	router := server.NewRouter(a)
+
+	for _, uri := range []string{
+		"/maps/a%FFb/1/1/1.pbf",
+		"/maps/metrics-labels-map/a%FFb/1/1/1.pbf",
+		"/maps/metrics-labels-map/99/1/1.pbf",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", uri, nil))
+		if w.Code == http.StatusOK {
+			t.Fatalf("%v status code, expected an error got %v", uri, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code, expected %v got %v", http.StatusOK, w.Code)
+	}
+
+	body := w.Body.String()
+	if strings.Contains(body, "\xff") {
+		t.Errorf("metrics, expected no requested names in\n%v", body)
+	}
+	for _, line := range []string{
+		`tegola_tile_requests_total{map="unknown",layer="",z="1",code="404"} 1`,
+		`tegola_tile_requests_total{map="metrics-labels-map",layer="unknown",z="1",code="404"} 1`,
+		`tegola_tile_requests_total{map="metrics-labels-map",layer="",z="unknown",code="400"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics, expected line %v in\n%v", line, body)
+		}
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	server.URIPrefix = "/"
+	server.Metrics = false
+
+	w := httptest.NewRecorder()
+	server.NewRouter(nil).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status code, expected %v got %v", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dimfeld/httptreemux"
+
+	"github.com/go-spatial/tegola/atlas"
+)
+
+var (
+	// RateLimit limits the requests of each client. nil (default) does not limit clients.
+	// configurable via the tegola config.toml file (set in main.go)
+	RateLimit *RateLimiter
+
+	// MaxConcurrentRenders caps the tile renders and feature queries in progress for
+	// each map. 0 (default) does not cap renders.
+	// configurable via the tegola config.toml file (set in main.go)
+	MaxConcurrentRenders int
+
+	// TrustForwardedFor identifies clients by the first address of the X-Forwarded-For
+	// header rather than the address of the connection.
+	// configurable via the tegola config.toml file (set in main.go)
+	TrustForwardedFor bool
+)
+
+// rateLimitSweepInterval is how often the buckets of idle clients are removed
+const rateLimitSweepInterval = time.Minute
+
+// RateLimiter is a token bucket rate limiter per client
+type RateLimiter struct {
+	// tokens added to the buckets per second
+	rate float64
+	// the size of the buckets
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	// now is overridden by tests
+	now func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a rate limiter which allows each client rate requests per
+// second with bursts of up to burst requests. A burst less than 1 defaults to the
+// rate rounded up.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	b := float64(burst)
+	if burst < 1 {
+		b = math.Max(1, math.Ceil(rate))
+	}
+
+	return &RateLimiter{
+		rate:    rate,
+		burst:   b,
+		buckets: map[string]*tokenBucket{},
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the client's bucket. If the bucket is empty false is
+// returned along with the time until a token is available.
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) > rateLimitSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	// refill the bucket for the time since the last request
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// sweep removes the buckets which have refilled, they're the same as new buckets
+func (l *RateLimiter) sweep(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastSweep = now
+}
+
+// clientKey is the context key of the client credentials set by the AuthHandler
+type clientKey struct{}
+
+// withClient returns a copy of ctx with the authenticated client's credentials
+func withClient(ctx context.Context, credentials string) context.Context {
+	return context.WithValue(ctx, clientKey{}, credentials)
+}
+
+// client identifies the client of the request by the credentials authenticated by
+// the AuthHandler, otherwise by its address
+func client(r *http.Request) string {
+	if credentials, ok := r.Context().Value(clientKey{}).(string); ok && credentials != "" {
+		return "key:" + credentials
+	}
+
+	if TrustForwardedFor {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			return "ip:" + strings.TrimSpace(strings.Split(fwd, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// RateLimitHandler answers requests of clients which are over the RateLimit with
+// 429 Too Many Requests. It must be wrapped by the AuthHandler so requests with
+// credentials are limited per API key or token rather than per address.
+func RateLimitHandler(a *atlas.Atlas, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if RateLimit == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if ok, wait := RateLimit.Allow(client(r)); !ok {
+			mapName := httptreemux.ContextParams(r.Context())["map_name"]
+			rateLimitedRequests.Inc(mapLabel(a, mapName), rateLimitClient)
+			tooManyRequests(w, wait, "rate limit exceeded, retry in %v", wait.Round(time.Millisecond))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RenderLimitHandler caps the requests being rendered for each map at
+// MaxConcurrentRenders. Requests over the cap are answered with 429 Too Many Requests.
+// It's wrapped by the TileCacheHandler so cache hits never wait on renders.
+func RenderLimitHandler(a *atlas.Atlas, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if MaxConcurrentRenders <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mapName := httptreemux.ContextParams(r.Context())["map_name"]
+
+		// requests for maps which are not in the atlas don't render, they're rejected
+		// by the handler. only the maps of the atlas have render slots.
+		if _, err := a.Map(mapName); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		release, ok := renderSlots.acquire(mapName, MaxConcurrentRenders)
+		if !ok {
+			rateLimitedRequests.Inc(mapName, rateLimitRenders)
+			tooManyRequests(w, time.Second, "map (%v) is rendering too many requests, retry later", mapName)
+			return
+		}
+		defer release()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tooManyRequests responds with 429 Too Many Requests and a Retry-After header of
+// wait rounded up to seconds
+func tooManyRequests(w http.ResponseWriter, wait time.Duration, format string, vals ...interface{}) {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+	logAndError(w, http.StatusTooManyRequests, format, vals...)
+}
+
+// renderSemaphores are the render slots of each map
+type renderSemaphores struct {
+	sync.Mutex
+	maps map[string]chan struct{}
+}
+
+// renderSlots are the render slots used by the RenderLimitHandler
+var renderSlots = renderSemaphores{maps: map[string]chan struct{}{}}
+
+// acquire takes one of the max render slots of the map without waiting. ok is false
+// if all the slots are taken.
+func (s *renderSemaphores) acquire(mapName string, max int) (release func(), ok bool) {
+	s.Lock()
+	slots, exists := s.maps[mapName]
+	if !exists || cap(slots) != max {
+		slots = make(chan struct{}, max)
+		s.maps[mapName] = slots
+	}
+	s.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dimfeld/httptreemux"
+
+	"github.com/go-spatial/tegola/atlas"
+)
+
+func TestRateLimiter(t *testing.T) {
+	type step struct {
+		// time since the start
+		at     time.Duration
+		client string
+		// expected result
+		allowed bool
+		wait    time.Duration
+	}
+
+	type tcase struct {
+		rate  float64
+		burst int
+		steps []step
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			start := time.Now()
+			var now time.Time
+
+			l := NewRateLimiter(tc.rate, tc.burst)
+			l.now = func() time.Time { return now }
+
+			for i, s := range tc.steps {
+				now = start.Add(s.at)
+
+				allowed, wait := l.Allow(s.client)
+				if allowed != s.allowed {
+					t.Errorf("step %v allowed, expected %v got %v", i, s.allowed, allowed)
+				}
+				if wait != s.wait {
+					t.Errorf("step %v wait, expected %v got %v", i, s.wait, wait)
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"burst": {
+			rate:  1,
+			burst: 2,
+			steps: []step{
+				{client: "a", allowed: true},
+				{client: "a", allowed: true},
+				{client: "a", wait: time.Second},
+				{at: 500 * time.Millisecond, client: "a", wait: 500 * time.Millisecond},
+				{at: time.Second, client: "a", allowed: true},
+			},
+		},
+		"clients": {
+			rate:  2,
+			burst: 1,
+			steps: []step{
+				{client: "a", allowed: true},
+				{client: "a", wait: 500 * time.Millisecond},
+				{client: "b", allowed: true},
+				{at: 500 * time.Millisecond, client: "a", allowed: true},
+			},
+		},
+		"default burst": {
+			rate: 1.5,
+			steps: []step{
+				{client: "a", allowed: true},
+				{client: "a", allowed: true},
+				{client: "a", wait: 666666666 * time.Nanosecond},
+			},
+		},
+		"refill is capped at the burst": {
+			rate:  10,
+			burst: 1,
+			steps: []step{
+				{client: "a", allowed: true},
+				{at: time.Hour, client: "a", allowed: true},
+				{at: time.Hour, client: "a", wait: 100 * time.Millisecond},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	start := time.Now()
+	now := start
+
+	l := NewRateLimiter(1, 1)
+	l.now = func() time.Time { return now }
+
+	l.Allow("a")
+
+	// the bucket of a has refilled when b's request sweeps the buckets
+	now = start.Add(rateLimitSweepInterval + time.Second)
+	l.Allow("b")
+
+	if _, ok := l.buckets["a"]; ok {
+		t.Errorf("bucket a, expected it to be removed")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Errorf("bucket b, expected it to be kept")
+	}
+}
+
+func TestRenderLimitHandlerUnknownMap(t *testing.T) {
+	MaxConcurrentRenders = 1
+	defer func() { MaxConcurrentRenders = 0 }()
+
+	a := &atlas.Atlas{}
+	a.AddMap(atlas.NewWebMercatorMap("render-limit-map"))
+
+	var served int
+	h := RenderLimitHandler(a, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+
+	for _, mapName := range []string{"render-limit-map", "render-limit-unknown"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(httptreemux.AddParamsToContext(r.Context(), map[string]string{"map_name": mapName}))
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if served != 2 {
+		t.Errorf("served, expected 2 got %v", served)
+	}
+
+	renderSlots.Lock()
+	defer renderSlots.Unlock()
+
+	if _, ok := renderSlots.maps["render-limit-map"]; !ok {
+		t.Errorf("render slots, expected slots for render-limit-map")
+	}
+	if _, ok := renderSlots.maps["render-limit-unknown"]; ok {
+		t.Errorf("render slots, expected no slots for render-limit-unknown")
+	}
+}
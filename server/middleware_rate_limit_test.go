@@ -0,0 +1,241 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-spatial/geom"
+	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/cache/memory"
+	"github.com/go-spatial/tegola/provider"
+	"github.com/go-spatial/tegola/provider/test"
+	"github.com/go-spatial/tegola/server"
+)
+
+func TestRateLimitHandler(t *testing.T) {
+	type request struct {
+		remoteAddr string
+		header     http.Header
+		uri        string
+		// expected status code
+		code int
+	}
+
+	type tcase struct {
+		trustForwardedFor bool
+		auth              *server.Authenticator
+		requests          []request
+	}
+
+	fn := func(tc tcase) func(*testing.T) {
+		return func(t *testing.T) {
+			server.RateLimit = server.NewRateLimiter(0.001, 2)
+			server.TrustForwardedFor = tc.trustForwardedFor
+			server.Auth = tc.auth
+			defer func() {
+				server.RateLimit = nil
+				server.TrustForwardedFor = false
+				server.Auth = nil
+			}()
+
+			router := server.NewRouter(newTestMapWithLayers(testLayer1, testLayer2, testLayer3))
+
+			for i, req := range tc.requests {
+				uri := req.uri
+				if uri == "" {
+					uri = "/maps/test-map/10/2/3.pbf"
+				}
+
+				r := httptest.NewRequest(http.MethodGet, uri, nil)
+				r.RemoteAddr = req.remoteAddr
+				for k := range req.header {
+					r.Header.Set(k, req.header.Get(k))
+				}
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+
+				if w.Code != req.code {
+					t.Errorf("request %v status code, expected %v got %v", i, req.code, w.Code)
+				}
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Errorf("request %v Retry-After, expected a value got none", i)
+				}
+			}
+		}
+	}
+
+	tests := map[string]tcase{
+		"address": {
+			requests: []request{
+				{remoteAddr: "192.0.2.1:1234", code: http.StatusOK},
+				{remoteAddr: "192.0.2.1:1235", code: http.StatusOK},
+				{remoteAddr: "192.0.2.1:1236", code: http.StatusTooManyRequests},
+				{remoteAddr: "192.0.2.2:1234", code: http.StatusOK},
+			},
+		},
+		"features": {
+			requests: []request{
+				{remoteAddr: "192.0.2.1:1234", uri: "/maps/test-map/features?lng=1&lat=1&z=5", code: http.StatusOK},
+				{remoteAddr: "192.0.2.1:1234", uri: "/maps/test-map/features?lng=1&lat=1&z=5", code: http.StatusOK},
+				{remoteAddr: "192.0.2.1:1234", uri: "/maps/test-map/features?lng=1&lat=1&z=5", code: http.StatusTooManyRequests},
+			},
+		},
+		"forwarded for": {
+			trustForwardedFor: true,
+			requests: []request{
+				{remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Forwarded-For": {"192.0.2.1, 10.0.0.2"}}, code: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Forwarded-For": {"192.0.2.1"}}, code: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Forwarded-For": {"192.0.2.1"}}, code: http.StatusTooManyRequests},
+				{remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Forwarded-For": {"192.0.2.2"}}, code: http.StatusOK},
+			},
+		},
+		"forwarded for not trusted": {
+			requests: []request{
+				{remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Forwarded-For": {"192.0.2.1"}}, code: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Forwarded-For": {"192.0.2.2"}}, code: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Forwarded-For": {"192.0.2.3"}}, code: http.StatusTooManyRequests},
+			},
+		},
+		"api keys": {
+			auth: &server.Authenticator{
+				APIKeys: map[string]server.Grant{"key1": {}, "key2": {}},
+			},
+			requests: []request{
+				{remoteAddr: "192.0.2.1:1234", uri: "/maps/test-map/10/2/3.pbf?api_key=key1", code: http.StatusOK},
+				{remoteAddr: "192.0.2.1:1234", uri: "/maps/test-map/10/2/3.pbf?api_key=key1", code: http.StatusOK},
+				{remoteAddr: "192.0.2.1:1234", uri: "/maps/test-map/10/2/3.pbf?api_key=key1", code: http.StatusTooManyRequests},
+				{remoteAddr: "192.0.2.1:1234", uri: "/maps/test-map/10/2/3.pbf?api_key=key2", code: http.StatusOK},
+				// rejected before the rate limit
+				{remoteAddr: "192.0.2.1:1234", uri: "/maps/test-map/10/2/3.pbf", code: http.StatusUnauthorized},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, fn(tc))
+	}
+}
+
+// blockingProvider blocks the renders of zoom 10 tiles until release is closed.
+// The first blocked render is signaled on started.
+type blockingProvider struct {
+	test.TileProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) TileFeatures(ctx context.Context, layer string, t provider.Tile, fn func(f *provider.Feature) error) error {
+	if z, _, _ := t.ZXY(); z == 10 {
+		select {
+		case p.started <- struct{}{}:
+		default:
+		}
+		<-p.release
+	}
+	return p.TileProvider.TileFeatures(ctx, layer, t, fn)
+}
+
+func TestRenderLimitHandler(t *testing.T) {
+	server.MaxConcurrentRenders = 1
+	defer func() { server.MaxConcurrentRenders = 0 }()
+
+	prvd := &blockingProvider{
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+
+	a := newTestMapWithLayers(atlas.Layer{
+		Name:     "blocking",
+		Provider: prvd,
+		GeomType: geom.Polygon{},
+	})
+	cacher, _ := memory.New(nil)
+	a.SetCache(cacher)
+
+	router := server.NewRouter(a)
+	get := func(uri string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, uri, nil))
+		return w
+	}
+
+	// cache a tile
+	if w := get("/maps/test-map/5/2/3.pbf"); w.Code != http.StatusOK {
+		t.Fatalf("cached tile status code, expected %v got %v", http.StatusOK, w.Code)
+	}
+
+	// block the render slot of the map
+	blocked := make(chan *httptest.ResponseRecorder)
+	go func() { blocked <- get("/maps/test-map/10/2/3.pbf") }()
+	<-prvd.started
+
+	if w := get("/maps/test-map/10/2/4.pbf"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("render over the cap, expected %v with Retry-After got %v (%v)", http.StatusTooManyRequests, w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := get("/maps/test-map/features?lng=1&lat=1&z=10"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("features over the cap, expected %v got %v", http.StatusTooManyRequests, w.Code)
+	}
+
+	// cache hits bypass the cap
+	w := get("/maps/test-map/5/2/3.pbf")
+	if w.Code != http.StatusOK || w.Header().Get("Tegola-Cache") != "HIT" {
+		t.Errorf("cache hit, expected %v HIT got %v %v", http.StatusOK, w.Code, w.Header().Get("Tegola-Cache"))
+	}
+
+	close(prvd.release)
+	if w := <-blocked; w.Code != http.StatusOK {
+		t.Errorf("blocked render status code, expected %v got %v", http.StatusOK, w.Code)
+	}
+
+	// the slot is released
+	if w := get("/maps/test-map/10/2/4.pbf"); w.Code != http.StatusOK {
+		t.Errorf("render after release status code, expected %v got %v", http.StatusOK, w.Code)
+	}
+}
+
+func TestRateLimitHandlerUnknownMap(t *testing.T) {
+	server.URIPrefix = "/"
+	server.Metrics = true
+	server.RateLimit = server.NewRateLimiter(0.001, 1)
+	defer func() {
+		server.Metrics = false
+		server.RateLimit = nil
+	}()
+
+	router := server.NewRouter(newTestMapWithLayers(testLayer1, testLayer2, testLayer3))
+
+	for i, uri := range []string{
+		"/maps/a%FFb/10/2/3.pbf",
+		"/maps/a%FFc/10/2/3.pbf",
+	} {
+		r := httptest.NewRequest(http.MethodGet, uri, nil)
+		r.RemoteAddr = "192.0.2.100:1234"
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		// the first request is allowed and rejected by the handler
+		expected := http.StatusNotFound
+		if i > 0 {
+			expected = http.StatusTooManyRequests
+		}
+		if w.Code != expected {
+			t.Errorf("%v status code, expected %v got %v", uri, expected, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := w.Body.String()
+	if strings.Contains(body, "\xff") {
+		t.Errorf("metrics, expected no requested names in\n%v", body)
+	}
+	if line := `tegola_rate_limited_requests_total{map="unknown",limit="client"} 1`; !strings.Contains(body, line+"\n") {
+		t.Errorf("metrics, expected line %v in\n%v", line, body)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/dimfeld/httptreemux"
 	"github.com/go-spatial/geom/encoding/mvt"
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/cache"
@@ -28,58 +29,173 @@ func TileCacheHandler(a *atlas.Atlas, next http.Handler) http.Handler {
 			return
 		}
 
-		// parse our URI into a cache key structure (remove any configured URIPrefix + "maps/" )
-		key, err := cache.ParseKey(strings.TrimPrefix(r.URL.Path, path.Join(URIPrefix, "maps")))
+		key, err := tileCacheKey(a, r)
 		if err != nil {
-			log.Errorf("cache middleware: ParseKey err: %v", err)
+			log.Errorf("cache middleware: %v", err)
 			next.ServeHTTP(w, r)
 			return
 		}
 
+		// tiles filtered by query parameters are cached under their own key
+		if m, err := a.Map(key.MapName); err == nil && len(m.Params) > 0 {
+			key.Params, err = m.QueryParameterKey(r.URL.Query())
+			if err != nil {
+				// the request is rejected by the handler
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		mapName := mapLabel(a, key.MapName)
+
 		// use the URL path as the key
 		cachedTile, hit, err := cacher.Get(key)
 		if err != nil {
+			tileCacheRequests.Inc(mapName, cacheError)
 			log.Errorf("cache middleware: error reading from cache: %v", err)
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// cache miss
-		if !hit {
-			// buffer which will hold a copy of the response for writing to the cache
-			var buff bytes.Buffer
+		// cache hit
+		if hit {
+			tileCacheRequests.Inc(mapName, cacheHit)
+			writeCachedTile(w, key, cachedTile, "HIT")
+			return
+		}
 
-			// ovewrite our current responseWriter with a tileCacheResponseWriter
-			w = newTileCacheResponseWriter(w, &buff)
+		// cache miss. concurrent requests for the tile share a single render, which
+		// is taken over by a waiting request if the rendering request is canceled
+		tile, shared := renders.do(r.Context(), key.String(), func() []byte {
+			tileCacheRequests.Inc(mapName, cacheMiss)
+			return renderTile(cacher, key, mapName, w, r, next)
+		})
+		if !shared {
+			// the response has been written by the render
+			return
+		}
 
-			next.ServeHTTP(w, r)
+		// check if our request context has been canceled
+		if r.Context().Err() != nil {
+			return
+		}
 
-			// check if our request context has been canceled
-			if r.Context().Err() != nil {
-				return
-			}
+		if tile == nil {
+			// the shared render failed, render the tile for this request
+			tileCacheRequests.Inc(mapName, cacheMiss)
+			renderTile(cacher, key, mapName, w, r, next)
+			return
+		}
 
-			// if nothing has been written to the buffer, don't write to the cache
-			if buff.Len() == 0 {
-				return
-			}
+		tileCacheRequests.Inc(mapName, cacheCoalesced)
+		writeCachedTile(w, key, tile, "MISS")
+	})
+}
 
-			if err := cacher.Set(key, buff.Bytes()); err != nil {
-				log.Warnf("cache response writer err: %v", err)
+// tileCacheKey returns the cache key of the tile request. OGC API and WMTS tile requests
+// (i.e. /collections/osm/tiles/WebMercatorQuad/1/4/3) are keyed like the equivalent
+// /maps/:map_name/:layer_name/:z/:x/:y request so they share the cached tiles.
+func tileCacheKey(a *atlas.Atlas, r *http.Request) (*cache.Key, error) {
+	if httptreemux.ContextParams(r.Context())["tile_matrix_set"] == "" {
+		// parse our URI into a cache key structure (remove any configured URIPrefix + "maps/" )
+		key, err := cache.ParseKey(strings.TrimPrefix(r.URL.Path, path.Join(URIPrefix, "maps")))
+		if err != nil {
+			return nil, fmt.Errorf("ParseKey err: %v", err)
+		}
+		return key, nil
+	}
+
+	var req HandleMapLayerZXY
+	if err := req.parseURI(r); err != nil {
+		return nil, err
+	}
+
+	// the cached tiles of a map are in the map's tile matrix set
+	m, err := a.Map(req.mapName)
+	if err != nil {
+		return nil, err
+	}
+	if m.Matrix().Name != req.tileMatrixSet {
+		return nil, fmt.Errorf("map (%v) is not served in the tile matrix set (%v)", req.mapName, req.tileMatrixSet)
+	}
+
+	key := cache.Key{
+		MapName:   req.mapName,
+		LayerName: req.layerName,
+		Z:         req.z,
+		X:         req.x,
+		Y:         req.y,
+	}
+	if isGeoJSONExtension(req.extension) {
+		key.Format = req.extension
+	}
+
+	return &key, nil
+}
+
+// renderTile renders the tile by calling next, writes the response to w and, if the
+// render succeeded, sets the tile in the cache. The rendered tile is returned, nil if
+// the render failed or was canceled. If the cache can lock keys across tegola instances
+// the tile is only rendered if the lock is acquired or the instance holding the lock
+// does not cache the tile in time. mapName is the map label of the cache metrics.
+func renderTile(cacher cache.Interface, key *cache.Key, mapName string, w http.ResponseWriter, r *http.Request, next http.Handler) []byte {
+	if locker, ok := cacher.(cache.Locker); ok {
+		unlock, acquired, err := locker.Lock(key)
+		switch {
+		case err != nil:
+			log.Warnf("cache middleware: error locking tile: %v", err)
+		case acquired:
+			defer unlock()
+		default:
+			// another instance is rendering the tile
+			if tile := waitForTile(r.Context(), cacher, key, locker.LockTTL()); tile != nil {
+				writeCachedTile(w, key, tile, "HIT")
+				return tile
 			}
-			return
 		}
+	}
 
-		// mimetype for mapbox vector tiles
+	// buffer which will hold a copy of the response for writing to the cache
+	var buff bytes.Buffer
+
+	// ovewrite our current responseWriter with a tileCacheResponseWriter
+	next.ServeHTTP(newTileCacheResponseWriter(w, &buff), r)
+
+	// check if our request context has been canceled
+	if r.Context().Err() != nil {
+		return nil
+	}
+
+	// if nothing has been written to the buffer, don't write to the cache
+	if buff.Len() == 0 {
+		return nil
+	}
+
+	if err := cacher.Set(key, buff.Bytes()); err != nil {
+		tileCacheRequests.Inc(mapName, cacheError)
+		log.Warnf("cache response writer err: %v", err)
+	}
+
+	return buff.Bytes()
+}
+
+// writeCachedTile writes a tile read from the cache or shared by another request.
+// cacheStatus is reported in the Tegola-Cache header.
+func writeCachedTile(w http.ResponseWriter, key *cache.Key, tile []byte, cacheStatus string) {
+	// mimetype for mapbox vector tiles or GeoJSON
+	if isGeoJSONExtension(key.Format) {
+		w.Header().Add("Content-Type", GeoJSONMimeType)
+	} else {
 		w.Header().Add("Content-Type", mvt.MimeType)
+	}
 
-		// communicate the cache is being used
-		w.Header().Add("Tegola-Cache", "HIT")
-		w.Header().Add("Content-Length", fmt.Sprintf("%d", len(cachedTile)))
+	// communicate the cache is being used
+	w.Header().Add("Tegola-Cache", cacheStatus)
+	w.Header().Add("Content-Length", fmt.Sprintf("%d", len(tile)))
 
-		w.Write(cachedTile)
-		return
-	})
+	// the status is required for the GZipHandler to decompress the tile
+	w.WriteHeader(http.StatusOK)
+	w.Write(tile)
 }
 
 func newTileCacheResponseWriter(resp http.ResponseWriter, w io.Writer) http.ResponseWriter {
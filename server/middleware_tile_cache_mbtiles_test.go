@@ -0,0 +1,72 @@
+// +build cgo
+
+package server_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-spatial/tegola/cache/mbtiles"
+	"github.com/go-spatial/tegola/dict"
+	"github.com/go-spatial/tegola/server"
+)
+
+func TestMiddlewareTileCacheHandlerQueryParametersMBTiles(t *testing.T) {
+	server.URIPrefix = "/"
+
+	dir, err := ioutil.TempDir("", "tegola-mbtiles")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cacher, err := mbtiles.New(dict.Dict{
+		"filepath": filepath.Join(dir, "test.mbtiles"),
+		"map":      testMapName,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer cacher.(*mbtiles.Cache).Close()
+
+	a := newTestMapWithParams(yearParam)
+	a.SetCache(cacher)
+
+	router := server.NewRouter(a)
+
+	// requests in order and their expected Tegola-Cache header
+	requests := []struct {
+		uri      string
+		expected string
+	}{
+		// the file only holds the tiles without query parameters
+		{uri: "/maps/test-map/10/2/3.pbf?year=2020", expected: "MISS"},
+		{uri: "/maps/test-map/10/2/3.pbf", expected: "MISS"},
+		{uri: "/maps/test-map/10/2/3.pbf?year=2020", expected: "MISS"},
+		{uri: "/maps/test-map/10/2/3.pbf?year=2021", expected: "MISS"},
+		// the default value shares the key of requests without the parameter
+		{uri: "/maps/test-map/10/2/3.pbf?year=2019", expected: "HIT"},
+		{uri: "/maps/test-map/10/2/3.pbf", expected: "HIT"},
+	}
+
+	for _, req := range requests {
+		r, err := http.NewRequest("GET", req.uri, nil)
+		if err != nil {
+			t.Fatalf("error making request, expected nil got %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%v status, expected %v got %v", req.uri, http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("Tegola-Cache"); got != req.expected {
+			t.Errorf("%v Tegola-Cache, expected %v got %v", req.uri, req.expected, got)
+		}
+	}
+}
@@ -13,6 +13,8 @@ func TestMiddlewareTileCacheHandler(t *testing.T) {
 	type tcase struct {
 		uri       string
 		uriPrefix string
+		// the uri of the request expected to HIT, defaults to uri
+		hitURI string
 	}
 
 	fn := func(tc tcase) func(t *testing.T) {
@@ -42,7 +44,11 @@ func TestMiddlewareTileCacheHandler(t *testing.T) {
 			}
 
 			// play the request again to get a HIT
-			r, err := http.NewRequest("GET", tc.uri, nil)
+			hitURI := tc.uri
+			if tc.hitURI != "" {
+				hitURI = tc.hitURI
+			}
+			r, err := http.NewRequest("GET", hitURI, nil)
 			if err != nil {
 				t.Errorf("error making request, expected nil got %v", err)
 				return
@@ -73,9 +79,67 @@ func TestMiddlewareTileCacheHandler(t *testing.T) {
 			uri:       "/tegola/maps/test-map/test-layer/4/2/3.pbf",
 			uriPrefix: "/tegola",
 		},
+		"OGC tile": {
+			uri: "/collections/test-map/tiles/WebMercatorQuad/10/3/2",
+		},
+		"OGC tile of a map tile": {
+			uri:    "/maps/test-map/10/2/3.pbf",
+			hitURI: "/collections/test-map/tiles/WebMercatorQuad/10/3/2?f=mvt",
+		},
+		"WMTS tile of a map layer tile": {
+			uri:    "/maps/test-map/test-layer/10/2/3.pbf",
+			hitURI: "/wmts/test-map/1.0.0/test-layer/default/WebMercatorQuad/10/3/2.pbf",
+		},
+		"OGC geojson tile of a map tile": {
+			uri:    "/maps/test-map/10/2/3.geojson",
+			hitURI: "/collections/test-map/tiles/WebMercatorQuad/10/3/2?f=geojson",
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, fn(tc))
 	}
 }
+
+func TestMiddlewareTileCacheHandlerQueryParameters(t *testing.T) {
+	server.URIPrefix = "/"
+
+	a := newTestMapWithParams(yearParam)
+	cacher, _ := memory.New(nil)
+	a.SetCache(cacher)
+
+	router := server.NewRouter(a)
+
+	// requests in order and their expected Tegola-Cache header
+	requests := []struct {
+		uri      string
+		expected string
+	}{
+		{uri: "/maps/test-map/10/2/3.pbf?year=2020", expected: "MISS"},
+		// tiles rendered with other values don't collide
+		{uri: "/maps/test-map/10/2/3.pbf", expected: "MISS"},
+		{uri: "/maps/test-map/10/2/3.pbf?year=2021", expected: "MISS"},
+		{uri: "/maps/test-map/10/2/3.pbf?year=2020", expected: "HIT"},
+		// the default value shares the key of requests without the parameter
+		{uri: "/maps/test-map/10/2/3.pbf?year=2019", expected: "HIT"},
+		// parameters which are not declared by the map are not part of the key
+		{uri: "/maps/test-map/10/2/3.pbf?year=2021&foo=bar", expected: "HIT"},
+	}
+
+	for _, req := range requests {
+		r, err := http.NewRequest("GET", req.uri, nil)
+		if err != nil {
+			t.Fatalf("error making request, expected nil got %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%v status, expected %v got %v", req.uri, http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("Tegola-Cache"); got != req.expected {
+			t.Errorf("%v Tegola-Cache, expected %v got %v", req.uri, req.expected, got)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-spatial/tegola/atlas"
+)
+
+// TrackHandler is middleware which tracks the request as work using the atlas' maps so
+// the providers of replaced maps are not cleaned up while they're in use (see atlas.ReplaceMaps)
+func TrackHandler(a *atlas.Atlas, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done := a.Track()
+		defer done()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-spatial/tegola/atlas"
 	"github.com/go-spatial/tegola/internal/log"
+	"github.com/go-spatial/tegola/internal/metrics"
 )
 
 const (
@@ -46,6 +47,11 @@ var (
 	// when the server sits behind a reverse proxy with a prefix (i.e. /tegola)
 	URIPrefix = "/"
 
+	// Metrics enables the /metrics endpoint which exposes the tile serving, provider
+	// and cache metrics in the Prometheus text format.
+	// configurable via the tegola config.toml file (set in main.go)
+	Metrics bool
+
 	// DefaultCORSHeaders define the default CORS response headers added to all requests
 	DefaultCORSHeaders = map[string]string{
 		"Access-Control-Allow-Origin":  "*",
@@ -63,18 +69,43 @@ func NewRouter(a *atlas.Atlas) *httptreemux.TreeMux {
 
 	// capabilities endpoints
 	group.UsingContext().Handler("GET", "/capabilities", HeadersHandler(HandleCapabilities{}))
-	group.UsingContext().Handler("GET", "/capabilities/:map_name", HeadersHandler(HandleMapCapabilities{}))
+	group.UsingContext().Handler("GET", "/capabilities/:map_name", HeadersHandler(MapCapabilitiesAuthHandler(a, HandleMapCapabilities{})))
 
 	// map tiles
 	hMapLayerZXY := HandleMapLayerZXY{Atlas: a}
-	group.UsingContext().Handler("GET", "/maps/:map_name/:z/:x/:y", HeadersHandler(GZipHandler(TileCacheHandler(a, hMapLayerZXY))))
-	group.UsingContext().Handler("GET", "/maps/:map_name/:layer_name/:z/:x/:y", HeadersHandler(GZipHandler(TileCacheHandler(a, hMapLayerZXY))))
+	group.UsingContext().Handler("GET", "/maps/:map_name/:z/:x/:y", HeadersHandler(MetricsHandler(a, AuthHandler(a, RateLimitHandler(a, TileConditionalHandler(a, GZipHandler(TrackHandler(a, TileCacheHandler(a, RenderLimitHandler(a, hMapLayerZXY))))))))))
+	group.UsingContext().Handler("GET", "/maps/:map_name/:layer_name/:z/:x/:y", HeadersHandler(MetricsHandler(a, AuthHandler(a, RateLimitHandler(a, TileConditionalHandler(a, GZipHandler(TrackHandler(a, TileCacheHandler(a, RenderLimitHandler(a, hMapLayerZXY))))))))))
+
+	// features at a point
+	hMapFeatures := HandleMapFeatures{Atlas: a}
+	group.UsingContext().Handler("GET", "/maps/:map_name/features", HeadersHandler(AuthHandler(a, RateLimitHandler(a, TrackHandler(a, RenderLimitHandler(a, hMapFeatures))))))
+	group.UsingContext().Handler("GET", "/maps/:map_name/:layer_name/features", HeadersHandler(AuthHandler(a, RateLimitHandler(a, TrackHandler(a, RenderLimitHandler(a, hMapFeatures))))))
 
 	// map style
-	group.UsingContext().Handler("GET", "/maps/:map_name/style.json", HeadersHandler(HandleMapStyle{}))
+	group.UsingContext().Handler("GET", "/maps/:map_name/style.json", HeadersHandler(MapAuthHandler(a, HandleMapStyle{})))
+
+	// prometheus metrics
+	if Metrics {
+		group.UsingContext().Handler("GET", "/metrics", metrics.Handler())
+	}
 
-	// setup viewer routes, which can be excluded via build flags
-	setupViewer(group)
+	// OGC API - Tiles
+	group.UsingContext().Handler("GET", "/conformance", HeadersHandler(HandleOGCConformance{}))
+	group.UsingContext().Handler("GET", "/collections", HeadersHandler(HandleOGCCollections{Atlas: a}))
+	group.UsingContext().Handler("GET", "/collections/:map_name", HeadersHandler(MapAuthHandler(a, HandleOGCCollection{Atlas: a})))
+	group.UsingContext().Handler("GET", "/collections/:map_name/tiles", HeadersHandler(MapAuthHandler(a, HandleOGCTileSets{Atlas: a})))
+	group.UsingContext().Handler("GET", "/collections/:map_name/tiles/:tile_matrix_set", HeadersHandler(MapAuthHandler(a, HandleOGCTileSet{Atlas: a})))
+	group.UsingContext().Handler("GET", "/collections/:map_name/tiles/:tile_matrix_set/:z/:y/:x", HeadersHandler(VaryAcceptHandler(MetricsHandler(a, AuthHandler(a, RateLimitHandler(a, TileConditionalHandler(a, GZipHandler(TrackHandler(a, TileCacheHandler(a, RenderLimitHandler(a, hMapLayerZXY)))))))))))
+	group.UsingContext().Handler("GET", "/tileMatrixSets", HeadersHandler(HandleOGCTileMatrixSets{Atlas: a}))
+	group.UsingContext().Handler("GET", "/tileMatrixSets/:tile_matrix_set", HeadersHandler(HandleOGCTileMatrixSet{Atlas: a}))
+
+	// WMTS
+	group.UsingContext().Handler("GET", "/wmts/:map_name/1.0.0/WMTSCapabilities.xml", HeadersHandler(MapAuthHandler(a, HandleWMTSCapabilities{Atlas: a})))
+	group.UsingContext().Handler("GET", "/wmts/:map_name/1.0.0/:layer_name/default/:tile_matrix_set/:z/:y/:x", HeadersHandler(MetricsHandler(a, AuthHandler(a, RateLimitHandler(a, TileConditionalHandler(a, GZipHandler(TrackHandler(a, TileCacheHandler(a, RenderLimitHandler(a, hMapLayerZXY))))))))))
+
+	// setup viewer routes, which can be excluded via build flags.
+	// the root is shared with the OGC API landing page
+	setupViewer(group, HeadersHandler(HandleOGCLandingPage{}))
 
 	return r
 }
@@ -183,6 +214,11 @@ func setHeaders(w http.ResponseWriter) {
 		w.Header().Set(name, val)
 	}
 
+	// allow cross origin requests to send credentials in headers
+	if Auth != nil {
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, X-API-Key")
+	}
+
 	// set user defined headers
 	for name, val := range Headers {
 		if val == "" {
@@ -13,6 +13,8 @@ import (
 	"github.com/dimfeld/httptreemux"
 	"github.com/go-spatial/geom"
 	"github.com/go-spatial/tegola/atlas"
+	"github.com/go-spatial/tegola/maths/tilematrix"
+	"github.com/go-spatial/tegola/provider"
 	"github.com/go-spatial/tegola/provider/test"
 	"github.com/go-spatial/tegola/server"
 )
@@ -79,6 +81,21 @@ func newTestMapWithLayers(layers ...atlas.Layer) *atlas.Atlas {
 	return a
 }
 
+func newTestMapWithTileMatrixSet(tms *tilematrix.TileMatrixSet) *atlas.Atlas {
+
+	testMap := atlas.NewWebMercatorMap(testMapName)
+	testMap.Attribution = testMapAttribution
+	testMap.Center = testMapCenter
+	testMap.Layers = append(testMap.Layers, testLayer1)
+	testMap.TileMatrixSet = tms
+	testMap.SRID = tms.SRID
+
+	a := &atlas.Atlas{}
+	a.AddMap(testMap)
+
+	return a
+}
+
 func newTestMapWithBounds(minx, miny, maxx, maxy float64) *atlas.Atlas {
 
 	testMap := atlas.NewWebMercatorMap(testMapName)
@@ -93,6 +110,21 @@ func newTestMapWithBounds(minx, miny, maxx, maxy float64) *atlas.Atlas {
 	return a
 }
 
+// newTestMapWithParams returns an atlas with a map which has the query parameters
+func newTestMapWithParams(params ...provider.QueryParameter) *atlas.Atlas {
+
+	testMap := atlas.NewWebMercatorMap(testMapName)
+	testMap.Attribution = testMapAttribution
+	testMap.Center = testMapCenter
+	testMap.Layers = append(testMap.Layers, testLayer1, testLayer2, testLayer3)
+	testMap.Params = params
+
+	a := &atlas.Atlas{}
+	a.AddMap(testMap)
+
+	return a
+}
+
 func doRequest(a *atlas.Atlas, method string, uri string, body io.Reader) (w *httptest.ResponseRecorder, router *httptreemux.TreeMux, err error) {
 
 	router = server.NewRouter(a)
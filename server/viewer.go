@@ -12,14 +12,22 @@ import (
 )
 
 // setupViewer in this file is used for reigstering the viewer routes when the viewer
-// is included in the build (default)
-func setupViewer(group *httptreemux.Group) {
+// is included in the build (default). Requests for the root which ask for JSON are
+// answered by the landing handler.
+func setupViewer(group *httptreemux.Group, landing http.Handler) {
 	prefixStripper := FilePathPrefixStripper{
 		fs: bindata.AssetFileSystem(),
 	}
 
-	group.UsingContext().Handler("GET", "/", http.FileServer(&prefixStripper))
-	group.UsingContext().Handler("GET", "/*path", http.FileServer(&prefixStripper))
+	viewer := http.FileServer(&prefixStripper)
+	group.UsingContext().Handler("GET", "/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wantsOGCLandingPage(r) {
+			landing.ServeHTTP(w, r)
+			return
+		}
+		viewer.ServeHTTP(w, r)
+	}))
+	group.UsingContext().Handler("GET", "/*path", viewer)
 }
 
 type FilePathPrefixStripper struct {
@@ -2,8 +2,14 @@
 
 package server
 
-import "github.com/dimfeld/httptreemux"
+import (
+	"net/http"
+
+	"github.com/dimfeld/httptreemux"
+)
 
 // setupViewer in this file is used for removing the viewer routes when the
-// build flag `noViewer` is set
-func setupViewer(group *httptreemux.Group) {}
+// build flag `noViewer` is set. The root is then the landing handler.
+func setupViewer(group *httptreemux.Group, landing http.Handler) {
+	group.UsingContext().Handler("GET", "/", landing)
+}